@@ -0,0 +1,183 @@
+package migration
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"tsc/pkg/common"
+	"tsc/pkg/util/migration/catalog"
+	"tsc/pkg/util/migration/discovery"
+)
+
+// ListAppsRequest 列出应用请求
+type ListAppsRequest struct {
+	// OS 只列出在该操作系统上有配置位置的应用 (windows, darwin, linux)
+	OS string `form:"os" example:"linux"`
+}
+
+// ListApps 获取应用目录
+// @Summary 获取应用目录
+// @Description 获取内置及用户覆盖的常用应用及其配置位置
+// @Tags 应用目录
+// @Produce json
+// @Param os query string false "操作系统"
+// @Success 200 {object} common.Response{data=[]catalog.App} "成功"
+// @Failure 500 {object} common.Response "服务器错误"
+// @Router /api/v1/apps [get]
+func (h *Handler) ListApps(c *gin.Context) {
+	var req ListAppsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.Error(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	apps, err := catalog.Load()
+	if err != nil {
+		common.Error(c, http.StatusInternalServerError, "加载应用目录失败: "+err.Error())
+		return
+	}
+
+	common.Success(c, apps.List(req.OS))
+}
+
+// GetApp 获取应用详情
+// @Summary 获取应用详情
+// @Description 获取指定应用的配置位置
+// @Tags 应用目录
+// @Produce json
+// @Param app_id path string true "应用ID"
+// @Success 200 {object} common.Response{data=catalog.App} "成功"
+// @Failure 404 {object} common.Response "应用不存在"
+// @Failure 500 {object} common.Response "服务器错误"
+// @Router /api/v1/apps/{app_id} [get]
+func (h *Handler) GetApp(c *gin.Context) {
+	app, ok := h.loadApp(c)
+	if !ok {
+		return
+	}
+
+	common.Success(c, app)
+}
+
+// ExpandApp 将应用展开为迁移配置
+// @Summary 展开应用迁移配置
+// @Description 按源、目标操作系统与路径变量将应用的配置位置展开为迁移配置，可直接用于执行、预览或导出
+// @Tags 应用目录
+// @Accept json
+// @Produce json
+// @Param app_id path string true "应用ID"
+// @Param request body catalog.ExpandOptions true "展开选项"
+// @Success 200 {object} common.Response "成功"
+// @Failure 400 {object} common.Response "请求参数错误"
+// @Failure 404 {object} common.Response "应用不存在"
+// @Router /api/v1/apps/{app_id}/configs [post]
+func (h *Handler) ExpandApp(c *gin.Context) {
+	var req catalog.ExpandOptions
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.Error(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	app, ok := h.loadApp(c)
+	if !ok {
+		return
+	}
+
+	configs, err := app.Expand(req)
+	if err != nil {
+		common.Error(c, http.StatusBadRequest, "展开应用配置失败: "+err.Error())
+		return
+	}
+	for _, config := range configs {
+		config.TaskID = uuid.New().String()
+	}
+
+	common.Success(c, configs)
+}
+
+// ListInstalledApps 扫描本机已安装的应用
+// @Summary 扫描已安装应用
+// @Description 按应用目录检查配置目录、PATH 中的可执行文件与包管理器元数据，返回已安装的应用及其版本
+// @Tags 应用目录
+// @Produce json
+// @Success 200 {object} common.Response{data=discovery.Inventory} "成功"
+// @Failure 500 {object} common.Response "服务器错误"
+// @Router /api/v1/apps/installed [get]
+func (h *Handler) ListInstalledApps(c *gin.Context) {
+	apps, err := catalog.Load()
+	if err != nil {
+		common.Error(c, http.StatusInternalServerError, "加载应用目录失败: "+err.Error())
+		return
+	}
+
+	inventory, err := discovery.NewScanner(apps).Scan(c.Request.Context())
+	if err != nil {
+		common.Error(c, http.StatusInternalServerError, "扫描已安装应用失败: "+err.Error())
+		return
+	}
+
+	common.Success(c, inventory)
+}
+
+// ExpandInstalledApps 将本机已安装的应用展开为迁移配置
+// @Summary 展开已安装应用迁移配置
+// @Description 扫描本机已安装的应用，并将其配置位置展开为迁移配置，配置中记录检测到的版本；不存在的配置位置跳过并在 warnings 中列出
+// @Tags 应用目录
+// @Accept json
+// @Produce json
+// @Param request body catalog.ExpandOptions true "展开选项"
+// @Success 200 {object} common.Response "成功"
+// @Failure 400 {object} common.Response "请求参数错误"
+// @Failure 500 {object} common.Response "服务器错误"
+// @Router /api/v1/apps/installed/configs [post]
+func (h *Handler) ExpandInstalledApps(c *gin.Context) {
+	var req catalog.ExpandOptions
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.Error(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	apps, err := catalog.Load()
+	if err != nil {
+		common.Error(c, http.StatusInternalServerError, "加载应用目录失败: "+err.Error())
+		return
+	}
+
+	inventory, err := discovery.NewScanner(apps).Scan(c.Request.Context())
+	if err != nil {
+		common.Error(c, http.StatusInternalServerError, "扫描已安装应用失败: "+err.Error())
+		return
+	}
+
+	configs, err := inventory.Configs(apps, req)
+	if err != nil {
+		common.Error(c, http.StatusBadRequest, "展开应用配置失败: "+err.Error())
+		return
+	}
+	for _, config := range configs {
+		config.TaskID = uuid.New().String()
+	}
+
+	common.Success(c, gin.H{
+		"configs":  configs,
+		"warnings": inventory.Warnings,
+	})
+}
+
+// loadApp 加载应用目录并按路径参数查找应用，失败时写入错误响应
+func (h *Handler) loadApp(c *gin.Context) (*catalog.App, bool) {
+	apps, err := catalog.Load()
+	if err != nil {
+		common.Error(c, http.StatusInternalServerError, "加载应用目录失败: "+err.Error())
+		return nil, false
+	}
+
+	app, ok := apps.Get(c.Param("app_id"))
+	if !ok {
+		common.Error(c, http.StatusNotFound, "应用不存在: "+c.Param("app_id"))
+		return nil, false
+	}
+	return app, true
+}
@@ -8,7 +8,7 @@ import (
 
 // ExecuteRequest 执行迁移请求
 type ExecuteRequest struct {
-	// Type 迁移类型 (env_variable, config_file, software, registry)
+	// Type 迁移类型 (env_variable, config_file, software, registry, jetbrains, dotfiles)
 	Type string `json:"type" binding:"required" example:"env_variable"`
 
 	// Name 任务名称
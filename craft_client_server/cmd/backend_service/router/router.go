@@ -53,4 +53,23 @@ func RegisterRoutes(r *gin.Engine) {
 		// 获取可用策略列表
 		migrationGroup.GET("/strategies", migrationHandler.ListStrategies)
 	}
+
+	// 应用目录 API 路由组
+	appsGroup := r.Group("/api/v1/apps")
+	{
+		// 获取应用目录
+		appsGroup.GET("", migrationHandler.ListApps)
+
+		// 扫描本机已安装的应用
+		appsGroup.GET("/installed", migrationHandler.ListInstalledApps)
+
+		// 将已安装的应用展开为迁移配置
+		appsGroup.POST("/installed/configs", migrationHandler.ExpandInstalledApps)
+
+		// 获取应用详情
+		appsGroup.GET("/:app_id", migrationHandler.GetApp)
+
+		// 将应用展开为迁移配置
+		appsGroup.POST("/:app_id/configs", migrationHandler.ExpandApp)
+	}
 }
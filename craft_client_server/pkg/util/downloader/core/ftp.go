@@ -7,10 +7,10 @@ import (
 	"io"
 	"net/url"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 	"tsc/pkg/util/downloader/util"
+	"tsc/pkg/util/safefile"
 )
 
 type ftpDownloader struct {
@@ -81,22 +81,12 @@ func (f *ftpDownloader) Download(info DownloadInfo, writer io.Writer) error {
 	}
 	defer resp.Close()
 
-	// 处理目标路径
-	dest := info.Dest
-	if fi, err := os.Stat(dest); err == nil && fi.IsDir() {
-	} else if os.IsNotExist(err) {
-		// 如果目标路径不存在，创建所有父目录
-		dir := filepath.Dir(dest)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory %s: %w", dir, err)
-		}
-	}
-	// 创建目标文件
-	file, err := os.OpenFile(info.Dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.FileMode)
+	// 下载内容先写入目标目录下的临时文件，校验通过后再替换目标文件
+	file, err := safefile.Create(info.Dest, safefile.Options{Perm: os.FileMode(info.FileMode)})
 	if err != nil {
 		return fmt.Errorf("create file failed: %w", err)
 	}
-	defer file.Close()
+	defer file.Abort()
 
 	// 设置进度写入器
 	var destWriter io.Writer = file
@@ -111,12 +101,12 @@ func (f *ftpDownloader) Download(info DownloadInfo, writer io.Writer) error {
 
 	// 校验文件
 	if info.Checksum != "" {
-		if err := util.VerifyChecksum(info.Dest, info.Checksum, info.ChecksumType); err != nil {
+		if err := util.VerifyChecksum(file.Name(), info.Checksum, info.ChecksumType); err != nil {
 			return fmt.Errorf("checksum verification failed: %w", err)
 		}
 	}
 
-	return nil
+	return file.Commit()
 }
 
 func (f *ftpDownloader) SetDefaultOptions(options DownloadOptions) {
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"time"
 	"tsc/pkg/util/downloader/util"
+	"tsc/pkg/util/safefile"
 )
 
 type httpDownloader struct {
@@ -81,23 +82,6 @@ func (h *httpDownloader) doDownload(client *http.Client, info DownloadInfo, writ
 		req.Header.Set("User-Agent", info.UserAgent)
 	}
 
-	// 支持断点续传
-	if info.ResumeDownload {
-		if fi, err := os.Stat(info.Dest); err == nil {
-			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", fi.Size()))
-		}
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
 	// 处理目标路径
 	dest := info.Dest
 	if fi, err := os.Stat(dest); err == nil && fi.IsDir() {
@@ -107,27 +91,45 @@ func (h *httpDownloader) doDownload(client *http.Client, info DownloadInfo, writ
 			filename = "downloaded_file"
 		}
 		dest = filepath.Join(dest, filename)
-	} else if os.IsNotExist(err) {
-		// 如果目标路径不存在，创建所有父目录
-		dir := filepath.Dir(dest)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory %s: %w", dir, err)
-		}
 	}
 
-	// 创建目标文件
-	flags := os.O_CREATE | os.O_WRONLY
+	// 下载内容先写入临时文件，校验通过后再替换目标文件；
+	// 断点续传时临时文件（dest.part）在失败后保留，下次从其末尾继续
+	opts := safefile.Options{Perm: os.FileMode(info.FileMode)}
+	var file *safefile.File
+	var offset int64
 	if info.ResumeDownload {
-		flags |= os.O_APPEND
+		file, offset, err = safefile.Resume(dest, opts)
 	} else {
-		flags |= os.O_TRUNC
+		file, err = safefile.Create(dest, opts)
 	}
-
-	file, err := os.OpenFile(dest, flags, info.FileMode)
 	if err != nil {
 		return fmt.Errorf("create file failed: %w", err)
 	}
-	defer file.Close()
+	defer file.Abort()
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// 服务器不支持 Range 时返回完整内容，丢弃已下载部分
+		if offset > 0 {
+			if err := file.Truncate(0); err != nil {
+				return fmt.Errorf("truncate file failed: %w", err)
+			}
+		}
+	default:
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
 
 	// 设置进度写入器
 	var destWriter io.Writer = file
@@ -145,12 +147,14 @@ func (h *httpDownloader) doDownload(client *http.Client, info DownloadInfo, writ
 
 	// 校验文件
 	if info.Checksum != "" {
-		if err := util.VerifyChecksum(dest, info.Checksum, info.ChecksumType); err != nil {
+		if err := util.VerifyChecksum(file.Name(), info.Checksum, info.ChecksumType); err != nil {
+			// 校验失败的内容无法续传，清空后下次重新下载
+			file.Truncate(0)
 			return fmt.Errorf("checksum verification failed: %w", err)
 		}
 	}
 
-	return nil
+	return file.Commit()
 }
 
 func (h *httpDownloader) SetDefaultOptions(options DownloadOptions) {
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -78,3 +79,34 @@ func TestHTTPDownloader_WithChecksum(t *testing.T) {
 		t.Error("Expected checksum error, got nil")
 	}
 }
+
+func TestHTTPDownloader_ChecksumFailureKeepsDest(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("corrupted content"))
+	}))
+	defer ts.Close()
+
+	dest := filepath.Join(t.TempDir(), "app.bin")
+	if err := os.WriteFile(dest, []byte("previous version"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info := NewDownloadInfo(ts.URL, dest)
+	info.MaxRetries = 1
+	info.Checksum = "6fe13b5c9a94c9da9d3cc3e1977f778c"
+	if err := NewHTTPDownloader(DownloadOptions{}).Download(info, nil); err == nil {
+		t.Fatal("Expected checksum error, got nil")
+	}
+
+	// 校验失败时目标文件保持原样，且不留下临时文件
+	content, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "previous version" {
+		t.Errorf("Destination overwritten by failed download: %s", content)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(dest)); len(entries) != 1 {
+		t.Errorf("Unexpected leftover files: %v", entries)
+	}
+}
@@ -0,0 +1,271 @@
+// Package catalog 内置的常用应用目录：列出各应用在不同操作系统上的配置位置，展开为迁移配置
+package catalog
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+// builtinApps 内置应用目录
+//
+//go:embed apps.yaml
+var builtinApps []byte
+
+// overrideFile 用户配置目录下的覆盖文件
+const overrideFile = "envcraft/apps.yaml"
+
+// App 应用条目
+type App struct {
+	// ID 应用标识，如 vscode
+	ID string `yaml:"id" json:"id" example:"vscode"`
+
+	// Name 应用名称
+	Name string `yaml:"name" json:"name" example:"Visual Studio Code"`
+
+	// Category 应用类别
+	Category string `yaml:"category" json:"category" example:"Editor"`
+
+	// Description 迁移内容说明
+	Description string `yaml:"description" json:"description"`
+
+	// Locations 配置位置
+	Locations []Location `yaml:"locations" json:"locations"`
+
+	// Probes 检测已安装版本的命令
+	Probes []Probe `yaml:"probes" json:"probes,omitempty"`
+
+	// Packages 包管理器中的包名（dpkg、Homebrew 等），用于检测已安装版本
+	Packages []string `yaml:"packages" json:"packages,omitempty"`
+
+	// Disabled 覆盖文件中隐藏同 id 的内置条目
+	Disabled bool `yaml:"disabled" json:"-"`
+}
+
+// Location 应用的一个配置位置，展开为一个迁移配置
+type Location struct {
+	// Name 位置名称，如 settings
+	Name string `yaml:"name" json:"name" example:"user"`
+
+	// Description 位置说明
+	Description string `yaml:"description" json:"description,omitempty"`
+
+	// Type 迁移类型 (software, config_file)
+	Type core.MigrationType `yaml:"type" json:"type" example:"software"`
+
+	// Paths 各操作系统上的路径，键为 windows、darwin、linux、unix（darwin 与 linux）或 default
+	Paths map[string]string `yaml:"paths" json:"paths"`
+
+	// Format 配置文件格式提示
+	Format string `yaml:"format" json:"format,omitempty" example:"json"`
+
+	// Include 包含的键（config_file）
+	Include []string `yaml:"include" json:"include,omitempty"`
+
+	// Exclude software 为 gitignore 语法的忽略规则，config_file 为排除的键（* 匹配任意字符）
+	Exclude []string `yaml:"exclude" json:"exclude,omitempty"`
+
+	// MergeMode 合并模式（config_file），未设置时覆盖目标
+	MergeMode string `yaml:"merge_mode" json:"merge_mode,omitempty" example:"merge"`
+
+	// Optional 源路径不存在时跳过
+	Optional bool `yaml:"optional" json:"optional"`
+}
+
+// Probe 版本检测命令：在 PATH 中查找 Command 并以 Args 执行，Pattern 的第一个分组为版本号
+type Probe struct {
+	// Command 可执行文件名
+	Command string `yaml:"command" json:"command" example:"git"`
+
+	// Args 命令参数
+	Args []string `yaml:"args" json:"args" example:"--version"`
+
+	// Pattern 从标准输出与标准错误中提取版本号的正则表达式，未设置时取第一个形如 1.2.3 的版本号
+	Pattern string `yaml:"pattern" json:"pattern,omitempty" example:"git version (\\S+)"`
+}
+
+// appFile 目录文件结构
+type appFile struct {
+	Apps []*App `yaml:"apps"`
+}
+
+// Catalog 应用目录，按 id 排序
+type Catalog struct {
+	apps []*App
+}
+
+// Builtin 返回内置应用目录
+func Builtin() (*Catalog, error) {
+	apps, err := parseApps(builtinApps)
+	if err != nil {
+		return nil, fmt.Errorf("invalid builtin catalog: %w", err)
+	}
+	c := &Catalog{}
+	c.Override(apps)
+	return c, nil
+}
+
+// Load 返回内置应用目录，并应用用户配置目录下的覆盖文件（如存在）
+func Load() (*Catalog, error) {
+	c, err := Builtin()
+	if err != nil {
+		return nil, err
+	}
+	path := DefaultOverridePath()
+	if path == "" {
+		return c, nil
+	}
+	if err := c.LoadOverrides(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return c, nil
+}
+
+// DefaultOverridePath 返回用户覆盖文件路径 <用户配置目录>/envcraft/apps.yaml，无法确定用户配置目录时返回空
+func DefaultOverridePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, filepath.FromSlash(overrideFile))
+}
+
+// LoadOverrides 读取覆盖文件并应用到目录
+func (c *Catalog) LoadOverrides(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	apps, err := parseApps(content)
+	if err != nil {
+		return fmt.Errorf("invalid catalog %s: %w", path, err)
+	}
+	c.Override(apps)
+	return nil
+}
+
+// Override 以 apps 替换同 id 的条目或新增条目，Disabled 的条目从目录中移除
+func (c *Catalog) Override(apps []*App) {
+	for _, app := range apps {
+		i := sort.Search(len(c.apps), func(i int) bool { return c.apps[i].ID >= app.ID })
+		found := i < len(c.apps) && c.apps[i].ID == app.ID
+		switch {
+		case app.Disabled && found:
+			c.apps = append(c.apps[:i], c.apps[i+1:]...)
+		case app.Disabled:
+		case found:
+			c.apps[i] = app
+		default:
+			c.apps = append(c.apps, nil)
+			copy(c.apps[i+1:], c.apps[i:])
+			c.apps[i] = app
+		}
+	}
+}
+
+// parseApps 解析并校验目录文件
+func parseApps(content []byte) ([]*App, error) {
+	var file appFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for _, app := range file.Apps {
+		if app.ID == "" {
+			return nil, fmt.Errorf("app id is required")
+		}
+		if seen[app.ID] {
+			return nil, fmt.Errorf("duplicate app id %q", app.ID)
+		}
+		seen[app.ID] = true
+		if app.Disabled {
+			continue
+		}
+		if len(app.Locations) == 0 {
+			return nil, fmt.Errorf("app %q has no locations", app.ID)
+		}
+		for _, loc := range app.Locations {
+			if loc.Name == "" {
+				return nil, fmt.Errorf("app %q: location name is required", app.ID)
+			}
+			if loc.Type != constants.MigrationTypeSoftware && loc.Type != constants.MigrationTypeConfigFile {
+				return nil, fmt.Errorf("app %q location %q: unsupported type %q", app.ID, loc.Name, loc.Type)
+			}
+			if len(loc.Paths) == 0 {
+				return nil, fmt.Errorf("app %q location %q: paths are required", app.ID, loc.Name)
+			}
+			switch loc.MergeMode {
+			case "", "overwrite", "merge", "skip":
+			default:
+				return nil, fmt.Errorf("app %q location %q: unsupported merge mode %q", app.ID, loc.Name, loc.MergeMode)
+			}
+		}
+		for _, probe := range app.Probes {
+			if probe.Command == "" {
+				return nil, fmt.Errorf("app %q: probe command is required", app.ID)
+			}
+			if _, err := regexp.Compile(probe.Pattern); err != nil {
+				return nil, fmt.Errorf("app %q probe %q: invalid pattern: %w", app.ID, probe.Command, err)
+			}
+		}
+	}
+	return file.Apps, nil
+}
+
+// List 返回在 goos 上有配置位置的应用，goos 为空时返回全部
+func (c *Catalog) List(goos string) []*App {
+	apps := make([]*App, 0, len(c.apps))
+	for _, app := range c.apps {
+		if goos == "" || len(app.LocationsFor(goos)) > 0 {
+			apps = append(apps, app)
+		}
+	}
+	return apps
+}
+
+// Get 按 id 查找应用
+func (c *Catalog) Get(id string) (*App, bool) {
+	i := sort.Search(len(c.apps), func(i int) bool { return c.apps[i].ID >= id })
+	if i < len(c.apps) && c.apps[i].ID == id {
+		return c.apps[i], true
+	}
+	return nil, false
+}
+
+// AppInfo 返回导出包中记录的应用信息
+func (a *App) AppInfo() core.AppInfo {
+	return core.AppInfo{Name: a.Name, Category: a.Category}
+}
+
+// LocationsFor 返回在 goos 上有路径的配置位置
+func (a *App) LocationsFor(goos string) []Location {
+	var locations []Location
+	for _, loc := range a.Locations {
+		if loc.Path(goos) != "" {
+			locations = append(locations, loc)
+		}
+	}
+	return locations
+}
+
+// Path 返回 goos 上的路径模板，依次查找 goos、unix（darwin 与 linux）、default
+func (l *Location) Path(goos string) string {
+	if path, ok := l.Paths[goos]; ok {
+		return path
+	}
+	if goos != "windows" {
+		if path, ok := l.Paths["unix"]; ok {
+			return path
+		}
+	}
+	return l.Paths["default"]
+}
@@ -0,0 +1,179 @@
+package catalog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tsc/pkg/util/migration/constants"
+)
+
+func TestBuiltinCatalog(t *testing.T) {
+	c, err := Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"vscode", "intellij-idea", "git", "ssh", "vim", "neovim", "zsh", "tmux", "maven", "npm", "pip", "docker"} {
+		if _, ok := c.Get(id); !ok {
+			t.Errorf("builtin app %q missing", id)
+		}
+	}
+
+	// zsh 与 tmux 只有 unix 路径
+	for _, app := range c.List("windows") {
+		if app.ID == "zsh" || app.ID == "tmux" {
+			t.Errorf("%s should not be listed on windows", app.ID)
+		}
+	}
+	if len(c.List("linux")) != len(c.List("")) {
+		t.Error("every builtin app should have a linux location")
+	}
+}
+
+func TestCatalogOverrides(t *testing.T) {
+	c, err := Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "apps.yaml")
+	os.WriteFile(path, []byte(`
+apps:
+  - id: docker
+    disabled: true
+  - id: git
+    name: Git (custom)
+    locations:
+      - name: gitconfig
+        type: config_file
+        paths:
+          default: ${HOME}/.config/git/config
+  - id: alacritty
+    name: Alacritty
+    locations:
+      - name: config
+        type: config_file
+        format: toml
+        paths:
+          unix: ${XDG_CONFIG_HOME}/alacritty/alacritty.toml
+`), 0644)
+	if err := c.LoadOverrides(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("docker"); ok {
+		t.Error("disabled app should be removed")
+	}
+	if git, _ := c.Get("git"); git.Name != "Git (custom)" || len(git.Locations) != 1 {
+		t.Errorf("git not replaced: %+v", git)
+	}
+	if _, ok := c.Get("alacritty"); !ok {
+		t.Error("new app should be added")
+	}
+
+	os.WriteFile(path, []byte("apps:\n  - id: x\n    locations:\n      - name: a\n        type: registry\n        paths: {default: /x}\n"), 0644)
+	if err := c.LoadOverrides(path); err == nil {
+		t.Error("unsupported location type should be rejected")
+	}
+}
+
+func TestExpand(t *testing.T) {
+	oldHome, newHome := t.TempDir(), t.TempDir()
+	os.MkdirAll(filepath.Join(oldHome, "AppData/Roaming/JetBrains/IntelliJIdea2023.3"), 0755)
+	os.MkdirAll(filepath.Join(oldHome, "AppData/Roaming/JetBrains/IntelliJIdea2024.9"), 0755)
+	os.MkdirAll(filepath.Join(oldHome, "AppData/Roaming/JetBrains/IntelliJIdea2024.10"), 0755)
+	// 备份目录与同名文件不作为源路径
+	os.MkdirAll(filepath.Join(oldHome, "AppData/Roaming/JetBrains/IntelliJIdea2024.10.backup"), 0755)
+	os.WriteFile(filepath.Join(oldHome, "AppData/Roaming/JetBrains/IntelliJIdea2099.1.txt"), nil, 0644)
+	os.WriteFile(filepath.Join(oldHome, ".gitconfig"), []byte("[user]\n"), 0644)
+	os.WriteFile(filepath.Join(oldHome, ".npmrc"), []byte("registry=https://registry.npmjs.org/\n"), 0644)
+
+	c, _ := Builtin()
+	opts := ExpandOptions{
+		SourceOS:   "windows",
+		TargetOS:   "linux",
+		SourceVars: map[string]string{"HOME": oldHome},
+		TargetVars: map[string]string{"HOME": newHome},
+	}
+
+	// 通配符选择最新版本，目标使用相同的目录名
+	idea, _ := c.Get("intellij-idea")
+	configs, err := idea.Expand(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 1 {
+		t.Fatalf("got %d configs", len(configs))
+	}
+	config := configs[0]
+	if config.Source.Path != filepath.Join(oldHome, "AppData/Roaming/JetBrains/IntelliJIdea2024.10") ||
+		config.Target.Path != filepath.Join(newHome, ".config/JetBrains/IntelliJIdea2024.10") {
+		t.Errorf("unexpected paths: %s -> %s", config.Source.Path, config.Target.Path)
+	}
+	if config.Type != constants.MigrationTypeSoftware || len(config.Source.Filter.Exclude) == 0 || config.Source.Variables["app_name"] != "IntelliJ IDEA" {
+		t.Errorf("unexpected config: %+v", config)
+	}
+
+	// 可选位置不存在时跳过
+	git, _ := c.Get("git")
+	configs, err = git.Expand(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 1 || configs[0].Source.Format != "ini" || configs[0].Target.Path != filepath.Join(newHome, ".gitconfig") {
+		t.Errorf("unexpected git configs: %+v", configs)
+	}
+
+	// npm 合并到目标 .npmrc，不迁移凭据
+	npm, _ := c.Get("npm")
+	configs, err = npm.Expand(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 1 || configs[0].Target.MergeMode != "merge" || !contains(configs[0].Source.Filter.Exclude, "*:_authToken") {
+		t.Errorf("unexpected npm configs: %+v", configs)
+	}
+
+	// 必需位置不存在时报错
+	maven, _ := c.Get("maven")
+	if _, err := maven.Expand(opts); err == nil || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected not exist error, got %v", err)
+	}
+
+	// 只展开指定位置
+	vscode, _ := c.Get("vscode")
+	os.MkdirAll(filepath.Join(oldHome, "AppData/Roaming/Code/User"), 0755)
+	opts.Locations = []string{"user"}
+	if configs, err := vscode.Expand(opts); err != nil || len(configs) != 1 {
+		t.Errorf("unexpected vscode configs: %v, %v", configs, err)
+	}
+}
+
+func TestPathVars(t *testing.T) {
+	vars := PathVars("linux", map[string]string{"HOME": "/home/bob", "XDG_CONFIG_HOME": "/cfg"})
+	if vars["XDG_CONFIG_HOME"] != "/cfg" || vars["XDG_DATA_HOME"] != "/home/bob/.local/share" || vars["APPDATA"] != "/home/bob/AppData/Roaming" {
+		t.Errorf("unexpected vars: %v", vars)
+	}
+	if _, err := expandPath("${NOPE}/x", vars); err == nil {
+		t.Error("unknown variable should be rejected")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"2024.1", "2023.3", 1},
+		{"1.10.0", "1.9.9", 1},
+		{"9.6p1", "9.6", 1},
+		{"2.43.0", "2.43.0", 0},
+		{"", "0.1", -1},
+		{"1.02", "1.2", 0},
+		{"2024.10", "2024.9", 1},
+		{"IntelliJIdea2024.1", "IntelliJIdea2024.2", -1},
+	} {
+		if got := CompareVersions(tc.a, tc.b); got != tc.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package catalog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"tsc/pkg/util/migration/core"
+)
+
+// backupSuffix 迁移前备份目录的后缀，通配符匹配时不作为源路径
+const backupSuffix = ".backup"
+
+// ExpandOptions 展开应用为迁移配置的选项
+type ExpandOptions struct {
+	// SourceOS 源路径使用的操作系统，默认为本机
+	SourceOS string `json:"source_os" example:"windows"`
+
+	// TargetOS 目标路径使用的操作系统，默认为本机
+	TargetOS string `json:"target_os" example:"linux"`
+
+	// SourceVars 源路径变量，如 {"HOME": "/mnt/old/Users/alice"}；未设置的变量由 HOME 推导
+	SourceVars map[string]string `json:"source_vars"`
+
+	// TargetVars 目标路径变量
+	TargetVars map[string]string `json:"target_vars"`
+
+	// Locations 只展开这些配置位置，为空时展开全部
+	Locations []string `json:"locations"`
+}
+
+// Expand 将应用展开为迁移配置，每个配置位置一个
+// 源路径中的通配符匹配多个目录时取版本最新的一个，目标路径中的通配符使用源路径对应部分的名称；
+// 可选位置的源路径不存在时跳过，必需位置不存在时返回错误
+func (a *App) Expand(opts ExpandOptions) ([]*core.MigrationConfig, error) {
+	sourceOS, targetOS := orLocal(opts.SourceOS), orLocal(opts.TargetOS)
+	sourceVars := PathVars(sourceOS, opts.SourceVars)
+	targetVars := PathVars(targetOS, opts.TargetVars)
+
+	var configs []*core.MigrationConfig
+	for _, loc := range a.Locations {
+		if len(opts.Locations) > 0 && !contains(opts.Locations, loc.Name) {
+			continue
+		}
+		if loc.Path(sourceOS) == "" || loc.Path(targetOS) == "" {
+			continue
+		}
+
+		source, err := loc.Expand(sourceOS, sourceVars)
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: %w", a.ID, loc.Name, err)
+		}
+		if source, err = resolveSource(source); err != nil {
+			if loc.Optional && os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("%s/%s: %w", a.ID, loc.Name, err)
+		}
+		target, err := loc.Expand(targetOS, targetVars)
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: %w", a.ID, loc.Name, err)
+		}
+		target = resolveTarget(target, source)
+
+		configs = append(configs, a.config(loc, source, target))
+	}
+	return configs, nil
+}
+
+// config 生成一个配置位置的迁移配置
+func (a *App) config(loc Location, source, target string) *core.MigrationConfig {
+	config := core.NewMigrationConfig()
+	config.Name = fmt.Sprintf("%s %s", a.Name, loc.Name)
+	config.Type = loc.Type
+	config.Source.Path = source
+	config.Source.Format = loc.Format
+	config.Source.Filter.Include = append(config.Source.Filter.Include, loc.Include...)
+	config.Source.Filter.Exclude = append(config.Source.Filter.Exclude, loc.Exclude...)
+	config.Source.Variables["app_id"] = a.ID
+	config.Source.Variables["app_name"] = a.Name
+	config.Source.Variables["app_category"] = a.Category
+	config.Target.Path = target
+	config.Target.Format = loc.Format
+	if loc.MergeMode != "" {
+		config.Target.MergeMode = loc.MergeMode
+	}
+	config.Target.Backup = true
+	config.Target.CreateIfNotExists = true
+	return config
+}
+
+// PathVars 返回 goos 上的路径变量：HOME、APPDATA、LOCALAPPDATA、XDG_CONFIG_HOME、XDG_DATA_HOME
+// 未在 vars 中设置的变量由 HOME 推导；goos 为本机且未指定 HOME 时使用本机的环境变量
+func PathVars(goos string, vars map[string]string) map[string]string {
+	home, custom := vars["HOME"]
+	if !custom {
+		home, _ = os.UserHomeDir()
+	}
+	result := map[string]string{
+		"HOME":            home,
+		"APPDATA":         home + "/AppData/Roaming",
+		"LOCALAPPDATA":    home + "/AppData/Local",
+		"XDG_CONFIG_HOME": home + "/.config",
+		"XDG_DATA_HOME":   home + "/.local/share",
+	}
+	if !custom && goos == runtime.GOOS {
+		for name := range result {
+			if value := os.Getenv(name); value != "" && name != "HOME" {
+				result[name] = value
+			}
+		}
+	}
+	for name, value := range vars {
+		result[name] = value
+	}
+	return result
+}
+
+// Expand 返回 goos 上替换路径变量后的路径，可能含通配符；goos 上没有路径时返回空
+func (l *Location) Expand(goos string, vars map[string]string) (string, error) {
+	template := l.Path(goos)
+	if template == "" {
+		return "", nil
+	}
+	return expandPath(template, vars)
+}
+
+// expandPath 替换路径模板中的 ${NAME} 变量
+func expandPath(template string, vars map[string]string) (string, error) {
+	var missing string
+	path := os.Expand(template, func(name string) string {
+		value, ok := vars[name]
+		if !ok || value == "" {
+			missing = name
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("unknown path variable %q in %s", missing, template)
+	}
+	return filepath.FromSlash(path), nil
+}
+
+// resolveSource 检查源路径是否存在；含通配符时取版本最新的目录，忽略文件与 .backup 备份目录
+// 如 IntelliJIdea2024.10 优先于 IntelliJIdea2024.9 与 IntelliJIdea2024.10.backup
+func resolveSource(path string) (string, error) {
+	if !hasGlob(path) {
+		if _, err := os.Stat(path); err != nil {
+			return "", err
+		}
+		return path, nil
+	}
+	matches, err := filepath.Glob(path)
+	if err != nil {
+		return "", err
+	}
+	candidates := matches[:0]
+	for _, match := range matches {
+		if strings.HasSuffix(match, backupSuffix) {
+			continue
+		}
+		if info, err := os.Stat(match); err == nil && info.IsDir() {
+			candidates = append(candidates, match)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", &os.PathError{Op: "glob", Path: path, Err: os.ErrNotExist}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if c := CompareVersions(candidates[i], candidates[j]); c != 0 {
+			return c < 0
+		}
+		return candidates[i] < candidates[j]
+	})
+	return candidates[len(candidates)-1], nil
+}
+
+// resolveTarget 将目标路径中含通配符的部分替换为源路径从末尾数同一位置的名称
+// 如源 .../JetBrains/IntelliJIdea2024.1、目标 .../JetBrains/IntelliJIdea* 得到 .../JetBrains/IntelliJIdea2024.1
+func resolveTarget(target, source string) string {
+	if !hasGlob(target) {
+		return target
+	}
+	targetParts := strings.Split(target, string(filepath.Separator))
+	sourceParts := strings.Split(source, string(filepath.Separator))
+	for i := range targetParts {
+		j := len(sourceParts) - (len(targetParts) - i)
+		if hasGlob(targetParts[i]) && j >= 0 {
+			targetParts[i] = sourceParts[j]
+		}
+	}
+	return strings.Join(targetParts, string(filepath.Separator))
+}
+
+// hasGlob 判断路径是否含通配符
+func hasGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// orLocal 操作系统为空时返回本机
+func orLocal(goos string) string {
+	if goos == "" {
+		return runtime.GOOS
+	}
+	return goos
+}
+
+// contains 判断切片是否包含 s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package catalog
+
+import "strings"
+
+// CompareVersions 按数字段比较版本号，返回 -1、0、1；非数字段按字符串比较，空版本最小
+// 如 2024.10 大于 2024.9，IntelliJIdea2024.1 小于 IntelliJIdea2024.2
+func CompareVersions(a, b string) int {
+	as, bs := versionFields(a), versionFields(b)
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		if i >= len(as) {
+			return -1
+		}
+		if i >= len(bs) {
+			return 1
+		}
+		x, y := as[i], bs[i]
+		if isDigits(x) && isDigits(y) {
+			x, y = strings.TrimLeft(x, "0"), strings.TrimLeft(y, "0")
+			if len(x) != len(y) {
+				if len(x) < len(y) {
+					return -1
+				}
+				return 1
+			}
+		}
+		if c := strings.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// versionFields 将版本号拆分为连续的数字段与非数字段，忽略分隔符
+func versionFields(v string) []string {
+	var fields []string
+	start := -1
+	digit := false
+	for i, r := range v + "." {
+		isDigit := r >= '0' && r <= '9'
+		isAlnum := isDigit || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if start >= 0 && (!isAlnum || isDigit != digit) {
+			fields = append(fields, v[start:i])
+			start = -1
+		}
+		if isAlnum && start < 0 {
+			start, digit = i, isDigit
+		}
+	}
+	return fields
+}
+
+// isDigits 判断字符串是否全为数字
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
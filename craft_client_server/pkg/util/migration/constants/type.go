@@ -8,6 +8,8 @@ const (
 	MigrationTypeConfigFile  core.MigrationType = "config_file"  // 配置文件迁移
 	MigrationTypeSoftware    core.MigrationType = "software"     // 软件配置迁移
 	MigrationTypeRegistry    core.MigrationType = "registry"     // 注册表迁移
+	MigrationTypeJetBrains   core.MigrationType = "jetbrains"    // JetBrains IDE 版本升级迁移
+	MigrationTypeDotfiles    core.MigrationType = "dotfiles"     // dotfiles 仓库符号链接管理
 )
 
 // 任务状态常量
@@ -29,11 +31,90 @@ const (
 
 // 操作类型常量
 const (
-	ActionTypeCreate string = "create" // 创建
-	ActionTypeUpdate string = "update" // 更新
-	ActionTypeDelete string = "delete" // 删除
-	ActionTypeCopy   string = "copy"   // 复制
-	ActionTypeMerge  string = "merge"  // 合并
-	ActionTypeExport string = "export" // 导出
-	ActionTypeImport string = "import" // 导入
+	ActionTypeCreate   string = "create"   // 创建
+	ActionTypeUpdate   string = "update"   // 更新
+	ActionTypeDelete   string = "delete"   // 删除
+	ActionTypeCopy     string = "copy"     // 复制
+	ActionTypeMerge    string = "merge"    // 合并
+	ActionTypeExport   string = "export"   // 导出
+	ActionTypeImport   string = "import"   // 导入
+	ActionTypeRewrite  string = "rewrite"  // 改写
+	ActionTypeValidate string = "validate" // 校验
+)
+
+// 数组合并策略常量
+const (
+	ArrayMergeReplace string = "replace"      // 整体替换
+	ArrayMergeAppend  string = "append"       // 追加到末尾
+	ArrayMergeUnion   string = "union"        // 并集去重
+	ArrayMergeByKey   string = "merge_by_key" // 按字段匹配元素后合并
+)
+
+// 改写规则常量
+const (
+	RewriteModeLiteral  string = "literal" // 按原文匹配
+	RewriteModeRegex    string = "regex"   // 按正则表达式匹配
+	RewriteScopeValue   string = "value"   // 改写解析后的配置值
+	RewriteScopeContent string = "content" // 改写文件内容
+)
+
+// 环境变量持久化目标常量（Linux/macOS），作为 Target.Type 使用
+const (
+	EnvTargetProfile        string = "profile"         // ~/.profile 中的受管块
+	EnvTargetBashrc         string = "bashrc"          // ~/.bashrc 中的受管块
+	EnvTargetZshenv         string = "zshenv"          // ~/.zshenv 中的受管块
+	EnvTargetFish           string = "fish"            // fish 的 conf.d/envcraft.fish
+	EnvTargetEnvironmentD   string = "environment.d"   // systemd 的 environment.d/50-envcraft.conf
+	EnvTargetEtcEnvironment string = "etc_environment" // /etc/environment 中的受管块
+)
+
+// 注册表后端常量，作为 Source.Type/Target.Type 使用
+const (
+	RegistryBackendWine string = "wine" // Wine 前缀中的注册表文件（system.reg、user.reg、userdef.reg）
+)
+
+// 目录同步常量
+const (
+	SyncModeFull        string = "full"        // 复制全部文件
+	SyncModeIncremental string = "incremental" // 仅复制新增或变化的文件
+	SyncModeMirror      string = "mirror"      // 增量复制并删除目标中多余的文件
+	SyncCompareMtime    string = "mtime"       // 比较大小与修改时间（秒）
+	SyncCompareHash     string = "hash"        // 比较大小与内容的 SHA-256
+)
+
+// 目录中符号链接的处理方式常量
+const (
+	SymlinkPreserve string = "preserve" // 在目标中重建符号链接，链接目标随目录的移动改写
+	SymlinkFollow   string = "follow"   // 复制链接指向的文件或目录
+	SymlinkSkip     string = "skip"     // 跳过符号链接
+)
+
+// dotfiles 链接目标已存在时的处理方式常量
+const (
+	DotfilesConflictAbort  string = "abort"  // 存在冲突时不做任何修改
+	DotfilesConflictBackup string = "backup" // 将已存在的文件移动到备份目录后创建链接
+	DotfilesConflictAdopt  string = "adopt"  // 将已存在的文件移入包中替换包内文件后创建链接
+)
+
+// 导出格式常量
+const (
+	ExportFormatPackage    string = "package"    // 标准导出包（JSON）
+	ExportFormatDotenv     string = "dotenv"     // dotenv 文件
+	ExportFormatShell      string = "shell"      // POSIX shell export 脚本
+	ExportFormatPowerShell string = "powershell" // PowerShell $env: 脚本
+)
+
+// 列表型环境变量操作常量
+const (
+	EnvListPrepend string = "prepend" // 插入到开头
+	EnvListAppend  string = "append"  // 追加到末尾
+	EnvListRemove  string = "remove"  // 移除条目
+	EnvListReplace string = "replace" // 整体替换为给定条目
+)
+
+// 环境变量引用写入方式常量
+const (
+	EnvReferencePreserve string = "preserve" // 保留引用，由系统或 shell 在加载时展开
+	EnvReferenceExpand   string = "expand"   // 写入展开后的值
+	EnvReferenceLiteral  string = "literal"  // 按字面写入整个值，不识别引用
 )
@@ -39,6 +39,13 @@ type ExportMetadata struct {
 	// AppInfo 应用信息 (可选，如 IDEA, VS Code 等)
 	AppInfo *AppInfo `json:"app_info,omitempty"`
 
+	// Placeholders 导出时替换的本机相关值：占位符名称 → 导出机器上的取值（如 HOME → /home/alice）
+	// 内容中以 ${名称} 表示，导入时按导入机器的取值还原
+	Placeholders map[string]string `json:"placeholders,omitempty" example:"{\"HOME\":\"/home/alice\"}"`
+
+	// PlaceholderEscape 内容中原有的 $ 在紧跟 {、$ 或占位符时已转义为 $$，导入时还原，使原有的 ${VAR} 不被当作占位符
+	PlaceholderEscape bool `json:"placeholder_escape,omitempty"`
+
 	// Checksum 内容校验和
 	Checksum string `json:"checksum" example:"sha256:abc123..."`
 
@@ -150,6 +157,9 @@ type ImportResult struct {
 	// Summary 汇总信息
 	Summary MigrationSummary `json:"summary"`
 
+	// Warnings 警告信息
+	Warnings []string `json:"warnings,omitempty"`
+
 	// StartTime 开始时间
 	StartTime time.Time `json:"start_time"`
 
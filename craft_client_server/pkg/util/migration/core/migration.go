@@ -64,6 +64,9 @@ type MigrationConfig struct {
 	// Options 迁移选项
 	Options MigrationOptions `json:"options" gorm:"type:json;comment:迁移选项"`
 
+	// Rewrites 值改写规则，按顺序应用（如将 C:\Users\alice 改写为 /home/bob）
+	Rewrites []RewriteRule `json:"rewrites" gorm:"type:json;comment:值改写规则"`
+
 	// Context 迁移上下文
 	Context *MigrationContext `json:"context,omitempty" gorm:"-"`
 }
@@ -87,6 +90,10 @@ type MigrationSource struct {
 
 	// Format 配置文件格式 (json, yaml, ini, toml)
 	Format string `json:"format" gorm:"size:16;comment:文件格式"`
+
+	// WinePrefix Wine 前缀目录，设置时（或 Type 为 wine）注册表从前缀的 system.reg、user.reg、userdef.reg 读取；
+	// Type 为 wine 且未设置时依次使用 $WINEPREFIX、~/.wine
+	WinePrefix string `json:"wine_prefix" gorm:"size:512;comment:Wine前缀"`
 }
 
 // SourceFilter 源过滤条件
@@ -94,9 +101,12 @@ type SourceFilter struct {
 	// Include 包含的键
 	Include []string `json:"include" gorm:"type:json;comment:包含的键"`
 
-	// Exclude 排除的键
+	// Exclude 排除的键；软件配置迁移中为 gitignore 语法的忽略规则，优先于忽略文件中的规则
 	Exclude []string `json:"exclude" gorm:"type:json;comment:排除的键"`
 
+	// IgnoreFile 忽略规则文件（gitignore 语法），相对路径相对于源目录；未设置时读取源目录下的 .envcraftignore（如存在）
+	IgnoreFile string `json:"ignore_file" gorm:"size:512;comment:忽略规则文件"`
+
 	// Pattern 匹配模式（正则表达式）
 	Pattern string `json:"pattern" gorm:"size:256;comment:匹配模式"`
 }
@@ -126,6 +136,88 @@ type MigrationTarget struct {
 
 	// Format 配置文件格式
 	Format string `json:"format" gorm:"size:16;comment:文件格式"`
+
+	// ArrayMerge 数组合并规则（merge 模式下生效，未匹配规则的数组整体替换）
+	ArrayMerge []ArrayMergeRule `json:"array_merge" gorm:"type:json;comment:数组合并规则"`
+
+	// Schema 写入前校验合并结果的 JSON Schema：本地文件路径（JSON 或 YAML），或 builtin:名称 使用内置 Schema
+	Schema string `json:"schema" gorm:"size:512;comment:校验Schema"`
+
+	// References 环境变量值中 %VAR%、$VAR、${VAR} 引用的写入方式 (preserve, expand, literal)；
+	// 默认进程变量写入展开后的值，其余目标保留引用并转换为目标支持的写法；preserve 按原文写入，
+	// 持久化文件中只有 ${VAR} 作为引用；literal 按字面写入整个值
+	References string `json:"references" gorm:"size:16;comment:变量引用写入方式"`
+
+	// ListVariables 列表型环境变量规则（PATH、CLASSPATH 等），匹配规则的变量按条目与当前值合并而非整体覆盖
+	ListVariables []EnvListRule `json:"list_variables" gorm:"type:json;comment:列表型变量规则"`
+
+	// WinePrefix Wine 前缀目录，设置时（或 Type 为 wine）注册表写入前缀的 system.reg、user.reg、userdef.reg
+	WinePrefix string `json:"wine_prefix" gorm:"size:512;comment:Wine前缀"`
+
+	// Sync 目录同步方式 (full, incremental, mirror)：full 每次复制全部文件；incremental 仅复制新增或变化的文件；
+	// mirror 在 incremental 的基础上删除目标中源目录没有的文件。默认 full
+	Sync string `json:"sync" gorm:"size:16;comment:同步方式"`
+
+	// SyncCompare 增量同步判断文件是否变化的方式 (mtime, hash)：mtime 比较大小与修改时间，hash 比较内容哈希。默认 mtime
+	SyncCompare string `json:"sync_compare" gorm:"size:16;comment:变化判断方式"`
+
+	// Symlinks 复制目录时符号链接的处理方式 (preserve, follow, skip)：preserve 在目标中重建链接，指向目录外的相对链接与
+	// 指向目录内的绝对链接按目标位置改写；follow 复制链接指向的内容；skip 跳过链接。默认 preserve
+	Symlinks string `json:"symlinks" gorm:"size:16;comment:符号链接处理方式"`
+
+	// Conflict dotfiles 迁移中链接目标已存在时的处理方式 (abort, backup, adopt)：abort 不做任何修改；backup 将已存在的文件
+	// 移动到备份目录；adopt 将已存在的文件移入包中（包内原文件移动到备份目录）。默认 abort
+	Conflict string `json:"conflict" gorm:"size:16;comment:冲突处理方式"`
+}
+
+// EnvListRule 列表型环境变量规则，变量的值为以分隔符连接的条目
+type EnvListRule struct {
+	// Name 变量名
+	Name string `json:"name" gorm:"size:128;comment:变量名"`
+
+	// Mode 操作 (prepend, append, remove, replace)，默认 append
+	Mode string `json:"mode" gorm:"size:16;comment:操作"`
+
+	// Separator 条目分隔符，为空时按目标选择（Windows 用户/系统变量为 ;，shell 配置为 :，进程变量按当前系统）
+	Separator string `json:"separator" gorm:"size:4;comment:分隔符"`
+
+	// Dedupe 是否移除当前值中的重复条目
+	Dedupe bool `json:"dedupe" gorm:"comment:是否去重"`
+
+	// CheckExists 是否检查新增条目指向的路径存在，不存在的条目不写入
+	CheckExists bool `json:"check_exists" gorm:"comment:是否检查路径存在"`
+}
+
+// ArrayMergeRule 数组合并规则
+type ArrayMergeRule struct {
+	// Path 数组路径，以 . 分隔，数组元素以 [] 表示，段可使用 * 通配（如 servers、profiles[].repositories）
+	Path string `json:"path" gorm:"size:512;comment:数组路径"`
+
+	// Strategy 合并策略 (replace, append, union, merge_by_key)
+	Strategy string `json:"strategy" gorm:"size:32;comment:合并策略"`
+
+	// Key 按键合并时用于匹配元素的字段名
+	Key string `json:"key" gorm:"size:128;comment:匹配字段"`
+}
+
+// RewriteRule 值改写规则
+type RewriteRule struct {
+	// Mode 匹配方式 (literal, regex)，默认 literal
+	Mode string `json:"mode" gorm:"size:16;comment:匹配方式"`
+
+	// Match 匹配内容，regex 模式下为正则表达式
+	Match string `json:"match" gorm:"type:text;comment:匹配内容"`
+
+	// Replace 替换内容，regex 模式下支持 $1、${name} 分组引用
+	Replace string `json:"replace" gorm:"type:text;comment:替换内容"`
+
+	// KeyPattern 生效范围：改写值时匹配配置项路径（以 . 分隔，* 匹配一段，** 匹配任意多段），
+	// 改写文件内容时匹配相对文件路径（不含 / 时匹配文件名）；为空时不限范围
+	KeyPattern string `json:"key_pattern" gorm:"size:512;comment:生效范围"`
+
+	// Scope 改写对象 (value, content)，默认 value；content 在解析前改写源文件内容。
+	// 软件配置迁移不解析文件，始终按文件内容改写
+	Scope string `json:"scope" gorm:"size:16;comment:改写对象"`
 }
 
 // MigrationOptions 迁移选项
@@ -160,6 +252,10 @@ type MigrationOptions struct {
 	// ExportPath 导出文件路径 (导出模式使用)
 	ExportPath string `json:"export_path" gorm:"size:512;comment:导出文件路径"`
 
+	// ExportFormat 导出格式，默认为标准导出包 (package)；环境变量还支持 dotenv、shell、powershell，
+	// 为空时按 ExportPath 扩展名推断
+	ExportFormat string `json:"export_format" gorm:"size:32;comment:导出格式"`
+
 	// ImportPath 导入文件路径 (导入模式使用)
 	ImportPath string `json:"import_path" gorm:"size:512;comment:导入文件路径"`
 
@@ -168,6 +264,9 @@ type MigrationOptions struct {
 
 	// PreserveFormat 是否保持原始格式 (导入时)
 	PreserveFormat bool `json:"preserve_format" gorm:"comment:是否保持原始格式"`
+
+	// NoPlaceholders 导出时保留本机相关值（主目录、用户名、主机名等），不替换为占位符
+	NoPlaceholders bool `json:"no_placeholders" gorm:"comment:是否禁用占位符"`
 }
 
 // MigrationResult 迁移结果
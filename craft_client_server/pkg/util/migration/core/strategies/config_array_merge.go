@@ -0,0 +1,189 @@
+package strategies
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+// validateArrayMergeRules 检查数组合并规则
+func validateArrayMergeRules(rules []core.ArrayMergeRule) error {
+	for _, rule := range rules {
+		if rule.Path == "" {
+			return fmt.Errorf("array merge rule path is required")
+		}
+		switch rule.Strategy {
+		case constants.ArrayMergeReplace, constants.ArrayMergeAppend, constants.ArrayMergeUnion:
+		case constants.ArrayMergeByKey:
+			if rule.Key == "" {
+				return fmt.Errorf("array merge rule %s: key is required for %s", rule.Path, rule.Strategy)
+			}
+		default:
+			return fmt.Errorf("array merge rule %s: unsupported strategy %q", rule.Path, rule.Strategy)
+		}
+		if _, err := path.Match(rule.Path, ""); err != nil {
+			return fmt.Errorf("array merge rule %s: invalid pattern: %w", rule.Path, err)
+		}
+	}
+	return nil
+}
+
+// joinConfigPath 拼接配置项路径
+func joinConfigPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// findArrayMergeRule 查找数组路径对应的规则，先配置的规则优先；未匹配时返回 nil
+// 规则路径按 . 分段逐段匹配，段内可使用 * 等通配符，** 匹配任意多段，末尾的 [] 可省略
+func findArrayMergeRule(rules []core.ArrayMergeRule, arrayPath string) *core.ArrayMergeRule {
+	segments := strings.Split(arrayPath, ".")
+	for i := range rules {
+		patterns := strings.Split(strings.TrimSuffix(rules[i].Path, "[]"), ".")
+		if matchPathSegments(patterns, segments) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// mergeArray 按规则合并数组，目标中已有的元素始终保持原位置，新元素追加在末尾
+func (s *ConfigFileStrategy) mergeArray(target, source []interface{}, rules []core.ArrayMergeRule, arrayPath string) []interface{} {
+	rule := findArrayMergeRule(rules, arrayPath)
+	if rule == nil {
+		return source
+	}
+
+	switch rule.Strategy {
+	case constants.ArrayMergeAppend:
+		result := make([]interface{}, 0, len(target)+len(source))
+		result = append(result, target...)
+		return append(result, source...)
+	case constants.ArrayMergeUnion:
+		// 目标中原有的重复元素保留不动，仅去除与已有元素相同的源元素
+		result := append([]interface{}{}, target...)
+		for _, item := range source {
+			if indexOfValue(result, item) < 0 {
+				result = append(result, item)
+			}
+		}
+		return result
+	case constants.ArrayMergeByKey:
+		result := append([]interface{}{}, target...)
+		for _, item := range source {
+			pos := indexOfKeyedElement(result[:len(target)], rule.Key, item)
+			if pos < 0 {
+				result = append(result, item)
+				continue
+			}
+			existing, ok1 := result[pos].(map[string]interface{})
+			fields, ok2 := item.(map[string]interface{})
+			if ok1 && ok2 {
+				result[pos] = s.mergeConfig(existing, fields, rules, arrayPath+"[]")
+			} else {
+				result[pos] = item
+			}
+		}
+		return result
+	default:
+		return source
+	}
+}
+
+// indexOfValue 返回值在数组中的位置，不存在时返回 -1
+func indexOfValue(items []interface{}, value interface{}) int {
+	for i, item := range items {
+		if valuesEqual(item, value) {
+			return i
+		}
+	}
+	return -1
+}
+
+// arrayElementKey 返回元素的匹配字段值，元素不是 map 或缺少该字段时返回 false
+func arrayElementKey(item interface{}, key string) (string, bool) {
+	fields, ok := item.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	value, ok := fields[key]
+	if !ok {
+		return "", false
+	}
+	return toString(value), true
+}
+
+// indexOfKeyedElement 返回匹配字段值与 item 相同的元素位置；item 没有匹配字段时按值比较
+func indexOfKeyedElement(items []interface{}, key string, item interface{}) int {
+	id, ok := arrayElementKey(item, key)
+	if !ok {
+		return indexOfValue(items, item)
+	}
+	for i, existing := range items {
+		if existingID, ok := arrayElementKey(existing, key); ok && existingID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// arrayElementChanges 比较合并前后的数据，列出按规则合并的数组中新增与修改的元素
+// 元素以 路径[字段=值] 或 路径[下标] 标识
+func arrayElementChanges(before, after map[string]interface{}, rules []core.ArrayMergeRule, prefix string) []core.PreviewChange {
+	var changes []core.PreviewChange
+	for _, key := range sortedKeys(after) {
+		keyPath := joinConfigPath(prefix, key)
+		switch value := after[key].(type) {
+		case map[string]interface{}:
+			if existing, ok := before[key].(map[string]interface{}); ok {
+				changes = append(changes, arrayElementChanges(existing, value, rules, keyPath)...)
+			}
+		case []interface{}:
+			rule := elementMergeRule(before[key], value, rules, keyPath)
+			if rule == nil {
+				continue
+			}
+			existing := before[key].([]interface{})
+			for i, item := range value {
+				label := fmt.Sprintf("%s[%d]", keyPath, i)
+				if id, ok := arrayElementKey(item, rule.Key); ok && rule.Strategy == constants.ArrayMergeByKey {
+					label = fmt.Sprintf("%s[%s=%s]", keyPath, rule.Key, id)
+				}
+
+				change := core.PreviewChange{Key: label, AfterValue: fmt.Sprintf("%v", item)}
+				if i < len(existing) {
+					if valuesEqual(existing[i], item) {
+						continue
+					}
+					change.ActionType = constants.ActionTypeUpdate
+					change.BeforeValue = fmt.Sprintf("%v", existing[i])
+					change.Description = fmt.Sprintf("将按 %s 策略更新数组元素 %s", rule.Strategy, label)
+				} else {
+					change.ActionType = constants.ActionTypeCreate
+					change.Description = fmt.Sprintf("将按 %s 策略添加数组元素 %s", rule.Strategy, label)
+				}
+				changes = append(changes, change)
+			}
+		}
+	}
+	return changes
+}
+
+// elementMergeRule 返回按元素合并目标已有数组时使用的规则；目标不是数组、没有规则或规则为 replace 时返回 nil
+func elementMergeRule(before, after interface{}, rules []core.ArrayMergeRule, arrayPath string) *core.ArrayMergeRule {
+	_, isArray := after.([]interface{})
+	_, existed := before.([]interface{})
+	if !isArray || !existed {
+		return nil
+	}
+	rule := findArrayMergeRule(rules, arrayPath)
+	if rule == nil || rule.Strategy == constants.ArrayMergeReplace {
+		return nil
+	}
+	return rule
+}
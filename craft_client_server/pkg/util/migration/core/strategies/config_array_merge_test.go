@@ -0,0 +1,124 @@
+package strategies
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+func TestMergeConfigArrayStrategies(t *testing.T) {
+	target := map[string]interface{}{
+		"tags":  []interface{}{"a", "b"},
+		"plain": []interface{}{1, 2},
+		"extra": []interface{}{"x"},
+		"settings": map[string]interface{}{
+			"servers": []interface{}{
+				map[string]interface{}{"name": "nexus", "url": "http://old", "user": "me"},
+				map[string]interface{}{"name": "personal", "url": "http://mine"},
+			},
+		},
+	}
+	source := map[string]interface{}{
+		"tags":  []interface{}{"b", "c"},
+		"plain": []interface{}{3},
+		"extra": []interface{}{"x"},
+		"settings": map[string]interface{}{
+			"servers": []interface{}{
+				map[string]interface{}{"name": "nexus", "url": "http://new"},
+				map[string]interface{}{"name": "central", "url": "http://central"},
+			},
+		},
+	}
+	rules := []core.ArrayMergeRule{
+		{Path: "tags", Strategy: constants.ArrayMergeUnion},
+		{Path: "ext*", Strategy: constants.ArrayMergeAppend},
+		{Path: "*.servers[]", Strategy: constants.ArrayMergeByKey, Key: "name"},
+	}
+
+	strategy := &ConfigFileStrategy{}
+	merged := strategy.mergeConfig(target, source, rules, "")
+	expected := map[string]interface{}{
+		"tags":  []interface{}{"a", "b", "c"},
+		"plain": []interface{}{3},
+		"extra": []interface{}{"x", "x"},
+		"settings": map[string]interface{}{
+			"servers": []interface{}{
+				map[string]interface{}{"name": "nexus", "url": "http://new", "user": "me"},
+				map[string]interface{}{"name": "personal", "url": "http://mine"},
+				map[string]interface{}{"name": "central", "url": "http://central"},
+			},
+		},
+	}
+	if !valuesEqual(merged, expected) {
+		t.Errorf("unexpected merge result:\n%v\nexpected:\n%v", merged, expected)
+	}
+
+	if err := validateArrayMergeRules([]core.ArrayMergeRule{{Path: "servers", Strategy: constants.ArrayMergeByKey}}); err == nil {
+		t.Error("merge_by_key without key should be rejected")
+	}
+	if err := validateArrayMergeRules([]core.ArrayMergeRule{{Path: "servers", Strategy: "zip"}}); err == nil {
+		t.Error("unknown strategy should be rejected")
+	}
+}
+
+func TestConfigFileArrayMergeDryRunAndExecute(t *testing.T) {
+	dir := t.TempDir()
+	sourcePath := filepath.Join(dir, "baseline", "extensions.json")
+	targetPath := filepath.Join(dir, "user", "extensions.json")
+	for _, p := range []string{sourcePath, targetPath} {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(sourcePath, []byte(`{"recommendations": ["golang.go", "ms-python.python"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(targetPath, []byte("{\n  \"recommendations\": [\"vscodevim.vim\", \"golang.go\"]\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = sourcePath
+	config.Target.Path = targetPath
+	config.Target.MergeMode = "merge"
+	config.Target.ArrayMerge = []core.ArrayMergeRule{{Path: "recommendations", Strategy: constants.ArrayMergeUnion}}
+
+	strategy := &ConfigFileStrategy{}
+	if err := strategy.Validate(config); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	preview, err := strategy.DryRun(context.Background(), config)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	var element *core.PreviewChange
+	for i := range preview.Changes {
+		if preview.Changes[i].Key == "recommendations[2]" {
+			element = &preview.Changes[i]
+		}
+	}
+	if element == nil || element.ActionType != constants.ActionTypeCreate || element.AfterValue != "ms-python.python" {
+		t.Errorf("expected element-level change for recommendations[2], got %+v", preview.Changes)
+	}
+	// 数组只按元素计数，不再作为整个键计入一次更新
+	if len(preview.Changes) != 1 || preview.Summary.Create != 1 || preview.Summary.Update != 0 || preview.Summary.Total != 1 {
+		t.Errorf("unexpected preview summary: %+v %+v", preview.Summary, preview.Changes)
+	}
+
+	if _, err := strategy.Execute(context.Background(), config); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	data, err := strategy.readConfigFile(targetPath, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{"vscodevim.vim", "golang.go", "ms-python.python"}
+	if !valuesEqual(data["recommendations"], expected) {
+		t.Errorf("personal extensions lost: %v", data["recommendations"])
+	}
+}
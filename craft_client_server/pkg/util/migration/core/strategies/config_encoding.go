@@ -0,0 +1,210 @@
+package strategies
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// 常用编码名称，带 -bom 后缀的编码在写入时保留字节序标记
+const (
+	encodingUTF8       = "utf-8"
+	encodingUTF8BOM    = "utf-8-bom"
+	encodingUTF16LE    = "utf-16le"
+	encodingUTF16LEBOM = "utf-16le-bom"
+	encodingUTF16BE    = "utf-16be"
+	encodingUTF16BEBOM = "utf-16be-bom"
+	encodingGBK        = "gbk"
+	encodingFallback   = "windows-1252"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// textEncoding 解析后的文件编码
+type textEncoding struct {
+	name     string
+	enc      encoding.Encoding // 为 nil 时表示 UTF-8，无需转换
+	bom      []byte            // 该编码族的字节序标记，读取时总会剥离
+	writeBOM bool              // 写入时是否输出字节序标记
+}
+
+// lookupEncoding 按名称查找编码，除 UTF-8/UTF-16 变体外的名称交给 WHATWG 编码表解析（gbk、gb18030、big5、shift_jis 等）
+func lookupEncoding(name string) (*textEncoding, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	switch key {
+	case "", "utf-8", "utf8":
+		return &textEncoding{name: encodingUTF8, bom: bomUTF8}, nil
+	case "utf-8-bom", "utf8-bom", "utf-8-sig":
+		return &textEncoding{name: encodingUTF8BOM, bom: bomUTF8, writeBOM: true}, nil
+	case "utf-16le", "utf16le":
+		return &textEncoding{name: encodingUTF16LE, enc: unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), bom: bomUTF16LE}, nil
+	case "utf-16le-bom", "utf-16", "utf16", "unicode", "ucs-2":
+		return &textEncoding{name: encodingUTF16LEBOM, enc: unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), bom: bomUTF16LE, writeBOM: true}, nil
+	case "utf-16be", "utf16be":
+		return &textEncoding{name: encodingUTF16BE, enc: unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), bom: bomUTF16BE}, nil
+	case "utf-16be-bom":
+		return &textEncoding{name: encodingUTF16BEBOM, enc: unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), bom: bomUTF16BE, writeBOM: true}, nil
+	}
+
+	enc, err := htmlindex.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported encoding: %s", name)
+	}
+	return &textEncoding{name: key, enc: enc}, nil
+}
+
+// detectEncoding 推断内容编码：优先识别 BOM，其次识别无 BOM 的 UTF-16，再依次尝试 UTF-8 与 GBK
+func detectEncoding(content []byte) string {
+	switch {
+	case bytes.HasPrefix(content, bomUTF8):
+		return encodingUTF8BOM
+	case bytes.HasPrefix(content, bomUTF16LE):
+		return encodingUTF16LEBOM
+	case bytes.HasPrefix(content, bomUTF16BE):
+		return encodingUTF16BEBOM
+	}
+
+	if enc := detectUTF16(content); enc != "" {
+		return enc
+	}
+	if utf8.Valid(content) {
+		return encodingUTF8
+	}
+	if decoded, err := simplifiedchinese.GBK.NewDecoder().Bytes(content); err == nil && !bytes.ContainsRune(decoded, utf8.RuneError) {
+		return encodingGBK
+	}
+	return encodingFallback
+}
+
+// detectUTF16 根据 ASCII 字符在 UTF-16 中产生的零字节分布识别无 BOM 的 UTF-16
+func detectUTF16(content []byte) string {
+	if len(content) < 4 || len(content)%2 != 0 {
+		return ""
+	}
+	sample := content
+	if len(sample) > 1024 {
+		sample = sample[:1024]
+	}
+
+	var evenZeros, oddZeros int
+	for i := 0; i < len(sample); i += 2 {
+		if sample[i] == 0 {
+			evenZeros++
+		}
+		if sample[i+1] == 0 {
+			oddZeros++
+		}
+	}
+	pairs := len(sample) / 2
+	switch {
+	case oddZeros*10 >= pairs*4 && evenZeros*10 < pairs:
+		return encodingUTF16LE
+	case evenZeros*10 >= pairs*4 && oddZeros*10 < pairs:
+		return encodingUTF16BE
+	default:
+		return ""
+	}
+}
+
+// decodeText 将内容解码为 UTF-8，name 为空或 auto 时自动检测，返回实际使用的编码名称
+func decodeText(content []byte, name string) ([]byte, string, error) {
+	if isAutoEncoding(name) {
+		name = detectEncoding(content)
+	}
+	te, err := lookupEncoding(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 指定了不带 BOM 的编码时，文件中已有的 BOM 同样不进入内容，并记录下来以便写回
+	resolved := te.name
+	if len(te.bom) > 0 && bytes.HasPrefix(content, te.bom) {
+		content = content[len(te.bom):]
+		if !te.writeBOM {
+			resolved += "-bom"
+		}
+	}
+	if te.enc == nil {
+		return content, resolved, nil
+	}
+
+	decoded, err := te.enc.NewDecoder().Bytes(content)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode %s content: %w", te.name, err)
+	}
+	return decoded, resolved, nil
+}
+
+// encodeText 将 UTF-8 内容编码为目标编码，需要时写入 BOM
+func encodeText(content []byte, name string) ([]byte, error) {
+	te, err := lookupEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+
+	out := content
+	if te.enc != nil {
+		out, err = te.enc.NewEncoder().Bytes(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode content as %s: %w", te.name, err)
+		}
+	}
+	if te.writeBOM {
+		out = append(append([]byte(nil), te.bom...), out...)
+	}
+	return out, nil
+}
+
+// isAutoEncoding 判断是否需要自动检测编码
+func isAutoEncoding(name string) bool {
+	name = strings.TrimSpace(name)
+	return name == "" || strings.EqualFold(name, "auto")
+}
+
+// readConfigText 读取文件并解码为 UTF-8，返回内容与实际编码
+func (s *ConfigFileStrategy) readConfigText(path, encoding string) ([]byte, string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return decodeText(content, encoding)
+}
+
+// fileEncoding 返回文件的编码：显式指定时直接使用，否则从文件内容检测；文件不存在时返回空串
+func (s *ConfigFileStrategy) fileEncoding(path, encoding string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if isAutoEncoding(encoding) {
+			return ""
+		}
+		return encoding
+	}
+	_, resolved, err := decodeText(content, encoding)
+	if err != nil {
+		return encoding
+	}
+	return resolved
+}
+
+// resolveWriteEncoding 确定写入编码：显式指定优先（目标文件已有 BOM 时保留），
+// 其次沿用目标文件现有编码，最后使用 fallback
+func (s *ConfigFileStrategy) resolveWriteEncoding(encoding, targetPath, fallback string) string {
+	if existing := s.fileEncoding(targetPath, encoding); existing != "" {
+		return existing
+	}
+	if !isAutoEncoding(fallback) {
+		return fallback
+	}
+	return encodingUTF8
+}
@@ -0,0 +1,136 @@
+package strategies
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+
+	"tsc/pkg/util/migration/core"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	gbk, err := simplifiedchinese.GBK.NewEncoder().Bytes([]byte("name = 张三\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	utf16le, err := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewEncoder().Bytes([]byte("[core]\nname = demo\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		content []byte
+		want    string
+	}{
+		{"utf-8", []byte("name = 张三\n"), encodingUTF8},
+		{"utf-8 bom", append([]byte{0xEF, 0xBB, 0xBF}, "a = 1\n"...), encodingUTF8BOM},
+		{"utf-16le bom", append([]byte{0xFF, 0xFE}, utf16le...), encodingUTF16LEBOM},
+		{"utf-16le", utf16le, encodingUTF16LE},
+		{"gbk", gbk, encodingGBK},
+	}
+	for _, tc := range cases {
+		if got := detectEncoding(tc.content); got != tc.want {
+			t.Errorf("%s: detectEncoding = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	if _, _, err := decodeText([]byte("a"), "no-such-encoding"); err == nil {
+		t.Error("expected error for unknown encoding")
+	}
+}
+
+func TestConfigFileExecuteKeepsTargetEncoding(t *testing.T) {
+	dir := t.TempDir()
+	sourcePath := filepath.Join(dir, "team.ini")
+	targetPath := filepath.Join(dir, "local.ini")
+
+	if err := os.WriteFile(sourcePath, []byte("[user]\nname = 李四\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	target, err := simplifiedchinese.GBK.NewEncoder().Bytes([]byte("; 本地配置\n[user]\nname = 张三\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(targetPath, target, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = sourcePath
+	config.Target.Path = targetPath
+	config.Target.MergeMode = "merge"
+
+	strategy := &ConfigFileStrategy{}
+	if _, err := strategy.Execute(context.Background(), config); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	out, err := os.ReadFile(targetPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := simplifiedchinese.GBK.NewEncoder().Bytes([]byte("; 本地配置\n[user]\nname = 李四\n"))
+	if !bytes.Equal(out, expected) {
+		t.Errorf("target not written as GBK: %q", out)
+	}
+}
+
+func TestConfigFileExportImportUTF16BOM(t *testing.T) {
+	dir := t.TempDir()
+	sourcePath := filepath.Join(dir, "settings.json")
+	targetPath := filepath.Join(dir, "restored", "settings.json")
+	exportPath := filepath.Join(dir, "settings.export.json")
+
+	encoded, err := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewEncoder().Bytes([]byte("{\n  \"title\": \"配置\"\n}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := append([]byte{0xFF, 0xFE}, encoded...)
+	if err := os.WriteFile(sourcePath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = sourcePath
+	config.Options.ExportPath = exportPath
+
+	strategy := &ConfigFileStrategy{}
+	exported, err := strategy.Export(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if got := exported.Package.Metadata.OriginalEncoding; got != encodingUTF16LEBOM {
+		t.Errorf("OriginalEncoding = %q, want %q", got, encodingUTF16LEBOM)
+	}
+	if exported.Package.Content.Data["title"] != "配置" {
+		t.Errorf("content not decoded: %v", exported.Package.Content.Data)
+	}
+
+	importConfig := core.NewMigrationConfig()
+	importConfig.Options.ImportPath = exportPath
+	importConfig.Target.Path = targetPath
+	if _, err := strategy.Import(context.Background(), importConfig); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	out, err := os.ReadFile(targetPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(out, []byte{0xFF, 0xFE}) {
+		t.Fatalf("BOM not preserved: % x", out[:4])
+	}
+	decoded, _, err := decodeText(out, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(decoded, []byte(`"title": "配置"`)) {
+		t.Errorf("unexpected restored content: %s", decoded)
+	}
+}
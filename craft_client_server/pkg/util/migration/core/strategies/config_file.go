@@ -1,16 +1,16 @@
 package strategies
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
-	"encoding/xml"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -19,6 +19,7 @@ import (
 
 	"tsc/pkg/util/migration/constants"
 	"tsc/pkg/util/migration/core"
+	"tsc/pkg/util/safefile"
 )
 
 func init() {
@@ -60,20 +61,33 @@ func (s *ConfigFileStrategy) Validate(config *core.MigrationConfig) error {
 		return fmt.Errorf("target path is required")
 	}
 
+	// 验证数组合并规则
+	if err := validateArrayMergeRules(config.Target.ArrayMerge); err != nil {
+		return err
+	}
+
+	// 验证改写规则
+	if _, err := newValueRewriter(config.Rewrites); err != nil {
+		return err
+	}
+
+	// 验证 Schema
+	if config.Target.Schema != "" {
+		if _, err := loadConfigSchema(config.Target.Schema); err != nil {
+			return fmt.Errorf("invalid schema %s: %w", config.Target.Schema, err)
+		}
+	}
+
+	// 目录或 glob 源逐个文件推断格式
+	if isMultiFileSource(config.Source.Path) {
+		return s.validateFiles(config)
+	}
+
 	// 验证文件格式
 	if config.Source.Format == "" && config.Target.Format == "" {
-		// 尝试从文件名推断格式
-		ext := strings.ToLower(filepath.Ext(config.Source.Path))
-		supportedFormats := []string{".json", ".yaml", ".yml", ".ini", ".toml"}
-		valid := false
-		for _, f := range supportedFormats {
-			if ext == f {
-				valid = true
-				break
-			}
-		}
-		if !valid {
-			return fmt.Errorf("unsupported file format: %s", ext)
+		// 尝试从文件名或文件内容推断格式
+		if s.detectFormat(config.Source.Path, "") == "unknown" {
+			return fmt.Errorf("unsupported file format: %s", filepath.Ext(config.Source.Path))
 		}
 	}
 
@@ -82,6 +96,10 @@ func (s *ConfigFileStrategy) Validate(config *core.MigrationConfig) error {
 
 // Execute 执行配置文件迁移
 func (s *ConfigFileStrategy) Execute(ctx context.Context, config *core.MigrationConfig) (*core.MigrationResult, error) {
+	if isMultiFileSource(config.Source.Path) {
+		return s.executeFiles(ctx, config)
+	}
+
 	result := core.NewMigrationResult(config.TaskID)
 	result.StartTime = time.Now()
 	defer func() {
@@ -89,8 +107,15 @@ func (s *ConfigFileStrategy) Execute(ctx context.Context, config *core.Migration
 		result.Duration = result.EndTime.Sub(result.StartTime).Milliseconds()
 	}()
 
+	rewriter, err := newValueRewriter(config.Rewrites)
+	if err != nil {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("改写规则无效: %v", err)
+		return result, err
+	}
+
 	// 读取源配置文件
-	sourceData, err := s.readConfigFile(config.Source.Path, config.Source.Format, config.Source.Encoding)
+	sourceContent, sourceData, contentRecords, err := s.readSourceConfig(config, rewriter)
 	if err != nil {
 		result.Status = constants.TaskStatusFailed
 		result.Message = fmt.Sprintf("读取源配置文件失败: %v", err)
@@ -122,24 +147,47 @@ func (s *ConfigFileStrategy) Execute(ctx context.Context, config *core.Migration
 	// 应用过滤条件
 	filteredSource := s.applyFilter(sourceData, config.Source.Filter)
 
+	// 改写源配置值
+	filteredSource, valueRecords := rewriter.rewriteData(filteredSource, "")
+
 	// 根据合并模式处理
-	var mergedData map[string]interface{}
-	switch config.Target.MergeMode {
-	case "overwrite":
-		mergedData = filteredSource
-	case "merge":
-		mergedData = s.mergeConfig(targetData, filteredSource)
-	case "skip":
-		mergedData = targetData
-		for k, v := range filteredSource {
-			if _, exists := targetData[k]; !exists {
-				mergedData[k] = v
-			}
+	mergedData := s.applyMergeMode(config.Target, targetData, filteredSource)
+
+	// 确定写入格式
+	format := s.writeFormat(config)
+
+	// 确定写入编码：未指定时沿用目标文件或源文件的编码
+	writeEncoding := s.resolveWriteEncoding(config.Target.Encoding, config.Target.Path, s.fileEncoding(config.Source.Path, config.Source.Encoding))
+
+	// XML 按元素合并，避免同名元素（如多个 <component>）组成的数组被整体替换
+	var mergedContent []byte
+	if content, data, records, ok := s.mergeXMLSource(config, format, sourceContent, rewriter); ok {
+		mergedContent, mergedData, valueRecords = content, data, records
+	}
+
+	// 写入前按 Schema 校验合并结果（XML 为合并后文档的解析结果），未通过时除非强制执行否则不写入
+	violations, err := validateConfigSchema(config.Target.Schema, mergedData)
+	if err != nil {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("加载 Schema 失败: %v", err)
+		return result, err
+	}
+	if len(violations) > 0 {
+		result.Records = append(result.Records, schemaViolationRecords(config.Target.Schema, violations)...)
+		for _, v := range violations {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("Schema 校验失败 %s", v))
+		}
+		if !config.Options.Force {
+			result.Status = constants.TaskStatusFailed
+			result.Message = fmt.Sprintf("合并结果未通过 Schema 校验（%d 处），未写入目标文件", len(violations))
+			return result, fmt.Errorf("schema validation failed with %d violation(s)", len(violations))
 		}
-	default:
-		mergedData = filteredSource
 	}
 
+	// 记录改写
+	result.Records = append(result.Records, contentRecords...)
+	result.Records = append(result.Records, valueRecords...)
+
 	// 记录变更
 	for key, newValue := range mergedData {
 		record := core.MigrationRecord{
@@ -161,14 +209,26 @@ func (s *ConfigFileStrategy) Execute(ctx context.Context, config *core.Migration
 	}
 
 	// 写入目标文件
-	format := config.Target.Format
-	if format == "" {
-		format = config.Source.Format
+	var writeErr error
+	if mergedContent != nil {
+		writeErr = s.writeConfigContent(config.Target.Path, mergedContent, writeEncoding)
+	} else {
+		baseContent := s.formatBase(config, config.Source.Path, config.Target.Path, format)
+		if config.Target.MergeMode != "merge" && config.Target.MergeMode != "skip" {
+			// 以源文件为格式基准时，基准内容同样按 content 规则改写
+			baseContent, _ = rewriter.rewriteContent(filepath.Base(config.Source.Path), baseContent)
+		}
+		writeData := iniWriteData(format, s.detectFormat(config.Source.Path, config.Source.Format), config.Source.Path, sourceContent, mergedData)
+		var warning string
+		warning, writeErr = s.writeConfigFileWithBase(config.Target.Path, baseContent, writeData, format, writeEncoding, config.Options.PreserveFormat)
+		if warning != "" {
+			result.Warnings = append(result.Warnings, warning)
+		}
 	}
-	if err := s.writeConfigFile(config.Target.Path, mergedData, format, config.Target.Encoding); err != nil {
+	if writeErr != nil {
 		result.Status = constants.TaskStatusFailed
-		result.Message = fmt.Sprintf("写入目标配置文件失败: %v", err)
-		return result, err
+		result.Message = fmt.Sprintf("写入目标配置文件失败: %v", writeErr)
+		return result, writeErr
 	}
 
 	// 如果需要创建不存在的目录
@@ -187,6 +247,10 @@ func (s *ConfigFileStrategy) Execute(ctx context.Context, config *core.Migration
 
 // Rollback 回滚配置文件迁移
 func (s *ConfigFileStrategy) Rollback(ctx context.Context, config *core.MigrationConfig) error {
+	if isMultiFileSource(config.Source.Path) {
+		return s.rollbackFiles(ctx, config)
+	}
+
 	// 检查是否有备份文件
 	backupPath := config.Target.BackupPath
 	if backupPath == "" {
@@ -207,6 +271,10 @@ func (s *ConfigFileStrategy) Rollback(ctx context.Context, config *core.Migratio
 
 // DryRun 预览配置文件迁移
 func (s *ConfigFileStrategy) DryRun(ctx context.Context, config *core.MigrationConfig) (*core.MigrationPreview, error) {
+	if isMultiFileSource(config.Source.Path) {
+		return s.dryRunFiles(ctx, config)
+	}
+
 	preview := core.NewMigrationPreview(config.TaskID)
 
 	// 检查源文件是否存在
@@ -215,8 +283,14 @@ func (s *ConfigFileStrategy) DryRun(ctx context.Context, config *core.MigrationC
 		return preview, nil
 	}
 
+	rewriter, err := newValueRewriter(config.Rewrites)
+	if err != nil {
+		preview.Errors = append(preview.Errors, fmt.Sprintf("改写规则无效: %v", err))
+		return preview, nil
+	}
+
 	// 读取源配置文件
-	sourceData, err := s.readConfigFile(config.Source.Path, config.Source.Format, config.Source.Encoding)
+	sourceContent, sourceData, _, err := s.readSourceConfig(config, rewriter)
 	if err != nil {
 		preview.Errors = append(preview.Errors, fmt.Sprintf("读取源配置文件失败: %v", err))
 		return preview, nil
@@ -234,11 +308,31 @@ func (s *ConfigFileStrategy) DryRun(ctx context.Context, config *core.MigrationC
 		preview.Warnings = append(preview.Warnings, fmt.Sprintf("目标配置文件不存在，将创建新文件: %s", config.Target.Path))
 	}
 
-	// 应用过滤条件
-	filteredSource := s.applyFilter(sourceData, config.Source.Filter)
+	// 应用过滤条件并改写源配置值
+	filteredSource, _ := rewriter.rewriteData(s.applyFilter(sourceData, config.Source.Filter), "")
 
-	// 生成预览
-	for key, newValue := range filteredSource {
+	// 预览按合并模式处理后的值；XML 与执行时一样按元素合并，预览与校验合并后文档的解析结果
+	afterData := s.applyMergeMode(config.Target, targetData, filteredSource)
+	if _, data, _, ok := s.mergeXMLSource(config, s.writeFormat(config), sourceContent, rewriter); ok {
+		afterData = data
+	}
+
+	// 合并结果按 Schema 校验，失败项作为错误报告
+	violations, err := validateConfigSchema(config.Target.Schema, afterData)
+	if err != nil {
+		preview.Errors = append(preview.Errors, fmt.Sprintf("加载 Schema 失败: %v", err))
+	}
+	for _, v := range violations {
+		preview.Errors = append(preview.Errors, fmt.Sprintf("Schema 校验失败 %s", v))
+	}
+
+	// 生成预览；按规则合并的数组只列出元素级变更，不再重复计入整个键
+	elementMerge := config.Target.MergeMode == "merge"
+	for key := range filteredSource {
+		if elementMerge && elementMergeRule(targetData[key], afterData[key], config.Target.ArrayMerge, key) != nil {
+			continue
+		}
+		newValue := afterData[key]
 		change := core.PreviewChange{
 			Key:        key,
 			AfterValue: fmt.Sprintf("%v", newValue),
@@ -264,46 +358,64 @@ func (s *ConfigFileStrategy) DryRun(ctx context.Context, config *core.MigrationC
 		preview.Summary.Total++
 	}
 
+	// 按规则合并的数组列出元素级变更
+	if elementMerge {
+		for _, change := range arrayElementChanges(targetData, afterData, config.Target.ArrayMerge, "") {
+			if change.ActionType == constants.ActionTypeCreate {
+				preview.Summary.Create++
+			} else {
+				preview.Summary.Update++
+			}
+			preview.Changes = append(preview.Changes, change)
+			preview.Summary.Total++
+		}
+	}
+
 	return preview, nil
 }
 
 // readConfigFile 读取配置文件
 func (s *ConfigFileStrategy) readConfigFile(path, format, encoding string) (map[string]interface{}, error) {
-	data := make(map[string]interface{})
+	// 读取文件内容并解码为 UTF-8
+	content, _, err := s.readConfigText(path, encoding)
+	if err != nil {
+		return nil, err
+	}
+	return s.parseConfigContent(path, format, content)
+}
 
-	// 读取文件内容
-	content, err := os.ReadFile(path)
+// readSourceConfig 读取源配置文件，先按 content 规则改写文件内容再解析
+// 返回改写后的内容、解析结果与改写记录
+func (s *ConfigFileStrategy) readSourceConfig(config *core.MigrationConfig, rewriter *valueRewriter) ([]byte, map[string]interface{}, []core.MigrationRecord, error) {
+	content, _, err := s.readConfigText(config.Source.Path, config.Source.Encoding)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	content, records := rewriter.rewriteContent(filepath.Base(config.Source.Path), content)
+	data, err := s.parseConfigContent(config.Source.Path, config.Source.Format, content)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+		return nil, nil, nil, err
 	}
+	return content, data, records, nil
+}
 
-	// 如果未指定格式，从文件扩展名推断
+// parseConfigContent 解析已解码为 UTF-8 的配置内容，path 仅用于推断格式与方言
+func (s *ConfigFileStrategy) parseConfigContent(path, format string, content []byte) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+
+	// 如果未指定格式，从文件名推断，再根据内容嗅探
 	if format == "" {
-		ext := strings.ToLower(filepath.Ext(path))
-		switch ext {
-		case ".json":
-			format = "json"
-		case ".yaml", ".yml":
-			format = "yaml"
-		case ".ini":
-			format = "ini"
-		case ".toml":
-			format = "toml"
-		case ".xml":
-			format = "xml"
-		case ".icls":
-			// IntelliJ color scheme files are XML-based
-			format = "xml"
-		default:
-			// 对于未知格式，返回空数据，让调用方可以处理原始内容
-			return data, nil
-		}
+		format = formatFromPath(path)
+	}
+	if format == "" {
+		format = sniffFormat(content)
 	}
 
 	// 根据格式解析
 	switch strings.ToLower(format) {
-	case "json":
-		if err := json.Unmarshal(content, &data); err != nil {
+	case "json", "jsonc", "json5":
+		// VS Code 等工具的 JSON 配置常带注释与尾随逗号，按 JSON5 宽松解析
+		if err := unmarshalJSON5(content, &data); err != nil {
 			return nil, fmt.Errorf("failed to parse JSON: %w", err)
 		}
 	case "yaml":
@@ -311,22 +423,10 @@ func (s *ConfigFileStrategy) readConfigFile(path, format, encoding string) (map[
 			return nil, fmt.Errorf("failed to parse YAML: %w", err)
 		}
 	case "ini":
-		cfg, err := ini.Load(path)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse INI: %w", err)
-		}
-		for _, section := range cfg.Sections() {
-			for _, key := range section.Keys() {
-				fullKey := section.Name() + "." + key.Name()
-				if section.Name() == ini.DefaultSection {
-					fullKey = key.Name()
-				}
-				data[fullKey] = key.Value()
-			}
-		}
+		data = readINI(path, content)
 	case "toml":
 		// 简化的 TOML 支持，使用类似 INI 的方式
-		cfg, err := ini.Load(path)
+		cfg, err := ini.Load(content)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse TOML: %w", err)
 		}
@@ -339,14 +439,30 @@ func (s *ConfigFileStrategy) readConfigFile(path, format, encoding string) (map[
 				data[fullKey] = key.Value()
 			}
 		}
+	case "properties":
+		data = readKV(content, propertiesSyntax{})
+	case "env":
+		data = readKV(content, dotenvSyntax{})
+	case "plist":
+		plistData, err := readPlist(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse plist: %w", err)
+		}
+		data = plistData
+	case "hcl":
+		hclData, err := readHCL(path, content)
+		if err != nil {
+			return nil, err
+		}
+		data = hclData
 	case "xml":
-		// XML 支持：将 XML 转换为 map
-		var xmlNode XMLNode
-		if err := xml.Unmarshal(content, &xmlNode); err != nil {
+		// XML 支持：以根元素名为键转换为 map，属性以 @ 为前缀
+		xmlData, err := readXML(content)
+		if err != nil {
 			// XML 解析失败时返回空 map，让调用方可以处理原始内容
 			return data, nil
 		}
-		data = xmlNodeToMap(&xmlNode)
+		data = xmlData
 	default:
 		// 对于不支持的格式，返回空数据，让调用方可以处理原始内容
 		return data, nil
@@ -357,33 +473,50 @@ func (s *ConfigFileStrategy) readConfigFile(path, format, encoding string) (map[
 
 // writeConfigFile 写入配置文件
 func (s *ConfigFileStrategy) writeConfigFile(path string, data map[string]interface{}, format, encoding string) error {
-	var content []byte
-	var err error
+	content, err := s.marshalConfig(path, data, format)
+	if err != nil {
+		return err
+	}
+	return s.writeConfigContent(path, content, encoding)
+}
 
-	// 确保目录存在
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+// writeConfigFileWithBase 以 base 为原始文档写入配置文件，只修改发生变化的节点
+// base 为空时完整序列化；无法保持原样写入时，strict 为 true 返回错误，否则退回完整序列化并返回说明注释与顺序丢失的警告
+func (s *ConfigFileStrategy) writeConfigFileWithBase(path string, base []byte, data map[string]interface{}, format, encoding string, strict bool) (string, error) {
+	if len(bytes.TrimSpace(base)) == 0 {
+		return "", s.writeConfigFile(path, data, format, encoding)
+	}
+	content, err := preserveConfigContent(path, base, data, format)
+	if err == nil {
+		return "", s.writeConfigContent(path, content, encoding)
 	}
+	if strict {
+		return "", fmt.Errorf("failed to preserve format of %s: %w", path, err)
+	}
+	warning := fmt.Sprintf("无法保持 %s 的原始格式（%v），已完整重写，注释与键顺序未保留", path, err)
+	return warning, s.writeConfigFile(path, data, format, encoding)
+}
+
+// marshalConfig 将配置数据序列化为指定格式，path 用于推断 INI 方言
+func (s *ConfigFileStrategy) marshalConfig(path string, data map[string]interface{}, format string) ([]byte, error) {
+	var content []byte
+	var err error
 
 	// 根据格式序列化
 	switch strings.ToLower(format) {
-	case "json":
+	case "json", "jsonc", "json5":
 		content, err = json.MarshalIndent(data, "", "  ")
 		if err != nil {
-			return fmt.Errorf("failed to serialize JSON: %w", err)
+			return nil, fmt.Errorf("failed to serialize JSON: %w", err)
 		}
 	case "yaml":
 		content, err = yaml.Marshal(data)
 		if err != nil {
-			return fmt.Errorf("failed to serialize YAML: %w", err)
+			return nil, fmt.Errorf("failed to serialize YAML: %w", err)
 		}
 	case "ini":
-		cfg := ini.Empty()
-		for key, value := range data {
-			cfg.Section("").Key(key).SetValue(fmt.Sprintf("%v", value))
-		}
-		content = []byte(cfgToString(cfg))
+		// 根据扁平化键或嵌套 map 重建节结构
+		content = marshalINI(path, data)
 	case "toml":
 		// 简化的 TOML 支持
 		var sb strings.Builder
@@ -391,37 +524,117 @@ func (s *ConfigFileStrategy) writeConfigFile(path string, data map[string]interf
 			sb.WriteString(fmt.Sprintf("%s = %v\n", key, value))
 		}
 		content = []byte(sb.String())
+	case "properties":
+		content = marshalKV(data, propertiesSyntax{})
+	case "env":
+		content = marshalKV(data, dotenvSyntax{})
+	case "plist":
+		content = marshalPlist(data)
+	case "hcl":
+		content = marshalHCL(data)
 	case "xml":
 		// XML 支持
-		xmlContent, err := mapToXML(data)
+		xmlContent, err := marshalXML(data)
 		if err != nil {
-			return fmt.Errorf("failed to serialize XML: %w", err)
+			return nil, fmt.Errorf("failed to serialize XML: %w", err)
 		}
 		content = xmlContent
 	default:
-		return fmt.Errorf("unsupported format: %s", format)
+		return nil, fmt.Errorf("unsupported format: %s", format)
 	}
 
-	// 写入文件
-	if err := os.WriteFile(path, content, 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+	return content, nil
+}
+
+// writeConfigContent 将序列化后的 UTF-8 内容按 encoding 编码后写入文件
+func (s *ConfigFileStrategy) writeConfigContent(path string, content []byte, encoding string) error {
+	content, err := encodeText(content, encoding)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	// 原子写入，目标文件已存在时保留其权限与属主
+	return safefile.WriteFile(path, content, 0)
 }
 
-// cfgToString 将 ini 配置转为字符串
-func cfgToString(cfg *ini.File) string {
-	var sb strings.Builder
-	for _, section := range cfg.Sections() {
-		if section.Name() != ini.DefaultSection {
-			sb.WriteString(fmt.Sprintf("[%s]\n", section.Name()))
-		}
-		for _, key := range section.Keys() {
-			sb.WriteString(fmt.Sprintf("%s = %s\n", key.Name(), key.Value()))
+// formatBase 返回保持格式写入时使用的基准文档
+// 合并/跳过模式以目标文件为基准；覆盖模式在源与目标格式相同时以源文件为基准
+func (s *ConfigFileStrategy) formatBase(config *core.MigrationConfig, sourcePath, targetPath, targetFormat string) []byte {
+	var basePath, encoding string
+	switch config.Target.MergeMode {
+	case "merge", "skip":
+		basePath, encoding = targetPath, config.Target.Encoding
+	default:
+		if sourcePath == "" || !strings.EqualFold(s.detectFormat(sourcePath, config.Source.Format), targetFormat) {
+			return nil
 		}
+		basePath, encoding = sourcePath, config.Source.Encoding
+	}
+
+	content, _, err := s.readConfigText(basePath, encoding)
+	if err != nil {
+		return nil
+	}
+	return content
+}
+
+// iniWriteData 写入 INI 时将源文档默认节中含点的键（如 log.level）保留在默认节，只有来自 [节] 的键按点拆分为节
+// 目标或源不是 INI、或没有源文档内容时原样返回
+func iniWriteData(format, sourceFormat, sourcePath string, sourceContent []byte, data map[string]interface{}) map[string]interface{} {
+	if !strings.EqualFold(format, "ini") || !strings.EqualFold(sourceFormat, "ini") || len(sourceContent) == 0 {
+		return data
+	}
+	return literalINIData(data, iniLiteralKeys(sourcePath, sourceContent))
+}
+
+// writeFormat 确定写入目标的格式：依次为目标格式、源格式、按目标路径与源路径推断
+func (s *ConfigFileStrategy) writeFormat(config *core.MigrationConfig) string {
+	format := config.Target.Format
+	if format == "" {
+		format = config.Source.Format
+	}
+	if format == "" {
+		format = s.detectFormat(config.Target.Path, "")
+	}
+	if format == "unknown" {
+		format = s.detectFormat(config.Source.Path, "")
+	}
+	return format
+}
+
+// mergeXMLSource 将 XML 源文档按元素合并进目标，返回合并后的内容、其解析结果与改写记录
+// 按元素合并时直接改写源文档，改写记录以文档为准；非 XML、设置了过滤条件或无法按元素合并时返回 false
+func (s *ConfigFileStrategy) mergeXMLSource(config *core.MigrationConfig, format string, sourceContent []byte, rewriter *valueRewriter) ([]byte, map[string]interface{}, []core.MigrationRecord, bool) {
+	if !strings.EqualFold(format, "xml") || len(config.Source.Filter.Include) > 0 || len(config.Source.Filter.Exclude) > 0 {
+		return nil, nil, nil, false
+	}
+	xmlSource, records := rewriter.rewriteXML(sourceContent)
+	content, ok := s.mergeXMLTarget(config.Target.MergeMode, config.Target.Path, config.Target.Encoding, xmlSource)
+	if !ok {
+		return nil, nil, nil, false
+	}
+	data, err := readXML(content)
+	if err != nil {
+		return nil, nil, nil, false
+	}
+	return content, data, records, true
+}
+
+// mergeXMLTarget 在合并/跳过模式下将 source 按元素合并进已存在的目标 XML 文件
+// 目标不存在、非合并模式或任一侧无法解析时返回 false，由调用方按普通方式写入
+func (s *ConfigFileStrategy) mergeXMLTarget(mergeMode, targetPath, targetEncoding string, source []byte) ([]byte, bool) {
+	if mergeMode != "merge" && mergeMode != "skip" {
+		return nil, false
+	}
+	target, _, err := s.readConfigText(targetPath, targetEncoding)
+	if err != nil {
+		return nil, false
 	}
-	return sb.String()
+	content, err := mergeXMLContent(target, source, mergeMode == "merge")
+	if err != nil {
+		return nil, false
+	}
+	return content, true
 }
 
 // applyFilter 应用过滤条件
@@ -432,7 +645,7 @@ func (s *ConfigFileStrategy) applyFilter(data map[string]interface{}, filter cor
 		// 检查是否在排除列表中
 		excluded := false
 		for _, exclude := range filter.Exclude {
-			if key == exclude || strings.HasPrefix(key, exclude+".") {
+			if matchFilterKey(key, exclude) {
 				excluded = true
 				break
 			}
@@ -445,7 +658,7 @@ func (s *ConfigFileStrategy) applyFilter(data map[string]interface{}, filter cor
 		if len(filter.Include) > 0 {
 			included := false
 			for _, include := range filter.Include {
-				if key == include || strings.HasPrefix(key, include+".") {
+				if matchFilterKey(key, include) {
 					included = true
 					break
 				}
@@ -461,8 +674,43 @@ func (s *ConfigFileStrategy) applyFilter(data map[string]interface{}, filter cor
 	return result
 }
 
+// matchFilterKey 判断键是否匹配包含/排除规则：键与规则相同或位于规则之下（规则.子键）；规则中的 * 匹配任意字符
+func matchFilterKey(key, pattern string) bool {
+	if key == pattern || strings.HasPrefix(key, pattern+".") {
+		return true
+	}
+	if !strings.Contains(pattern, "*") {
+		return false
+	}
+	expr := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	matched, _ := regexp.MatchString(`^`+expr+`(\..*)?$`, key)
+	return matched
+}
+
+// applyMergeMode 按目标的合并模式处理目标与源配置，返回写入目标的数据
+func (s *ConfigFileStrategy) applyMergeMode(target core.MigrationTarget, targetData, sourceData map[string]interface{}) map[string]interface{} {
+	switch target.MergeMode {
+	case "merge":
+		return s.mergeConfig(targetData, sourceData, target.ArrayMerge, "")
+	case "skip":
+		merged := make(map[string]interface{}, len(targetData)+len(sourceData))
+		for k, v := range targetData {
+			merged[k] = v
+		}
+		for k, v := range sourceData {
+			if _, exists := targetData[k]; !exists {
+				merged[k] = v
+			}
+		}
+		return merged
+	default:
+		return sourceData
+	}
+}
+
 // mergeConfig 合并配置
-func (s *ConfigFileStrategy) mergeConfig(target, source map[string]interface{}) map[string]interface{} {
+// map 递归合并；数组按 rules 中匹配 prefix 路径的规则合并，未匹配时整体替换
+func (s *ConfigFileStrategy) mergeConfig(target, source map[string]interface{}, rules []core.ArrayMergeRule, prefix string) map[string]interface{} {
 	result := make(map[string]interface{})
 
 	// 复制目标配置
@@ -476,7 +724,14 @@ func (s *ConfigFileStrategy) mergeConfig(target, source map[string]interface{})
 			// 如果两者都是 map，递归合并
 			if existingMap, ok1 := existing.(map[string]interface{}); ok1 {
 				if sourceMap, ok2 := v.(map[string]interface{}); ok2 {
-					result[k] = s.mergeConfig(existingMap, sourceMap)
+					result[k] = s.mergeConfig(existingMap, sourceMap, rules, joinConfigPath(prefix, k))
+					continue
+				}
+			}
+			// 如果两者都是数组，按规则合并
+			if existingArr, ok1 := existing.([]interface{}); ok1 {
+				if sourceArr, ok2 := v.([]interface{}); ok2 {
+					result[k] = s.mergeArray(existingArr, sourceArr, rules, joinConfigPath(prefix, k))
 					continue
 				}
 			}
@@ -494,20 +749,7 @@ func (s *ConfigFileStrategy) backupFile(src, dst string) error {
 
 // copyFile 复制文件
 func (s *ConfigFileStrategy) copyFile(src, dst string) error {
-	source, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer source.Close()
-
-	destination, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer destination.Close()
-
-	_, err = io.Copy(destination, source)
-	return err
+	return safefile.CopyFile(src, dst)
 }
 
 // getActionType 获取操作类型
@@ -520,6 +762,10 @@ func (s *ConfigFileStrategy) getActionType(data map[string]interface{}, key stri
 
 // Export 导出配置文件
 func (s *ConfigFileStrategy) Export(ctx context.Context, config *core.MigrationConfig) (*core.ExportResult, error) {
+	if isMultiFileSource(config.Source.Path) {
+		return s.exportFiles(ctx, config)
+	}
+
 	result := core.NewExportResult(config.TaskID)
 	result.ExportID = generateExportID()
 	defer func() {
@@ -551,19 +797,33 @@ func (s *ConfigFileStrategy) Export(ctx context.Context, config *core.MigrationC
 	exportPkg.Metadata.SourceType = string(constants.MigrationTypeConfigFile)
 	exportPkg.Metadata.OriginalFormat = s.detectFormat(config.Source.Path, config.Source.Format)
 	exportPkg.Metadata.OriginalPath = config.Source.Path
-	exportPkg.Metadata.OriginalEncoding = config.Source.Encoding
-	exportPkg.Metadata.Checksum = s.calculateChecksum(filteredData)
+	exportPkg.Metadata.OriginalEncoding = s.fileEncoding(config.Source.Path, config.Source.Encoding)
 
 	exportPkg.Content.Data = filteredData
 
-	// 5. 可选：包含原始内容
-	if config.Options.IncludeRawContent {
+	// 5. 可选：包含原始内容；过滤掉了部分键（如凭据）时原始内容中仍有这些键，不包含
+	if config.Options.IncludeRawContent && len(filteredData) < len(sourceData) {
+		result.Records = append(result.Records, core.MigrationRecord{
+			StepName:   "包含原始内容",
+			ActionType: constants.ActionTypeExport,
+			Key:        config.Source.Path,
+			Status:     constants.RecordStatusSkipped,
+			Message:    "已按过滤条件排除部分配置项，导出包不包含原始内容",
+			Timestamp:  time.Now(),
+		})
+	} else if config.Options.IncludeRawContent {
 		rawContent, err := os.ReadFile(config.Source.Path)
 		if err == nil {
 			exportPkg.Content.RawContent = base64.StdEncoding.EncodeToString(rawContent)
 		}
 	}
 
+	// 本机相关值替换为占位符，使导出包可在其他机器导入；校验和按替换后的数据计算
+	if !config.Options.NoPlaceholders {
+		result.Records = append(result.Records, encodePackagePlaceholders(exportPkg)...)
+	}
+	exportPkg.Metadata.Checksum = s.calculateChecksum(exportPkg.Content.Data)
+
 	// 6. 确定导出路径
 	exportPath := config.Options.ExportPath
 	if exportPath == "" {
@@ -586,7 +846,7 @@ func (s *ConfigFileStrategy) Export(ctx context.Context, config *core.MigrationC
 		return result, err
 	}
 
-	if err := os.WriteFile(exportPath, exportJSON, 0644); err != nil {
+	if err := safefile.WriteFile(exportPath, exportJSON, 0); err != nil {
 		result.Status = constants.TaskStatusFailed
 		result.Message = fmt.Sprintf("写入导出文件失败: %v", err)
 		return result, err
@@ -613,17 +873,20 @@ func (s *ConfigFileStrategy) Export(ctx context.Context, config *core.MigrationC
 
 // Import 导入配置文件
 func (s *ConfigFileStrategy) Import(ctx context.Context, config *core.MigrationConfig) (*core.ImportResult, error) {
-	result := core.NewImportResult(config.TaskID)
-	defer func() {
-		result.EndTime = time.Now()
-		result.Duration = result.EndTime.Sub(result.StartTime).Milliseconds()
-	}()
-
 	// 1. 确定导入文件路径
 	importPath := config.Options.ImportPath
 	if importPath == "" {
 		importPath = config.Source.Path
 	}
+	if isMultiFileSource(importPath) {
+		return s.importFiles(ctx, config, importPath)
+	}
+
+	result := core.NewImportResult(config.TaskID)
+	defer func() {
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime).Milliseconds()
+	}()
 
 	// 2. 读取导入文件
 	importContent, err := os.ReadFile(importPath)
@@ -650,6 +913,16 @@ func (s *ConfigFileStrategy) Import(ctx context.Context, config *core.MigrationC
 		return result, err
 	}
 
+	rewriter, err := newValueRewriter(config.Rewrites)
+	if err != nil {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("改写规则无效: %v", err)
+		return result, err
+	}
+
+	// 按本机取值还原占位符
+	result.Records = append(result.Records, resolvePackagePlaceholders(&exportPkg)...)
+
 	// 5. 确定目标格式
 	targetFormat := config.Target.Format
 	if config.Options.PreserveFormat || targetFormat == "" {
@@ -662,6 +935,9 @@ func (s *ConfigFileStrategy) Import(ctx context.Context, config *core.MigrationC
 		targetPath = exportPkg.Metadata.OriginalPath
 	}
 
+	// 确定写入编码：未指定时沿用目标文件现有编码，否则还原为导出时的原始编码
+	writeEncoding := s.resolveWriteEncoding(config.Target.Encoding, targetPath, exportPkg.Metadata.OriginalEncoding)
+
 	// 7. 备份现有文件（如果需要）
 	if config.Target.Backup {
 		if _, err := os.Stat(targetPath); err == nil {
@@ -686,22 +962,53 @@ func (s *ConfigFileStrategy) Import(ctx context.Context, config *core.MigrationC
 		targetData = make(map[string]interface{})
 	}
 
-	// 9. 应用合并策略
-	var mergedData map[string]interface{}
-	switch config.Target.MergeMode {
-	case "overwrite":
-		mergedData = exportPkg.Content.Data
-	case "merge":
-		mergedData = s.mergeConfig(targetData, exportPkg.Content.Data)
-	case "skip":
-		mergedData = targetData
-		for k, v := range exportPkg.Content.Data {
-			if _, exists := targetData[k]; !exists {
-				mergedData[k] = v
+	// 9. 改写导入的配置值，随后应用合并策略
+	sourceName := filepath.Base(exportPkg.Metadata.OriginalPath)
+	importData, valueRecords := rewriter.rewriteData(exportPkg.Content.Data, "")
+
+	mergedData := s.applyMergeMode(config.Target, targetData, importData)
+
+	// XML 按元素合并，优先使用导出包中的原始内容
+	var mergedContent []byte
+	if strings.EqualFold(targetFormat, "xml") {
+		// 原始内容保持导出时的编码，需先解码
+		sourceContent, err := base64.StdEncoding.DecodeString(exportPkg.Content.RawContent)
+		if err == nil && len(sourceContent) > 0 {
+			sourceContent, _, err = decodeText(sourceContent, exportPkg.Metadata.OriginalEncoding)
+		}
+		// 原始内容需按规则改写；由已改写的数据生成时无需再次改写
+		xmlRecords := valueRecords
+		if err == nil && len(sourceContent) > 0 {
+			var contentRecords []core.MigrationRecord
+			sourceContent, contentRecords = rewriter.rewriteContent(sourceName, sourceContent)
+			sourceContent, xmlRecords = rewriter.rewriteXML(sourceContent)
+			xmlRecords = append(contentRecords, xmlRecords...)
+		} else {
+			sourceContent, err = marshalXML(importData)
+		}
+		if err == nil {
+			if content, ok := s.mergeXMLTarget(config.Target.MergeMode, targetPath, config.Target.Encoding, sourceContent); ok {
+				mergedContent = content
+				mergedData, _ = readXML(content)
+				valueRecords = xmlRecords
 			}
 		}
-	default:
-		mergedData = exportPkg.Content.Data
+	}
+
+	// 写入前按 Schema 校验合并结果，未通过时除非强制执行否则不写入
+	violations, err := validateConfigSchema(config.Target.Schema, mergedData)
+	if err != nil {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("加载 Schema 失败: %v", err)
+		return result, err
+	}
+	if len(violations) > 0 {
+		result.Records = append(result.Records, schemaViolationRecords(config.Target.Schema, violations)...)
+		if !config.Options.Force {
+			result.Status = constants.TaskStatusFailed
+			result.Message = fmt.Sprintf("导入结果未通过 Schema 校验（%d 处），未写入目标文件: %s", len(violations), violations[0])
+			return result, fmt.Errorf("schema validation failed with %d violation(s)", len(violations))
+		}
 	}
 
 	// 10. 确保目标目录存在
@@ -713,8 +1020,11 @@ func (s *ConfigFileStrategy) Import(ctx context.Context, config *core.MigrationC
 	}
 
 	// 11. 写入目标文件
-	// 优先使用原始内容（如果有）
-	if exportPkg.Content.RawContent != "" && config.Options.PreserveFormat {
+	// 优先使用原始内容（如果有）；配置值被改写时原始内容已过时，改为写入数据
+	// 原始内容不含目标现有配置，也未经 Schema 校验，仅在覆盖模式且未设置 Schema 时直接写入
+	overwrite := config.Target.MergeMode == "" || config.Target.MergeMode == "overwrite"
+	if mergedContent == nil && exportPkg.Content.RawContent != "" && config.Options.PreserveFormat && len(valueRecords) == 0 &&
+		overwrite && config.Target.Schema == "" {
 		// 解码原始内容并直接写入
 		rawBytes, err := base64.StdEncoding.DecodeString(exportPkg.Content.RawContent)
 		if err != nil {
@@ -722,7 +1032,16 @@ func (s *ConfigFileStrategy) Import(ctx context.Context, config *core.MigrationC
 			result.Message = fmt.Sprintf("解码原始内容失败: %v", err)
 			return result, err
 		}
-		if err := os.WriteFile(targetPath, rawBytes, 0644); err != nil {
+		// 按 content 规则改写原始内容，改写后还原为原始编码
+		if text, encoding, err := decodeText(rawBytes, exportPkg.Metadata.OriginalEncoding); err == nil {
+			if rewritten, records := rewriter.rewriteContent(sourceName, text); len(records) > 0 {
+				if encoded, err := encodeText(rewritten, encoding); err == nil {
+					rawBytes = encoded
+					result.Records = append(result.Records, records...)
+				}
+			}
+		}
+		if err := safefile.WriteFile(targetPath, rawBytes, 0); err != nil {
 			result.Status = constants.TaskStatusFailed
 			result.Message = fmt.Sprintf("写入目标文件失败: %v", err)
 			return result, err
@@ -739,11 +1058,31 @@ func (s *ConfigFileStrategy) Import(ctx context.Context, config *core.MigrationC
 		result.Records = append(result.Records, record)
 		result.Summary.Total++
 		result.Summary.Success++
-	} else if err := s.writeConfigFile(targetPath, mergedData, targetFormat, config.Target.Encoding); err != nil {
-		result.Status = constants.TaskStatusFailed
-		result.Message = fmt.Sprintf("写入目标文件失败: %v", err)
-		return result, err
 	} else {
+		result.Records = append(result.Records, valueRecords...)
+
+		var writeErr error
+		if mergedContent != nil {
+			writeErr = s.writeConfigContent(targetPath, mergedContent, writeEncoding)
+		} else {
+			// 导出包含原始内容时，按原始内容区分 INI 默认节中含点的键
+			var rawText []byte
+			if raw, err := base64.StdEncoding.DecodeString(exportPkg.Content.RawContent); err == nil && len(raw) > 0 {
+				rawText, _, _ = decodeText(raw, exportPkg.Metadata.OriginalEncoding)
+			}
+			writeData := iniWriteData(targetFormat, exportPkg.Metadata.OriginalFormat, exportPkg.Metadata.OriginalPath, rawText, mergedData)
+			var warning string
+			warning, writeErr = s.writeConfigFileWithBase(targetPath, s.formatBase(config, "", targetPath, targetFormat), writeData, targetFormat, writeEncoding, config.Options.PreserveFormat)
+			if warning != "" {
+				result.Warnings = append(result.Warnings, warning)
+			}
+		}
+		if writeErr != nil {
+			result.Status = constants.TaskStatusFailed
+			result.Message = fmt.Sprintf("写入目标文件失败: %v", writeErr)
+			return result, writeErr
+		}
+
 		// 12. 记录导入操作
 		for key, value := range mergedData {
 			record := core.MigrationRecord{
@@ -781,6 +1120,10 @@ func (s *ConfigFileStrategy) ValidateExport(config *core.MigrationConfig) error
 		return fmt.Errorf("source path is required for export")
 	}
 
+	if isMultiFileSource(config.Source.Path) {
+		return s.validateFiles(config)
+	}
+
 	if _, err := os.Stat(config.Source.Path); os.IsNotExist(err) {
 		return fmt.Errorf("source file does not exist: %s", config.Source.Path)
 	}
@@ -803,6 +1146,20 @@ func (s *ConfigFileStrategy) ValidateImport(config *core.MigrationConfig) error
 		importPath = config.Source.Path
 	}
 
+	if isMultiFileSource(importPath) {
+		if config.Target.Path == "" {
+			return fmt.Errorf("target path is required when importing multiple files")
+		}
+		files, err := expandImportSource(importPath)
+		if err != nil {
+			return fmt.Errorf("failed to list import files: %w", err)
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no export packages matched: %s", importPath)
+		}
+		return nil
+	}
+
 	if _, err := os.Stat(importPath); os.IsNotExist(err) {
 		return fmt.Errorf("import file does not exist: %s", importPath)
 	}
@@ -810,30 +1167,29 @@ func (s *ConfigFileStrategy) ValidateImport(config *core.MigrationConfig) error
 	return nil
 }
 
-// detectFormat 检测文件格式
+// detectFormat 检测文件格式：显式指定优先，其次按文件名推断，最后嗅探文件内容
 func (s *ConfigFileStrategy) detectFormat(path, format string) string {
 	if format != "" {
 		return format
 	}
-	ext := strings.ToLower(filepath.Ext(path))
-	switch ext {
-	case ".json":
-		return "json"
-	case ".yaml", ".yml":
-		return "yaml"
-	case ".ini":
-		return "ini"
-	case ".toml":
-		return "toml"
-	case ".xml":
-		return "xml"
-	default:
-		return "unknown"
+	if detected := formatFromPath(path); detected != "" {
+		return detected
 	}
+	if content, _, err := s.readConfigText(path, ""); err == nil {
+		if detected := sniffFormat(content); detected != "" {
+			return detected
+		}
+	}
+	return "unknown"
 }
 
 // calculateChecksum 计算内容校验和
 func (s *ConfigFileStrategy) calculateChecksum(data map[string]interface{}) string {
+	return calculateDataChecksum(data)
+}
+
+// calculateDataChecksum 计算导出包内容校验和
+func calculateDataChecksum(data map[string]interface{}) string {
 	content, _ := json.Marshal(data)
 	hash := sha256.Sum256(content)
 	return "sha256:" + hex.EncodeToString(hash[:])
@@ -854,97 +1210,3 @@ func (s *ConfigFileStrategy) validateExportPackage(pkg *core.ExportPackage) erro
 func generateExportID() string {
 	return fmt.Sprintf("export_%d", time.Now().UnixNano())
 }
-
-// XMLNode 用于解析 XML 的通用节点结构
-type XMLNode struct {
-	XMLName xml.Name
-	Attrs   []xml.Attr `xml:",any,attr"`
-	Content string     `xml:",chardata"`
-	Nodes   []XMLNode  `xml:",any"`
-}
-
-// xmlNodeToMap 将 XML 节点转换为 map
-func xmlNodeToMap(node *XMLNode) map[string]interface{} {
-	result := make(map[string]interface{})
-
-	// 处理属性
-	for _, attr := range node.Attrs {
-		result["@"+attr.Name.Local] = attr.Value
-	}
-
-	// 处理子节点
-	for _, child := range node.Nodes {
-		key := child.XMLName.Local
-		childMap := xmlNodeToMap(&child)
-
-		// 如果已有同名键，转换为数组
-		if existing, ok := result[key]; ok {
-			var arr []interface{}
-			switch v := existing.(type) {
-			case []interface{}:
-				arr = v
-			default:
-				arr = []interface{}{v}
-			}
-			result[key] = append(arr, childMap)
-		} else {
-			result[key] = childMap
-		}
-	}
-
-	// 处理文本内容
-	if strings.TrimSpace(node.Content) != "" && len(result) == 0 {
-		return map[string]interface{}{"#text": strings.TrimSpace(node.Content)}
-	} else if strings.TrimSpace(node.Content) != "" {
-		result["#text"] = strings.TrimSpace(node.Content)
-	}
-
-	return result
-}
-
-// mapToXML 将 map 转换为 XML 字节
-func mapToXML(data map[string]interface{}) ([]byte, error) {
-	var sb strings.Builder
-	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
-	sb.WriteString("\n")
-
-	rootWritten := false
-	for key, value := range data {
-		if strings.HasPrefix(key, "@") || key == "#text" {
-			continue
-		}
-		if !rootWritten {
-			sb.WriteString(fmt.Sprintf("<%s>", key))
-			mapToXMLElement(&sb, value)
-			sb.WriteString(fmt.Sprintf("</%s>\n", key))
-			rootWritten = true
-		}
-	}
-
-	return []byte(sb.String()), nil
-}
-
-// mapToXMLElement 将值转换为 XML 元素
-func mapToXMLElement(sb *strings.Builder, value interface{}) {
-	switch v := value.(type) {
-	case map[string]interface{}:
-		for key, val := range v {
-			if strings.HasPrefix(key, "@") {
-				continue // 跳过属性处理（简化）
-			}
-			if key == "#text" {
-				sb.WriteString(fmt.Sprintf("%v", val))
-				continue
-			}
-			sb.WriteString(fmt.Sprintf("<%s>", key))
-			mapToXMLElement(sb, val)
-			sb.WriteString(fmt.Sprintf("</%s>", key))
-		}
-	case []interface{}:
-		for _, item := range v {
-			mapToXMLElement(sb, item)
-		}
-	default:
-		sb.WriteString(fmt.Sprintf("%v", v))
-	}
-}
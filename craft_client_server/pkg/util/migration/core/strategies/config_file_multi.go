@@ -0,0 +1,446 @@
+package strategies
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+// exportSuffix 导出包文件后缀
+const exportSuffix = ".export.json"
+
+// configSourceFile 多文件源展开后的单个文件
+type configSourceFile struct {
+	path string // 文件路径
+	rel  string // 相对源基准目录的路径，以 / 分隔
+}
+
+// isMultiFileSource 判断源路径是否为目录或 glob 模式（如 options/**/*.xml）
+func isMultiFileSource(source string) bool {
+	if strings.ContainsAny(source, "*?[") {
+		return true
+	}
+	info, err := os.Stat(source)
+	return err == nil && info.IsDir()
+}
+
+// backupSuffix 迁移前备份文件的后缀
+const backupSuffix = ".backup"
+
+// expandConfigSource 展开目录或 glob 源为配置文件列表，按路径排序
+// 导出包（.export.json）与备份文件（.backup）是迁移自身的产物，不作为配置文件
+func expandConfigSource(source string) ([]configSourceFile, error) {
+	files, err := walkConfigSource(source)
+	if err != nil {
+		return nil, err
+	}
+	configs := files[:0]
+	for _, file := range files {
+		if strings.HasSuffix(file.rel, exportSuffix) || strings.HasSuffix(file.rel, backupSuffix) {
+			continue
+		}
+		configs = append(configs, file)
+	}
+	return configs, nil
+}
+
+// walkConfigSource 展开目录或 glob 源为文件列表，按路径排序
+// 目录源包含其下全部文件；glob 中的 ** 匹配任意多级目录
+func walkConfigSource(source string) ([]configSourceFile, error) {
+	base, pattern := splitGlobPattern(source)
+	info, err := os.Stat(base)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", base)
+	}
+
+	var files []configSourceFile
+	err = filepath.WalkDir(base, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if pattern != nil && !matchPathSegments(pattern, strings.Split(rel, "/")) {
+			return nil
+		}
+		files = append(files, configSourceFile{path: path, rel: rel})
+		return nil
+	})
+	return files, err
+}
+
+// splitGlobPattern 将 glob 源拆分为不含通配符的基准目录与其后的模式段；非 glob 源的模式为 nil
+func splitGlobPattern(source string) (string, []string) {
+	if !strings.ContainsAny(source, "*?[") {
+		return source, nil
+	}
+	segments := strings.Split(filepath.ToSlash(source), "/")
+	i := 0
+	for i < len(segments) && !strings.ContainsAny(segments[i], "*?[") {
+		i++
+	}
+	base := strings.Join(segments[:i], "/")
+	if base == "" && i > 0 {
+		base = "/"
+	} else if base == "" {
+		base = "."
+	}
+	return filepath.FromSlash(base), segments[i:]
+}
+
+// expandImportSource 展开目录或 glob 形式的导入路径；目录中只取 .export.json 文件
+func expandImportSource(source string) ([]configSourceFile, error) {
+	files, err := walkConfigSource(source)
+	if err != nil || strings.ContainsAny(source, "*?[") {
+		return files, err
+	}
+	exports := files[:0]
+	for _, file := range files {
+		if strings.HasSuffix(file.rel, exportSuffix) {
+			exports = append(exports, file)
+		}
+	}
+	return exports, nil
+}
+
+// fileConfig 返回处理单个文件时使用的配置副本
+// 目标路径按相对路径镜像到 Target.Path 下；各文件格式分别推断，忽略 Format 设置
+func (s *ConfigFileStrategy) fileConfig(config *core.MigrationConfig, sourcePath, rel string) *core.MigrationConfig {
+	fileConfig := *config
+	fileConfig.Source.Path = sourcePath
+	fileConfig.Source.Format = ""
+	fileConfig.Target.Format = ""
+	fileConfig.Target.Path = filepath.Join(config.Target.Path, filepath.FromSlash(rel))
+	if config.Target.BackupPath != "" {
+		fileConfig.Target.BackupPath = filepath.Join(config.Target.BackupPath, filepath.FromSlash(rel)+backupSuffix)
+	}
+	if config.Options.ExportPath != "" {
+		fileConfig.Options.ExportPath = filepath.Join(config.Options.ExportPath, filepath.FromSlash(rel)+exportSuffix)
+	}
+	return &fileConfig
+}
+
+// prefixRecords 为记录的键加上文件的相对路径前缀，如 options/editor.xml:key
+func prefixRecords(rel string, records []core.MigrationRecord) []core.MigrationRecord {
+	for i := range records {
+		records[i].Key = prefixKey(rel, records[i].Key)
+	}
+	return records
+}
+
+// prefixKey 为键加上文件的相对路径前缀，键为空时返回相对路径
+func prefixKey(rel, key string) string {
+	if key == "" {
+		return rel
+	}
+	return rel + ":" + key
+}
+
+// fileRecord 生成单个文件级别的记录（格式无法识别、处理失败等）
+func fileRecord(stepName, actionType, rel, status, message string) core.MigrationRecord {
+	return core.MigrationRecord{
+		StepName:   stepName,
+		ActionType: actionType,
+		Key:        rel,
+		Status:     status,
+		Message:    message,
+		Timestamp:  time.Now(),
+	}
+}
+
+// addSummary 累加汇总信息
+func addSummary(total *core.MigrationSummary, summary core.MigrationSummary) {
+	total.Total += summary.Total
+	total.Success += summary.Success
+	total.Failed += summary.Failed
+	total.Skipped += summary.Skipped
+	total.RolledBack += summary.RolledBack
+}
+
+// validateFiles 验证多文件源：源须至少匹配一个可识别格式的文件
+func (s *ConfigFileStrategy) validateFiles(config *core.MigrationConfig) error {
+	files, err := expandConfigSource(config.Source.Path)
+	if err != nil {
+		return fmt.Errorf("failed to list source files: %w", err)
+	}
+	for _, file := range files {
+		if s.detectFormat(file.path, "") != "unknown" {
+			return nil
+		}
+	}
+	return fmt.Errorf("no config files matched: %s", config.Source.Path)
+}
+
+// executeFiles 逐个迁移多文件源中的配置文件，汇总为一个结果
+// 单个文件失败不中断其余文件，全部处理后返回合并的错误
+func (s *ConfigFileStrategy) executeFiles(ctx context.Context, config *core.MigrationConfig) (*core.MigrationResult, error) {
+	result := core.NewMigrationResult(config.TaskID)
+	result.StartTime = time.Now()
+	defer func() {
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime).Milliseconds()
+	}()
+
+	files, err := expandConfigSource(config.Source.Path)
+	if err != nil {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("读取源目录失败: %v", err)
+		return result, err
+	}
+
+	var errs []error
+	migrated := 0
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		if s.detectFormat(file.path, "") == "unknown" {
+			result.Records = append(result.Records, fileRecord("迁移配置文件", constants.ActionTypeUpdate, file.rel, constants.RecordStatusSkipped, "无法识别的文件格式"))
+			result.Summary.Total++
+			result.Summary.Skipped++
+			continue
+		}
+
+		fileResult, err := s.Execute(ctx, s.fileConfig(config, file.path, file.rel))
+		result.Records = append(result.Records, prefixRecords(file.rel, fileResult.Records)...)
+		addSummary(&result.Summary, fileResult.Summary)
+		for _, warning := range fileResult.Warnings {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %s", file.rel, warning))
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file.rel, err))
+			result.Records = append(result.Records, fileRecord("迁移配置文件", constants.ActionTypeUpdate, file.rel, constants.RecordStatusFailed, fileResult.Message))
+			result.Summary.Total++
+			result.Summary.Failed++
+			continue
+		}
+		migrated++
+	}
+
+	if len(errs) > 0 {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("迁移配置文件 %d 个，失败 %d 个", migrated, len(errs))
+		return result, errors.Join(errs...)
+	}
+	result.Status = constants.TaskStatusCompleted
+	result.Message = fmt.Sprintf("成功迁移 %d 个配置文件，共 %d 项", migrated, result.Summary.Success)
+	return result, nil
+}
+
+// dryRunFiles 预览多文件源的迁移，汇总为一个预览
+func (s *ConfigFileStrategy) dryRunFiles(ctx context.Context, config *core.MigrationConfig) (*core.MigrationPreview, error) {
+	preview := core.NewMigrationPreview(config.TaskID)
+
+	files, err := expandConfigSource(config.Source.Path)
+	if err != nil {
+		preview.Errors = append(preview.Errors, fmt.Sprintf("读取源目录失败: %v", err))
+		return preview, nil
+	}
+
+	for _, file := range files {
+		if s.detectFormat(file.path, "") == "unknown" {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("%s: 无法识别的文件格式，将跳过", file.rel))
+			continue
+		}
+
+		filePreview, err := s.DryRun(ctx, s.fileConfig(config, file.path, file.rel))
+		if err != nil {
+			return preview, err
+		}
+		for _, change := range filePreview.Changes {
+			change.Key = prefixKey(file.rel, change.Key)
+			preview.Changes = append(preview.Changes, change)
+		}
+		for _, warning := range filePreview.Warnings {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("%s: %s", file.rel, warning))
+		}
+		for _, e := range filePreview.Errors {
+			preview.Errors = append(preview.Errors, fmt.Sprintf("%s: %s", file.rel, e))
+		}
+		preview.Summary.Total += filePreview.Summary.Total
+		preview.Summary.Create += filePreview.Summary.Create
+		preview.Summary.Update += filePreview.Summary.Update
+		preview.Summary.Delete += filePreview.Summary.Delete
+		preview.Summary.HighImpact += filePreview.Summary.HighImpact
+	}
+
+	return preview, nil
+}
+
+// exportFiles 逐个导出多文件源中的配置文件
+// 导出包按相对路径写入 ExportPath 目录，文件名为 原文件名.export.json
+// 未指定 ExportPath 时写入源目录旁的 <源目录>.export 目录，不在源目录中留下导出包
+func (s *ConfigFileStrategy) exportFiles(ctx context.Context, config *core.MigrationConfig) (*core.ExportResult, error) {
+	result := core.NewExportResult(config.TaskID)
+	result.ExportID = generateExportID()
+	defer func() {
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime).Milliseconds()
+	}()
+
+	if config.Options.ExportPath == "" {
+		exportDir, err := defaultExportDir(config.Source.Path)
+		if err != nil {
+			result.Status = constants.TaskStatusFailed
+			result.Message = err.Error()
+			return result, err
+		}
+		exportConfig := *config
+		exportConfig.Options.ExportPath = exportDir
+		config = &exportConfig
+	}
+	result.ExportPath = config.Options.ExportPath
+
+	files, err := expandConfigSource(config.Source.Path)
+	if err != nil {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("读取源目录失败: %v", err)
+		return result, err
+	}
+
+	var errs []error
+	exported := 0
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		if s.detectFormat(file.path, "") == "unknown" {
+			result.Records = append(result.Records, fileRecord("导出配置文件", constants.ActionTypeExport, file.rel, constants.RecordStatusSkipped, "无法识别的文件格式"))
+			continue
+		}
+
+		fileResult, err := s.Export(ctx, s.fileConfig(config, file.path, file.rel))
+		result.Records = append(result.Records, prefixRecords(file.rel, fileResult.Records)...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file.rel, err))
+			result.Records = append(result.Records, fileRecord("导出配置文件", constants.ActionTypeExport, file.rel, constants.RecordStatusFailed, fileResult.Message))
+			continue
+		}
+		exported++
+	}
+
+	if len(errs) > 0 {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("导出配置文件 %d 个，失败 %d 个", exported, len(errs))
+		return result, errors.Join(errs...)
+	}
+	result.Status = constants.TaskStatusCompleted
+	result.Message = fmt.Sprintf("成功导出 %d 个配置文件", exported)
+	return result, nil
+}
+
+// defaultExportDir 返回多文件源默认的导出目录：源基准目录旁的 <目录名>.export
+func defaultExportDir(source string) (string, error) {
+	base, _ := splitGlobPattern(source)
+	abs, err := filepath.Abs(base)
+	if err != nil {
+		return "", err
+	}
+	if filepath.Dir(abs) == abs {
+		return "", fmt.Errorf("export path is required when exporting from %s", abs)
+	}
+	return abs + ".export", nil
+}
+
+// importFiles 逐个导入目录或 glob 中的导出包
+// 目标路径为 Target.Path 下去掉 .export.json 后缀的相对路径
+func (s *ConfigFileStrategy) importFiles(ctx context.Context, config *core.MigrationConfig, importPath string) (*core.ImportResult, error) {
+	result := core.NewImportResult(config.TaskID)
+	defer func() {
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime).Milliseconds()
+	}()
+
+	files, err := expandImportSource(importPath)
+	if err != nil {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("读取导入目录失败: %v", err)
+		return result, err
+	}
+
+	var errs []error
+	imported := 0
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		rel := strings.TrimSuffix(file.rel, exportSuffix)
+		fileConfig := s.fileConfig(config, "", rel)
+		fileConfig.Options.ImportPath = file.path
+
+		fileResult, err := s.Import(ctx, fileConfig)
+		result.Records = append(result.Records, prefixRecords(rel, fileResult.Records)...)
+		addSummary(&result.Summary, fileResult.Summary)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", rel, err))
+			result.Records = append(result.Records, fileRecord("导入配置文件", constants.ActionTypeImport, rel, constants.RecordStatusFailed, fileResult.Message))
+			result.Summary.Total++
+			result.Summary.Failed++
+			continue
+		}
+		imported++
+	}
+
+	if len(errs) > 0 {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("导入配置文件 %d 个，失败 %d 个", imported, len(errs))
+		return result, errors.Join(errs...)
+	}
+	result.Status = constants.TaskStatusCompleted
+	result.Message = fmt.Sprintf("成功导入 %d 个配置文件", imported)
+	return result, nil
+}
+
+// rollbackFiles 恢复多文件源各目标文件的备份，没有备份的文件（迁移前不存在）跳过
+func (s *ConfigFileStrategy) rollbackFiles(ctx context.Context, config *core.MigrationConfig) error {
+	files, err := expandConfigSource(config.Source.Path)
+	if err != nil {
+		return fmt.Errorf("failed to list source files: %w", err)
+	}
+
+	var errs []error
+	restored := 0
+	for _, file := range files {
+		fileConfig := s.fileConfig(config, file.path, file.rel)
+		backupPath := fileConfig.Target.BackupPath
+		if backupPath == "" {
+			backupPath = fileConfig.Target.Path + backupSuffix
+		}
+		if _, err := os.Stat(backupPath); err != nil {
+			continue
+		}
+		if err := s.Rollback(ctx, fileConfig); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file.rel, err))
+			continue
+		}
+		restored++
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if restored == 0 {
+		return fmt.Errorf("no backup files found under: %s", config.Target.Path)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package strategies
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+// writeTree 按相对路径创建文件
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExpandConfigSource(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"options/editor.xml":       "<application/>",
+		"options/nested/debug.xml": "<application/>",
+		"options/ui.json":          "{}",
+		"keymaps/mine.xml":         "<keymap/>",
+	})
+
+	var rels []string
+	files, err := expandConfigSource(filepath.Join(dir, "**", "*.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		rels = append(rels, file.rel)
+	}
+	if got := strings.Join(rels, ","); got != "keymaps/mine.xml,options/editor.xml,options/nested/debug.xml" {
+		t.Errorf("unexpected glob expansion: %s", got)
+	}
+
+	files, err = expandConfigSource(filepath.Join(dir, "options"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Errorf("expected 3 files in directory, got %v", files)
+	}
+}
+
+func TestConfigFileExportDirectoryDefaultPath(t *testing.T) {
+	dir := t.TempDir()
+	sourceDir := filepath.Join(dir, "options")
+	// 源目录中残留的备份与旧导出包不作为配置文件
+	writeTree(t, sourceDir, map[string]string{
+		"editor.xml":             "<application/>",
+		"editor.xml.backup":      "<application/>",
+		"editor.xml.export.json": "{}",
+	})
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = sourceDir
+	strategy := &ConfigFileStrategy{}
+	for i := 0; i < 2; i++ {
+		result, err := strategy.Export(context.Background(), config)
+		if err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+		if result.ExportPath != sourceDir+".export" || result.Message != "成功导出 1 个配置文件" {
+			t.Errorf("unexpected export result: %s %s", result.ExportPath, result.Message)
+		}
+	}
+	entries, _ := os.ReadDir(sourceDir)
+	if len(entries) != 3 {
+		t.Errorf("export must not write into the source directory: %v", entries)
+	}
+	exported, _ := os.ReadDir(sourceDir + ".export")
+	if len(exported) != 1 || exported[0].Name() != "editor.xml.export.json" {
+		t.Errorf("unexpected export packages: %v", exported)
+	}
+}
+
+func TestConfigFileExecuteDirectory(t *testing.T) {
+	dir := t.TempDir()
+	sourceDir := filepath.Join(dir, "source")
+	targetDir := filepath.Join(dir, "target")
+	writeTree(t, sourceDir, map[string]string{
+		"app.json":           "{\n  \"theme\": \"dark\"\n}\n",
+		"conf/server.yaml":   "port: 8080\n",
+		"conf/settings.ini":  "[core]\neditor = vim\n",
+		"assets/logo.bin":    "\x00\x01\x02",
+		"conf/broken.json":   "{ not json",
+		"conf/ignored.txt~":  "\x00",
+		"conf/nested/x.toml": "name = \"x\"\n",
+	})
+	writeTree(t, targetDir, map[string]string{
+		"conf/server.yaml": "port: 80\nhost: example.com\n",
+	})
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = sourceDir
+	config.Target.Path = targetDir
+	config.Target.MergeMode = "merge"
+
+	strategy := &ConfigFileStrategy{}
+	if err := strategy.Validate(config); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	preview, err := strategy.DryRun(context.Background(), config)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	keys := make(map[string]bool)
+	for _, change := range preview.Changes {
+		keys[change.Key] = true
+	}
+	if !keys["conf/server.yaml:port"] || !keys["app.json:theme"] {
+		t.Errorf("preview keys should carry file prefixes: %v", keys)
+	}
+
+	result, err := strategy.Execute(context.Background(), config)
+	if err == nil || !strings.Contains(err.Error(), "conf/broken.json") {
+		t.Fatalf("expected aggregated error for broken file, got %v", err)
+	}
+	if result.Status != constants.TaskStatusFailed {
+		t.Errorf("unexpected status %s", result.Status)
+	}
+
+	// 其余文件照常迁移，目标路径镜像源目录结构
+	out, err := os.ReadFile(filepath.Join(targetDir, "conf", "server.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "port: 8080\nhost: example.com\n" {
+		t.Errorf("unexpected merged yaml:\n%s", out)
+	}
+	for _, rel := range []string{"app.json", "conf/settings.ini", "conf/nested/x.toml"} {
+		if _, err := os.Stat(filepath.Join(targetDir, filepath.FromSlash(rel))); err != nil {
+			t.Errorf("%s not migrated: %v", rel, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(targetDir, "assets", "logo.bin")); !os.IsNotExist(err) {
+		t.Error("unrecognized files must be skipped")
+	}
+
+	if result.Summary.Failed != 1 || result.Summary.Skipped != 2 {
+		t.Errorf("unexpected summary %+v", result.Summary)
+	}
+}
+
+func TestConfigFileExportImportDirectory(t *testing.T) {
+	dir := t.TempDir()
+	sourceDir := filepath.Join(dir, "settingsSync")
+	exportDir := filepath.Join(dir, "export")
+	importDir := filepath.Join(dir, "import")
+	files := map[string]string{
+		"options/editor.xml":     "<application>\n  <component name=\"EditorSettings\">\n    <option name=\"IS_VIRTUAL_SPACE\" value=\"true\" />\n  </component>\n</application>\n",
+		"codestyles/Default.xml": "<code_scheme name=\"Default\" version=\"173\" />\n",
+		"options/notes.md":       "# not exported\n",
+	}
+	writeTree(t, sourceDir, files)
+	stubMachineValues(t, "/home/alice", "alice", "alice-laptop")
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = filepath.Join(sourceDir, "**", "*.xml")
+	config.Options.ExportPath = exportDir
+	config.Options.IncludeRawContent = true
+
+	strategy := &ConfigFileStrategy{}
+	if err := strategy.ValidateExport(config); err != nil {
+		t.Fatalf("ValidateExport failed: %v", err)
+	}
+	result, err := strategy.Export(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if result.Status != constants.TaskStatusCompleted || result.ExportPath != exportDir {
+		t.Errorf("unexpected export result: %s %s", result.Status, result.Message)
+	}
+	for _, rel := range []string{"options/editor.xml.export.json", "codestyles/Default.xml.export.json"} {
+		if _, err := os.Stat(filepath.Join(exportDir, filepath.FromSlash(rel))); err != nil {
+			t.Errorf("missing export package %s: %v", rel, err)
+		}
+	}
+
+	importConfig := core.NewMigrationConfig()
+	importConfig.Options.ImportPath = exportDir
+	importConfig.Options.PreserveFormat = true
+	importConfig.Target.Path = importDir
+	if err := strategy.ValidateImport(importConfig); err != nil {
+		t.Fatalf("ValidateImport failed: %v", err)
+	}
+	importResult, err := strategy.Import(context.Background(), importConfig)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if importResult.Summary.Success != 2 {
+		t.Errorf("unexpected import summary %+v", importResult.Summary)
+	}
+	for _, rel := range []string{"options/editor.xml", "codestyles/Default.xml"} {
+		out, err := os.ReadFile(filepath.Join(importDir, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != files[rel] {
+			t.Errorf("%s not restored verbatim:\n%s", rel, out)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package strategies
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	sniffINISection = regexp.MustCompile(`^\[[^\]]+\]$`)
+	sniffHCLBlock   = regexp.MustCompile(`^[A-Za-z_][\w-]*(\s+"[^"]*")*\s*\{$`)
+	sniffDotenvLine = regexp.MustCompile(`^(export\s+[A-Za-z_][A-Za-z0-9_]*\s*=|[A-Z_][A-Z0-9_]*=)`)
+	sniffYAMLLine   = regexp.MustCompile(`^(-\s|[\w.-]+:(\s|$))`)
+	sniffKeyValue   = regexp.MustCompile(`^[\w.-]+\s*[=:]`)
+	sniffHCLAttr    = regexp.MustCompile(`^[A-Za-z_][\w-]*\s*=\s*("|\[|\{)`)
+)
+
+// formatFromPath 根据文件名与扩展名推断格式，无法推断时返回空串
+func formatFromPath(path string) string {
+	base := strings.ToLower(filepath.Base(path))
+	if base == ".env" || strings.HasPrefix(base, ".env.") {
+		return "env"
+	}
+	if base == ".npmrc" || base == "npmrc" {
+		return "ini"
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".jsonc":
+		return "jsonc"
+	case ".json5":
+		return "json5"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".ini", ".cnf", ".gitconfig":
+		return "ini"
+	case ".toml":
+		return "toml"
+	case ".xml", ".icls":
+		// IntelliJ color scheme files are XML-based
+		return "xml"
+	case ".properties":
+		return "properties"
+	case ".env":
+		return "env"
+	case ".plist":
+		return "plist"
+	case ".hcl", ".tf", ".tfvars", ".nomad":
+		return "hcl"
+	default:
+		return ""
+	}
+}
+
+// sniffFormat 根据内容推断格式，用于没有可识别扩展名的文件；无法推断时返回空串
+func sniffFormat(content []byte) string {
+	text := bytes.TrimSpace(content)
+	if len(text) == 0 {
+		return ""
+	}
+	if bytes.HasPrefix(text, []byte("bplist")) {
+		return "plist"
+	}
+
+	switch text[0] {
+	case '<':
+		if bytes.Contains(text, []byte("<plist")) {
+			return "plist"
+		}
+		return "xml"
+	case '{', '[':
+		if json.Valid(text) {
+			return "json"
+		}
+		if _, err := normalizeJSON5(text); err == nil {
+			return "jsonc"
+		}
+	}
+
+	// 逐行检查第一条有效内容
+	for _, line := range strings.Split(string(text), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") ||
+			strings.HasPrefix(line, "!") || strings.HasPrefix(line, "//") || line == "---" {
+			continue
+		}
+		switch {
+		case sniffINISection.MatchString(line):
+			return "ini"
+		case sniffHCLBlock.MatchString(line):
+			return "hcl"
+		case sniffDotenvLine.MatchString(line):
+			return "env"
+		case sniffYAMLLine.MatchString(line):
+			return "yaml"
+		case sniffHCLAttr.MatchString(line):
+			return "hcl"
+		case sniffKeyValue.MatchString(line):
+			return "properties"
+		}
+		return ""
+	}
+	return ""
+}
@@ -0,0 +1,322 @@
+package strategies
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPropertiesFormat(t *testing.T) {
+	content := `# 应用配置
+app.name = Demo App
+app.greeting=\u4f60\u597d
+app.path : C:\\Program Files\\demo
+app.list = a,\
+           b,\
+           c
+key\ with\ spaces=value
+`
+	data := readKV([]byte(content), propertiesSyntax{})
+	expected := map[string]interface{}{
+		"app.name":        "Demo App",
+		"app.greeting":    "你好",
+		"app.path":        `C:\Program Files\demo`,
+		"app.list":        "a,b,c",
+		"key with spaces": "value",
+	}
+	if !valuesEqual(data, expected) {
+		t.Errorf("unexpected properties:\n%v\nexpected:\n%v", data, expected)
+	}
+
+	data["app.greeting"] = "再见"
+	data["app.name"] = "Demo"
+	delete(data, "app.list")
+	data["app.version"] = "2"
+	out, err := preserveKV([]byte(content), data, propertiesSyntax{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# 应用配置
+app.name = Demo
+app.greeting=\u518D\u89C1
+app.path : C:\\Program Files\\demo
+key\ with\ spaces=value
+app.version=2
+`
+	if string(out) != want {
+		t.Errorf("unexpected preserved properties:\n%s\nexpected:\n%s", out, want)
+	}
+}
+
+func TestDotenvFormat(t *testing.T) {
+	content := `# 数据库
+export DB_HOST=localhost # 本机
+DB_PASS='p@ss#word'
+GREETING="hello\nworld"
+CERT="-----BEGIN-----
+abc
+-----END-----"
+`
+	data := readKV([]byte(content), dotenvSyntax{})
+	expected := map[string]interface{}{
+		"DB_HOST":  "localhost",
+		"DB_PASS":  "p@ss#word",
+		"GREETING": "hello\nworld",
+		"CERT":     "-----BEGIN-----\nabc\n-----END-----",
+	}
+	if !valuesEqual(data, expected) {
+		t.Errorf("unexpected dotenv:\n%v\nexpected:\n%v", data, expected)
+	}
+
+	data["DB_HOST"] = "db.internal"
+	data["DB_PASS"] = "it's secret"
+	// 新键沿用最后一个条目的书写风格（此处为双引号）
+	data["APP"] = map[string]interface{}{"PORT": 8080}
+	out, err := preserveKV([]byte(content), data, dotenvSyntax{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# 数据库
+export DB_HOST=db.internal # 本机
+DB_PASS="it's secret"
+GREETING="hello\nworld"
+CERT="-----BEGIN-----
+abc
+-----END-----"
+APP_PORT="8080"
+`
+	if string(out) != want {
+		t.Errorf("unexpected preserved dotenv:\n%s\nexpected:\n%s", out, want)
+	}
+}
+
+func TestReadJSONCAndJSON5(t *testing.T) {
+	dir := t.TempDir()
+	settings := filepath.Join(dir, "settings.json")
+	if err := os.WriteFile(settings, []byte(`{
+  // 编辑器
+  "editor.fontSize": 14,
+  /* 主题 */
+  "workbench.colorTheme": "Default Dark+",
+}
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	strategy := &ConfigFileStrategy{}
+	data, err := strategy.readConfigFile(settings, "", "")
+	if err != nil {
+		t.Fatalf("reading VS Code settings failed: %v", err)
+	}
+	if data["editor.fontSize"] != float64(14) || data["workbench.colorTheme"] != "Default Dark+" {
+		t.Errorf("unexpected JSONC data: %v", data)
+	}
+
+	var value map[string]interface{}
+	json5 := `{unquoted: 'single "quoted"', hex: 0x1F, half: .5, positive: +1, list: [1, 2,],}`
+	if err := unmarshalJSON5([]byte(json5), &value); err != nil {
+		t.Fatalf("unmarshalJSON5 failed: %v", err)
+	}
+	expected := map[string]interface{}{
+		"unquoted": `single "quoted"`,
+		"hex":      float64(31),
+		"half":     0.5,
+		"positive": float64(1),
+		"list":     []interface{}{float64(1), float64(2)},
+	}
+	if !valuesEqual(value, expected) {
+		t.Errorf("unexpected JSON5 value: %v", value)
+	}
+
+	// JSON5 原文在保持格式写入时保留注释与写法
+	base := "{\n  // 端口\n  port: 8080,\n  host: 'localhost',\n}\n"
+	out, err := preserveConfigContent("app.json5", []byte(base), map[string]interface{}{"port": 9090, "host": "localhost"}, "json5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Replace(base, "8080", "9090", 1); string(out) != want {
+		t.Errorf("unexpected JSON5 output:\n%s\nexpected:\n%s", out, want)
+	}
+}
+
+func TestPlistFormat(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<!-- 窗口 -->
+	<key>NSWindowFrame</key>
+	<string>0 0 800 600</string>
+	<key>FontSize</key>
+	<integer>12</integer>
+	<key>LastOpened</key>
+	<date>2024-01-02T03:04:05Z</date>
+	<key>ShowHidden</key>
+	<false/>
+</dict>
+</plist>
+`
+	data, err := readPlist([]byte(content))
+	if err != nil {
+		t.Fatalf("readPlist failed: %v", err)
+	}
+	if data["FontSize"] != int64(12) || data["ShowHidden"] != false || data["LastOpened"] != "2024-01-02T03:04:05Z" {
+		t.Errorf("unexpected plist data: %v", data)
+	}
+
+	data["FontSize"] = float64(14)
+	data["ShowHidden"] = true
+	delete(data, "NSWindowFrame")
+	data["Recent"] = []interface{}{"a.txt"}
+	out, err := preservePlist([]byte(content), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<!-- 窗口 -->
+	<key>FontSize</key>
+	<integer>14</integer>
+	<key>LastOpened</key>
+	<date>2024-01-02T03:04:05Z</date>
+	<key>ShowHidden</key>
+	<true/>
+	<key>Recent</key>
+	<array>
+		<string>a.txt</string>
+	</array>
+</dict>
+</plist>
+`
+	if string(out) != want {
+		t.Errorf("unexpected preserved plist:\n%s\nexpected:\n%s", out, want)
+	}
+
+	back, err := readPlist(marshalPlist(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !valuesEqual(back, data) {
+		t.Errorf("plist round-trip mismatch: %v != %v", back, data)
+	}
+}
+
+func TestHCLFormat(t *testing.T) {
+	content := `# 区域
+region = "us-east-1"
+zones  = ["a", "b"]
+ami    = var.base_ami
+
+resource "aws_instance" "web" {
+  instance_type = "t3.micro" # 规格
+  count         = 2
+}
+`
+	data, err := readHCL("main.tf", []byte(content))
+	if err != nil {
+		t.Fatalf("readHCL failed: %v", err)
+	}
+	web, ok := data[`resource "aws_instance" "web"`].(map[string]interface{})
+	if !ok {
+		t.Fatalf("labelled block not read: %v", data)
+	}
+	if web["count"] != float64(2) || data["ami"] != "${var.base_ami}" {
+		t.Errorf("unexpected HCL data: %v", data)
+	}
+
+	web["instance_type"] = "t3.large"
+	data["region"] = "eu-west-1"
+	out, err := preserveHCL("main.tf", []byte(content), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.NewReplacer(`"us-east-1"`, `"eu-west-1"`, `"t3.micro"`, `"t3.large"`).Replace(content)
+	if string(out) != want {
+		t.Errorf("unexpected preserved HCL:\n%s\nexpected:\n%s", out, want)
+	}
+
+	back, err := readHCL("main.tf", marshalHCL(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !valuesEqual(back, data) {
+		t.Errorf("HCL round-trip mismatch: %v != %v", back, data)
+	}
+}
+
+func TestHCLTemplateExpressions(t *testing.T) {
+	content := `name   = "${var.env}-app"
+script = <<EOT
+echo ${var.env}
+EOT
+region = "us-east-1"
+`
+	data, err := readHCL("main.tf", []byte(content))
+	if err != nil {
+		t.Fatalf("readHCL failed: %v", err)
+	}
+
+	// 未修改的插值字符串与 heredoc 保持原文
+	out, err := preserveHCL("main.tf", []byte(content), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != content {
+		t.Errorf("untouched expressions changed:\n%s", out)
+	}
+
+	// 合并到没有这些属性的目标时按原文写入，不转义为 $${
+	out, err = preserveHCL("main.tf", []byte("region = \"eu-west-1\"\n"), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`= "${var.env}-app"`, "= <<EOT\necho ${var.env}\nEOT\n"} {
+		if !strings.Contains(string(out), want) || strings.Contains(string(out), "$${") {
+			t.Errorf("expected %q in merged HCL:\n%s", want, out)
+		}
+	}
+	back, err := readHCL("main.tf", out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !valuesEqual(back, data) {
+		t.Errorf("merged HCL mismatch: %v != %v", back, data)
+	}
+
+	// 可静态求值的字符串即使形如 ${...} 也按字符串写入
+	if tokens := hclRawTokens(`${"abc"}`); tokens != nil {
+		t.Errorf("static expression should be written as a string: %s", tokens.Bytes())
+	}
+	if back, err := readHCL("main.tf", marshalHCL(data)); err != nil || !valuesEqual(back, data) {
+		t.Errorf("HCL round-trip mismatch: %v != %v (%v)", back, data, err)
+	}
+}
+
+func TestSniffFormat(t *testing.T) {
+	cases := map[string]string{
+		"<?xml version=\"1.0\"?>\n<plist version=\"1.0\"><dict/></plist>": "plist",
+		"<application/>":              "xml",
+		`{"a": 1}`:                    "json",
+		"{\n  // c\n  \"a\": 1,\n}":   "jsonc",
+		"# c\n[core]\nname = x":       "ini",
+		"export PATH=/usr/bin":        "env",
+		"DB_HOST=localhost":           "env",
+		"server:\n  port: 8080":       "yaml",
+		"terraform {\n}":              "hcl",
+		"name = \"demo\"":             "hcl",
+		"! comment\nserver.port=8080": "properties",
+		"":                            "",
+	}
+	for content, want := range cases {
+		if got := sniffFormat([]byte(content)); got != want {
+			t.Errorf("sniffFormat(%q) = %q, want %q", content, got, want)
+		}
+	}
+
+	if got := formatFromPath("/app/.env.production"); got != "env" {
+		t.Errorf("formatFromPath(.env.production) = %q", got)
+	}
+}
@@ -0,0 +1,325 @@
+package strategies
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// readHCL 读取 HCL 文件
+// 属性按值读取，无法静态求值的表达式保存为 "${原文}"；
+// 块以 `类型 "标签1" "标签2"` 为键（与 gitconfig 子节写法一致），无标签的同名块转换为数组
+func readHCL(path string, content []byte) (map[string]interface{}, error) {
+	file, diags := hclsyntax.ParseConfig(content, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse HCL: %s", diags.Error())
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse HCL: unexpected body type %T", file.Body)
+	}
+	return hclBodyValue(body, content), nil
+}
+
+// hclBodyValue 将 HCL 主体转换为 map
+func hclBodyValue(body *hclsyntax.Body, src []byte) map[string]interface{} {
+	result := make(map[string]interface{})
+	for name, attr := range body.Attributes {
+		result[name] = hclExpressionValue(attr.Expr, src)
+	}
+	for _, block := range body.Blocks {
+		key := hclBlockKey(block.Type, block.Labels)
+		value := hclBodyValue(block.Body, src)
+		if existing, ok := result[key]; ok {
+			if arr, ok := existing.([]interface{}); ok {
+				result[key] = append(arr, value)
+			} else {
+				result[key] = []interface{}{existing, value}
+			}
+		} else {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// hclExpressionValue 静态求值表达式，失败时返回 "${原文}"
+func hclExpressionValue(expr hclsyntax.Expression, src []byte) interface{} {
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() || !value.IsWhollyKnown() {
+		return "${" + string(expr.Range().SliceBytes(src)) + "}"
+	}
+	return ctyToGo(value)
+}
+
+// ctyToGo 将 cty 值转换为通用 Go 值
+func ctyToGo(value cty.Value) interface{} {
+	if value.IsNull() {
+		return nil
+	}
+
+	switch {
+	case value.Type() == cty.String:
+		return value.AsString()
+	case value.Type() == cty.Number:
+		if i, accuracy := value.AsBigFloat().Int64(); accuracy == big.Exact {
+			return float64(i)
+		}
+		f, _ := value.AsBigFloat().Float64()
+		return f
+	case value.Type() == cty.Bool:
+		return value.True()
+	case value.Type().IsListType() || value.Type().IsTupleType() || value.Type().IsSetType():
+		result := make([]interface{}, 0, value.LengthInt())
+		for it := value.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			result = append(result, ctyToGo(elem))
+		}
+		return result
+	case value.Type().IsMapType() || value.Type().IsObjectType():
+		result := make(map[string]interface{})
+		for it := value.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			result[key.AsString()] = ctyToGo(elem)
+		}
+		return result
+	default:
+		return value.GoString()
+	}
+}
+
+// goToCty 将通用 Go 值转换为 cty 值
+func goToCty(value interface{}) cty.Value {
+	switch v := value.(type) {
+	case nil:
+		return cty.NullVal(cty.DynamicPseudoType)
+	case string:
+		return cty.StringVal(v)
+	case bool:
+		return cty.BoolVal(v)
+	case float64:
+		return cty.NumberFloatVal(v)
+	case float32:
+		return cty.NumberFloatVal(float64(v))
+	case int:
+		return cty.NumberIntVal(int64(v))
+	case int64:
+		return cty.NumberIntVal(v)
+	case []interface{}:
+		if len(v) == 0 {
+			return cty.EmptyTupleVal
+		}
+		items := make([]cty.Value, len(v))
+		for i, item := range v {
+			items[i] = goToCty(item)
+		}
+		return cty.TupleVal(items)
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return cty.EmptyObjectVal
+		}
+		attrs := make(map[string]cty.Value, len(v))
+		for key, item := range v {
+			attrs[key] = goToCty(item)
+		}
+		return cty.ObjectVal(attrs)
+	default:
+		return cty.StringVal(toString(v))
+	}
+}
+
+// hclBlockKey 生成块在 map 中的键，如 resource "aws_instance" "web"
+func hclBlockKey(typeName string, labels []string) string {
+	key := typeName
+	for _, label := range labels {
+		key += " " + strconv.Quote(label)
+	}
+	return key
+}
+
+// parseHCLBlockKey 拆分块键为类型与标签
+func parseHCLBlockKey(key string) (string, []string) {
+	typeName, rest, _ := strings.Cut(key, " ")
+	var labels []string
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		label, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			labels = append(labels, rest)
+			break
+		}
+		unquoted, _ := strconv.Unquote(label)
+		labels = append(labels, unquoted)
+		rest = rest[len(label):]
+	}
+	return typeName, labels
+}
+
+// isHCLBlockValue 判断值是否应写为块：map 或全部由 map 组成的非空数组
+func isHCLBlockValue(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return true
+	case []interface{}:
+		if len(v) == 0 {
+			return false
+		}
+		for _, item := range v {
+			if _, ok := item.(map[string]interface{}); !ok {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// setHCLAttribute 写入属性，readHCL 以 "${原文}" 保存的表达式按原文的词法单元写回
+func setHCLAttribute(body *hclwrite.Body, name string, value interface{}) {
+	if str, ok := value.(string); ok {
+		if tokens := hclRawTokens(str); tokens != nil {
+			body.SetAttributeRaw(name, tokens)
+			return
+		}
+	}
+	body.SetAttributeValue(name, goToCty(value))
+}
+
+// hclRawTokens 将 "${原文}" 还原为表达式的词法单元，原文可含引号与花括号（如 "${var.env}-app"、heredoc）
+// 只有原文为无法静态求值的表达式（变量引用、函数调用、含插值的模板）时还原，否则返回 nil 按字符串写入
+func hclRawTokens(value string) hclwrite.Tokens {
+	if !strings.HasPrefix(value, "${") || !strings.HasSuffix(value, "}") {
+		return nil
+	}
+	src := []byte("v = " + value[2:len(value)-1] + "\n")
+	parsed, diags := hclsyntax.ParseConfig(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil
+	}
+	attr := parsed.Body.(*hclsyntax.Body).Attributes["v"]
+	if attr == nil || hclExpressionValue(attr.Expr, src) != value {
+		return nil
+	}
+	file, diags := hclwrite.ParseConfig(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil
+	}
+	return file.Body().GetAttribute("v").Expr().BuildTokens(nil)
+}
+
+// appendHCLBlocks 追加块，块之间以空行分隔
+func appendHCLBlocks(body *hclwrite.Body, key string, value interface{}) {
+	typeName, labels := parseHCLBlockKey(key)
+	for _, item := range xmlValueList(value) {
+		if len(body.Attributes()) > 0 || len(body.Blocks()) > 0 {
+			body.AppendNewline()
+		}
+		block := body.AppendNewBlock(typeName, labels)
+		writeHCLBody(block.Body(), item.(map[string]interface{}))
+	}
+}
+
+// writeHCLBody 按名称顺序写入属性，随后写入块
+func writeHCLBody(body *hclwrite.Body, data map[string]interface{}) {
+	keys := sortedKeys(data)
+	for _, key := range keys {
+		if !isHCLBlockValue(data[key]) {
+			setHCLAttribute(body, key, data[key])
+		}
+	}
+	for _, key := range keys {
+		if isHCLBlockValue(data[key]) {
+			appendHCLBlocks(body, key, data[key])
+		}
+	}
+}
+
+// marshalHCL 生成新的 HCL 文档，map 值写为块
+func marshalHCL(data map[string]interface{}) []byte {
+	file := hclwrite.NewEmptyFile()
+	writeHCLBody(file.Body(), data)
+	return hclwrite.Format(file.Bytes())
+}
+
+// preserveHCL 以 base 为原始文档写入 data，保留注释、顺序与未变化的表达式
+func preserveHCL(path string, base []byte, data map[string]interface{}) ([]byte, error) {
+	current, err := readHCL(path, base)
+	if err != nil {
+		return nil, errPreserveUnsupported
+	}
+	file, diags := hclwrite.ParseConfig(base, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, errPreserveUnsupported
+	}
+	patchHCLBody(file.Body(), current, data)
+	return file.Bytes(), nil
+}
+
+// patchHCLBody 更新主体：属性原位修改，块按类型与标签匹配后递归更新，新键追加在末尾
+func patchHCLBody(body *hclwrite.Body, current, data map[string]interface{}) {
+	for name := range body.Attributes() {
+		value, ok := data[name]
+		switch {
+		case !ok:
+			body.RemoveAttribute(name)
+		case !valuesEqual(current[name], value):
+			setHCLAttribute(body, name, value)
+		}
+	}
+
+	groups := make(map[string][]*hclwrite.Block)
+	var keys []string
+	for _, block := range body.Blocks() {
+		key := hclBlockKey(block.Type(), block.Labels())
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], block)
+	}
+	for _, key := range keys {
+		value, ok := data[key]
+		if !ok || !isHCLBlockValue(value) {
+			for _, block := range groups[key] {
+				body.RemoveBlock(block)
+			}
+			if ok {
+				setHCLAttribute(body, key, value)
+			}
+			continue
+		}
+
+		items := xmlValueList(value)
+		currentItems := xmlValueList(current[key])
+		for i, block := range groups[key] {
+			if i >= len(items) {
+				body.RemoveBlock(block)
+				continue
+			}
+			currentItem, _ := currentItems[i].(map[string]interface{})
+			patchHCLBody(block.Body(), currentItem, items[i].(map[string]interface{}))
+		}
+		if len(items) > len(groups[key]) {
+			appendHCLBlocks(body, key, items[len(groups[key]):])
+		}
+	}
+
+	for _, key := range sortedKeys(data) {
+		if _, exists := body.Attributes()[key]; exists {
+			continue
+		}
+		if _, exists := groups[key]; exists {
+			continue
+		}
+		if isHCLBlockValue(data[key]) {
+			appendHCLBlocks(body, key, data[key])
+		} else {
+			setHCLAttribute(body, key, data[key])
+		}
+	}
+}
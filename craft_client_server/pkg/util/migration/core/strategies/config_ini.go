@@ -0,0 +1,716 @@
+package strategies
+
+import (
+	"bytes"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// iniDialect INI 方言，描述注释、引号、子节等语法差异
+type iniDialect struct {
+	// name 方言名称
+	name string
+	// inlineComments 行内注释起始字符
+	inlineComments string
+	// commentAnywhere 行内注释是否无需前置空白（git 风格）
+	commentAnywhere bool
+	// bareKeys 是否允许无值的键，读取时视为 true
+	bareKeys bool
+	// directives 是否识别 !include 等指令行
+	directives bool
+	// subsections 是否使用 [section "sub"] 形式的子节
+	subsections bool
+	// escapes 是否处理双引号与反斜杠转义
+	escapes bool
+	// tripleQuotes 是否支持 """ 多行值
+	tripleQuotes bool
+	// continuation 是否支持反斜杠续行
+	continuation bool
+	// equalsOnly 是否只使用 = 作为分隔符
+	equalsOnly bool
+	// literalKeys 默认节的键名可含点（如 npmrc 的 //registry.npmjs.org/:_authToken），新增键不按点拆分为节
+	literalKeys bool
+	// keyIndent 新增键的缩进
+	keyIndent string
+}
+
+// INI 方言定义
+var (
+	iniDialectGeneric = &iniDialect{name: "ini", inlineComments: ";#", tripleQuotes: true, continuation: true}
+	iniDialectPHP     = &iniDialect{name: "php", inlineComments: ";", equalsOnly: true}
+	iniDialectMySQL   = &iniDialect{name: "mysql", inlineComments: "#", bareKeys: true, directives: true, equalsOnly: true}
+	iniDialectGit     = &iniDialect{name: "git", inlineComments: ";#", commentAnywhere: true, bareKeys: true, subsections: true, escapes: true, continuation: true, equalsOnly: true, keyIndent: "\t"}
+	iniDialectNpm     = &iniDialect{name: "npmrc", inlineComments: ";#", bareKeys: true, equalsOnly: true, literalKeys: true}
+)
+
+// iniDialectFor 根据文件名推断 INI 方言
+func iniDialectFor(path string) *iniDialect {
+	name := strings.ToLower(filepath.Base(path))
+	parent := strings.ToLower(filepath.Base(filepath.Dir(path)))
+	switch {
+	case name == ".gitconfig" || name == "gitconfig" || name == ".gitmodules" || (name == "config" && parent == ".git"):
+		return iniDialectGit
+	case name == ".npmrc" || name == "npmrc":
+		return iniDialectNpm
+	case strings.HasSuffix(name, ".cnf"):
+		return iniDialectMySQL
+	case name == "php.ini" || (strings.HasPrefix(name, "php") && strings.HasSuffix(name, ".ini")) || parent == "conf.d" && strings.Contains(strings.ToLower(filepath.ToSlash(path)), "/php"):
+		return iniDialectPHP
+	default:
+		return iniDialectGeneric
+	}
+}
+
+// iniLineKind INI 行类型
+type iniLineKind int
+
+const (
+	iniLineBlank   iniLineKind = iota // 空行
+	iniLineComment                    // 注释行
+	iniLineSection                    // 节标题
+	iniLineKey                        // 键值行
+	iniLineOther                      // 指令或无法识别的行，原样保留
+)
+
+// iniLine INI 文档中的一个逻辑行（续行的值会合并为一行）
+type iniLine struct {
+	kind    iniLineKind
+	raw     string
+	section string
+	key     string
+	value   string
+	// bare 是否为无值的键（如 my.cnf 中的 skip-name-resolve）
+	bare bool
+	// prefix 键与分隔符部分，如 "name = "
+	prefix string
+	// quote 值两侧的引号
+	quote string
+	// comment 行内注释（含前导空白）
+	comment string
+	removed bool
+}
+
+// iniDocument 保留注释、空行与键顺序的 INI 文档模型
+type iniDocument struct {
+	dialect *iniDialect
+	lines   []*iniLine
+	newline string
+	// trailingNewline 原文是否以换行结尾
+	trailingNewline bool
+}
+
+// iniEntry 待写入的键值，value 为 []interface{} 时写为重复键
+type iniEntry struct {
+	section string
+	key     string
+	value   interface{}
+}
+
+// newINIDocument 创建空白 INI 文档
+func newINIDocument(dialect *iniDialect) *iniDocument {
+	return &iniDocument{dialect: dialect, newline: "\n", trailingNewline: true}
+}
+
+// parseINIDocument 解析 INI 文档
+func parseINIDocument(content []byte, dialect *iniDialect) *iniDocument {
+	doc := &iniDocument{
+		dialect:         dialect,
+		newline:         detectNewline(content),
+		trailingNewline: bytes.HasSuffix(content, []byte("\n")),
+	}
+
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	if strings.TrimSpace(text) == "" {
+		return doc
+	}
+	rawLines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	section := ""
+
+	for i := 0; i < len(rawLines); i++ {
+		raw := rawLines[i]
+		trimmed := strings.TrimSpace(raw)
+		line := &iniLine{raw: raw, section: section}
+
+		switch {
+		case trimmed == "":
+			line.kind = iniLineBlank
+		case trimmed[0] == ';' || trimmed[0] == '#':
+			line.kind = iniLineComment
+		case trimmed[0] == '!' && dialect.directives:
+			line.kind = iniLineOther
+		case trimmed[0] == '[' && strings.Contains(trimmed, "]"):
+			line.kind = iniLineSection
+			section = parseINISectionName(trimmed[1:strings.LastIndex(trimmed, "]")], dialect)
+			line.section = section
+		default:
+			idx := strings.IndexAny(raw, "=:")
+			if dialect.equalsOnly {
+				idx = strings.IndexByte(raw, '=')
+			}
+			if idx < 0 {
+				if !dialect.bareKeys {
+					line.kind = iniLineOther
+					break
+				}
+				line.kind = iniLineKey
+				line.bare = true
+				body, comment := splitINIComment(raw, dialect)
+				line.key = strings.TrimSpace(body)
+				line.prefix = body
+				line.comment = comment
+				line.value = "true"
+				break
+			}
+			line.kind = iniLineKey
+			line.key = strings.TrimSpace(raw[:idx])
+			valueStart := idx + 1
+			for valueStart < len(raw) && (raw[valueStart] == ' ' || raw[valueStart] == '\t') {
+				valueStart++
+			}
+			line.prefix = raw[:valueStart]
+
+			// 续行：以反斜杠结尾或未闭合的三引号
+			for i+1 < len(rawLines) && dialect.continues(line.raw[valueStart:]) {
+				i++
+				line.raw += "\n" + rawLines[i]
+			}
+			line.value, line.quote, line.comment = dialect.parseValue(line.raw[valueStart:])
+		}
+
+		doc.lines = append(doc.lines, line)
+	}
+
+	return doc
+}
+
+// parseINISectionName 解析节名，git 方言将 [remote "origin"] 规范为 remote "origin"
+func parseINISectionName(name string, dialect *iniDialect) string {
+	name = strings.TrimSpace(name)
+	if !dialect.subsections {
+		return name
+	}
+	if idx := strings.Index(name, `"`); idx > 0 {
+		return strings.TrimSpace(name[:idx]) + " " + name[idx:]
+	}
+	return name
+}
+
+// continues 判断值是否延续到下一行
+func (d *iniDialect) continues(value string) bool {
+	trimmed := strings.TrimSpace(value)
+	if d.tripleQuotes && strings.HasPrefix(trimmed, `"""`) {
+		return strings.Count(trimmed, `"""`) < 2
+	}
+	if !d.continuation {
+		return false
+	}
+	lines := strings.Split(value, "\n")
+	body, _ := splitINIComment(lines[len(lines)-1], d)
+	return strings.HasSuffix(strings.TrimSpace(body), `\`)
+}
+
+// splitINIComment 拆分值文本与行内注释（注释包含其前导空白），引号内的注释符不生效
+func splitINIComment(text string, dialect *iniDialect) (string, string) {
+	inQuote := byte(0)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\\' && dialect.escapes:
+			i++
+		case inQuote != 0:
+			if c == inQuote {
+				inQuote = 0
+			}
+		case c == '"' || (c == '\'' && !dialect.escapes):
+			inQuote = c
+		case strings.IndexByte(dialect.inlineComments, c) >= 0:
+			if dialect.commentAnywhere || i == 0 || text[i-1] == ' ' || text[i-1] == '\t' {
+				before := strings.TrimRight(text[:i], " \t")
+				return before, text[len(before):]
+			}
+		}
+	}
+	return text, ""
+}
+
+// parseValue 解析值文本，返回值、引号和行内注释
+func (d *iniDialect) parseValue(text string) (string, string, string) {
+	trimmed := strings.TrimSpace(text)
+
+	if d.tripleQuotes && strings.HasPrefix(trimmed, `"""`) {
+		inner := strings.TrimPrefix(trimmed, `"""`)
+		if idx := strings.LastIndex(inner, `"""`); idx >= 0 {
+			inner = inner[:idx]
+		}
+		return inner, `"""`, ""
+	}
+
+	if strings.Contains(trimmed, "\n") {
+		// 反斜杠续行
+		parts := strings.Split(trimmed, "\n")
+		for i, part := range parts {
+			body, _ := splitINIComment(part, d)
+			parts[i] = strings.TrimSuffix(strings.TrimSpace(body), `\`)
+		}
+		return strings.Join(parts, ""), "", ""
+	}
+
+	body, comment := splitINIComment(text, d)
+	body = strings.TrimSpace(body)
+
+	if d.escapes {
+		quote := ""
+		if len(body) >= 2 && body[0] == '"' && body[len(body)-1] == '"' {
+			quote = `"`
+		}
+		return unescapeINIValue(body), quote, comment
+	}
+
+	quote := ""
+	if len(body) >= 2 && (body[0] == '"' || body[0] == '\'') && body[len(body)-1] == body[0] {
+		quote = body[:1]
+		body = body[1 : len(body)-1]
+	}
+	return body, quote, comment
+}
+
+// unescapeINIValue 处理 git 风格的引号与反斜杠转义
+func unescapeINIValue(text string) string {
+	var sb strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '"':
+			continue
+		case c == '\\' && i+1 < len(text):
+			i++
+			switch text[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'b':
+				sb.WriteByte('\b')
+			default:
+				sb.WriteByte(text[i])
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// renderValue 按方言渲染值，必要时添加引号
+func (d *iniDialect) renderValue(value, quote string) (string, string) {
+	if strings.Contains(value, "\n") {
+		if d.tripleQuotes {
+			return `"""` + value + `"""`, `"""`
+		}
+		quote = `"`
+	}
+	if quote == `"""` {
+		quote = ""
+	}
+
+	if quote == "" {
+		_, comment := splitINIComment(value, d)
+		if comment != "" || strings.TrimSpace(value) != value || strings.HasPrefix(value, `"`) {
+			quote = `"`
+		}
+	}
+
+	if d.escapes {
+		escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`).Replace(value)
+		return quote + escaped + quote, quote
+	}
+	return quote + value + quote, quote
+}
+
+// flatKey 返回与 readConfigFile 一致的扁平化键名
+func (l *iniLine) flatKey() string {
+	if l.section == "" {
+		return l.key
+	}
+	return l.section + "." + l.key
+}
+
+// id 返回节与键组成的唯一标识
+func (l *iniLine) id() string {
+	return l.section + "\x00" + l.key
+}
+
+// typedValue 返回读取时使用的值，无值的键视为 true
+func (l *iniLine) typedValue() interface{} {
+	if l.bare {
+		return true
+	}
+	return l.value
+}
+
+// setValue 替换值，保留键、分隔符、引号和行内注释
+func (l *iniLine) setValue(value interface{}, dialect *iniDialect) {
+	if b, ok := value.(bool); ok && b && l.bare {
+		return
+	}
+
+	formatted := formatINIValue(value)
+	if l.bare {
+		// 无值的键改为 key = value 形式
+		l.prefix = l.prefix + " = "
+		l.bare = false
+	}
+	rendered, quote := dialect.renderValue(formatted, l.quote)
+	l.raw = strings.TrimRight(l.prefix+rendered, " \t") + l.comment
+	l.value = formatted
+	l.quote = quote
+}
+
+// formatINIValue 将值格式化为 INI 字符串，整数形式的浮点数不使用科学计数法
+func formatINIValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	default:
+		return toString(v)
+	}
+}
+
+// values 将文档转换为扁平化数据：重复键转为数组，无值的键视为 true
+func (d *iniDocument) values() map[string]interface{} {
+	data := make(map[string]interface{})
+	for _, line := range d.lines {
+		if line.kind != iniLineKey || line.removed {
+			continue
+		}
+		name := line.flatKey()
+		value := line.typedValue()
+		switch existing := data[name].(type) {
+		case nil:
+			data[name] = value
+		case []interface{}:
+			data[name] = append(existing, value)
+		default:
+			data[name] = []interface{}{existing, value}
+		}
+	}
+	return data
+}
+
+// entries 将扁平化或嵌套的数据转换为节/键条目
+// 值为 map 的顶层键视为节，节内值为 map 时视为子节
+func (d *iniDocument) entries(data map[string]interface{}) []iniEntry {
+	var entries []iniEntry
+	for _, key := range sortedKeys(data) {
+		value := data[key]
+		section, isSection := value.(map[string]interface{})
+		if !isSection {
+			sectionName, name := d.resolveKey(key)
+			entries = append(entries, iniEntry{section: sectionName, key: name, value: value})
+			continue
+		}
+
+		for _, subKey := range sortedKeys(section) {
+			subValue := section[subKey]
+			if subsection, ok := subValue.(map[string]interface{}); ok {
+				name := key + "." + subKey
+				if d.dialect.subsections {
+					name = key + ` "` + subKey + `"`
+				}
+				for _, item := range flattenINIMap(subsection, "") {
+					entries = append(entries, iniEntry{section: name, key: item.key, value: item.value})
+				}
+				continue
+			}
+			entries = append(entries, iniEntry{section: key, key: subKey, value: subValue})
+		}
+	}
+
+	// 默认节的键排在最前，保证新建文档中它们位于所有节标题之前
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].section == "" && entries[j].section != ""
+	})
+	return entries
+}
+
+// flattenINIMap 将更深层的嵌套 map 展开为以点分隔的键
+func flattenINIMap(data map[string]interface{}, prefix string) []iniEntry {
+	var entries []iniEntry
+	for _, key := range sortedKeys(data) {
+		if nested, ok := data[key].(map[string]interface{}); ok {
+			entries = append(entries, flattenINIMap(nested, prefix+key+".")...)
+			continue
+		}
+		entries = append(entries, iniEntry{key: prefix + key, value: data[key]})
+	}
+	return entries
+}
+
+// apply 将数据写回文档：修改已有键、删除缺失键、追加新键
+func (d *iniDocument) apply(data map[string]interface{}) {
+	entries := d.entries(data)
+	wanted := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		wanted[entry.section+"\x00"+entry.key] = true
+	}
+
+	existing := make(map[string][]*iniLine)
+	for _, line := range d.lines {
+		if line.kind != iniLineKey {
+			continue
+		}
+		if !wanted[line.id()] {
+			line.removed = true
+			continue
+		}
+		existing[line.id()] = append(existing[line.id()], line)
+	}
+
+	for _, entry := range entries {
+		values, repeated := entry.value.([]interface{})
+		if !repeated {
+			values = []interface{}{entry.value}
+		}
+
+		lines := existing[entry.section+"\x00"+entry.key]
+		for i, line := range lines {
+			if i >= len(values) {
+				line.removed = true
+				continue
+			}
+			if !valuesEqual(line.typedValue(), values[i]) && formatINIValue(values[i]) != line.value {
+				line.setValue(values[i], d.dialect)
+			}
+		}
+
+		for i := len(lines); i < len(values); i++ {
+			line := d.newKeyLine(entry.section, entry.key, values[i])
+			if len(lines) > 0 {
+				d.insertAfter(lines[len(lines)-1], line)
+			} else {
+				d.insertKey(line)
+			}
+			lines = append(lines, line)
+		}
+	}
+}
+
+// resolveKey 将扁平化键拆分为节名与键名：文档默认节中已有的键保持原样，其次匹配文档中已有的节
+func (d *iniDocument) resolveKey(flat string) (string, string) {
+	if d.hasDefaultKey(flat) {
+		return "", flat
+	}
+	best := ""
+	for _, section := range d.sectionNames() {
+		if strings.HasPrefix(flat, section+".") && len(section) > len(best) {
+			best = section
+		}
+	}
+	if best != "" {
+		return best, strings.TrimPrefix(flat, best+".")
+	}
+	if d.dialect.literalKeys {
+		return "", flat
+	}
+
+	// 在引号之外的第一个点处拆分，兼容 remote "origin".url 形式
+	inQuote := false
+	for i, c := range flat {
+		switch {
+		case c == '"':
+			inQuote = !inQuote
+		case c == '.' && !inQuote && i > 0:
+			return flat[:i], flat[i+1:]
+		}
+	}
+	return "", flat
+}
+
+// hasDefaultKey 判断文档默认节中是否有该键
+func (d *iniDocument) hasDefaultKey(key string) bool {
+	for _, line := range d.lines {
+		if line.kind == iniLineKey && line.section == "" && line.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// sectionNames 返回文档中的节名列表
+func (d *iniDocument) sectionNames() []string {
+	var names []string
+	for _, line := range d.lines {
+		if line.kind == iniLineSection {
+			names = append(names, line.section)
+		}
+	}
+	return names
+}
+
+// newKeyLine 按文档风格创建键值行，my.cnf 中值为 true 的键写为无值形式
+func (d *iniDocument) newKeyLine(section, key string, value interface{}) *iniLine {
+	indent := d.dialect.keyIndent
+	if section == "" {
+		indent = ""
+	}
+	line := &iniLine{kind: iniLineKey, section: section, key: key}
+	if b, ok := value.(bool); ok && b && d.dialect == iniDialectMySQL {
+		line.bare = true
+		line.prefix = indent + key
+		line.raw = line.prefix
+		line.value = "true"
+		return line
+	}
+	line.prefix = indent + key + d.separator()
+	line.setValue(value, d.dialect)
+	return line
+}
+
+// insertAfter 在指定行之后插入新行
+func (d *iniDocument) insertAfter(after, line *iniLine) {
+	for i, existing := range d.lines {
+		if existing == after {
+			d.lines = append(d.lines[:i+1], append([]*iniLine{line}, d.lines[i+1:]...)...)
+			return
+		}
+	}
+	d.lines = append(d.lines, line)
+}
+
+// insertKey 在所属节末尾插入键，节不存在时在文档末尾新建
+func (d *iniDocument) insertKey(line *iniLine) {
+	section := line.section
+	header, last := -1, -1
+	for i, existing := range d.lines {
+		if existing.kind == iniLineSection {
+			if section == "" || header >= 0 {
+				break
+			}
+			if existing.section == section {
+				header = i
+			}
+			continue
+		}
+		if existing.kind == iniLineKey && !existing.removed && existing.section == section && (section == "" || header >= 0) {
+			last = i
+		}
+	}
+
+	insertAt := last
+	switch {
+	case last >= 0:
+	case header >= 0:
+		insertAt = header
+	case section == "":
+		// 默认节中没有键：插入到文档开头，与其后的节标题以空行分隔
+		head := []*iniLine{line}
+		if len(d.lines) > 0 && d.lines[0].kind == iniLineSection {
+			head = append(head, &iniLine{kind: iniLineBlank})
+		}
+		d.lines = append(head, d.lines...)
+		return
+	default:
+		// 节不存在：在文档末尾新建
+		if len(d.lines) > 0 && d.lines[len(d.lines)-1].kind != iniLineBlank {
+			d.lines = append(d.lines, &iniLine{kind: iniLineBlank})
+		}
+		d.lines = append(d.lines, &iniLine{kind: iniLineSection, raw: "[" + section + "]", section: section}, line)
+		return
+	}
+
+	d.lines = append(d.lines[:insertAt+1], append([]*iniLine{line}, d.lines[insertAt+1:]...)...)
+}
+
+// separator 返回文档中第一个键使用的分隔符风格
+func (d *iniDocument) separator() string {
+	for _, line := range d.lines {
+		if line.kind != iniLineKey || line.bare {
+			continue
+		}
+		idx := strings.IndexAny(line.prefix, "=:")
+		if d.dialect.equalsOnly {
+			idx = strings.IndexByte(line.prefix, '=')
+		}
+		if idx < 0 {
+			continue
+		}
+		return line.prefix[len(strings.TrimRight(line.prefix[:idx], " \t")):]
+	}
+	return " = "
+}
+
+// Bytes 序列化文档
+func (d *iniDocument) Bytes() []byte {
+	var sb strings.Builder
+	first := true
+	for _, line := range d.lines {
+		if line.removed {
+			continue
+		}
+		if !first {
+			sb.WriteString(d.newline)
+		}
+		first = false
+		sb.WriteString(strings.ReplaceAll(line.raw, "\n", d.newline))
+	}
+	if d.trailingNewline && !first {
+		sb.WriteString(d.newline)
+	}
+	return []byte(sb.String())
+}
+
+// readINI 读取 INI 内容为扁平化的 section.key 数据
+func readINI(path string, content []byte) map[string]interface{} {
+	return parseINIDocument(content, iniDialectFor(path)).values()
+}
+
+// marshalINI 将扁平化或嵌套的数据序列化为分节的 INI 内容
+func marshalINI(path string, data map[string]interface{}) []byte {
+	doc := newINIDocument(iniDialectFor(path))
+	doc.apply(data)
+	return doc.Bytes()
+}
+
+// iniLiteralKeys 返回 INI 内容默认节中含点的键，写入时保持为默认节的键而不拆分为节
+func iniLiteralKeys(path string, content []byte) map[string]bool {
+	keys := make(map[string]bool)
+	for _, line := range parseINIDocument(content, iniDialectFor(path)).lines {
+		if line.kind == iniLineKey && line.section == "" && strings.Contains(line.key, ".") {
+			keys[line.key] = true
+		}
+	}
+	return keys
+}
+
+// literalINIData 将 literal 中的键移入空节名对应的 map，使其写入默认节；未改动 data
+func literalINIData(data map[string]interface{}, literal map[string]bool) map[string]interface{} {
+	if len(literal) == 0 {
+		return data
+	}
+	result := make(map[string]interface{}, len(data))
+	defaults := make(map[string]interface{})
+	for key, value := range data {
+		if literal[key] {
+			defaults[key] = value
+			continue
+		}
+		result[key] = value
+	}
+	if len(defaults) > 0 {
+		result[""] = defaults
+	}
+	return result
+}
+
+// preserveINI 以保持节、注释与键顺序的方式更新 INI 文档
+func preserveINI(path string, base []byte, data map[string]interface{}) ([]byte, error) {
+	doc := parseINIDocument(base, iniDialectFor(path))
+	doc.apply(data)
+	return doc.Bytes(), nil
+}
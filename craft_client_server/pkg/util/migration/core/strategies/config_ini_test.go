@@ -0,0 +1,286 @@
+package strategies
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tsc/pkg/util/migration/core"
+)
+
+func TestMarshalINIRebuildsSections(t *testing.T) {
+	data := map[string]interface{}{
+		"name":         "demo",
+		"server.host":  "0.0.0.0",
+		"server.port":  float64(8080),
+		"database":     map[string]interface{}{"user": "root", "pool": 10},
+		"plugins.load": []interface{}{"a.so", "b.so"},
+	}
+
+	out := string(marshalINI("app.ini", data))
+	expected := `name = demo
+
+[database]
+pool = 10
+user = root
+
+[plugins]
+load = a.so
+load = b.so
+
+[server]
+host = 0.0.0.0
+port = 8080
+`
+	if out != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", out, expected)
+	}
+
+	back := readINI("app.ini", []byte(out))
+	if back["server.port"] != "8080" || back["database.user"] != "root" {
+		t.Errorf("unexpected round-trip values: %v", back)
+	}
+	if !valuesEqual(back["plugins.load"], []interface{}{"a.so", "b.so"}) {
+		t.Errorf("repeated keys not preserved: %v", back["plugins.load"])
+	}
+}
+
+func TestINIMySQLDialect(t *testing.T) {
+	content := `!includedir /etc/mysql/conf.d/
+
+[mysqld]
+skip-name-resolve
+port = 3306 # 默认端口
+plugin-load-add = auth_socket.so
+plugin-load-add = validate_password.so
+`
+	data := readINI("/etc/mysql/my.cnf", []byte(content))
+	if data["mysqld.skip-name-resolve"] != true {
+		t.Errorf("bare key should read as true, got %v", data["mysqld.skip-name-resolve"])
+	}
+	if data["mysqld.port"] != "3306" {
+		t.Errorf("inline comment not stripped: %q", data["mysqld.port"])
+	}
+	if !valuesEqual(data["mysqld.plugin-load-add"], []interface{}{"auth_socket.so", "validate_password.so"}) {
+		t.Errorf("repeated keys not read as array: %v", data["mysqld.plugin-load-add"])
+	}
+
+	// 迁移到另一台服务器时重新生成的 my.cnf 必须保持节与无值键
+	dir := t.TempDir()
+	target := filepath.Join(dir, "my.cnf")
+	strategy := &ConfigFileStrategy{}
+	if err := strategy.writeConfigFile(target, data, "ini", ""); err != nil {
+		t.Fatalf("writeConfigFile failed: %v", err)
+	}
+	written, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[mysqld]
+plugin-load-add = auth_socket.so
+plugin-load-add = validate_password.so
+port = 3306
+skip-name-resolve
+`
+	if string(written) != expected {
+		t.Errorf("unexpected my.cnf:\n%s\nexpected:\n%s", written, expected)
+	}
+
+	// 在原文上更新时保留指令、注释与顺序
+	data["mysqld.port"] = "3307"
+	data["mysqld.plugin-load-add"] = []interface{}{"auth_socket.so"}
+	out, err := preserveINI("my.cnf", []byte(content), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `!includedir /etc/mysql/conf.d/
+
+[mysqld]
+skip-name-resolve
+port = 3307 # 默认端口
+plugin-load-add = auth_socket.so
+`
+	if string(out) != expected {
+		t.Errorf("unexpected preserved my.cnf:\n%s\nexpected:\n%s", out, expected)
+	}
+}
+
+func TestINIGitConfigDialect(t *testing.T) {
+	content := "[user]\n\tname = Alice ; 作者\n[remote \"origin\"]\n\turl = git@example.com:team/repo.git\n\tfetch = +refs/heads/*:refs/remotes/origin/*\n[core]\n\tbare\n\teditor = \"vim -u \\\"~/.vimrc\\\"\"\n"
+
+	data := readINI(".gitconfig", []byte(content))
+	if data["user.name"] != "Alice" {
+		t.Errorf("unexpected user.name: %q", data["user.name"])
+	}
+	if data[`remote "origin".url`] != "git@example.com:team/repo.git" {
+		t.Errorf("subsection key not read: %v", data)
+	}
+	if data["core.bare"] != true {
+		t.Errorf("bare key should read as true, got %v", data["core.bare"])
+	}
+	if data["core.editor"] != `vim -u "~/.vimrc"` {
+		t.Errorf("escaped value not decoded: %q", data["core.editor"])
+	}
+
+	nested := map[string]interface{}{
+		"user": map[string]interface{}{"email": "bob@example.com"},
+		"remote": map[string]interface{}{
+			"upstream": map[string]interface{}{"url": "https://example.com/repo.git"},
+		},
+	}
+	out := string(marshalINI(".gitconfig", nested))
+	expected := "[remote \"upstream\"]\n\turl = https://example.com/repo.git\n\n[user]\n\temail = bob@example.com\n"
+	if out != expected {
+		t.Errorf("unexpected gitconfig:\n%q\nexpected:\n%q", out, expected)
+	}
+}
+
+func TestINIPHPDialect(t *testing.T) {
+	content := `[PHP]
+; 扩展
+extension=curl
+extension=mbstring
+error_reporting = E_ALL & ~E_DEPRECATED
+date.timezone = "Asia/Shanghai"
+color = #fff
+`
+	data := readINI("php.ini", []byte(content))
+	if !valuesEqual(data["PHP.extension"], []interface{}{"curl", "mbstring"}) {
+		t.Errorf("repeated extension lines not read as array: %v", data["PHP.extension"])
+	}
+	if data["PHP.date.timezone"] != "Asia/Shanghai" {
+		t.Errorf("unexpected timezone: %q", data["PHP.date.timezone"])
+	}
+	if data["PHP.color"] != "#fff" {
+		t.Errorf("# must not start a comment in php.ini: %q", data["PHP.color"])
+	}
+
+	data["PHP.extension"] = []interface{}{"curl", "mbstring", "openssl"}
+	out, err := preserveINI("php.ini", []byte(content), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[PHP]
+; 扩展
+extension=curl
+extension=mbstring
+extension=openssl
+error_reporting = E_ALL & ~E_DEPRECATED
+date.timezone = "Asia/Shanghai"
+color = #fff
+`
+	if string(out) != expected {
+		t.Errorf("unexpected php.ini:\n%s\nexpected:\n%s", out, expected)
+	}
+}
+
+func TestINIMultilineValue(t *testing.T) {
+	content := "[script]\nbody = \"\"\"line1\nline2\"\"\"\npath = /usr/local/bin:\\\n  /usr/bin\n"
+	data := readINI("app.ini", []byte(content))
+	if data["script.body"] != "line1\nline2" {
+		t.Errorf("unexpected multi-line value: %q", data["script.body"])
+	}
+	if data["script.path"] != "/usr/local/bin:/usr/bin" {
+		t.Errorf("unexpected continuation value: %q", data["script.path"])
+	}
+
+	out := readINI("app.ini", marshalINI("app.ini", data))
+	if !valuesEqual(out, data) {
+		t.Errorf("multi-line round-trip mismatch: %v != %v", out, data)
+	}
+}
+
+func TestININpmrcDialect(t *testing.T) {
+	content := "registry=https://registry.npmjs.org/\n//registry.npmjs.org/:_authToken=abc\ninit.author.name=Alice\n"
+	data := readINI(".npmrc", []byte(content))
+	if data["//registry.npmjs.org/:_authToken"] != "abc" || data["init.author.name"] != "Alice" {
+		t.Fatalf("npmrc keys must split only at '=': %v", data)
+	}
+
+	// 重新生成与合并到已有文件时键名保持原样，不拆分为节
+	if out := string(marshalINI(".npmrc", data)); out != "//registry.npmjs.org/:_authToken = abc\ninit.author.name = Alice\nregistry = https://registry.npmjs.org/\n" {
+		t.Errorf("unexpected npmrc:\n%s", out)
+	}
+	out, err := preserveINI(".npmrc", []byte("registry=https://mirror.example.com/\n"), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "registry=https://registry.npmjs.org/\n//registry.npmjs.org/:_authToken=abc\ninit.author.name=Alice\n"
+	if string(out) != expected {
+		t.Errorf("unexpected merged npmrc:\n%s\nexpected:\n%s", out, expected)
+	}
+}
+
+func TestINIDottedDefaultKeyRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	sourcePath := filepath.Join(dir, "source.ini")
+	targetPath := filepath.Join(dir, "app.ini")
+	os.WriteFile(sourcePath, []byte("log.level = debug\n\n[server]\nport = 8080\n"), 0644)
+	os.WriteFile(targetPath, []byte("name = demo\n"), 0644)
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = sourcePath
+	config.Target.Path = targetPath
+	config.Target.MergeMode = "merge"
+	if _, err := (&ConfigFileStrategy{}).Execute(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	// 默认节中含点的键保持在默认节，来自 [server] 的键写入同名节
+	written, _ := os.ReadFile(targetPath)
+	expected := "name = demo\nlog.level = debug\n\n[server]\nport = 8080\n"
+	if string(written) != expected {
+		t.Errorf("unexpected app.ini:\n%s\nexpected:\n%s", written, expected)
+	}
+	if back := readINI(targetPath, written); back["log.level"] != "debug" || back["server.port"] != "8080" {
+		t.Errorf("unexpected round-trip values: %v", back)
+	}
+
+	// 目标默认节中已有的含点键原地更新
+	data := readINI("app.ini", written)
+	data["log.level"] = "info"
+	out, err := preserveINI("app.ini", written, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "name = demo\nlog.level = info\n\n[server]\nport = 8080\n" {
+		t.Errorf("unexpected updated app.ini:\n%s", out)
+	}
+}
+
+func TestINIExcludeNpmCredentials(t *testing.T) {
+	dir := t.TempDir()
+	sourcePath := filepath.Join(dir, "source", ".npmrc")
+	targetPath := filepath.Join(dir, "target", ".npmrc")
+	writeTree(t, dir, map[string]string{
+		"source/.npmrc": "registry=https://registry.npmmirror.com/\n//registry.npmjs.org/:_authToken=source-token\n_auth=c291cmNl\n",
+		"target/.npmrc": "//registry.npmjs.org/:_authToken=target-token\n",
+	})
+
+	// 与应用目录中 npm 的配置一致：凭据不迁移，目标中已有的凭据保留
+	config := core.NewMigrationConfig()
+	config.Source.Path = sourcePath
+	config.Source.Filter.Exclude = []string{"_auth", "_authToken", "_password", "*:_auth", "*:_authToken", "*:_password"}
+	config.Target.Path = targetPath
+	config.Target.MergeMode = "merge"
+	if _, err := (&ConfigFileStrategy{}).Execute(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	written, _ := os.ReadFile(targetPath)
+	expected := "//registry.npmjs.org/:_authToken=target-token\nregistry=https://registry.npmmirror.com/\n"
+	if string(written) != expected {
+		t.Errorf("unexpected .npmrc:\n%s\nexpected:\n%s", written, expected)
+	}
+
+	// 导出包不包含仍有凭据的原始内容
+	config.Options.ExportPath = filepath.Join(dir, "npmrc.export.json")
+	config.Options.IncludeRawContent = true
+	result, err := (&ConfigFileStrategy{}).Export(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Package.Content.RawContent != "" || len(result.Package.Content.Data) != 1 {
+		t.Errorf("credentials leaked into the export package: %+v", result.Package.Content)
+	}
+}
@@ -0,0 +1,262 @@
+package strategies
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// unmarshalJSON5 解析 JSON、JSONC 或 JSON5 文本：不是合法 JSON 时先转换为标准 JSON
+func unmarshalJSON5(content []byte, v interface{}) error {
+	if json.Valid(content) {
+		return json.Unmarshal(content, v)
+	}
+	normalized, err := normalizeJSON5(content)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(normalized, v)
+}
+
+// normalizeJSON5 将 JSONC/JSON5 转换为标准 JSON
+// 去除注释与尾随逗号，为标识符键加引号，转换单引号字符串、十六进制数与省略整数/小数部分的数字
+func normalizeJSON5(src []byte) ([]byte, error) {
+	var out bytes.Buffer
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '/' && i+1 < len(src) && (src[i+1] == '/' || src[i+1] == '*'):
+			next, err := skipJSON5Comment(src, i)
+			if err != nil {
+				return nil, err
+			}
+			i = next
+		case c == '"' || c == '\'':
+			str, n, err := readJSON5String(src[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%w at offset %d", err, i)
+			}
+			encoded, _ := json.Marshal(str)
+			out.Write(encoded)
+			i += n
+		case c == ',':
+			// 尾随逗号：其后第一个有效字符为闭合括号
+			j, err := skipJSON5Space(src, i+1)
+			if err != nil {
+				return nil, err
+			}
+			if j >= len(src) || src[j] != '}' && src[j] != ']' {
+				out.WriteByte(',')
+			}
+			i++
+		case isJSON5TokenStart(c):
+			j := i
+			for j < len(src) && !strings.ContainsRune(" \t\r\n,:[]{}/\"'", rune(src[j])) {
+				j++
+			}
+			token := string(src[i:j])
+			next, err := skipJSON5Space(src, j)
+			if err != nil {
+				return nil, err
+			}
+			if next < len(src) && src[next] == ':' && isJSON5Identifier(token) {
+				encoded, _ := json.Marshal(token)
+				out.Write(encoded)
+			} else {
+				literal, err := convertJSON5Literal(token)
+				if err != nil {
+					return nil, fmt.Errorf("%w at offset %d", err, i)
+				}
+				out.WriteString(literal)
+			}
+			i = j
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+
+	if !json.Valid(out.Bytes()) {
+		return nil, fmt.Errorf("invalid JSON5 document")
+	}
+	return out.Bytes(), nil
+}
+
+// skipJSON5Comment 跳过从 i 开始的注释，返回注释之后的位置
+func skipJSON5Comment(src []byte, i int) (int, error) {
+	if src[i+1] == '/' {
+		for i < len(src) && src[i] != '\n' {
+			i++
+		}
+		return i, nil
+	}
+	end := bytes.Index(src[i+2:], []byte("*/"))
+	if end < 0 {
+		return 0, fmt.Errorf("unterminated comment at offset %d", i)
+	}
+	return i + 2 + end + 2, nil
+}
+
+// skipJSON5Space 跳过空白与注释
+func skipJSON5Space(src []byte, i int) (int, error) {
+	for i < len(src) {
+		switch {
+		case strings.ContainsRune(" \t\r\n", rune(src[i])):
+			i++
+		case src[i] == '/' && i+1 < len(src) && (src[i+1] == '/' || src[i+1] == '*'):
+			next, err := skipJSON5Comment(src, i)
+			if err != nil {
+				return 0, err
+			}
+			i = next
+		default:
+			return i, nil
+		}
+	}
+	return i, nil
+}
+
+func isJSON5TokenStart(c byte) bool {
+	return c == '_' || c == '$' || c == '+' || c == '-' || c == '.' ||
+		c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= utf8.RuneSelf
+}
+
+// isJSON5Identifier 判断是否为可作为对象键的标识符
+func isJSON5Identifier(token string) bool {
+	for i, r := range token {
+		switch {
+		case r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= utf8.RuneSelf:
+		case i > 0 && r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+	return token != ""
+}
+
+// convertJSON5Literal 将 JSON5 字面量转换为 JSON 字面量
+func convertJSON5Literal(token string) (string, error) {
+	switch token {
+	case "true", "false", "null":
+		return token, nil
+	}
+
+	sign := ""
+	number := token
+	if strings.HasPrefix(number, "+") || strings.HasPrefix(number, "-") {
+		if number[0] == '-' {
+			sign = "-"
+		}
+		number = number[1:]
+	}
+
+	switch {
+	case number == "Infinity" || number == "NaN":
+		return "", fmt.Errorf("%s cannot be represented in JSON", token)
+	case strings.HasPrefix(number, "0x") || strings.HasPrefix(number, "0X"):
+		value, err := strconv.ParseUint(number[2:], 16, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid hexadecimal number %q", token)
+		}
+		return sign + strconv.FormatUint(value, 10), nil
+	}
+
+	if strings.HasPrefix(number, ".") {
+		number = "0" + number
+	}
+	if i := strings.IndexAny(number, "eE"); i > 0 && number[i-1] == '.' {
+		number = number[:i-1] + number[i:]
+	} else if strings.HasSuffix(number, ".") {
+		number = strings.TrimSuffix(number, ".")
+	}
+	if _, err := strconv.ParseFloat(number, 64); err != nil {
+		return "", fmt.Errorf("invalid literal %q", token)
+	}
+	return sign + number, nil
+}
+
+// readJSON5String 解码以单引号或双引号开头的字符串，返回解码结果与占用的字节数
+func readJSON5String(src []byte) (string, int, error) {
+	quote := src[0]
+	var sb strings.Builder
+	i := 1
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == quote:
+			return sb.String(), i + 1, nil
+		case c == '\\' && i+1 < len(src):
+			n, err := decodeJSON5Escape(src[i:], &sb)
+			if err != nil {
+				return "", 0, err
+			}
+			i += n
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+// decodeJSON5Escape 解码以反斜杠开头的转义序列，返回占用的字节数
+func decodeJSON5Escape(src []byte, sb *strings.Builder) (int, error) {
+	switch c := src[1]; c {
+	case 'b':
+		sb.WriteByte('\b')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'n':
+		sb.WriteByte('\n')
+	case 'r':
+		sb.WriteByte('\r')
+	case 't':
+		sb.WriteByte('\t')
+	case 'v':
+		sb.WriteByte('\v')
+	case '0':
+		sb.WriteByte(0)
+	case '\n':
+		// 行延续
+	case '\r':
+		if len(src) > 2 && src[2] == '\n' {
+			return 3, nil
+		}
+	case 'x':
+		if len(src) < 4 {
+			return 0, fmt.Errorf("invalid \\x escape")
+		}
+		value, err := strconv.ParseUint(string(src[2:4]), 16, 8)
+		if err != nil {
+			return 0, fmt.Errorf("invalid \\x escape")
+		}
+		sb.WriteRune(rune(value))
+		return 4, nil
+	case 'u':
+		if len(src) < 6 {
+			return 0, fmt.Errorf("invalid \\u escape")
+		}
+		value, err := strconv.ParseUint(string(src[2:6]), 16, 16)
+		if err != nil {
+			return 0, fmt.Errorf("invalid \\u escape")
+		}
+		r := rune(value)
+		// 代理对
+		if utf16.IsSurrogate(r) && len(src) >= 12 && src[6] == '\\' && src[7] == 'u' {
+			if low, err := strconv.ParseUint(string(src[8:12]), 16, 16); err == nil {
+				sb.WriteRune(utf16.DecodeRune(r, rune(low)))
+				return 12, nil
+			}
+		}
+		sb.WriteRune(r)
+		return 6, nil
+	default:
+		sb.WriteByte(c)
+	}
+	return 2, nil
+}
@@ -0,0 +1,547 @@
+package strategies
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// kvSyntax 行式键值格式（Java .properties、dotenv .env）的语法
+type kvSyntax interface {
+	// parseEntry 从第 i 行开始解析一个条目，返回条目与占用的物理行数；注释、空行及无法识别的行返回 nil
+	parseEntry(lines []string, i int) (*kvEntry, int)
+	// renderEntry 按条目记录的风格输出条目文本，多行条目以 \n 分隔
+	renderEntry(entry *kvEntry) string
+	// newEntry 以 template（文档中最后一个条目，可能为 nil）的风格创建新条目
+	newEntry(key, value string, template *kvEntry) *kvEntry
+	// keySeparator 嵌套 map 展开为扁平键时使用的连接符
+	keySeparator() string
+}
+
+// kvEntry 键值条目及其书写风格
+type kvEntry struct {
+	key   string
+	value string
+	// indent 行首空白
+	indent string
+	// sep 键值分隔符（含两侧空白），如 "="、" = "、": "
+	sep string
+	// unicodeEscaped 原文使用 \uXXXX 书写非 ASCII 字符（.properties）
+	unicodeEscaped bool
+	// export 带 export 前缀（.env）
+	export bool
+	// quote 值的引号，0 表示未加引号（.env）
+	quote byte
+	// comment 行尾注释，含前导空白（.env）
+	comment string
+}
+
+// kvLine 文档中的一行或一个跨多行的条目
+type kvLine struct {
+	raw     []string
+	entry   *kvEntry
+	dirty   bool
+	removed bool
+}
+
+// kvDocument 保留注释、空行与书写风格的行式键值文档
+type kvDocument struct {
+	syntax          kvSyntax
+	lines           []*kvLine
+	newline         string
+	trailingNewline bool
+}
+
+// parseKVDocument 解析行式键值文档
+func parseKVDocument(content []byte, syntax kvSyntax) *kvDocument {
+	doc := &kvDocument{syntax: syntax, newline: detectNewline(content), trailingNewline: true}
+
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	if text == "" {
+		return doc
+	}
+	doc.trailingNewline = strings.HasSuffix(text, "\n")
+	physical := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+
+	for i := 0; i < len(physical); {
+		entry, n := syntax.parseEntry(physical, i)
+		if n < 1 {
+			n = 1
+		}
+		if i+n > len(physical) {
+			n = len(physical) - i
+		}
+		doc.lines = append(doc.lines, &kvLine{raw: physical[i : i+n], entry: entry})
+		i += n
+	}
+	return doc
+}
+
+// values 返回所有键值，重复的键以最后一次出现为准
+func (d *kvDocument) values() map[string]interface{} {
+	result := make(map[string]interface{})
+	for _, line := range d.lines {
+		if line.entry != nil && !line.removed {
+			result[line.entry.key] = line.entry.value
+		}
+	}
+	return result
+}
+
+// apply 将 data 写入文档：原位更新变化的值，删除缺失的键，新键按名称排序追加在末尾
+func (d *kvDocument) apply(data map[string]interface{}) {
+	flat := make(map[string]string)
+	flattenKV(flat, "", data, d.syntax.keySeparator())
+
+	seen := make(map[string]bool)
+	var template *kvEntry
+	for _, line := range d.lines {
+		if line.entry == nil {
+			continue
+		}
+		value, ok := flat[line.entry.key]
+		if !ok || seen[line.entry.key] {
+			line.removed = true
+			continue
+		}
+		seen[line.entry.key] = true
+		template = line.entry
+		if value != line.entry.value {
+			line.entry.value = value
+			line.dirty = true
+		}
+	}
+
+	for _, key := range sortedKeys(toInterfaceMap(flat)) {
+		if !seen[key] {
+			entry := d.syntax.newEntry(key, flat[key], template)
+			d.lines = append(d.lines, &kvLine{entry: entry, dirty: true})
+		}
+	}
+}
+
+// Bytes 序列化文档
+func (d *kvDocument) Bytes() []byte {
+	var out []string
+	for _, line := range d.lines {
+		switch {
+		case line.removed:
+		case line.dirty:
+			out = append(out, strings.Split(d.syntax.renderEntry(line.entry), "\n")...)
+		default:
+			out = append(out, line.raw...)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+
+	text := strings.Join(out, d.newline)
+	if d.trailingNewline {
+		text += d.newline
+	}
+	return []byte(text)
+}
+
+// flattenKV 将嵌套 map 展开为扁平键，数组以逗号连接
+func flattenKV(out map[string]string, prefix string, data map[string]interface{}, sep string) {
+	for key, value := range data {
+		fullKey := key
+		if prefix != "" {
+			fullKey = prefix + sep + key
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flattenKV(out, fullKey, v, sep)
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = toString(item)
+			}
+			out[fullKey] = strings.Join(items, ",")
+		case nil:
+			out[fullKey] = ""
+		default:
+			out[fullKey] = toString(v)
+		}
+	}
+}
+
+// toInterfaceMap 将字符串 map 转换为通用 map
+func toInterfaceMap(m map[string]string) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
+
+// readKV 读取行式键值文档
+func readKV(content []byte, syntax kvSyntax) map[string]interface{} {
+	return parseKVDocument(content, syntax).values()
+}
+
+// marshalKV 生成新的行式键值文档
+func marshalKV(data map[string]interface{}, syntax kvSyntax) []byte {
+	doc := &kvDocument{syntax: syntax, newline: "\n", trailingNewline: true}
+	doc.apply(data)
+	return doc.Bytes()
+}
+
+// preserveKV 在原文基础上写入 data，保留注释、空行与书写风格
+func preserveKV(base []byte, data map[string]interface{}, syntax kvSyntax) ([]byte, error) {
+	doc := parseKVDocument(base, syntax)
+	doc.apply(data)
+	return doc.Bytes(), nil
+}
+
+// propertiesSyntax Java .properties 语法
+// 支持 = / : / 空白分隔符，# 与 ! 注释，反斜杠续行以及 \t \n \uXXXX 等转义
+type propertiesSyntax struct{}
+
+func (propertiesSyntax) keySeparator() string { return "." }
+
+func (propertiesSyntax) parseEntry(lines []string, i int) (*kvEntry, int) {
+	first := strings.TrimLeft(lines[i], " \t\f")
+	if first == "" || first[0] == '#' || first[0] == '!' {
+		return nil, 1
+	}
+
+	// 拼接续行：行尾有奇数个反斜杠时下一行（去除前导空白）接续本行
+	logical := first
+	n := 1
+	for endsWithContinuation(logical) && i+n < len(lines) {
+		logical = logical[:len(logical)-1] + strings.TrimLeft(lines[i+n], " \t\f")
+		n++
+	}
+	if endsWithContinuation(logical) {
+		logical = logical[:len(logical)-1]
+	}
+
+	entry := &kvEntry{indent: lines[i][:len(lines[i])-len(first)]}
+
+	// 键在第一个未转义的 =、: 或空白处结束
+	keyEnd := len(logical)
+	for j := 0; j < len(logical); j++ {
+		if logical[j] == '\\' {
+			j++
+			continue
+		}
+		if strings.ContainsRune("=: \t\f", rune(logical[j])) {
+			keyEnd = j
+			break
+		}
+	}
+
+	valueStart := keyEnd
+	for valueStart < len(logical) && strings.ContainsRune(" \t\f", rune(logical[valueStart])) {
+		valueStart++
+	}
+	if valueStart < len(logical) && (logical[valueStart] == '=' || logical[valueStart] == ':') {
+		valueStart++
+		for valueStart < len(logical) && strings.ContainsRune(" \t\f", rune(logical[valueStart])) {
+			valueStart++
+		}
+	}
+
+	rawValue := logical[valueStart:]
+	entry.key = unescapeProperties(logical[:keyEnd])
+	entry.sep = logical[keyEnd:valueStart]
+	entry.value = unescapeProperties(rawValue)
+	entry.unicodeEscaped = strings.Contains(rawValue, `\u`)
+	return entry, n
+}
+
+func (propertiesSyntax) renderEntry(entry *kvEntry) string {
+	sep := entry.sep
+	if strings.TrimSpace(sep) == "" && (sep == "" || entry.value == "") {
+		sep = "="
+	}
+	return entry.indent + escapeProperties(entry.key, true, entry.unicodeEscaped) + sep +
+		escapeProperties(entry.value, false, entry.unicodeEscaped)
+}
+
+func (propertiesSyntax) newEntry(key, value string, template *kvEntry) *kvEntry {
+	entry := &kvEntry{key: key, value: value, sep: "="}
+	if template != nil {
+		entry.indent = template.indent
+		entry.sep = template.sep
+		entry.unicodeEscaped = template.unicodeEscaped
+	}
+	return entry
+}
+
+// endsWithContinuation 判断行尾是否为未转义的反斜杠
+func endsWithContinuation(line string) bool {
+	count := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		count++
+	}
+	return count%2 == 1
+}
+
+// unescapeProperties 解码 .properties 转义
+func unescapeProperties(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			if i+4 < len(s) {
+				if value, err := strconv.ParseUint(s[i+1:i+5], 16, 16); err == nil {
+					r := rune(value)
+					i += 4
+					// 代理对
+					if utf16.IsSurrogate(r) && i+6 < len(s) && s[i+1] == '\\' && s[i+2] == 'u' {
+						if low, err := strconv.ParseUint(s[i+3:i+7], 16, 16); err == nil {
+							r = utf16.DecodeRune(r, rune(low))
+							i += 6
+						}
+					}
+					sb.WriteRune(r)
+					continue
+				}
+			}
+			sb.WriteByte('u')
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
+
+// escapeProperties 编码 .properties 键或值；asciiOnly 时非 ASCII 字符写为 \uXXXX
+func escapeProperties(s string, isKey, asciiOnly bool) string {
+	var sb strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '=', ':', '#', '!':
+			if isKey || i == 0 && (r == '#' || r == '!') {
+				sb.WriteByte('\\')
+			}
+			sb.WriteRune(r)
+		case ' ':
+			if isKey || i == 0 {
+				sb.WriteByte('\\')
+			}
+			sb.WriteRune(r)
+		default:
+			if asciiOnly && r > 0x7e {
+				for _, unit := range utf16.Encode([]rune{r}) {
+					sb.WriteString(fmt.Sprintf(`\u%04X`, unit))
+				}
+				continue
+			}
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// dotenvSyntax dotenv .env 语法
+// 支持 export 前缀、单/双引号（可跨行）、未加引号值的行尾 # 注释
+type dotenvSyntax struct{}
+
+func (dotenvSyntax) keySeparator() string { return "_" }
+
+func (dotenvSyntax) parseEntry(lines []string, i int) (*kvEntry, int) {
+	line := lines[i]
+	rest := strings.TrimLeft(line, " \t")
+	if rest == "" || rest[0] == '#' {
+		return nil, 1
+	}
+
+	entry := &kvEntry{indent: line[:len(line)-len(rest)]}
+	if strings.HasPrefix(rest, "export ") || strings.HasPrefix(rest, "export\t") {
+		entry.export = true
+		rest = strings.TrimLeft(rest[len("export"):], " \t")
+	}
+
+	eq := strings.IndexByte(rest, '=')
+	if eq <= 0 {
+		return nil, 1
+	}
+	entry.key = strings.TrimSpace(rest[:eq])
+	if !isDotenvKey(entry.key) {
+		return nil, 1
+	}
+	afterEq := rest[eq+1:]
+	value := strings.TrimLeft(afterEq, " \t")
+	entry.sep = rest[len(strings.TrimRight(rest[:eq], " \t")):eq+1] + afterEq[:len(afterEq)-len(value)]
+
+	if value == "" || (value[0] != '"' && value[0] != '\'' && value[0] != '`') {
+		// 未加引号：空白后的 # 开始注释，行尾空白随注释一起保留
+		end := len(value)
+		for j := 1; j < len(value); j++ {
+			if value[j] == '#' && (value[j-1] == ' ' || value[j-1] == '\t') {
+				end = j
+				break
+			}
+		}
+		entry.value = strings.TrimRight(value[:end], " \t")
+		entry.comment = value[len(entry.value):]
+		return entry, 1
+	}
+
+	// 加引号的值可以跨行，找到未转义的结束引号
+	entry.quote = value[0]
+	text := value[1:]
+	n := 1
+	for {
+		if end := findDotenvQuote(text, entry.quote); end >= 0 {
+			raw := text[:end]
+			entry.comment = text[end+1:]
+			if entry.quote == '"' {
+				entry.value = unescapeDotenv(raw)
+			} else {
+				entry.value = raw
+			}
+			return entry, n
+		}
+		if i+n >= len(lines) {
+			// 缺少结束引号时按原样保留
+			return nil, 1
+		}
+		text += "\n" + lines[i+n]
+		n++
+	}
+}
+
+func (dotenvSyntax) renderEntry(entry *kvEntry) string {
+	var sb strings.Builder
+	sb.WriteString(entry.indent)
+	if entry.export {
+		sb.WriteString("export ")
+	}
+	sb.WriteString(entry.key)
+	if entry.sep != "" {
+		sb.WriteString(entry.sep)
+	} else {
+		sb.WriteString("=")
+	}
+
+	quote := entry.quote
+	switch {
+	case quote == '\'' && strings.ContainsRune(entry.value, '\''),
+		quote == '`' && strings.ContainsRune(entry.value, '`'):
+		quote = '"'
+	case quote == 0 && dotenvNeedsQuote(entry.value):
+		quote = '"'
+	}
+	switch quote {
+	case 0:
+		sb.WriteString(entry.value)
+	case '"':
+		sb.WriteString(`"` + escapeDotenv(entry.value) + `"`)
+	default:
+		sb.WriteString(string(quote) + entry.value + string(quote))
+	}
+
+	sb.WriteString(entry.comment)
+	return sb.String()
+}
+
+func (dotenvSyntax) newEntry(key, value string, template *kvEntry) *kvEntry {
+	entry := &kvEntry{key: key, value: value, sep: "="}
+	if template != nil {
+		entry.indent = template.indent
+		entry.export = template.export
+		entry.sep = template.sep
+		if template.quote != 0 {
+			entry.quote = '"'
+		}
+	}
+	return entry
+}
+
+// isDotenvKey 判断是否为合法的 dotenv 键
+func isDotenvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if !(r == '_' || r == '.' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// findDotenvQuote 查找未转义的结束引号
+func findDotenvQuote(text string, quote byte) int {
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '\\' && quote == '"':
+			i++
+		case text[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// dotenvNeedsQuote 判断未加引号时值是否会被误解析
+func dotenvNeedsQuote(value string) bool {
+	return value != strings.TrimSpace(value) || strings.ContainsAny(value, "\n\r\"'`#\\")
+}
+
+// unescapeDotenv 解码双引号值中的转义
+func unescapeDotenv(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case '"', '\\', '$', '`':
+			sb.WriteByte(s[i])
+		default:
+			sb.WriteByte('\\')
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
+
+// escapeDotenv 编码双引号值
+func escapeDotenv(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`).Replace(s)
+}
@@ -0,0 +1,257 @@
+package strategies
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// plistDoctype Apple 属性列表的文档类型声明
+const plistDoctype = `DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd"`
+
+// readPlist 读取 XML 属性列表，根 <dict> 转换为 map
+// <integer> 读为 int64，<real> 读为 float64，<date> 与 <data> 保持原文字符串
+func readPlist(content []byte) (map[string]interface{}, error) {
+	dict, _, err := parsePlist(content)
+	if err != nil {
+		return nil, err
+	}
+	if dict == nil {
+		return make(map[string]interface{}), nil
+	}
+	value, err := plistValue(dict)
+	if err != nil {
+		return nil, err
+	}
+	return value.(map[string]interface{}), nil
+}
+
+// parsePlist 解析属性列表，返回根 <dict>（空文档为 nil）与文档
+func parsePlist(content []byte) (*xmlNode, *xmlDocument, error) {
+	if bytes.HasPrefix(content, []byte("bplist")) {
+		return nil, nil, fmt.Errorf("binary plist is not supported")
+	}
+	doc, err := parseXMLDocument(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	root := doc.root()
+	if root.name != "plist" {
+		return nil, nil, fmt.Errorf("unexpected plist root element <%s>", root.name)
+	}
+	elements := root.elements()
+	if len(elements) == 0 {
+		return nil, doc, nil
+	}
+	if elements[0].name != "dict" {
+		return nil, nil, fmt.Errorf("plist root must be a <dict>, got <%s>", elements[0].name)
+	}
+	return elements[0], doc, nil
+}
+
+// plistValue 将属性列表元素转换为 Go 值
+func plistValue(n *xmlNode) (interface{}, error) {
+	switch n.name {
+	case "dict":
+		result := make(map[string]interface{})
+		elements := n.elements()
+		for i := 0; i+1 < len(elements); i += 2 {
+			if elements[i].name != "key" {
+				return nil, fmt.Errorf("expected <key> in plist dict, got <%s>", elements[i].name)
+			}
+			value, err := plistValue(elements[i+1])
+			if err != nil {
+				return nil, err
+			}
+			result[elements[i].textContent()] = value
+		}
+		if len(elements)%2 != 0 {
+			return nil, fmt.Errorf("plist dict key %q has no value", elements[len(elements)-1].textContent())
+		}
+		return result, nil
+	case "array":
+		result := make([]interface{}, 0)
+		for _, element := range n.elements() {
+			value, err := plistValue(element)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		}
+		return result, nil
+	case "string":
+		return n.textContent(), nil
+	case "integer":
+		value, err := strconv.ParseInt(strings.TrimSpace(n.textContent()), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid plist integer %q", n.textContent())
+		}
+		return value, nil
+	case "real":
+		value, err := strconv.ParseFloat(strings.TrimSpace(n.textContent()), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid plist real %q", n.textContent())
+		}
+		return value, nil
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "date":
+		return strings.TrimSpace(n.textContent()), nil
+	case "data":
+		return strings.Join(strings.Fields(n.textContent()), ""), nil
+	default:
+		return nil, fmt.Errorf("unsupported plist element <%s>", n.name)
+	}
+}
+
+// plistElement 由 Go 值构建属性列表元素
+func plistElement(value interface{}) *xmlNode {
+	text := func(name, s string) *xmlNode {
+		n := &xmlNode{kind: xmlElementNode, name: name}
+		if s != "" {
+			n.children = []*xmlNode{{kind: xmlTextNode, text: s}}
+		} else {
+			n.selfClosing = true
+		}
+		return n
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		n := &xmlNode{kind: xmlElementNode, name: "dict"}
+		for _, key := range sortedKeys(v) {
+			n.children = append(n.children, text("key", key), plistElement(v[key]))
+		}
+		n.selfClosing = len(n.children) == 0
+		return n
+	case []interface{}:
+		n := &xmlNode{kind: xmlElementNode, name: "array"}
+		for _, item := range v {
+			n.children = append(n.children, plistElement(item))
+		}
+		n.selfClosing = len(n.children) == 0
+		return n
+	case bool:
+		if v {
+			return &xmlNode{kind: xmlElementNode, name: "true", selfClosing: true}
+		}
+		return &xmlNode{kind: xmlElementNode, name: "false", selfClosing: true}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return text("integer", fmt.Sprintf("%d", v))
+	case float32:
+		return plistElement(float64(v))
+	case float64:
+		// JSON 往返后整数会变为 float64，整数值仍写为 <integer>
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return text("integer", strconv.FormatFloat(v, 'f', -1, 64))
+		}
+		return text("real", strconv.FormatFloat(v, 'g', -1, 64))
+	case nil:
+		return text("string", "")
+	default:
+		return text("string", toString(v))
+	}
+}
+
+// marshalPlist 生成新的 XML 属性列表
+func marshalPlist(data map[string]interface{}) []byte {
+	dict := plistElement(data)
+	reindentXML(dict, "", "\t")
+	doc := &xmlDocument{nodes: []*xmlNode{
+		{kind: xmlProcInstNode, name: "xml", text: `version="1.0" encoding="UTF-8"`},
+		{kind: xmlTextNode, text: "\n"},
+		{kind: xmlDirectiveNode, text: plistDoctype},
+		{kind: xmlTextNode, text: "\n"},
+		{kind: xmlElementNode, name: "plist", attrs: []xmlAttr{{name: "version", value: "1.0"}}, children: []*xmlNode{
+			{kind: xmlTextNode, text: "\n"},
+			dict,
+			{kind: xmlTextNode, text: "\n"},
+		}},
+		{kind: xmlTextNode, text: "\n"},
+	}}
+	return doc.Bytes()
+}
+
+// preservePlist 以 base 为原始文档写入 data，未变化的条目、注释与缩进保持原样
+func preservePlist(base []byte, data map[string]interface{}) ([]byte, error) {
+	dict, doc, err := parsePlist(base)
+	if err != nil || dict == nil {
+		return nil, errPreserveUnsupported
+	}
+
+	// 空 <dict> 无从推断缩进时使用 Apple 默认的制表符
+	p := &xmlPatcher{unit: "\t"}
+	if len(dict.elements()) > 0 {
+		p.unit = detectXMLIndent(dict)
+	}
+	root := doc.root()
+	p.patchPlist(root, dict, data, p.indentOf(root, dict, ""))
+	return doc.Bytes(), nil
+}
+
+// patchPlist 将 value 写入属性列表元素 n，类型不兼容时整体替换
+func (p *xmlPatcher) patchPlist(parent, n *xmlNode, value interface{}, own string) {
+	if current, err := plistValue(n); err == nil && valuesEqual(current, value) {
+		return
+	}
+
+	switch n.name {
+	case "dict":
+		if fields, ok := value.(map[string]interface{}); ok {
+			p.patchPlistDict(n, fields, own)
+			return
+		}
+	case "array":
+		if items, ok := value.([]interface{}); ok && len(items) == len(n.elements()) {
+			for i, element := range n.elements() {
+				p.patchPlist(n, element, items[i], p.indentOf(n, element, own))
+			}
+			return
+		}
+	case "string", "date", "data":
+		// 字符串值保持原有的 <date>/<data> 类型
+		if text, ok := value.(string); ok {
+			n.children = []*xmlNode{{kind: xmlTextNode, text: text}}
+			if n.selfClosing {
+				n.selfClosing = false
+				n.raw = ""
+			}
+			return
+		}
+	}
+
+	replacement := plistElement(value)
+	reindentXML(replacement, own, p.unit)
+	parent.children[parent.indexOf(n)] = replacement
+}
+
+// patchPlistDict 更新 <dict>：已有键原位修改，删除缺失的键，新键按名称排序追加
+func (p *xmlPatcher) patchPlistDict(dict *xmlNode, fields map[string]interface{}, own string) {
+	elements := dict.elements()
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(elements); i += 2 {
+		keyNode, valueNode := elements[i], elements[i+1]
+		key := keyNode.textContent()
+		if value, ok := fields[key]; ok && !seen[key] {
+			seen[key] = true
+			p.patchPlist(dict, valueNode, value, p.indentOf(dict, valueNode, own))
+			continue
+		}
+		p.remove(dict, keyNode)
+		p.remove(dict, valueNode)
+	}
+
+	for _, key := range sortedKeys(fields) {
+		if seen[key] {
+			continue
+		}
+		keyNode := &xmlNode{kind: xmlElementNode, name: "key", children: []*xmlNode{{kind: xmlTextNode, text: key}}}
+		valueNode := plistElement(fields[key])
+		p.insert(dict, keyNode, p.anchor(dict, ""), own)
+		p.insert(dict, valueNode, keyNode, own)
+	}
+}
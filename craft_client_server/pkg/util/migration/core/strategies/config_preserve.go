@@ -0,0 +1,139 @@
+package strategies
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// errPreserveUnsupported 表示当前格式或文档结构无法保持原始格式写入，调用方应退回完整序列化
+var errPreserveUnsupported = errors.New("format preserving write is not supported")
+
+// preserveConfigContent 以 base 为原始文档，将 data 的内容写回其中
+// 仅修改发生变化的节点，保留注释、键顺序、空行与引号风格；path 用于推断 INI 方言
+func preserveConfigContent(path string, base []byte, data map[string]interface{}, format string) ([]byte, error) {
+	if len(bytes.TrimSpace(base)) == 0 {
+		return nil, errPreserveUnsupported
+	}
+
+	switch strings.ToLower(format) {
+	case "json", "jsonc", "json5":
+		return preserveJSON(base, data)
+	case "yaml", "yml":
+		return preserveYAML(base, data)
+	case "ini":
+		return preserveINI(path, base, data)
+	case "xml":
+		return preserveXML(base, data)
+	case "properties":
+		return preserveKV(base, data, propertiesSyntax{})
+	case "env":
+		return preserveKV(base, data, dotenvSyntax{})
+	case "plist":
+		return preservePlist(base, data)
+	case "hcl":
+		return preserveHCL(path, base, data)
+	default:
+		return nil, errPreserveUnsupported
+	}
+}
+
+// textEdit 对原文的一处替换，start == end 时表示插入
+type textEdit struct {
+	start int
+	end   int
+	text  string
+}
+
+// applyTextEdits 按位置顺序应用互不重叠的替换
+func applyTextEdits(src []byte, edits []textEdit) []byte {
+	if len(edits) == 0 {
+		return src
+	}
+
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].start != edits[j].start {
+			return edits[i].start < edits[j].start
+		}
+		// 同一位置先插入再删除
+		return edits[i].end-edits[i].start < edits[j].end-edits[j].start
+	})
+
+	var buf bytes.Buffer
+	last := 0
+	for _, edit := range edits {
+		if edit.start < last {
+			continue
+		}
+		buf.Write(src[last:edit.start])
+		buf.WriteString(edit.text)
+		last = edit.end
+	}
+	buf.Write(src[last:])
+	return buf.Bytes()
+}
+
+// valuesEqual 比较两个配置值是否等价（忽略 int/float 等数值类型差异）
+func valuesEqual(a, b interface{}) bool {
+	left, err1 := json.Marshal(normalizeValue(a))
+	right, err2 := json.Marshal(normalizeValue(b))
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return bytes.Equal(left, right)
+}
+
+// normalizeValue 将 YAML 解码得到的 map[interface{}]interface{} 等结构转换为可 JSON 序列化的形式
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = normalizeValue(item)
+		}
+		return result
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[toString(key)] = normalizeValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = normalizeValue(item)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// sortedKeys 返回 map 的有序键列表，保证新增键的写入顺序稳定
+func sortedKeys[V any](data map[string]V) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// detectNewline 检测文档使用的换行符
+func detectNewline(content []byte) string {
+	if bytes.Contains(content, []byte("\r\n")) {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// toString 将任意值格式化为字符串
+func toString(value interface{}) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+	return fmt.Sprintf("%v", value)
+}
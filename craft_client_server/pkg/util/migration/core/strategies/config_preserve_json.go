@@ -0,0 +1,451 @@
+package strategies
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jsonValue JSON(C) 文档中的值节点，记录其在原文中的字节区间
+type jsonValue struct {
+	// kind 节点类型：'{' 对象，'[' 数组，0 标量
+	kind byte
+	// start/end 值在原文中的区间 [start, end)
+	start int
+	end   int
+	// members 对象成员（按原文顺序）
+	members []*jsonMember
+	// elems 数组元素
+	elems []*jsonValue
+	// trailingComma 最后一个成员/元素之后是否带逗号（JSONC 风格）
+	trailingComma bool
+}
+
+// jsonMember 对象成员
+type jsonMember struct {
+	key      string
+	keyStart int
+	value    *jsonValue
+	// comma 值之后逗号的位置，-1 表示没有逗号
+	comma int
+}
+
+// jsonScanner 支持注释与尾随逗号的 JSON 扫描器，同时接受 JSON5 的单引号字符串与标识符键
+type jsonScanner struct {
+	src []byte
+	pos int
+}
+
+// parseJSONDocument 解析 JSON(C) 文档，返回根节点
+func parseJSONDocument(src []byte) (*jsonValue, error) {
+	scanner := &jsonScanner{src: src}
+	scanner.skipSpace()
+	value, err := scanner.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	scanner.skipSpace()
+	if scanner.pos != len(src) {
+		return nil, fmt.Errorf("unexpected content at offset %d", scanner.pos)
+	}
+	return value, nil
+}
+
+// skipSpace 跳过空白与 // 、/* */ 注释
+func (s *jsonScanner) skipSpace() {
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			s.pos++
+		case c == '/' && s.pos+1 < len(s.src) && s.src[s.pos+1] == '/':
+			for s.pos < len(s.src) && s.src[s.pos] != '\n' {
+				s.pos++
+			}
+		case c == '/' && s.pos+1 < len(s.src) && s.src[s.pos+1] == '*':
+			end := bytes.Index(s.src[s.pos+2:], []byte("*/"))
+			if end < 0 {
+				s.pos = len(s.src)
+				return
+			}
+			s.pos += end + 4
+		default:
+			return
+		}
+	}
+}
+
+// parseValue 解析一个值
+func (s *jsonScanner) parseValue() (*jsonValue, error) {
+	if s.pos >= len(s.src) {
+		return nil, fmt.Errorf("unexpected end of JSON input")
+	}
+
+	switch s.src[s.pos] {
+	case '{':
+		return s.parseObject()
+	case '[':
+		return s.parseArray()
+	case '"', '\'':
+		start := s.pos
+		if _, err := s.parseString(); err != nil {
+			return nil, err
+		}
+		return &jsonValue{start: start, end: s.pos}, nil
+	default:
+		start := s.pos
+		for s.pos < len(s.src) && !strings.ContainsRune(" \t\r\n,]}/", rune(s.src[s.pos])) {
+			s.pos++
+		}
+		if s.pos == start {
+			return nil, fmt.Errorf("invalid character %q at offset %d", s.src[start], start)
+		}
+		return &jsonValue{start: start, end: s.pos}, nil
+	}
+}
+
+// parseString 解析字符串字面量并返回解码后的值
+func (s *jsonScanner) parseString() (string, error) {
+	str, n, err := readJSON5String(s.src[s.pos:])
+	if err != nil {
+		return "", fmt.Errorf("%w at offset %d", err, s.pos)
+	}
+	s.pos += n
+	return str, nil
+}
+
+// parseKey 解析对象键：字符串或 JSON5 标识符
+func (s *jsonScanner) parseKey() (string, error) {
+	if c := s.src[s.pos]; c == '"' || c == '\'' {
+		return s.parseString()
+	}
+	start := s.pos
+	for s.pos < len(s.src) && !strings.ContainsRune(" \t\r\n:/", rune(s.src[s.pos])) {
+		s.pos++
+	}
+	key := string(s.src[start:s.pos])
+	if !isJSON5Identifier(key) {
+		return "", fmt.Errorf("expected object key at offset %d", start)
+	}
+	return key, nil
+}
+
+// parseObject 解析对象
+func (s *jsonScanner) parseObject() (*jsonValue, error) {
+	node := &jsonValue{kind: '{', start: s.pos}
+	s.pos++
+
+	for {
+		s.skipSpace()
+		if s.pos >= len(s.src) {
+			return nil, fmt.Errorf("unterminated object at offset %d", node.start)
+		}
+		if s.src[s.pos] == '}' {
+			s.pos++
+			node.end = s.pos
+			return node, nil
+		}
+		member := &jsonMember{keyStart: s.pos, comma: -1}
+		key, err := s.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		member.key = key
+
+		s.skipSpace()
+		if s.pos >= len(s.src) || s.src[s.pos] != ':' {
+			return nil, fmt.Errorf("expected ':' at offset %d", s.pos)
+		}
+		s.pos++
+		s.skipSpace()
+
+		if member.value, err = s.parseValue(); err != nil {
+			return nil, err
+		}
+		node.members = append(node.members, member)
+
+		s.skipSpace()
+		if s.pos < len(s.src) && s.src[s.pos] == ',' {
+			member.comma = s.pos
+			s.pos++
+			node.trailingComma = true
+		} else {
+			node.trailingComma = false
+		}
+	}
+}
+
+// parseArray 解析数组
+func (s *jsonScanner) parseArray() (*jsonValue, error) {
+	node := &jsonValue{kind: '[', start: s.pos}
+	s.pos++
+
+	for {
+		s.skipSpace()
+		if s.pos >= len(s.src) {
+			return nil, fmt.Errorf("unterminated array at offset %d", node.start)
+		}
+		if s.src[s.pos] == ']' {
+			s.pos++
+			node.end = s.pos
+			return node, nil
+		}
+
+		elem, err := s.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		node.elems = append(node.elems, elem)
+
+		s.skipSpace()
+		if s.pos < len(s.src) && s.src[s.pos] == ',' {
+			s.pos++
+			node.trailingComma = true
+		} else {
+			node.trailingComma = false
+		}
+	}
+}
+
+// jsonPatcher 将新数据以最小改动写回 JSON(C) 原文
+type jsonPatcher struct {
+	src    []byte
+	indent string
+	edits  []textEdit
+}
+
+// preserveJSON 以保持键顺序、注释和空白的方式更新 JSON 文档
+func preserveJSON(base []byte, data map[string]interface{}) ([]byte, error) {
+	root, err := parseJSONDocument(base)
+	if err != nil {
+		return nil, err
+	}
+	if root.kind != '{' {
+		return nil, errPreserveUnsupported
+	}
+
+	patcher := &jsonPatcher{src: base, indent: detectJSONIndent(base, root)}
+	if err := patcher.patch(root, data); err != nil {
+		return nil, err
+	}
+	return applyTextEdits(base, patcher.edits), nil
+}
+
+// detectJSONIndent 根据根对象第一个成员的缩进推断缩进单位，单行文档返回空串（紧凑输出）
+func detectJSONIndent(src []byte, root *jsonValue) string {
+	if !bytes.Contains(bytes.TrimSpace(src), []byte("\n")) {
+		return ""
+	}
+	if root.kind == '{' && len(root.members) > 0 {
+		member := root.members[0]
+		lineStart := lineStartOf(src, member.keyStart)
+		if indent := string(src[lineStart:member.keyStart]); strings.TrimSpace(indent) == "" && indent != "" {
+			return indent
+		}
+	}
+	return "  "
+}
+
+// patch 将 value 写入 node 对应的原文区间
+func (p *jsonPatcher) patch(node *jsonValue, value interface{}) error {
+	switch node.kind {
+	case '{':
+		if newMap, ok := value.(map[string]interface{}); ok {
+			return p.patchObject(node, newMap)
+		}
+	case '[':
+		if newArr, ok := value.([]interface{}); ok && len(newArr) == len(node.elems) {
+			for i, elem := range node.elems {
+				if err := p.patch(elem, newArr[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	default:
+		var current interface{}
+		if err := unmarshalJSON5(p.src[node.start:node.end], &current); err == nil && valuesEqual(current, value) {
+			return nil
+		}
+	}
+	return p.replace(node, value)
+}
+
+// patchObject 更新对象：原有成员原位修改，删除缺失成员，新增成员追加在末尾
+func (p *jsonPatcher) patchObject(node *jsonValue, value map[string]interface{}) error {
+	seen := make(map[string]bool)
+	var kept, removed []*jsonMember
+	for _, member := range node.members {
+		if _, ok := value[member.key]; ok && !seen[member.key] {
+			seen[member.key] = true
+			kept = append(kept, member)
+		} else {
+			removed = append(removed, member)
+		}
+	}
+
+	var added []string
+	for _, key := range sortedKeys(value) {
+		if !seen[key] {
+			added = append(added, key)
+		}
+	}
+
+	if len(removed) == 0 && len(added) == 0 {
+		for _, member := range kept {
+			if err := p.patch(member.value, value[member.key]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// 成员不是逐行排列或全部被删除时，整体重写该对象
+	if len(kept) == 0 || !p.isLineLayout(node) {
+		return p.replace(node, value)
+	}
+
+	for _, member := range kept {
+		if err := p.patch(member.value, value[member.key]); err != nil {
+			return err
+		}
+	}
+
+	for _, member := range removed {
+		p.edits = append(p.edits, textEdit{start: p.memberLineStart(member), end: p.memberLineEnd(member)})
+	}
+
+	last := kept[len(kept)-1]
+	if len(added) == 0 {
+		// 删除尾部成员后，修正最后一个保留成员的逗号
+		if last.comma >= 0 && !node.trailingComma {
+			p.edits = append(p.edits, textEdit{start: last.comma, end: last.comma + 1})
+		}
+		return nil
+	}
+
+	if last.comma < 0 {
+		p.edits = append(p.edits, textEdit{start: last.value.end, end: last.value.end, text: ","})
+	}
+
+	lineStart := lineStartOf(p.src, last.keyStart)
+	prefix := string(p.src[lineStart:last.keyStart])
+	newline := detectNewline(p.src)
+
+	var sb strings.Builder
+	for i, key := range added {
+		keyJSON, err := marshalJSONValue(key, "", p.indent)
+		if err != nil {
+			return err
+		}
+		valueJSON, err := marshalJSONValue(value[key], prefix, p.indent)
+		if err != nil {
+			return err
+		}
+		sb.WriteString(prefix + keyJSON + ": " + valueJSON)
+		if i < len(added)-1 || node.trailingComma {
+			sb.WriteString(",")
+		}
+		sb.WriteString(newline)
+	}
+
+	insertAt := p.memberLineEnd(last)
+	p.edits = append(p.edits, textEdit{start: insertAt, end: insertAt, text: sb.String()})
+	return nil
+}
+
+// replace 用重新序列化的值替换节点原文
+func (p *jsonPatcher) replace(node *jsonValue, value interface{}) error {
+	lineStart := lineStartOf(p.src, node.start)
+	prefix := leadingSpace(string(p.src[lineStart:node.start]))
+	text, err := marshalJSONValue(value, prefix, p.indent)
+	if err != nil {
+		return err
+	}
+	p.edits = append(p.edits, textEdit{start: node.start, end: node.end, text: text})
+	return nil
+}
+
+// isLineLayout 判断对象是否每个成员独占一行、闭合括号独占一行
+func (p *jsonPatcher) isLineLayout(node *jsonValue) bool {
+	for _, member := range node.members {
+		if !onlySpaceBefore(p.src, member.keyStart) {
+			return false
+		}
+		after := member.value.end
+		if member.comma >= 0 {
+			after = member.comma + 1
+		}
+		if !restOfLineBlank(p.src, after) {
+			return false
+		}
+	}
+	return onlySpaceBefore(p.src, node.end-1)
+}
+
+// memberLineStart 返回成员所在行（连同紧邻其上的注释行）的起始位置
+func (p *jsonPatcher) memberLineStart(member *jsonMember) int {
+	start := lineStartOf(p.src, member.keyStart)
+	for start > 0 {
+		prevStart := lineStartOf(p.src, start-1)
+		line := strings.TrimSpace(string(p.src[prevStart : start-1]))
+		if !strings.HasPrefix(line, "//") {
+			break
+		}
+		start = prevStart
+	}
+	return start
+}
+
+// memberLineEnd 返回成员最后一行（含换行符）的结束位置
+func (p *jsonPatcher) memberLineEnd(member *jsonMember) int {
+	pos := member.value.end
+	if member.comma >= 0 {
+		pos = member.comma + 1
+	}
+	if idx := bytes.IndexByte(p.src[pos:], '\n'); idx >= 0 {
+		return pos + idx + 1
+	}
+	return len(p.src)
+}
+
+// marshalJSONValue 序列化单个 JSON 值，首行不带前缀以便原位替换
+func marshalJSONValue(value interface{}, prefix, indent string) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent(prefix, indent)
+	if err := encoder.Encode(normalizeValue(value)); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+// lineStartOf 返回 pos 所在行的起始位置
+func lineStartOf(src []byte, pos int) int {
+	if idx := bytes.LastIndexByte(src[:pos], '\n'); idx >= 0 {
+		return idx + 1
+	}
+	return 0
+}
+
+// onlySpaceBefore 判断 pos 所在行中 pos 之前是否只有空白
+func onlySpaceBefore(src []byte, pos int) bool {
+	return strings.TrimSpace(string(src[lineStartOf(src, pos):pos])) == ""
+}
+
+// restOfLineBlank 判断 pos 之后到行尾是否只有空白或行注释
+func restOfLineBlank(src []byte, pos int) bool {
+	end := len(src)
+	if idx := bytes.IndexByte(src[pos:], '\n'); idx >= 0 {
+		end = pos + idx
+	}
+	rest := strings.TrimSpace(string(src[pos:end]))
+	return rest == "" || strings.HasPrefix(rest, "//")
+}
+
+// leadingSpace 返回字符串开头的空白部分
+func leadingSpace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
@@ -0,0 +1,212 @@
+package strategies
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tsc/pkg/util/migration/core"
+)
+
+func TestPreserveYAMLScalarUpdate(t *testing.T) {
+	base := `# 服务配置
+server:
+  host: "localhost"   # 监听地址
+  port: 8080
+
+  # 数据库
+  db:
+    name: 'app'
+`
+	data := map[string]interface{}{
+		"server": map[string]interface{}{
+			"host": "0.0.0.0",
+			"port": 9090,
+			"db":   map[string]interface{}{"name": "app"},
+		},
+	}
+
+	out, err := preserveConfigContent("", []byte(base), data, "yaml")
+	if err != nil {
+		t.Fatalf("preserveConfigContent failed: %v", err)
+	}
+
+	expected := strings.Replace(strings.Replace(base, `"localhost"`, `"0.0.0.0"`, 1), "8080", "9090", 1)
+	if string(out) != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", out, expected)
+	}
+}
+
+func TestPreserveYAMLStructuralChange(t *testing.T) {
+	base := `# 服务配置
+server:
+  host: localhost
+  port: 8080
+
+# 日志
+log:
+  level: info
+legacy: true
+`
+	data := map[string]interface{}{
+		"server": map[string]interface{}{"host": "localhost", "port": 8080, "tls": true},
+		"log":    map[string]interface{}{"level": "debug"},
+	}
+
+	out, err := preserveConfigContent("", []byte(base), data, "yaml")
+	if err != nil {
+		t.Fatalf("preserveConfigContent failed: %v", err)
+	}
+
+	text := string(out)
+	for _, want := range []string{"# 服务配置", "# 日志", "\n\n# 日志", "tls: true", "level: debug"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "legacy") {
+		t.Errorf("removed key still present:\n%s", text)
+	}
+	if strings.Index(text, "server:") > strings.Index(text, "log:") {
+		t.Errorf("key order not preserved:\n%s", text)
+	}
+}
+
+func TestPreserveJSONOrderAndComments(t *testing.T) {
+	base := `{
+  // 编辑器设置
+  "editor.fontSize": 14,
+  "workbench.colorTheme": "Default Dark+",
+  "files.exclude": {
+    "**/.git": true
+  },
+  "obsolete": 1
+}
+`
+	data := map[string]interface{}{
+		"editor.fontSize":      16,
+		"workbench.colorTheme": "Default Dark+",
+		"files.exclude":        map[string]interface{}{"**/.git": true},
+		"aaa.new":              "x",
+	}
+
+	out, err := preserveConfigContent("", []byte(base), data, "json")
+	if err != nil {
+		t.Fatalf("preserveConfigContent failed: %v", err)
+	}
+
+	expected := `{
+  // 编辑器设置
+  "editor.fontSize": 16,
+  "workbench.colorTheme": "Default Dark+",
+  "files.exclude": {
+    "**/.git": true
+  },
+  "aaa.new": "x"
+}
+`
+	if string(out) != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", out, expected)
+	}
+}
+
+func TestPreserveINISections(t *testing.T) {
+	base := `; 全局配置
+name = demo
+
+[mysqld]
+# 端口
+port = 3306
+datadir = /var/lib/mysql ; 数据目录
+
+[client]
+port = 3306
+`
+	data := map[string]interface{}{
+		"name":            "demo",
+		"mysqld.port":     "3307",
+		"mysqld.datadir":  "/data/mysql",
+		"mysqld.bind":     "0.0.0.0",
+		"client.port":     "3306",
+		"mysqldump.quick": "true",
+	}
+
+	out, err := preserveConfigContent("", []byte(base), data, "ini")
+	if err != nil {
+		t.Fatalf("preserveConfigContent failed: %v", err)
+	}
+
+	expected := `; 全局配置
+name = demo
+
+[mysqld]
+# 端口
+port = 3307
+datadir = /data/mysql ; 数据目录
+bind = 0.0.0.0
+
+[client]
+port = 3306
+
+[mysqldump]
+quick = true
+`
+	if string(out) != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", out, expected)
+	}
+}
+
+func TestConfigFileExecuteMergePreservesComments(t *testing.T) {
+	dir := t.TempDir()
+	sourcePath := filepath.Join(dir, "team.yaml")
+	targetPath := filepath.Join(dir, "local.yaml")
+
+	if err := os.WriteFile(sourcePath, []byte("server:\n  port: 9090\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	target := "# 本地配置，请勿提交\nserver:\n  host: localhost # 本机\n  port: 8080\n"
+	if err := os.WriteFile(targetPath, []byte(target), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = sourcePath
+	config.Target.Path = targetPath
+	config.Target.MergeMode = "merge"
+
+	strategy := &ConfigFileStrategy{}
+	if _, err := strategy.Execute(context.Background(), config); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	out, err := os.ReadFile(targetPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Replace(target, "8080", "9090", 1)
+	if string(out) != expected {
+		t.Errorf("unexpected target content:\n%s\nexpected:\n%s", out, expected)
+	}
+}
+
+func TestWriteConfigFileWithBaseReportsLostFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	// 多文档 YAML 无法保持原样写入
+	base := []byte("# comment\na: 1\n---\nb: 2\n")
+	data := map[string]interface{}{"a": 2}
+	s := &ConfigFileStrategy{}
+
+	warning, err := s.writeConfigFileWithBase(path, base, data, "yaml", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(warning, "注释") {
+		t.Errorf("expected warning about lost comments, got %q", warning)
+	}
+
+	if _, err := s.writeConfigFileWithBase(path, base, data, "yaml", "", true); err == nil {
+		t.Error("expected error when preserving format is required")
+	}
+}
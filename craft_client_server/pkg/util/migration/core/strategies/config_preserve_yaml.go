@@ -0,0 +1,410 @@
+package strategies
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlScalarEdit 可在原文中原位替换的标量修改
+type yamlScalarEdit struct {
+	node  *yaml.Node
+	line  int
+	col   int
+	style yaml.Style
+	value *yaml.Node
+}
+
+// yamlPatcher 在 yaml.v3 节点树上应用修改
+type yamlPatcher struct {
+	// structural 是否发生了结构性修改（增删键、类型变化等）
+	structural bool
+	// scalars 仅标量值变化时的原位替换列表
+	scalars []yamlScalarEdit
+}
+
+// preserveYAML 以保持注释、键顺序、空行与引号风格的方式更新 YAML 文档
+func preserveYAML(base []byte, data map[string]interface{}) ([]byte, error) {
+	doc, err := decodeSingleYAMLDocument(base)
+	if err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errPreserveUnsupported
+	}
+
+	patcher := &yamlPatcher{}
+	if err := patcher.patch(doc.Content[0], data); err != nil {
+		return nil, err
+	}
+
+	// 只有标量变化时直接在原文上替换，原文其余部分保持逐字节不变
+	if !patcher.structural {
+		if out, ok := patcher.splice(base, data); ok {
+			return out, nil
+		}
+	}
+
+	return reencodeYAML(base, doc, data)
+}
+
+// decodeSingleYAMLDocument 解析只包含一个文档的 YAML
+func decodeSingleYAMLDocument(content []byte) (*yaml.Node, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	var doc yaml.Node
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	var extra yaml.Node
+	if err := decoder.Decode(&extra); !errors.Is(err, io.EOF) {
+		// 多文档 YAML 暂不支持保持格式写入
+		return nil, errPreserveUnsupported
+	}
+	return &doc, nil
+}
+
+// patch 将 value 写入节点
+func (p *yamlPatcher) patch(node *yaml.Node, value interface{}) error {
+	var current interface{}
+	if err := node.Decode(&current); err == nil && valuesEqual(current, value) {
+		return nil
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		if newMap, ok := value.(map[string]interface{}); ok {
+			return p.patchMapping(node, newMap)
+		}
+	case yaml.SequenceNode:
+		if newArr, ok := value.([]interface{}); ok && len(newArr) == len(node.Content) {
+			for i, item := range node.Content {
+				if err := p.patch(item, newArr[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case yaml.ScalarNode:
+		if _, isMap := value.(map[string]interface{}); !isMap {
+			if _, isArr := value.([]interface{}); !isArr {
+				return p.patchScalar(node, value)
+			}
+		}
+	}
+
+	return p.replace(node, value)
+}
+
+// patchMapping 更新映射：原有键原位修改，删除缺失键，新增键追加在末尾
+func (p *yamlPatcher) patchMapping(node *yaml.Node, value map[string]interface{}) error {
+	seen := make(map[string]bool)
+	content := make([]*yaml.Node, 0, len(node.Content))
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		newValue, ok := value[keyNode.Value]
+		if keyNode.Value == "<<" {
+			// 合并键保持原样
+			content = append(content, keyNode, valueNode)
+			continue
+		}
+		if !ok || seen[keyNode.Value] {
+			p.structural = true
+			continue
+		}
+		seen[keyNode.Value] = true
+		if err := p.patch(valueNode, newValue); err != nil {
+			return err
+		}
+		content = append(content, keyNode, valueNode)
+	}
+
+	for _, key := range sortedKeys(value) {
+		if seen[key] {
+			continue
+		}
+		valueNode := &yaml.Node{}
+		if err := valueNode.Encode(value[key]); err != nil {
+			return err
+		}
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+		content = append(content, keyNode, valueNode)
+		p.structural = true
+	}
+
+	node.Content = content
+	return nil
+}
+
+// patchScalar 更新标量值，尽量保留原有的引号风格
+func (p *yamlPatcher) patchScalar(node *yaml.Node, value interface{}) error {
+	newNode := &yaml.Node{}
+	if err := newNode.Encode(value); err != nil {
+		return err
+	}
+	if newNode.Kind != yaml.ScalarNode {
+		return p.replace(node, value)
+	}
+
+	style := newNode.Style
+	if newNode.Tag == "!!str" && node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		style = node.Style
+	}
+
+	edit := yamlScalarEdit{node: node, line: node.Line, col: node.Column, style: node.Style, value: newNode}
+	node.Tag = newNode.Tag
+	node.Value = newNode.Value
+	node.Style = style
+	p.scalars = append(p.scalars, edit)
+	return nil
+}
+
+// replace 以新值整体替换节点，保留节点上的注释
+func (p *yamlPatcher) replace(node *yaml.Node, value interface{}) error {
+	newNode := &yaml.Node{}
+	if err := newNode.Encode(value); err != nil {
+		return err
+	}
+	newNode.HeadComment = node.HeadComment
+	newNode.LineComment = node.LineComment
+	newNode.FootComment = node.FootComment
+	*node = *newNode
+	p.structural = true
+	return nil
+}
+
+// splice 在原文上原位替换单行标量，替换结果与预期数据不一致时返回 false
+func (p *yamlPatcher) splice(base []byte, data map[string]interface{}) ([]byte, bool) {
+	lines := strings.SplitAfter(string(base), "\n")
+	lineOffsets := make([]int, len(lines)+1)
+	for i, line := range lines {
+		lineOffsets[i+1] = lineOffsets[i] + len(line)
+	}
+
+	edits := make([]textEdit, 0, len(p.scalars))
+	for _, scalar := range p.scalars {
+		if scalar.style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 || scalar.line < 1 || scalar.line > len(lines) {
+			return nil, false
+		}
+
+		line := strings.TrimRight(lines[scalar.line-1], "\r\n")
+		start := runeOffset(line, scalar.col-1)
+		if start < 0 {
+			return nil, false
+		}
+		end := yamlScalarEnd(line, start, scalar.style)
+		if end < 0 {
+			return nil, false
+		}
+
+		text, ok := renderYAMLScalar(scalar.node)
+		if !ok {
+			return nil, false
+		}
+
+		offset := lineOffsets[scalar.line-1]
+		edits = append(edits, textEdit{start: offset + start, end: offset + end, text: text})
+	}
+
+	out := applyTextEdits(base, edits)
+
+	var check map[string]interface{}
+	if err := yaml.Unmarshal(out, &check); err != nil || !valuesEqual(check, data) {
+		return nil, false
+	}
+	return out, true
+}
+
+// runeOffset 将 yaml.v3 的字符列号转换为字节偏移
+func runeOffset(line string, col int) int {
+	offset := 0
+	for i := 0; i < col; i++ {
+		if offset >= len(line) {
+			return -1
+		}
+		_, size := utf8.DecodeRuneInString(line[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// yamlScalarEnd 计算单行标量在行内的结束位置
+func yamlScalarEnd(line string, start int, style yaml.Style) int {
+	switch {
+	case style&yaml.DoubleQuotedStyle != 0:
+		for i := start + 1; i < len(line); i++ {
+			switch line[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1
+			}
+		}
+		return -1
+	case style&yaml.SingleQuotedStyle != 0:
+		for i := start + 1; i < len(line); i++ {
+			if line[i] == '\'' {
+				if i+1 < len(line) && line[i+1] == '\'' {
+					i++
+					continue
+				}
+				return i + 1
+			}
+		}
+		return -1
+	default:
+		end := len(line)
+		if idx := strings.Index(line[start:], " #"); idx >= 0 {
+			end = start + idx
+		}
+		// 流式集合中的标量以逗号或括号结束
+		if idx := strings.IndexAny(line[start:end], ",]}"); idx >= 0 && strings.ContainsAny(line[:start], "[{") {
+			end = start + idx
+		}
+		return start + len(strings.TrimRight(line[start:end], " \t"))
+	}
+}
+
+// renderYAMLScalar 将标量节点渲染为单行文本
+func renderYAMLScalar(node *yaml.Node) (string, bool) {
+	scalar := *node
+	scalar.HeadComment, scalar.LineComment, scalar.FootComment = "", "", ""
+	out, err := yaml.Marshal(&scalar)
+	if err != nil {
+		return "", false
+	}
+	text := strings.TrimSuffix(string(out), "\n")
+	if strings.Contains(text, "\n") {
+		return "", false
+	}
+	return text, true
+}
+
+// reencodeYAML 重新序列化节点树，并恢复原文中键之前的空行
+func reencodeYAML(base []byte, doc *yaml.Node, data map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(detectYAMLIndent(base))
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	original, err := decodeSingleYAMLDocument(base)
+	if err != nil {
+		return buf.Bytes(), nil
+	}
+	blanks := make(map[string]int)
+	originalLines := strings.Split(string(base), "\n")
+	walkYAMLKeys(original, "", func(path string, key *yaml.Node) {
+		start := yamlKeyStartLine(key)
+		count := 0
+		for i := start - 2; i >= 0 && strings.TrimSpace(originalLines[i]) == ""; i-- {
+			count++
+		}
+		if count > 0 {
+			blanks[path] = count
+		}
+	})
+
+	output := buf.String()
+	encoded, err := decodeSingleYAMLDocument([]byte(output))
+	if err != nil || len(blanks) == 0 {
+		return []byte(output), nil
+	}
+
+	type insertion struct {
+		line  int
+		count int
+	}
+	var insertions []insertion
+	outputLines := strings.Split(output, "\n")
+	walkYAMLKeys(encoded, "", func(path string, key *yaml.Node) {
+		count, ok := blanks[path]
+		if !ok {
+			return
+		}
+		start := yamlKeyStartLine(key)
+		if start >= 2 && strings.TrimSpace(outputLines[start-2]) == "" {
+			return
+		}
+		insertions = append(insertions, insertion{line: start, count: count})
+	})
+
+	sort.Slice(insertions, func(i, j int) bool { return insertions[i].line > insertions[j].line })
+	for _, ins := range insertions {
+		if ins.line < 1 || ins.line > len(outputLines) {
+			continue
+		}
+		blankLines := make([]string, ins.count)
+		outputLines = append(outputLines[:ins.line-1], append(blankLines, outputLines[ins.line-1:]...)...)
+	}
+
+	return []byte(strings.Join(outputLines, "\n")), nil
+}
+
+// walkYAMLKeys 遍历所有映射键，path 为以 "/" 分隔的键路径
+func walkYAMLKeys(node *yaml.Node, path string, fn func(path string, key *yaml.Node)) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			walkYAMLKeys(child, path, fn)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			childPath := path + "/" + node.Content[i].Value
+			fn(childPath, node.Content[i])
+			walkYAMLKeys(node.Content[i+1], childPath, fn)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			walkYAMLKeys(child, path+"/"+strconv.Itoa(i), fn)
+		}
+	}
+}
+
+// yamlKeyStartLine 返回键（含其头部注释）的起始行号
+func yamlKeyStartLine(key *yaml.Node) int {
+	start := key.Line
+	if key.HeadComment != "" {
+		start -= strings.Count(key.HeadComment, "\n") + 1
+	}
+	return start
+}
+
+// detectYAMLIndent 根据第一个嵌套映射推断缩进宽度
+func detectYAMLIndent(base []byte) int {
+	doc, err := decodeSingleYAMLDocument(base)
+	if err != nil || len(doc.Content) == 0 {
+		return 2
+	}
+
+	indent := 0
+	var find func(node *yaml.Node)
+	find = func(node *yaml.Node) {
+		if indent > 0 || node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			child := node.Content[i+1]
+			if child.Kind == yaml.MappingNode && len(child.Content) > 0 && child.Content[0].Line > node.Content[i].Line {
+				indent = child.Content[0].Column - node.Content[i].Column
+				return
+			}
+			find(child)
+		}
+	}
+	find(doc.Content[0])
+
+	if indent <= 0 {
+		return 2
+	}
+	return indent
+}
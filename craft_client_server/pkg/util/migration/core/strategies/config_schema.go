@@ -0,0 +1,173 @@
+package strategies
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v3"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+// builtinSchemas 内置的常用配置文件 Schema，以 builtin:文件名（不含扩展名）引用
+//
+//go:embed schemas/*.json
+var builtinSchemas embed.FS
+
+// builtinSchemaPrefix 内置 Schema 引用前缀
+const builtinSchemaPrefix = "builtin:"
+
+// schemaViolation Schema 校验失败项
+type schemaViolation struct {
+	path    string // 配置项路径，如 servers[0].port，根节点为空
+	message string
+}
+
+// String 返回校验失败项的描述
+func (v schemaViolation) String() string {
+	path := v.path
+	if path == "" {
+		path = "(根节点)"
+	}
+	return fmt.Sprintf("%s: %s", path, v.message)
+}
+
+// loadConfigSchema 加载并编译 Schema，ref 为本地文件路径或 builtin:名称
+func loadConfigSchema(ref string) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+
+	if name, ok := strings.CutPrefix(ref, builtinSchemaPrefix); ok {
+		content, err := builtinSchemas.ReadFile("schemas/" + name + ".json")
+		if err != nil {
+			return nil, fmt.Errorf("unknown builtin schema %q", name)
+		}
+		url := "builtin:///" + name + ".json"
+		if err := compiler.AddResource(url, bytes.NewReader(content)); err != nil {
+			return nil, fmt.Errorf("failed to load builtin schema %q: %w", name, err)
+		}
+		return compiler.Compile(url)
+	}
+
+	path, err := filepath.Abs(ref)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	// YAML 编写的 Schema 先转换为 JSON
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var doc interface{}
+		if err := yaml.Unmarshal(content, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse schema: %w", err)
+		}
+		if content, err = json.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("failed to parse schema: %w", err)
+		}
+	}
+	if err := compiler.AddResource(path, bytes.NewReader(content)); err != nil {
+		return nil, fmt.Errorf("failed to load schema: %w", err)
+	}
+	return compiler.Compile(path)
+}
+
+// validateConfigSchema 按 Schema 校验配置数据，ref 为空时不校验
+// 返回全部校验失败项，按路径排序；Schema 本身无法加载时返回错误
+func validateConfigSchema(ref string, data map[string]interface{}) ([]schemaViolation, error) {
+	if ref == "" {
+		return nil, nil
+	}
+	schema, err := loadConfigSchema(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	// 各格式解析出的值（int、int64 等）统一转换为 JSON 值
+	content, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert config for schema validation: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var instance interface{}
+	if err := decoder.Decode(&instance); err != nil {
+		return nil, fmt.Errorf("failed to convert config for schema validation: %w", err)
+	}
+
+	err = schema.Validate(instance)
+	if err == nil {
+		return nil, nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, err
+	}
+
+	var violations []schemaViolation
+	collectSchemaViolations(validationErr, &violations)
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].path < violations[j].path })
+	return violations, nil
+}
+
+// collectSchemaViolations 收集校验错误树的叶子节点
+func collectSchemaViolations(err *jsonschema.ValidationError, violations *[]schemaViolation) {
+	if len(err.Causes) == 0 {
+		*violations = append(*violations, schemaViolation{
+			path:    jsonPointerToKeyPath(err.InstanceLocation),
+			message: err.Message,
+		})
+		return
+	}
+	for _, cause := range err.Causes {
+		collectSchemaViolations(cause, violations)
+	}
+}
+
+// jsonPointerToKeyPath 将 JSON Pointer 转换为配置项路径，如 /servers/0/port → servers[0].port
+func jsonPointerToKeyPath(pointer string) string {
+	if pointer == "" || pointer == "/" {
+		return ""
+	}
+	var b strings.Builder
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		if _, err := strconv.Atoi(token); err == nil {
+			b.WriteString("[" + token + "]")
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(token)
+	}
+	return b.String()
+}
+
+// schemaViolationRecords 将校验失败项转换为迁移记录
+func schemaViolationRecords(ref string, violations []schemaViolation) []core.MigrationRecord {
+	records := make([]core.MigrationRecord, 0, len(violations))
+	for _, v := range violations {
+		records = append(records, core.MigrationRecord{
+			StepName:   "Schema 校验",
+			ActionType: constants.ActionTypeValidate,
+			Key:        v.path,
+			Status:     constants.RecordStatusFailed,
+			Message:    fmt.Sprintf("%s（%s）", v.message, ref),
+			Timestamp:  time.Now(),
+		})
+	}
+	return records
+}
@@ -0,0 +1,212 @@
+package strategies
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tsc/pkg/util/migration/core"
+)
+
+// serviceSchema 测试用的服务配置 Schema（YAML 编写）
+const serviceSchema = `type: object
+required: [server]
+properties:
+  server:
+    type: object
+    required: [port]
+    properties:
+      port: {type: integer, minimum: 1, maximum: 65535}
+      host: {type: string}
+  replicas:
+    type: array
+    items:
+      type: object
+      required: [name]
+`
+
+func TestValidateConfigSchema(t *testing.T) {
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "service.schema.yaml")
+	if err := os.WriteFile(schemaPath, []byte(serviceSchema), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data := map[string]interface{}{
+		"server":   map[string]interface{}{"port": 70000, "host": "0.0.0.0"},
+		"replicas": []interface{}{map[string]interface{}{"name": "a"}, map[string]interface{}{}},
+	}
+	violations, err := validateConfigSchema(schemaPath, data)
+	if err != nil {
+		t.Fatalf("validateConfigSchema failed: %v", err)
+	}
+	var paths []string
+	for _, v := range violations {
+		paths = append(paths, v.path)
+	}
+	if strings.Join(paths, ",") != "replicas[1],server.port" {
+		t.Errorf("unexpected violation paths: %v", violations)
+	}
+
+	violations, err = validateConfigSchema("builtin:docker-daemon", map[string]interface{}{"log-level": "verbose", "debug": true})
+	if err != nil {
+		t.Fatalf("builtin schema failed: %v", err)
+	}
+	if len(violations) != 1 || violations[0].path != "log-level" {
+		t.Errorf("unexpected builtin violations: %v", violations)
+	}
+
+	if _, err := validateConfigSchema("builtin:missing", data); err == nil {
+		t.Error("unknown builtin schema should fail")
+	}
+}
+
+func TestConfigFileSchemaBlocksInvalidMerge(t *testing.T) {
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "service.schema.yaml")
+	sourcePath := filepath.Join(dir, "override.yaml")
+	targetPath := filepath.Join(dir, "config.yaml")
+	target := "server:\n  port: 8080\n  host: 0.0.0.0\n"
+	files := map[string]string{
+		schemaPath: serviceSchema,
+		sourcePath: "server:\n  port: \"8081\"\n",
+		targetPath: target,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = sourcePath
+	config.Target.Path = targetPath
+	config.Target.MergeMode = "merge"
+	config.Target.Schema = schemaPath
+
+	strategy := &ConfigFileStrategy{}
+	if err := strategy.Validate(config); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	preview, err := strategy.DryRun(context.Background(), config)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if len(preview.Errors) != 1 || !strings.Contains(preview.Errors[0], "server.port") {
+		t.Errorf("expected schema error for server.port, got %v", preview.Errors)
+	}
+
+	if _, err := strategy.Execute(context.Background(), config); err == nil {
+		t.Fatal("Execute should refuse to write an invalid config")
+	}
+	if out, _ := os.ReadFile(targetPath); string(out) != target {
+		t.Errorf("target must be left untouched, got:\n%s", out)
+	}
+
+	config.Options.Force = true
+	result, err := strategy.Execute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("forced Execute failed: %v", err)
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("expected schema warning, got %v", result.Warnings)
+	}
+	if out, _ := os.ReadFile(targetPath); !strings.Contains(string(out), `port: "8081"`) {
+		t.Errorf("forced Execute should write the merged config, got:\n%s", out)
+	}
+}
+
+func TestConfigFileImportValidatesWrittenContent(t *testing.T) {
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "service.schema.yaml")
+	sourcePath := filepath.Join(dir, "service.yaml")
+	targetPath := filepath.Join(dir, "restored.yaml")
+	exportPath := filepath.Join(dir, "service.export.json")
+	target := "server:\n  port: 8080\nreplicas: []\n"
+	files := map[string]string{
+		schemaPath: serviceSchema,
+		sourcePath: "# 导出的配置\nserver:\n  port: \"8081\"\n",
+		targetPath: target,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = sourcePath
+	config.Options.ExportPath = exportPath
+	config.Options.IncludeRawContent = true
+	strategy := &ConfigFileStrategy{}
+	if _, err := strategy.Export(context.Background(), config); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	// 设置 Schema 时不直接写入未校验的原始内容
+	importConfig := core.NewMigrationConfig()
+	importConfig.Options.ImportPath = exportPath
+	importConfig.Options.PreserveFormat = true
+	importConfig.Target.Path = targetPath
+	importConfig.Target.Schema = schemaPath
+	if _, err := strategy.Import(context.Background(), importConfig); err == nil {
+		t.Fatal("Import should refuse to write an invalid config")
+	}
+	if out, _ := os.ReadFile(targetPath); string(out) != target {
+		t.Errorf("target must be left untouched, got:\n%s", out)
+	}
+
+	// 合并模式写入合并结果，而不是导出包中的原始内容
+	importConfig.Target.Schema = ""
+	importConfig.Target.MergeMode = "merge"
+	if _, err := strategy.Import(context.Background(), importConfig); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if out, _ := os.ReadFile(targetPath); !strings.Contains(string(out), "replicas") || !strings.Contains(string(out), "8081") {
+		t.Errorf("merged config not written, got:\n%s", out)
+	}
+}
+
+func TestConfigFileSchemaValidatesMergedXML(t *testing.T) {
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "ide.schema.yaml")
+	sourcePath := filepath.Join(dir, "source", "ide.general.xml")
+	targetPath := filepath.Join(dir, "target", "ide.general.xml")
+	os.MkdirAll(filepath.Dir(sourcePath), 0755)
+	os.MkdirAll(filepath.Dir(targetPath), 0755)
+	// 按元素合并后 component 为两个元素组成的数组；按扁平化数据合并则被源的单个元素替换
+	files := map[string]string{
+		schemaPath: "type: object\nproperties:\n  application:\n    type: object\n    properties:\n      component: {type: array, minItems: 2}\n",
+		sourcePath: "<application>\n  <component name=\"GeneralSettings\" />\n</application>\n",
+		targetPath: "<application>\n  <component name=\"Other\" />\n</application>\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = sourcePath
+	config.Target.Path = targetPath
+	config.Target.MergeMode = "merge"
+	config.Target.Schema = schemaPath
+
+	strategy := &ConfigFileStrategy{}
+	preview, err := strategy.DryRun(context.Background(), config)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if len(preview.Errors) != 0 {
+		t.Errorf("preview must validate the element-merged document: %v", preview.Errors)
+	}
+	if _, err := strategy.Execute(context.Background(), config); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if out, _ := os.ReadFile(targetPath); strings.Count(string(out), "<component") != 2 {
+		t.Errorf("unexpected merged document:\n%s", out)
+	}
+}
@@ -0,0 +1,737 @@
+package strategies
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xmlNodeKind XML 节点类型
+type xmlNodeKind int
+
+const (
+	xmlElementNode xmlNodeKind = iota
+	xmlTextNode
+	xmlCDataNode
+	xmlCommentNode
+	xmlProcInstNode
+	xmlDirectiveNode
+)
+
+// xmlIdentityAttrs 用于识别同名兄弟元素的属性，按优先级排列
+// IntelliJ 的 <component>/<option>/<property> 使用 name，<entry> 使用 key，<action> 使用 id
+var xmlIdentityAttrs = []string{"name", "key", "id"}
+
+var (
+	xmlTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	xmlAttrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;",
+		"\n", "&#10;", "\r", "&#13;", "\t", "&#9;")
+)
+
+// xmlAttr 属性，名称保留命名空间前缀（如 xmlns:xsi、xsi:schemaLocation）
+type xmlAttr struct {
+	name  string
+	value string
+}
+
+// xmlNode 无损 XML 节点
+// 元素保留属性顺序、命名空间前缀与子节点顺序；文本、CDATA、注释与处理指令按原位置保存
+type xmlNode struct {
+	kind        xmlNodeKind
+	name        string // 元素限定名或处理指令目标
+	attrs       []xmlAttr
+	children    []*xmlNode
+	text        string // 文本、CDATA、注释、指令内容（已解码）
+	raw         string // 原始文本，节点未修改时原样输出；元素只保存起始标签
+	selfClosing bool
+}
+
+// xmlDocument 根元素及其前后的 XML 声明、注释与空白
+type xmlDocument struct {
+	nodes []*xmlNode
+}
+
+// parseXMLDocument 解析 XML 为无损节点树
+func parseXMLDocument(content []byte) (*xmlDocument, error) {
+	doc := &xmlDocument{}
+	dec := xml.NewDecoder(bytes.NewReader(content))
+	// 编码声明只做保留，不在解析时转换
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	var stack []*xmlNode
+	offset := dec.InputOffset()
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XML: %w", err)
+		}
+		raw := string(content[offset:dec.InputOffset()])
+		offset = dec.InputOffset()
+
+		var node *xmlNode
+		switch t := tok.(type) {
+		case xml.StartElement:
+			node = &xmlNode{kind: xmlElementNode, name: xmlQualifiedName(t.Name), raw: raw}
+			for _, attr := range t.Attr {
+				node.attrs = append(node.attrs, xmlAttr{name: xmlQualifiedName(attr.Name), value: attr.Value})
+			}
+			node.selfClosing = strings.HasSuffix(raw, "/>")
+		case xml.EndElement:
+			name := xmlQualifiedName(t.Name)
+			if len(stack) == 0 || stack[len(stack)-1].name != name {
+				return nil, fmt.Errorf("failed to parse XML: unexpected end element </%s>", name)
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		case xml.CharData:
+			node = &xmlNode{kind: xmlTextNode, text: string(t), raw: raw}
+			if strings.HasPrefix(raw, "<![CDATA[") {
+				node.kind = xmlCDataNode
+			}
+		case xml.Comment:
+			node = &xmlNode{kind: xmlCommentNode, text: string(t), raw: raw}
+		case xml.ProcInst:
+			node = &xmlNode{kind: xmlProcInstNode, name: t.Target, text: string(t.Inst), raw: raw}
+		case xml.Directive:
+			node = &xmlNode{kind: xmlDirectiveNode, text: string(t), raw: raw}
+		default:
+			continue
+		}
+
+		if len(stack) > 0 {
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, node)
+		} else {
+			doc.nodes = append(doc.nodes, node)
+		}
+		// 自闭合元素也会收到一个合成的结束标记
+		if node.kind == xmlElementNode {
+			stack = append(stack, node)
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("failed to parse XML: element <%s> is not closed", stack[len(stack)-1].name)
+	}
+	if doc.root() == nil {
+		return nil, fmt.Errorf("failed to parse XML: no root element")
+	}
+	return doc, nil
+}
+
+// xmlQualifiedName 返回带前缀的限定名；RawToken 不解析命名空间，Space 即为前缀
+func xmlQualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// root 返回文档根元素
+func (d *xmlDocument) root() *xmlNode {
+	for _, node := range d.nodes {
+		if node.kind == xmlElementNode {
+			return node
+		}
+	}
+	return nil
+}
+
+// Bytes 序列化文档，未修改的节点按原文输出
+func (d *xmlDocument) Bytes() []byte {
+	var buf bytes.Buffer
+	for _, node := range d.nodes {
+		writeXMLNode(&buf, node)
+	}
+	return buf.Bytes()
+}
+
+func writeXMLNode(buf *bytes.Buffer, n *xmlNode) {
+	if n.kind != xmlElementNode {
+		if n.raw != "" {
+			buf.WriteString(n.raw)
+			return
+		}
+		switch n.kind {
+		case xmlTextNode:
+			buf.WriteString(xmlTextEscaper.Replace(n.text))
+		case xmlCDataNode:
+			buf.WriteString("<![CDATA[" + n.text + "]]>")
+		case xmlCommentNode:
+			buf.WriteString("<!--" + n.text + "-->")
+		case xmlProcInstNode:
+			buf.WriteString("<?" + n.name)
+			if n.text != "" {
+				buf.WriteString(" " + n.text)
+			}
+			buf.WriteString("?>")
+		case xmlDirectiveNode:
+			buf.WriteString("<!" + n.text + ">")
+		}
+		return
+	}
+
+	empty := n.selfClosing && len(n.children) == 0
+	if n.raw != "" {
+		buf.WriteString(n.raw)
+	} else {
+		buf.WriteString("<" + n.name)
+		for _, attr := range n.attrs {
+			buf.WriteString(" " + attr.name + `="` + xmlAttrEscaper.Replace(attr.value) + `"`)
+		}
+		if empty {
+			buf.WriteString("/>")
+		} else {
+			buf.WriteString(">")
+		}
+	}
+	if empty {
+		return
+	}
+	for _, child := range n.children {
+		writeXMLNode(buf, child)
+	}
+	buf.WriteString("</" + n.name + ">")
+}
+
+// attr 返回属性值
+func (n *xmlNode) attr(name string) (string, bool) {
+	for _, attr := range n.attrs {
+		if attr.name == name {
+			return attr.value, true
+		}
+	}
+	return "", false
+}
+
+// setAttr 更新或追加属性，值变化时丢弃原始起始标签
+func (n *xmlNode) setAttr(name, value string) {
+	for i, attr := range n.attrs {
+		if attr.name == name {
+			if attr.value != value {
+				n.attrs[i].value = value
+				n.raw = ""
+			}
+			return
+		}
+	}
+	n.attrs = append(n.attrs, xmlAttr{name: name, value: value})
+	n.raw = ""
+}
+
+// removeAttr 删除属性
+func (n *xmlNode) removeAttr(name string) {
+	for i, attr := range n.attrs {
+		if attr.name == name {
+			n.attrs = append(n.attrs[:i], n.attrs[i+1:]...)
+			n.raw = ""
+			return
+		}
+	}
+}
+
+// elements 返回子元素
+func (n *xmlNode) elements() []*xmlNode {
+	var result []*xmlNode
+	for _, child := range n.children {
+		if child.kind == xmlElementNode {
+			result = append(result, child)
+		}
+	}
+	return result
+}
+
+// textContent 返回直接子文本与 CDATA 的拼接
+func (n *xmlNode) textContent() string {
+	var sb strings.Builder
+	for _, child := range n.children {
+		if child.kind == xmlTextNode || child.kind == xmlCDataNode {
+			sb.WriteString(child.text)
+		}
+	}
+	return sb.String()
+}
+
+// identity 返回元素的标识属性，如 name=Git.Settings；无标识属性时返回空串
+func (n *xmlNode) identity() string {
+	for _, name := range xmlIdentityAttrs {
+		if value, ok := n.attr(name); ok {
+			return name + "=" + value
+		}
+	}
+	return ""
+}
+
+// indexOf 返回子节点位置
+func (n *xmlNode) indexOf(child *xmlNode) int {
+	for i, c := range n.children {
+		if c == child {
+			return i
+		}
+	}
+	return -1
+}
+
+// clone 深拷贝节点
+func (n *xmlNode) clone() *xmlNode {
+	copied := *n
+	copied.attrs = append([]xmlAttr(nil), n.attrs...)
+	copied.children = nil
+	for _, child := range n.children {
+		copied.children = append(copied.children, child.clone())
+	}
+	return &copied
+}
+
+// isXMLSpace 判断节点是否为纯空白文本
+func isXMLSpace(n *xmlNode) bool {
+	return n.kind == xmlTextNode && strings.TrimSpace(n.text) == ""
+}
+
+// xmlElementValue 将元素转换为 map：属性以 @ 为前缀，文本为 #text，同名子元素转换为数组
+func xmlElementValue(n *xmlNode) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	for _, attr := range n.attrs {
+		result["@"+attr.name] = attr.value
+	}
+
+	for _, child := range n.elements() {
+		value := xmlElementValue(child)
+		if existing, ok := result[child.name]; ok {
+			if arr, ok := existing.([]interface{}); ok {
+				result[child.name] = append(arr, value)
+			} else {
+				result[child.name] = []interface{}{existing, value}
+			}
+		} else {
+			result[child.name] = value
+		}
+	}
+
+	if text := strings.TrimSpace(n.textContent()); text != "" {
+		result["#text"] = text
+	}
+
+	return result
+}
+
+// xmlElementFromValue 由 map 形式构建元素，属性与子元素按名称排序
+func xmlElementFromValue(name string, value interface{}) *xmlNode {
+	n := &xmlNode{kind: xmlElementNode, name: name}
+
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		if value != nil {
+			if text := toString(value); text != "" {
+				n.children = append(n.children, &xmlNode{kind: xmlTextNode, text: text})
+			}
+		}
+		n.selfClosing = len(n.children) == 0
+		return n
+	}
+
+	for _, key := range sortedKeys(fields) {
+		switch {
+		case strings.HasPrefix(key, "@"):
+			n.attrs = append(n.attrs, xmlAttr{name: key[1:], value: toString(fields[key])})
+		case key == "#text":
+			n.children = append(n.children, &xmlNode{kind: xmlTextNode, text: toString(fields[key])})
+		default:
+			for _, item := range xmlValueList(fields[key]) {
+				n.children = append(n.children, xmlElementFromValue(key, item))
+			}
+		}
+	}
+	n.selfClosing = len(n.children) == 0
+	return n
+}
+
+// xmlValueList 将单个值或数组统一为列表
+func xmlValueList(value interface{}) []interface{} {
+	if arr, ok := value.([]interface{}); ok {
+		return arr
+	}
+	return []interface{}{value}
+}
+
+// reindentXML 按 indent 重新排列只含元素（及注释）的子节点，混合内容保持不变
+func reindentXML(n *xmlNode, indent, unit string) {
+	if n.kind != xmlElementNode || len(n.elements()) == 0 || strings.TrimSpace(n.textContent()) != "" {
+		return
+	}
+
+	var children []*xmlNode
+	for _, child := range n.children {
+		if isXMLSpace(child) {
+			continue
+		}
+		children = append(children, &xmlNode{kind: xmlTextNode, text: "\n" + indent + unit}, child)
+		reindentXML(child, indent+unit, unit)
+	}
+	n.children = append(children, &xmlNode{kind: xmlTextNode, text: "\n" + indent})
+}
+
+// detectXMLIndent 从根元素的子元素缩进推断缩进单位
+func detectXMLIndent(root *xmlNode) string {
+	for _, child := range root.children {
+		if isXMLSpace(child) {
+			if i := strings.LastIndex(child.text, "\n"); i >= 0 && i < len(child.text)-1 {
+				return child.text[i+1:]
+			}
+		}
+	}
+	return "  "
+}
+
+// readXML 将 XML 文档转换为以根元素名为唯一键的 map
+func readXML(content []byte) (map[string]interface{}, error) {
+	doc, err := parseXMLDocument(content)
+	if err != nil {
+		return nil, err
+	}
+	root := doc.root()
+	return map[string]interface{}{root.name: xmlElementValue(root)}, nil
+}
+
+// marshalXML 由 map 形式生成新的 XML 文档，data 必须只有一个根元素
+func marshalXML(data map[string]interface{}) ([]byte, error) {
+	var roots []string
+	for _, key := range sortedKeys(data) {
+		if !strings.HasPrefix(key, "@") && key != "#text" {
+			roots = append(roots, key)
+		}
+	}
+	if len(roots) != 1 {
+		return nil, fmt.Errorf("XML document requires exactly one root element, got %d", len(roots))
+	}
+	if _, ok := data[roots[0]].([]interface{}); ok {
+		return nil, fmt.Errorf("XML root element <%s> cannot be repeated", roots[0])
+	}
+
+	root := xmlElementFromValue(roots[0], data[roots[0]])
+	reindentXML(root, "", "  ")
+	doc := &xmlDocument{nodes: []*xmlNode{
+		{kind: xmlProcInstNode, name: "xml", text: `version="1.0" encoding="UTF-8"`},
+		{kind: xmlTextNode, text: "\n"},
+		root,
+		{kind: xmlTextNode, text: "\n"},
+	}}
+	return doc.Bytes(), nil
+}
+
+// preserveXML 以 base 为原始文档写入 data，未变化的元素、注释与声明保持原样
+func preserveXML(base []byte, data map[string]interface{}) ([]byte, error) {
+	doc, err := parseXMLDocument(base)
+	if err != nil {
+		return nil, errPreserveUnsupported
+	}
+	root := doc.root()
+	value, ok := data[root.name]
+	if !ok || len(data) != 1 {
+		return nil, errPreserveUnsupported
+	}
+	if _, ok := value.([]interface{}); ok {
+		return nil, errPreserveUnsupported
+	}
+
+	p := &xmlPatcher{unit: detectXMLIndent(root)}
+	p.apply(root, value, "")
+	return doc.Bytes(), nil
+}
+
+// mergeXMLContent 按元素将 source 合并进 target，以 target 为基准保留其格式
+// 同名兄弟元素按 name/key/id 属性匹配（IntelliJ 的 <component name=...>、<option name=...>），
+// 无标识属性且无属性的元素（如 <list>、<map>）在两边唯一时递归合并，其余按内容去重追加
+// overwrite 为 false 时只补充缺失的元素与属性，对应 skip 合并模式
+func mergeXMLContent(target, source []byte, overwrite bool) ([]byte, error) {
+	targetDoc, err := parseXMLDocument(target)
+	if err != nil {
+		return nil, err
+	}
+	sourceDoc, err := parseXMLDocument(source)
+	if err != nil {
+		return nil, err
+	}
+
+	targetRoot, sourceRoot := targetDoc.root(), sourceDoc.root()
+	if targetRoot.name != sourceRoot.name {
+		return nil, fmt.Errorf("XML root element mismatch: <%s> and <%s>", targetRoot.name, sourceRoot.name)
+	}
+
+	p := &xmlPatcher{unit: detectXMLIndent(targetRoot)}
+	p.merge(targetRoot, sourceRoot, "", overwrite)
+	return targetDoc.Bytes(), nil
+}
+
+// xmlPatcher 在无损节点树上做最小修改
+type xmlPatcher struct {
+	unit string
+}
+
+// apply 将 map 形式的 value 写入元素 n，own 为 n 自身的缩进
+func (p *xmlPatcher) apply(n *xmlNode, value interface{}, own string) {
+	if valuesEqual(xmlElementValue(n), value) {
+		return
+	}
+
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		text := ""
+		if value != nil {
+			text = toString(value)
+		}
+		p.setText(n, text)
+		return
+	}
+
+	// 属性：原有属性保持顺序，新增属性按名称追加
+	for _, attr := range append([]xmlAttr(nil), n.attrs...) {
+		if v, ok := fields["@"+attr.name]; ok {
+			n.setAttr(attr.name, toString(v))
+		} else {
+			n.removeAttr(attr.name)
+		}
+	}
+	for _, key := range sortedKeys(fields) {
+		if strings.HasPrefix(key, "@") {
+			if _, exists := n.attr(key[1:]); !exists {
+				n.setAttr(key[1:], toString(fields[key]))
+			}
+		}
+	}
+
+	// 文本：仅处理不含子元素的元素，混合内容保持不变
+	if len(n.elements()) == 0 {
+		text := ""
+		if v, ok := fields["#text"]; ok {
+			text = toString(v)
+		}
+		p.setText(n, text)
+	}
+
+	// 子元素：同名元素按出现顺序一一对应
+	groups := make(map[string][]*xmlNode)
+	var names []string
+	for _, child := range n.elements() {
+		if _, ok := groups[child.name]; !ok {
+			names = append(names, child.name)
+		}
+		groups[child.name] = append(groups[child.name], child)
+	}
+	for _, name := range names {
+		if _, ok := fields[name]; !ok {
+			for _, child := range groups[name] {
+				p.remove(n, child)
+			}
+		}
+	}
+	for _, key := range sortedKeys(fields) {
+		if strings.HasPrefix(key, "@") || key == "#text" {
+			continue
+		}
+		group := groups[key]
+		items := xmlValueList(fields[key])
+		for i, item := range items {
+			if i < len(group) {
+				p.apply(group[i], item, p.indentOf(n, group[i], own))
+				continue
+			}
+			child := xmlElementFromValue(key, item)
+			p.insert(n, child, p.anchor(n, key), own)
+		}
+		for i := len(items); i < len(group); i++ {
+			p.remove(n, group[i])
+		}
+	}
+}
+
+// merge 将 source 元素合并进 target 元素
+func (p *xmlPatcher) merge(t, s *xmlNode, own string, overwrite bool) {
+	for _, attr := range s.attrs {
+		if _, exists := t.attr(attr.name); !exists || overwrite {
+			t.setAttr(attr.name, attr.value)
+		}
+	}
+
+	sourceChildren := s.elements()
+	if len(sourceChildren) == 0 {
+		if text := s.textContent(); strings.TrimSpace(text) != "" && overwrite && len(t.elements()) == 0 {
+			p.setText(t, strings.TrimSpace(text))
+		}
+		return
+	}
+
+	matched := make(map[*xmlNode]bool)
+	for _, sc := range sourceChildren {
+		if tc := p.match(t, sc, sourceChildren, matched); tc != nil {
+			matched[tc] = true
+			p.merge(tc, sc, p.indentOf(t, tc, own), overwrite)
+			continue
+		}
+		child := sc.clone()
+		matched[child] = true
+		p.insert(t, child, p.anchor(t, sc.name), own)
+	}
+}
+
+// match 在 target 中查找与 source 子元素对应的元素
+func (p *xmlPatcher) match(t, sc *xmlNode, siblings []*xmlNode, matched map[*xmlNode]bool) *xmlNode {
+	if key := sc.identity(); key != "" {
+		for _, tc := range t.elements() {
+			if !matched[tc] && tc.name == sc.name && tc.identity() == key {
+				return tc
+			}
+		}
+		return nil
+	}
+
+	// 无属性的容器元素在两边唯一时视为同一元素
+	if len(sc.attrs) == 0 && countXMLElements(siblings, sc.name) == 1 {
+		candidates := make([]*xmlNode, 0, 1)
+		for _, tc := range t.elements() {
+			if tc.name == sc.name && tc.identity() == "" {
+				candidates = append(candidates, tc)
+			}
+		}
+		if len(candidates) == 1 && !matched[candidates[0]] {
+			return candidates[0]
+		}
+	}
+
+	// 其余元素（如列表中的 <option value="..."/>）按内容去重
+	value := xmlElementValue(sc)
+	for _, tc := range t.elements() {
+		if !matched[tc] && tc.name == sc.name && valuesEqual(xmlElementValue(tc), value) {
+			return tc
+		}
+	}
+	return nil
+}
+
+// countXMLElements 统计同名元素数量
+func countXMLElements(elements []*xmlNode, name string) int {
+	count := 0
+	for _, element := range elements {
+		if element.name == name {
+			count++
+		}
+	}
+	return count
+}
+
+// anchor 返回新元素的插入位置：同名元素之后，否则最后一个子元素之后
+func (p *xmlPatcher) anchor(n *xmlNode, name string) *xmlNode {
+	var last, lastSame *xmlNode
+	for _, child := range n.elements() {
+		last = child
+		if child.name == name {
+			lastSame = child
+		}
+	}
+	if lastSame != nil {
+		return lastSame
+	}
+	return last
+}
+
+// indentOf 返回子元素的缩进，取其前方空白的最后一行
+func (p *xmlPatcher) indentOf(parent, child *xmlNode, own string) string {
+	if i := parent.indexOf(child); i > 0 && isXMLSpace(parent.children[i-1]) {
+		text := parent.children[i-1].text
+		if j := strings.LastIndex(text, "\n"); j >= 0 {
+			return text[j+1:]
+		}
+	}
+	return own + p.unit
+}
+
+// insert 在 after 之后插入子元素，after 为空时追加到末尾
+func (p *xmlPatcher) insert(parent, child, after *xmlNode, own string) {
+	if after == nil {
+		indent := own + p.unit
+		reindentXML(child, indent, p.unit)
+		if parent.selfClosing {
+			parent.selfClosing = false
+			parent.raw = ""
+		}
+		if n := len(parent.children); n > 0 && isXMLSpace(parent.children[n-1]) {
+			parent.children = parent.children[:n-1]
+		}
+		parent.children = append(parent.children,
+			&xmlNode{kind: xmlTextNode, text: "\n" + indent},
+			child,
+			&xmlNode{kind: xmlTextNode, text: "\n" + own})
+		return
+	}
+
+	i := parent.indexOf(after)
+	nodes := []*xmlNode{child}
+	if i > 0 && isXMLSpace(parent.children[i-1]) && strings.Contains(parent.children[i-1].text, "\n") {
+		indent := p.indentOf(parent, after, own)
+		reindentXML(child, indent, p.unit)
+		nodes = []*xmlNode{{kind: xmlTextNode, text: "\n" + indent}, child}
+	}
+
+	children := append([]*xmlNode(nil), parent.children[:i+1]...)
+	children = append(children, nodes...)
+	parent.children = append(children, parent.children[i+1:]...)
+}
+
+// remove 删除子元素及其前方的空白
+func (p *xmlPatcher) remove(parent, child *xmlNode) {
+	i := parent.indexOf(child)
+	if i < 0 {
+		return
+	}
+	start := i
+	if i > 0 && isXMLSpace(parent.children[i-1]) {
+		start = i - 1
+	}
+	parent.children = append(parent.children[:start], parent.children[i+1:]...)
+
+	for _, c := range parent.children {
+		if !isXMLSpace(c) {
+			return
+		}
+	}
+	parent.children = nil
+}
+
+// setText 替换不含子元素的元素的文本，保留 CDATA 形式与注释
+func (p *xmlPatcher) setText(n *xmlNode, text string) {
+	if len(n.elements()) > 0 || strings.TrimSpace(n.textContent()) == text {
+		return
+	}
+
+	kind := xmlTextNode
+	var kept []*xmlNode
+	for _, child := range n.children {
+		switch child.kind {
+		case xmlCDataNode:
+			kind = xmlCDataNode
+		case xmlTextNode:
+		default:
+			kept = append(kept, child)
+		}
+	}
+	if kind == xmlCDataNode && strings.Contains(text, "]]>") {
+		kind = xmlTextNode
+	}
+
+	if text != "" {
+		kept = append([]*xmlNode{{kind: kind, text: text}}, kept...)
+	}
+	n.children = kept
+	if n.selfClosing && len(kept) > 0 {
+		n.selfClosing = false
+		n.raw = ""
+	}
+}
@@ -0,0 +1,236 @@
+package strategies
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tsc/pkg/util/migration/core"
+)
+
+func TestXMLDocumentRoundTrip(t *testing.T) {
+	content := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\r\n" +
+		"<!-- 由 IDE 生成 -->\r\n" +
+		"<project version=\"4\" xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\">\r\n" +
+		"  <component name=\"RunManager\" selected='Application.Main'>\r\n" +
+		"    <option name=\"VM_PARAMETERS\" value=\"-Xmx1g&#10;-ea\" />\r\n" +
+		"    <script><![CDATA[if (a < b) { run(); }]]></script>\r\n" +
+		"    <description>Run <b>main</b> class &amp; exit</description>\r\n" +
+		"    <xsi:note/>\r\n" +
+		"  </component>\r\n" +
+		"</project>\r\n"
+
+	doc, err := parseXMLDocument([]byte(content))
+	if err != nil {
+		t.Fatalf("parseXMLDocument failed: %v", err)
+	}
+	if out := string(doc.Bytes()); out != content {
+		t.Errorf("round-trip mismatch:\n%q\nexpected:\n%q", out, content)
+	}
+
+	data, err := readXML([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := data["project"].(map[string]interface{})
+	if project["@xmlns:xsi"] != "http://www.w3.org/2001/XMLSchema-instance" {
+		t.Errorf("namespace declaration not read: %v", project)
+	}
+	component := project["component"].(map[string]interface{})
+	option := component["option"].(map[string]interface{})
+	if option["@value"] != "-Xmx1g\n-ea" {
+		t.Errorf("unexpected attribute value: %q", option["@value"])
+	}
+	if script := component["script"].(map[string]interface{}); script["#text"] != "if (a < b) { run(); }" {
+		t.Errorf("unexpected CDATA text: %v", script)
+	}
+	if _, ok := component["xsi:note"]; !ok {
+		t.Errorf("prefixed element not read: %v", component)
+	}
+
+	// 只修改一个属性时其余内容保持原样
+	option["@value"] = "-Xmx2g"
+	out, err := preserveXML([]byte(content), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Replace(content, `<option name="VM_PARAMETERS" value="-Xmx1g&#10;-ea" />`, `<option name="VM_PARAMETERS" value="-Xmx2g"/>`, 1)
+	if string(out) != expected {
+		t.Errorf("unexpected preserved output:\n%s\nexpected:\n%s", out, expected)
+	}
+}
+
+func TestMarshalXMLAttributes(t *testing.T) {
+	data := map[string]interface{}{
+		"application": map[string]interface{}{
+			"component": []interface{}{
+				map[string]interface{}{
+					"@name":  "GeneralSettings",
+					"option": map[string]interface{}{"@name": "confirmExit", "@value": "false"},
+				},
+				map[string]interface{}{"@name": "Empty"},
+			},
+		},
+	}
+
+	out, err := marshalXML(data)
+	if err != nil {
+		t.Fatalf("marshalXML failed: %v", err)
+	}
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<application>
+  <component name="GeneralSettings">
+    <option name="confirmExit" value="false"/>
+  </component>
+  <component name="Empty"/>
+</application>
+`
+	if string(out) != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", out, expected)
+	}
+
+	back, err := readXML(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !valuesEqual(back, data) {
+		t.Errorf("round-trip mismatch: %v != %v", back, data)
+	}
+
+	if _, err := marshalXML(map[string]interface{}{"a": "1", "b": "2"}); err == nil {
+		t.Error("expected error for multiple root elements")
+	}
+}
+
+func TestMergeXMLIntelliJComponents(t *testing.T) {
+	target := `<application>
+  <!-- 本机设置 -->
+  <component name="GeneralSettings">
+    <option name="confirmExit" value="false" />
+    <option name="showTipsOnStartup" value="false" />
+  </component>
+  <component name="RecentProjectsManager">
+    <option name="recentPaths">
+      <list>
+        <option value="$USER_HOME$/work/a" />
+      </list>
+    </option>
+  </component>
+</application>
+`
+	source := `<application>
+  <component name="GeneralSettings">
+    <option name="confirmExit" value="true" />
+    <option name="autoSaveFiles" value="false" />
+  </component>
+  <component name="RecentProjectsManager">
+    <option name="recentPaths">
+      <list>
+        <option value="$USER_HOME$/work/a" />
+        <option value="$USER_HOME$/work/b" />
+      </list>
+    </option>
+  </component>
+  <component name="Registry">
+    <entry key="ide.tree.horizontal.default.autoscrolling" value="false" />
+  </component>
+</application>
+`
+
+	out, err := mergeXMLContent([]byte(target), []byte(source), true)
+	if err != nil {
+		t.Fatalf("mergeXMLContent failed: %v", err)
+	}
+	expected := `<application>
+  <!-- 本机设置 -->
+  <component name="GeneralSettings">
+    <option name="confirmExit" value="true"/>
+    <option name="showTipsOnStartup" value="false" />
+    <option name="autoSaveFiles" value="false" />
+  </component>
+  <component name="RecentProjectsManager">
+    <option name="recentPaths">
+      <list>
+        <option value="$USER_HOME$/work/a" />
+        <option value="$USER_HOME$/work/b" />
+      </list>
+    </option>
+  </component>
+  <component name="Registry">
+    <entry key="ide.tree.horizontal.default.autoscrolling" value="false" />
+  </component>
+</application>
+`
+	if string(out) != expected {
+		t.Errorf("unexpected merge result:\n%s\nexpected:\n%s", out, expected)
+	}
+
+	// skip 模式不覆盖已有值
+	out, err = mergeXMLContent([]byte(target), []byte(source), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `<option name="confirmExit" value="false" />`) {
+		t.Errorf("skip mode overwrote existing value:\n%s", out)
+	}
+
+	if _, err := mergeXMLContent([]byte(target), []byte("<project/>"), true); err == nil {
+		t.Error("expected error for mismatched root elements")
+	}
+}
+
+func TestConfigFileExecuteMergesXML(t *testing.T) {
+	dir := t.TempDir()
+	sourcePath := filepath.Join(dir, "source", "ide.general.xml")
+	targetPath := filepath.Join(dir, "target", "ide.general.xml")
+	if err := os.MkdirAll(filepath.Dir(sourcePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	source := "<application>\n  <component name=\"GeneralSettings\">\n    <option name=\"confirmExit\" value=\"true\" />\n  </component>\n</application>\n"
+	target := "<application>\n  <component name=\"Other\" />\n</application>\n"
+	if err := os.WriteFile(sourcePath, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(targetPath, []byte(target), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = sourcePath
+	config.Target.Path = targetPath
+	config.Target.MergeMode = "merge"
+
+	strategy := &ConfigFileStrategy{}
+	if err := strategy.Validate(config); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	preview, err := strategy.DryRun(context.Background(), config)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if _, err := strategy.Execute(context.Background(), config); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	out, err := os.ReadFile(targetPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "<application>\n  <component name=\"Other\" />\n  <component name=\"GeneralSettings\">\n    <option name=\"confirmExit\" value=\"true\" />\n  </component>\n</application>\n"
+	if string(out) != expected {
+		t.Errorf("unexpected target content:\n%s\nexpected:\n%s", out, expected)
+	}
+
+	// 预览与执行按同一方式合并元素，预览的结果即写入的文档
+	written, _ := readXML(out)
+	if len(preview.Changes) != 1 || preview.Changes[0].AfterValue != fmt.Sprintf("%v", written["application"]) {
+		t.Errorf("preview differs from written document: %+v", preview.Changes)
+	}
+}
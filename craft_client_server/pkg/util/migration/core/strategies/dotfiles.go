@@ -0,0 +1,588 @@
+package strategies
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+func init() {
+	// 自动注册到全局注册表
+	if err := core.RegisterStrategy(&DotfilesStrategy{}); err != nil {
+		panic(fmt.Sprintf("failed to register dotfiles strategy: %v", err))
+	}
+}
+
+// dotfilesDefaultIgnore 包中默认不链接的文件（与 GNU stow 的默认忽略列表一致），规则相对于包目录
+var dotfilesDefaultIgnore = []string{
+	"RCS", ".svn", "CVS", ".git", ".gitignore", ".gitmodules",
+	"README*", "LICENSE*", "COPYING", ".#*", "*~", "#*#",
+	softwareIgnoreFile,
+}
+
+// dotfilesAdoptedDir 备份目录中保存被采纳文件替换的包内原文件的子目录
+const dotfilesAdoptedDir = ".packages"
+
+// 链接计划中条目的状态
+const (
+	dotfileCreate   = "create"   // 将创建链接
+	dotfileLinked   = "linked"   // 已链接到包中
+	dotfileConflict = "conflict" // 目标已存在
+)
+
+// dotfileLink 链接计划中的一个条目
+type dotfileLink struct {
+	pkg     string // 包名
+	rel     string // 相对包目录与目标目录的路径
+	source  string // 包中的文件
+	target  string // 目标路径
+	link    string // 链接内容，为相对目标所在目录的路径
+	dir     bool   // 条目为目录（已由指向包目录的链接管理，或目标位置被非目录占用）
+	state   string // 状态
+	owner   string // 冲突来自其他包时为该包名
+	current string // 目标当前的内容描述
+}
+
+// DotfilesStrategy dotfiles 仓库符号链接管理策略：按 GNU stow 的方式将包中的每个文件链接到目标目录
+//
+// Source.Path 为 dotfiles 仓库，其中每个子目录是一个包；Source.Filter.Include 指定要链接的包，默认为全部非隐藏子目录；
+// Target.Path 为链接所在的目录，默认为用户主目录；Target.Conflict 指定目标已存在时的处理方式；
+// Target.BackupPath 为备份目录，默认为目标目录下的 .dotfiles.backup
+type DotfilesStrategy struct{}
+
+// Name 返回策略名称
+func (s *DotfilesStrategy) Name() string {
+	return "dotfiles 符号链接管理策略"
+}
+
+// Type 返回策略类型
+func (s *DotfilesStrategy) Type() core.MigrationType {
+	return constants.MigrationTypeDotfiles
+}
+
+// Description 返回策略描述
+func (s *DotfilesStrategy) Description() string {
+	return "按 GNU stow 的方式将 dotfiles 仓库中各个包的文件链接到主目录，处理已存在文件的冲突并支持取消链接"
+}
+
+// Validate 验证配置是否有效
+func (s *DotfilesStrategy) Validate(config *core.MigrationConfig) error {
+	if config == nil {
+		return fmt.Errorf("config cannot be nil")
+	}
+	if config.Source.Path == "" {
+		return fmt.Errorf("source path is required")
+	}
+	if info, err := os.Stat(config.Source.Path); err != nil {
+		return fmt.Errorf("source path not accessible: %w", err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("source path must be a directory: %s", config.Source.Path)
+	}
+	switch config.Target.Conflict {
+	case "", constants.DotfilesConflictAbort, constants.DotfilesConflictBackup, constants.DotfilesConflictAdopt:
+	default:
+		return fmt.Errorf("unknown conflict mode %q", config.Target.Conflict)
+	}
+	if _, err := dotfilesTarget(config); err != nil {
+		return err
+	}
+	if _, err := dotfilesPackages(config); err != nil {
+		return err
+	}
+	_, err := dotfilesIgnore(config)
+	return err
+}
+
+// Execute 创建链接：先生成完整的链接计划，abort 方式下存在冲突时不做任何修改
+func (s *DotfilesStrategy) Execute(ctx context.Context, config *core.MigrationConfig) (*core.MigrationResult, error) {
+	result := core.NewMigrationResult(config.TaskID)
+	result.StartTime = time.Now()
+	defer func() {
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime).Milliseconds()
+	}()
+
+	plan, err := s.plan(config)
+	if err != nil {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("生成链接计划失败: %v", err)
+		return result, err
+	}
+	if conflicts := dotfilesUnresolved(config, plan); len(conflicts) > 0 {
+		err := fmt.Errorf("%d conflicting targets: %s", len(conflicts), strings.Join(conflicts, ", "))
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("目标已存在，未做任何修改: %s", strings.Join(conflicts, ", "))
+		return result, err
+	}
+
+	backupPath := dotfilesBackupPath(config)
+	for _, entry := range plan {
+		select {
+		case <-ctx.Done():
+			result.Status = constants.TaskStatusFailed
+			result.Message = "迁移已取消"
+			return result, ctx.Err()
+		default:
+		}
+
+		if entry.state == dotfileLinked {
+			result.Records = append(result.Records, core.MigrationRecord{
+				StepName:    fmt.Sprintf("链接 %s", entry.rel),
+				ActionType:  constants.ActionTypeCreate,
+				Key:         entry.target,
+				BeforeValue: entry.current,
+				AfterValue:  entry.link,
+				Status:      constants.RecordStatusSkipped,
+				Message:     "已链接",
+				Timestamp:   time.Now(),
+			})
+			result.Summary.Skipped++
+			continue
+		}
+
+		if entry.state == dotfileConflict {
+			record := s.resolveConflict(config, entry, backupPath)
+			result.Records = append(result.Records, record)
+			if record.Status != constants.RecordStatusSuccess {
+				result.Summary.Failed++
+				continue
+			}
+			result.Summary.Success++
+		}
+
+		record := core.MigrationRecord{
+			StepName:    fmt.Sprintf("链接 %s", entry.rel),
+			ActionType:  constants.ActionTypeCreate,
+			Key:         entry.target,
+			BeforeValue: entry.current,
+			AfterValue:  entry.link,
+			Timestamp:   time.Now(),
+		}
+		if err := createSymlink(entry.target, entry.link); err != nil {
+			record.Status = constants.RecordStatusFailed
+			record.Message = err.Error()
+			result.Summary.Failed++
+		} else {
+			record.Status = constants.RecordStatusSuccess
+			result.Summary.Success++
+		}
+		result.Records = append(result.Records, record)
+	}
+
+	result.Summary.Total = result.Summary.Success + result.Summary.Failed + result.Summary.Skipped
+	if result.Summary.Failed > 0 {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("部分链接创建失败，失败 %d 项", result.Summary.Failed)
+		return result, nil
+	}
+	result.Status = constants.TaskStatusCompleted
+	result.Message = fmt.Sprintf("成功链接 dotfiles，共处理 %d 项", result.Summary.Success)
+	return result, nil
+}
+
+// resolveConflict 按冲突处理方式移走已存在的目标：backup 移动到备份目录，adopt 移入包中并备份包内原文件
+func (s *DotfilesStrategy) resolveConflict(config *core.MigrationConfig, entry *dotfileLink, backupPath string) core.MigrationRecord {
+	record := core.MigrationRecord{
+		StepName:    fmt.Sprintf("处理冲突 %s", entry.rel),
+		ActionType:  constants.ActionTypeUpdate,
+		Key:         entry.target,
+		BeforeValue: entry.target,
+		Timestamp:   time.Now(),
+	}
+
+	var err error
+	if dotfilesConflictMode(config) == constants.DotfilesConflictAdopt {
+		packageBackup := filepath.Join(backupPath, dotfilesAdoptedDir, entry.pkg, entry.rel)
+		record.AfterValue = entry.source
+		record.Message = fmt.Sprintf("已将现有文件采纳到包 %s，包内原文件备份到 %s", entry.pkg, packageBackup)
+		if err = moveFile(entry.source, packageBackup); err == nil {
+			err = moveFile(entry.target, entry.source)
+		}
+	} else {
+		record.AfterValue = filepath.Join(backupPath, entry.rel)
+		record.Message = "已将现有文件移动到备份目录"
+		err = moveFile(entry.target, record.AfterValue)
+	}
+
+	if err != nil {
+		record.Status = constants.RecordStatusFailed
+		record.Message = err.Error()
+	} else {
+		record.Status = constants.RecordStatusSuccess
+	}
+	return record
+}
+
+// Rollback 取消链接：删除指向包中文件的链接，并将备份或采纳的文件恢复到原位置
+func (s *DotfilesStrategy) Rollback(ctx context.Context, config *core.MigrationConfig) error {
+	plan, err := s.plan(config)
+	if err != nil {
+		return err
+	}
+	backupPath := dotfilesBackupPath(config)
+
+	for _, entry := range plan {
+		if entry.state != dotfileLinked {
+			continue
+		}
+		if err := os.Remove(entry.target); err != nil {
+			return fmt.Errorf("failed to remove link %s: %w", entry.target, err)
+		}
+
+		// 采纳的文件移回目标位置，包内原文件从备份恢复
+		packageBackup := filepath.Join(backupPath, dotfilesAdoptedDir, entry.pkg, entry.rel)
+		if _, err := os.Lstat(packageBackup); err == nil {
+			if err := moveFile(entry.source, entry.target); err != nil {
+				return fmt.Errorf("failed to restore adopted file %s: %w", entry.target, err)
+			}
+			if err := moveFile(packageBackup, entry.source); err != nil {
+				return fmt.Errorf("failed to restore package file %s: %w", entry.source, err)
+			}
+			continue
+		}
+		backup := filepath.Join(backupPath, entry.rel)
+		if _, err := os.Lstat(backup); err == nil {
+			if err := moveFile(backup, entry.target); err != nil {
+				return fmt.Errorf("failed to restore backup %s: %w", entry.target, err)
+			}
+		}
+	}
+	return removeEmptyDirs(backupPath)
+}
+
+// DryRun 预览链接计划：逐个列出将创建的链接与冲突的处理方式，abort 方式下的冲突记录为错误
+func (s *DotfilesStrategy) DryRun(ctx context.Context, config *core.MigrationConfig) (*core.MigrationPreview, error) {
+	preview := core.NewMigrationPreview(config.TaskID)
+	plan, err := s.plan(config)
+	if err != nil {
+		preview.Errors = append(preview.Errors, err.Error())
+		return preview, nil
+	}
+
+	mode := dotfilesConflictMode(config)
+	backupPath := dotfilesBackupPath(config)
+	linked := 0
+	for _, entry := range plan {
+		change := core.PreviewChange{
+			ActionType:  constants.ActionTypeCreate,
+			Key:         entry.target,
+			BeforeValue: entry.current,
+			AfterValue:  entry.link,
+			Impact:      "low",
+			Description: fmt.Sprintf("将链接到包 %s 中的 %s", entry.pkg, entry.rel),
+		}
+		switch {
+		case entry.state == dotfileLinked:
+			linked++
+			continue
+		case entry.state == dotfileCreate:
+			preview.Summary.Create++
+		case entry.owner != "":
+			preview.Errors = append(preview.Errors, fmt.Sprintf("%s 同时由包 %s 与 %s 提供", entry.target, entry.owner, entry.pkg))
+			continue
+		case mode == constants.DotfilesConflictBackup:
+			change.ActionType = constants.ActionTypeUpdate
+			change.Impact = "high"
+			change.Description = fmt.Sprintf("%s 已存在，将移动到 %s 后链接到包 %s", entry.current, filepath.Join(backupPath, entry.rel), entry.pkg)
+			preview.Summary.Update++
+			preview.Summary.HighImpact++
+		case mode == constants.DotfilesConflictAdopt && !entry.dir:
+			change.ActionType = constants.ActionTypeUpdate
+			change.Impact = "high"
+			change.Description = fmt.Sprintf("%s 已存在，将移入包 %s 替换 %s 后链接", entry.current, entry.pkg, entry.rel)
+			preview.Summary.Update++
+			preview.Summary.HighImpact++
+		default:
+			preview.Errors = append(preview.Errors, fmt.Sprintf("%s 已存在（%s），与包 %s 冲突", entry.target, entry.current, entry.pkg))
+			continue
+		}
+		preview.Changes = append(preview.Changes, change)
+		preview.Summary.Total++
+	}
+	if linked > 0 {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("%d 项已链接，将跳过", linked))
+	}
+	return preview, nil
+}
+
+// Export 将 dotfiles 仓库导出为软件配置归档
+func (s *DotfilesStrategy) Export(ctx context.Context, config *core.MigrationConfig) (*core.ExportResult, error) {
+	return (&SoftwareStrategy{}).Export(ctx, dotfilesSoftwareConfig(config))
+}
+
+// Import 将归档恢复为 dotfiles 仓库，Target.Path 为仓库目录；恢复后再以 Execute 创建链接
+func (s *DotfilesStrategy) Import(ctx context.Context, config *core.MigrationConfig) (*core.ImportResult, error) {
+	return (&SoftwareStrategy{}).Import(ctx, dotfilesSoftwareConfig(config))
+}
+
+// ValidateExport 验证导出配置
+func (s *DotfilesStrategy) ValidateExport(config *core.MigrationConfig) error {
+	if config == nil {
+		return fmt.Errorf("config cannot be nil")
+	}
+	return (&SoftwareStrategy{}).ValidateExport(dotfilesSoftwareConfig(config))
+}
+
+// ValidateImport 验证导入配置
+func (s *DotfilesStrategy) ValidateImport(config *core.MigrationConfig) error {
+	if config == nil {
+		return fmt.Errorf("config cannot be nil")
+	}
+	return (&SoftwareStrategy{}).ValidateImport(dotfilesSoftwareConfig(config))
+}
+
+// dotfilesSoftwareConfig 生成导出、导入仓库的软件配置迁移配置，仓库中的链接原样保留
+func dotfilesSoftwareConfig(config *core.MigrationConfig) *core.MigrationConfig {
+	c := *config
+	c.Type = constants.MigrationTypeSoftware
+	c.Source.Filter.Include = nil
+	c.Target.Symlinks = constants.SymlinkPreserve
+	return &c
+}
+
+// plan 遍历各个包，生成按包与路径排序的链接计划
+func (s *DotfilesStrategy) plan(config *core.MigrationConfig) ([]*dotfileLink, error) {
+	sourceRoot, err := filepath.Abs(config.Source.Path)
+	if err != nil {
+		return nil, err
+	}
+	targetRoot, err := dotfilesTarget(config)
+	if err != nil {
+		return nil, err
+	}
+	packages, err := dotfilesPackages(config)
+	if err != nil {
+		return nil, err
+	}
+	ignore, err := dotfilesIgnore(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []*dotfileLink
+	owners := make(map[string]string)
+	for _, pkg := range packages {
+		pkgDir := filepath.Join(sourceRoot, pkg)
+		err := filepath.Walk(pkgDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(pkgDir, path)
+			if err != nil || rel == "." {
+				return err
+			}
+			if skip, err := ignore.skip(rel, info); skip {
+				return err
+			}
+
+			entry := &dotfileLink{pkg: pkg, rel: rel, source: path, target: filepath.Join(targetRoot, rel)}
+			entry.link, err = filepath.Rel(filepath.Dir(entry.target), path)
+			if err != nil {
+				return err
+			}
+			existing, err := os.Lstat(entry.target)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+
+			if info.IsDir() {
+				switch {
+				case err != nil:
+					// 目录不存在时在创建其中文件的链接时创建
+					return nil
+				case existing.IsDir():
+					return nil
+				case isSymlink(existing) && dotfilesLinksTo(entry.target, path):
+					// 目录整体链接到包中（stow 的目录折叠），其中的文件已链接
+					entry.dir, entry.state = true, dotfileLinked
+					entry.current = describeDotfile(entry.target, existing)
+				default:
+					entry.dir, entry.state = true, dotfileConflict
+					entry.current = describeDotfile(entry.target, existing)
+				}
+				plan = append(plan, entry)
+				return filepath.SkipDir
+			}
+
+			switch {
+			case owners[rel] != "":
+				entry.state, entry.owner = dotfileConflict, owners[rel]
+				entry.current = fmt.Sprintf("包 %s 中的 %s", owners[rel], rel)
+			case err != nil:
+				entry.state = dotfileCreate
+			case isSymlink(existing) && dotfilesLinksTo(entry.target, path):
+				entry.state = dotfileLinked
+				entry.current = describeDotfile(entry.target, existing)
+			default:
+				entry.state = dotfileConflict
+				entry.current = describeDotfile(entry.target, existing)
+			}
+			if entry.owner == "" {
+				owners[rel] = pkg
+			}
+			plan = append(plan, entry)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("package %s: %w", pkg, err)
+		}
+	}
+	return plan, nil
+}
+
+// dotfilesUnresolved 返回无法按冲突处理方式解决的冲突：abort 方式下的全部冲突、多个包提供的同一路径与 adopt 方式下的目录
+func dotfilesUnresolved(config *core.MigrationConfig, plan []*dotfileLink) []string {
+	mode := dotfilesConflictMode(config)
+	var conflicts []string
+	for _, entry := range plan {
+		if entry.state != dotfileConflict {
+			continue
+		}
+		if mode == constants.DotfilesConflictAbort || entry.owner != "" || (mode == constants.DotfilesConflictAdopt && entry.dir) {
+			conflicts = append(conflicts, entry.target)
+		}
+	}
+	return conflicts
+}
+
+// dotfilesConflictMode 返回冲突处理方式，未设置时为 abort
+func dotfilesConflictMode(config *core.MigrationConfig) string {
+	if config.Target.Conflict == "" {
+		return constants.DotfilesConflictAbort
+	}
+	return config.Target.Conflict
+}
+
+// dotfilesTarget 返回链接所在目录的绝对路径，未指定时为用户主目录
+func dotfilesTarget(config *core.MigrationConfig) (string, error) {
+	if config.Target.Path != "" {
+		return filepath.Abs(config.Target.Path)
+	}
+	return os.UserHomeDir()
+}
+
+// dotfilesBackupPath 返回备份目录，未指定 BackupPath 时为目标目录下的 .dotfiles.backup
+func dotfilesBackupPath(config *core.MigrationConfig) string {
+	if config.Target.BackupPath != "" {
+		return config.Target.BackupPath
+	}
+	target, err := dotfilesTarget(config)
+	if err != nil {
+		target = config.Target.Path
+	}
+	return filepath.Join(target, ".dotfiles.backup")
+}
+
+// dotfilesPackages 返回要链接的包，未指定 Filter.Include 时为仓库中全部非隐藏子目录，按名称排序
+// 指定的包同样须为仓库下的非隐藏子目录，不能是仓库本身（.）、上级目录（..）或 .git 这类隐藏目录
+func dotfilesPackages(config *core.MigrationConfig) ([]string, error) {
+	if len(config.Source.Filter.Include) > 0 {
+		for _, pkg := range config.Source.Filter.Include {
+			if pkg == "" || strings.HasPrefix(pkg, ".") || pkg != filepath.Base(pkg) {
+				return nil, fmt.Errorf("invalid package name %q: must be a non-hidden directory in %s", pkg, config.Source.Path)
+			}
+			info, err := os.Stat(filepath.Join(config.Source.Path, pkg))
+			if err != nil || !info.IsDir() {
+				return nil, fmt.Errorf("package %q not found in %s", pkg, config.Source.Path)
+			}
+		}
+		return config.Source.Filter.Include, nil
+	}
+
+	entries, err := os.ReadDir(config.Source.Path)
+	if err != nil {
+		return nil, err
+	}
+	var packages []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			packages = append(packages, entry.Name())
+		}
+	}
+	sort.Strings(packages)
+	return packages, nil
+}
+
+// dotfilesIgnore 返回包中不链接的文件规则：默认忽略列表、仓库根目录的忽略规则文件与 Filter.Exclude，规则相对于包目录
+func dotfilesIgnore(config *core.MigrationConfig) (*ignoreMatcher, error) {
+	source := config.Source
+	source.Filter.Exclude = append(append([]string{}, dotfilesDefaultIgnore...), config.Source.Filter.Exclude...)
+	return loadIgnoreMatcher(source)
+}
+
+// dotfilesLinksTo 判断符号链接 link 是否指向 path
+func dotfilesLinksTo(link, path string) bool {
+	target, err := os.Readlink(link)
+	if err != nil {
+		return false
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(link), target)
+	}
+	if filepath.Clean(target) == filepath.Clean(path) {
+		return true
+	}
+	resolved, err := filepath.EvalSymlinks(link)
+	if err != nil {
+		return false
+	}
+	real, err := filepath.EvalSymlinks(path)
+	return err == nil && resolved == real
+}
+
+// describeDotfile 描述目标当前的内容
+func describeDotfile(path string, info os.FileInfo) string {
+	switch {
+	case isSymlink(info):
+		target, _ := os.Readlink(path)
+		return fmt.Sprintf("符号链接 -> %s", target)
+	case info.IsDir():
+		return "目录"
+	default:
+		return "文件"
+	}
+}
+
+// moveFile 移动文件或目录，创建目标的上级目录；目标已存在时返回错误
+func moveFile(src, dst string) error {
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("%s already exists", dst)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.Rename(src, dst)
+}
+
+// removeEmptyDirs 自下而上删除目录中的空目录，目录本身为空时一并删除；目录不存在时忽略
+func removeEmptyDirs(root string) error {
+	var dirs []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if entries, err := os.ReadDir(dirs[i]); err == nil && len(entries) == 0 {
+			os.Remove(dirs[i])
+		}
+	}
+	return nil
+}
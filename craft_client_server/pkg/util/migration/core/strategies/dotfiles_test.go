@@ -0,0 +1,191 @@
+package strategies
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+// newDotfilesConfig 创建包含 vim、git、nvim 三个包的仓库与空的主目录
+func newDotfilesConfig(t *testing.T) *core.MigrationConfig {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on windows")
+	}
+	base := t.TempDir()
+	writeTree(t, base, map[string]string{
+		"dotfiles/vim/.vimrc":                 "set nu",
+		"dotfiles/vim/.vim/colors/dark.vim":   "hi Normal",
+		"dotfiles/vim/README.md":              "vim package",
+		"dotfiles/git/.gitconfig":             "[user]",
+		"dotfiles/git/.config/git/ignore":     "*.swp",
+		"dotfiles/nvim/.config/nvim/init.lua": "vim.o.nu = true",
+		"dotfiles/.git/HEAD":                  "ref: refs/heads/main",
+	})
+	os.MkdirAll(filepath.Join(base, "home"), 0755)
+
+	config := core.NewMigrationConfig()
+	config.Type = constants.MigrationTypeDotfiles
+	config.Source.Path = filepath.Join(base, "dotfiles")
+	config.Target.Path = filepath.Join(base, "home")
+	return config
+}
+
+func TestDotfilesLinkAndUnlink(t *testing.T) {
+	config := newDotfilesConfig(t)
+	home := config.Target.Path
+	s := &DotfilesStrategy{}
+	if err := s.Validate(config); err != nil {
+		t.Fatal(err)
+	}
+
+	preview, err := s.DryRun(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Summary.Create != 5 || len(preview.Errors) != 0 {
+		t.Errorf("unexpected preview: %+v %v", preview.Summary, preview.Errors)
+	}
+
+	result, err := s.Execute(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Summary.Success != 5 {
+		t.Errorf("unexpected summary: %+v", result.Summary)
+	}
+	for rel, want := range map[string]string{
+		".vimrc":                "../dotfiles/vim/.vimrc",
+		".vim/colors/dark.vim":  "../../../dotfiles/vim/.vim/colors/dark.vim",
+		".config/nvim/init.lua": "../../../dotfiles/nvim/.config/nvim/init.lua",
+	} {
+		if got, err := os.Readlink(filepath.Join(home, rel)); err != nil || got != want {
+			t.Errorf("%s -> %q (%v), want %q", rel, got, err, want)
+		}
+	}
+	if _, err := os.Lstat(filepath.Join(home, "README.md")); err == nil {
+		t.Error("README.md should be ignored")
+	}
+
+	// 再次执行时已链接的文件记录为跳过
+	result, err = s.Execute(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Summary.Skipped != 5 || result.Summary.Success != 0 {
+		t.Errorf("unexpected summary: %+v", result.Summary)
+	}
+
+	if err := s.Rollback(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(filepath.Join(home, ".vimrc")); !os.IsNotExist(err) {
+		t.Errorf("link not removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(config.Source.Path, "vim/.vimrc")); err != nil {
+		t.Errorf("package file removed: %v", err)
+	}
+}
+
+func TestDotfilesConflicts(t *testing.T) {
+	config := newDotfilesConfig(t)
+	home := config.Target.Path
+	config.Source.Filter.Include = []string{"vim", "git"}
+	os.WriteFile(filepath.Join(home, ".vimrc"), []byte("local vimrc"), 0644)
+	s := &DotfilesStrategy{}
+
+	// abort：存在冲突时不做任何修改
+	preview, _ := s.DryRun(context.Background(), config)
+	if len(preview.Errors) != 1 || !strings.Contains(preview.Errors[0], ".vimrc") {
+		t.Errorf("unexpected preview errors: %v", preview.Errors)
+	}
+	if _, err := s.Execute(context.Background(), config); err == nil {
+		t.Fatal("expected conflict error")
+	}
+	if _, err := os.Lstat(filepath.Join(home, ".gitconfig")); err == nil {
+		t.Error("abort should not create any link")
+	}
+
+	// backup：已存在的文件移动到备份目录，回滚时恢复
+	config.Target.Conflict = constants.DotfilesConflictBackup
+	if _, err := s.Execute(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	backup := filepath.Join(home, ".dotfiles.backup", ".vimrc")
+	if data, _ := os.ReadFile(backup); string(data) != "local vimrc" {
+		t.Errorf("unexpected backup: %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(home, ".vimrc")); string(data) != "set nu" {
+		t.Errorf("link not created: %q", data)
+	}
+	if err := s.Rollback(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Lstat(filepath.Join(home, ".vimrc"))
+	if err != nil || !info.Mode().IsRegular() {
+		t.Fatalf("backup not restored: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".dotfiles.backup")); !os.IsNotExist(err) {
+		t.Errorf("empty backup directory should be removed: %v", err)
+	}
+
+	// adopt：已存在的文件移入包中，回滚时恢复包内原文件
+	config.Target.Conflict = constants.DotfilesConflictAdopt
+	if _, err := s.Execute(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	packageFile := filepath.Join(config.Source.Path, "vim/.vimrc")
+	if data, _ := os.ReadFile(packageFile); string(data) != "local vimrc" {
+		t.Errorf("file not adopted: %q", data)
+	}
+	if err := s.Rollback(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(packageFile); string(data) != "set nu" {
+		t.Errorf("package file not restored: %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(home, ".vimrc")); string(data) != "local vimrc" {
+		t.Errorf("adopted file not restored: %q", data)
+	}
+}
+
+func TestDotfilesFoldedDirectory(t *testing.T) {
+	config := newDotfilesConfig(t)
+	home := config.Target.Path
+	config.Source.Filter.Include = []string{"nvim", "git"}
+	// stow 将整个目录链接到包中（目录折叠）
+	os.MkdirAll(filepath.Join(home, ".config"), 0755)
+	if err := os.Symlink("../../dotfiles/nvim/.config/nvim", filepath.Join(home, ".config/nvim")); err != nil {
+		t.Fatal(err)
+	}
+	s := &DotfilesStrategy{}
+	result, err := s.Execute(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Summary.Skipped != 1 || result.Summary.Success != 2 {
+		t.Errorf("unexpected summary: %+v", result.Summary)
+	}
+	if _, err := os.Lstat(filepath.Join(config.Source.Path, "nvim/.config/nvim/init.lua/init.lua")); err == nil {
+		t.Error("link created inside the package")
+	}
+
+	config.Source.Filter.Include = []string{"missing"}
+	if err := s.Validate(config); err == nil {
+		t.Error("expected error for missing package")
+	}
+
+	// 仓库本身、上级目录与隐藏目录不能作为包
+	for _, pkg := range []string{".", "..", ".git", ""} {
+		config.Source.Filter.Include = []string{pkg}
+		if err := s.Validate(config); err == nil || !strings.Contains(err.Error(), "invalid package name") {
+			t.Errorf("package %q should be rejected, got %v", pkg, err)
+		}
+	}
+}
@@ -0,0 +1,412 @@
+package strategies
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+	"tsc/pkg/util/safefile"
+)
+
+// envScope 返回导出变量的作用域：从持久化文件读取时为 Source.Type（user 或 system），否则为 process
+func envScope(config *core.MigrationConfig) string {
+	if _, ok := envSourceTarget(config); ok {
+		return config.Source.Type
+	}
+	return "process"
+}
+
+// envSourceTarget 返回导出 user 或 system 作用域变量时读取的持久化文件，Source.Path 非空时覆盖默认路径
+// 非 Windows 系统上 user 对应默认 shell 配置，system 对应 /etc/environment；
+// Windows 上尚不支持读取注册表中的变量，与其他作用域一样按当前进程环境取值
+func envSourceTarget(config *core.MigrationConfig) (*envPersistTarget, bool) {
+	if runtime.GOOS == "windows" || (config.Source.Type != "user" && config.Source.Type != "system") {
+		return nil, false
+	}
+	target, ok, err := resolveEnvPersistTarget(config.Source.Type, config.Source.Path)
+	if err != nil || !ok {
+		return nil, false
+	}
+	return target, true
+}
+
+// persistedEntries 解析持久化文件中的全部赋值行（不限于受管块），同名变量以后出现的为准
+func (t *envPersistTarget) persistedEntries(content []byte) map[string]string {
+	entries := make(map[string]string)
+	lines, _, _ := splitEnvBlock(content)
+	for _, line := range lines {
+		if name, value, ok := t.syntax.parse(strings.TrimSpace(line)); ok {
+			entries[name] = value
+		}
+	}
+	return entries
+}
+
+// envExportFormat 返回导出格式：显式指定优先，其次按导出路径扩展名推断
+func envExportFormat(config *core.MigrationConfig) string {
+	if config.Options.ExportFormat != "" {
+		return strings.ToLower(config.Options.ExportFormat)
+	}
+	base := strings.ToLower(filepath.Base(config.Options.ExportPath))
+	switch {
+	case base == ".env" || strings.HasPrefix(base, ".env.") || strings.HasSuffix(base, ".env"):
+		return constants.ExportFormatDotenv
+	case strings.HasSuffix(base, ".sh"):
+		return constants.ExportFormatShell
+	case strings.HasSuffix(base, ".ps1"):
+		return constants.ExportFormatPowerShell
+	default:
+		return constants.ExportFormatPackage
+	}
+}
+
+// exportVariables 收集要导出的变量；Variables 中取值为空的变量与 Filter.Pattern 匹配的变量
+// 按作用域取值：user、system 从持久化文件读取，其余为当前进程环境
+func (s *EnvVariableStrategy) exportVariables(config *core.MigrationConfig) (map[string]string, []core.MigrationRecord, error) {
+	variables := s.getVariablesToMigrate(config)
+	lookup := os.LookupEnv
+	missing := "当前环境中未设置该变量"
+
+	if target, ok := envSourceTarget(config); ok {
+		content, err := target.read()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", target.path, err)
+		}
+		persisted := target.persistedEntries(content)
+		variables = make(map[string]string, len(config.Source.Variables))
+		for name, value := range config.Source.Variables {
+			variables[name] = value
+		}
+		if pattern := config.Source.Filter.Pattern; pattern != "" {
+			for name, value := range persisted {
+				if s.matchPattern(name, pattern) && !s.isExcluded(name, config.Source.Filter.Exclude) {
+					variables[name] = value
+				}
+			}
+		}
+		lookup = func(name string) (string, bool) {
+			value, ok := persisted[name]
+			return value, ok
+		}
+		missing = fmt.Sprintf("%s 中未设置该变量", target.path)
+	}
+
+	var skipped []core.MigrationRecord
+	for _, name := range sortedKeys(variables) {
+		if variables[name] != "" {
+			continue
+		}
+		if value, ok := lookup(name); ok {
+			variables[name] = value
+			continue
+		}
+		delete(variables, name)
+		skipped = append(skipped, core.MigrationRecord{
+			StepName:   fmt.Sprintf("导出环境变量 %s", name),
+			ActionType: constants.ActionTypeExport,
+			Key:        name,
+			Status:     constants.RecordStatusSkipped,
+			Message:    missing,
+			Timestamp:  time.Now(),
+		})
+	}
+	return variables, skipped, nil
+}
+
+// renderEnvScript 生成 dotenv、shell 或 PowerShell 格式的环境变量文件
+func renderEnvScript(format string, variables map[string]string, scope string) ([]byte, error) {
+	header := fmt.Sprintf("由 EnvCraft 导出于 %s（%s，作用域 %s）", time.Now().Format(time.RFC3339), runtime.GOOS, scope)
+	var b bytes.Buffer
+	switch format {
+	case constants.ExportFormatDotenv:
+		b.WriteString("# " + header + "\n")
+		b.Write(marshalKV(toInterfaceMap(variables), dotenvSyntax{}))
+	case constants.ExportFormatShell:
+		b.WriteString("#!/bin/sh\n# " + header + "\n")
+		for _, name := range sortedKeys(variables) {
+			line, _ := shellEnvSyntax{}.format(name, variables[name])
+			b.WriteString(line + "\n")
+		}
+	case constants.ExportFormatPowerShell:
+		b.WriteString("# " + header + "\n")
+		for _, name := range sortedKeys(variables) {
+			b.WriteString(powerShellEnvLine(name, variables[name]) + "\n")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+	return b.Bytes(), nil
+}
+
+// powerShellEnvLine 生成 PowerShell 赋值行：$env:NAME = 'value'
+func powerShellEnvLine(name, value string) string {
+	return fmt.Sprintf("$env:%s = '%s'", name, strings.ReplaceAll(value, "'", "''"))
+}
+
+// parsePowerShellEnvLine 解析 PowerShell 赋值行
+func parsePowerShellEnvLine(line string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(line, "$env:")
+	if !ok {
+		return "", "", false
+	}
+	name, raw, ok := strings.Cut(rest, "=")
+	name, raw = strings.TrimSpace(name), strings.TrimSpace(raw)
+	if !ok || !envNamePattern.MatchString(name) || len(raw) < 2 {
+		return "", "", false
+	}
+	switch {
+	case raw[0] == '\'' && raw[len(raw)-1] == '\'':
+		return name, strings.ReplaceAll(raw[1:len(raw)-1], "''", "'"), true
+	case raw[0] == '"' && raw[len(raw)-1] == '"':
+		return name, strings.ReplaceAll(raw[1:len(raw)-1], "`\"", `"`), true
+	default:
+		return "", "", false
+	}
+}
+
+// parseEnvScript 读取 dotenv、shell 或 PowerShell 格式的环境变量文件，返回变量与识别出的格式
+func parseEnvScript(content []byte) (map[string]string, string) {
+	variables := make(map[string]string)
+	format := constants.ExportFormatDotenv
+	for _, line := range strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if name, value, ok := parsePowerShellEnvLine(line); ok {
+			variables[name] = value
+			format = constants.ExportFormatPowerShell
+		} else if name, value, ok := (shellEnvSyntax{}).parse(line); ok {
+			variables[name] = value
+			format = constants.ExportFormatShell
+		}
+	}
+	if len(variables) > 0 {
+		return variables, format
+	}
+
+	for name, value := range readKV(content, dotenvSyntax{}) {
+		variables[name] = toString(value)
+	}
+	return variables, format
+}
+
+// Export 导出环境变量：默认写入标准导出包，也可导出为 dotenv、shell 或 PowerShell 脚本
+// 导出包中的本机相关值替换为占位符；脚本供直接加载使用，保留原值
+func (s *EnvVariableStrategy) Export(ctx context.Context, config *core.MigrationConfig) (*core.ExportResult, error) {
+	result := core.NewExportResult(config.TaskID)
+	result.ExportID = generateExportID()
+	defer func() {
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime).Milliseconds()
+	}()
+
+	variables, skipped, err := s.exportVariables(config)
+	if err != nil {
+		result.Status = constants.TaskStatusFailed
+		result.Message = err.Error()
+		return result, err
+	}
+	result.Records = append(result.Records, skipped...)
+	scope := envScope(config)
+	format := envExportFormat(config)
+
+	exportPath := config.Options.ExportPath
+	if exportPath == "" {
+		exportPath = fmt.Sprintf("env_%s.export.json", scope)
+	}
+
+	var content []byte
+	if format == constants.ExportFormatPackage {
+		pkg := core.NewExportPackage()
+		pkg.Metadata.ExportID = result.ExportID
+		pkg.Metadata.SourceType = string(constants.MigrationTypeEnvVariable)
+		pkg.Metadata.OriginalFormat = "env"
+		pkg.Metadata.OriginalPath = scope
+		pkg.Metadata.Tags = append(pkg.Metadata.Tags, runtime.GOOS, scope)
+		pkg.Content.Data = toInterfaceMap(variables)
+		pkg.Content.FormatSpecificData["scope"] = scope
+		pkg.Content.FormatSpecificData["os"] = runtime.GOOS
+
+		if !config.Options.NoPlaceholders {
+			result.Records = append(result.Records, encodePackagePlaceholders(pkg)...)
+		}
+		pkg.Metadata.Checksum = calculateDataChecksum(pkg.Content.Data)
+
+		var err error
+		if content, err = json.MarshalIndent(pkg, "", "  "); err != nil {
+			result.Status = constants.TaskStatusFailed
+			result.Message = fmt.Sprintf("序列化导出包失败: %v", err)
+			return result, err
+		}
+		result.Package = pkg
+	} else {
+		var err error
+		if content, err = renderEnvScript(format, variables, scope); err != nil {
+			result.Status = constants.TaskStatusFailed
+			result.Message = err.Error()
+			return result, err
+		}
+	}
+
+	if err := safefile.WriteFile(exportPath, content, 0); err != nil {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("写入导出文件失败: %v", err)
+		return result, err
+	}
+
+	for _, name := range sortedKeys(variables) {
+		result.Records = append(result.Records, core.MigrationRecord{
+			StepName:   fmt.Sprintf("导出环境变量 %s", name),
+			ActionType: constants.ActionTypeExport,
+			Key:        name,
+			AfterValue: variables[name],
+			Status:     constants.RecordStatusSuccess,
+			Timestamp:  time.Now(),
+		})
+	}
+
+	result.Status = constants.TaskStatusCompleted
+	result.Message = fmt.Sprintf("成功导出 %d 个环境变量到: %s", len(variables), exportPath)
+	result.ExportPath = exportPath
+	return result, nil
+}
+
+// Import 导入环境变量：读取导出包或 dotenv/shell/PowerShell 文件，按 Target 写入
+// Target.Type 为空时使用导出包记录的作用域
+func (s *EnvVariableStrategy) Import(ctx context.Context, config *core.MigrationConfig) (*core.ImportResult, error) {
+	result := core.NewImportResult(config.TaskID)
+	defer func() {
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime).Milliseconds()
+	}()
+
+	importPath := config.Options.ImportPath
+	if importPath == "" {
+		importPath = config.Source.Path
+	}
+	content, err := os.ReadFile(importPath)
+	if err != nil {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("读取导入文件失败: %v", err)
+		return result, err
+	}
+
+	variables, scope, records, err := s.readImportFile(content)
+	if err != nil {
+		result.Status = constants.TaskStatusFailed
+		result.Message = err.Error()
+		return result, err
+	}
+	result.Records = append(result.Records, records...)
+	if pkg, ok := parseEnvPackage(content); ok {
+		result.SourcePackage = pkg
+	}
+
+	// 以导入的变量执行迁移
+	importConfig := *config
+	importConfig.Source.Variables = variables
+	importConfig.Source.Filter = core.SourceFilter{}
+	if importConfig.Source.Type == "" {
+		importConfig.Source.Type = "file"
+	}
+	if importConfig.Target.Type == "" {
+		importConfig.Target.Type = scope
+	}
+	if importConfig.Target.Type == "" {
+		importConfig.Target.Type = "process"
+	}
+
+	migrateResult, err := s.Execute(ctx, &importConfig)
+	if migrateResult != nil {
+		result.Records = append(result.Records, migrateResult.Records...)
+		result.Summary = migrateResult.Summary
+		result.Status = migrateResult.Status
+		result.Message = migrateResult.Message
+	}
+	if err != nil {
+		result.Status = constants.TaskStatusFailed
+		if result.Message == "" {
+			result.Message = err.Error()
+		}
+		return result, err
+	}
+	return result, nil
+}
+
+// readImportFile 解析导入文件，返回变量、导出时的作用域与占位符还原记录
+func (s *EnvVariableStrategy) readImportFile(content []byte) (map[string]string, string, []core.MigrationRecord, error) {
+	if pkg, ok := parseEnvPackage(content); ok {
+		if pkg.Metadata.SourceType != string(constants.MigrationTypeEnvVariable) {
+			return nil, "", nil, fmt.Errorf("export package source type %q is not %s", pkg.Metadata.SourceType, constants.MigrationTypeEnvVariable)
+		}
+		if pkg.Metadata.Checksum != "" && calculateDataChecksum(pkg.Content.Data) != pkg.Metadata.Checksum {
+			return nil, "", nil, fmt.Errorf("export package checksum mismatch")
+		}
+		records := resolvePackagePlaceholders(pkg)
+		variables := make(map[string]string, len(pkg.Content.Data))
+		for name, value := range pkg.Content.Data {
+			variables[name] = toString(value)
+		}
+		scope, _ := pkg.Content.FormatSpecificData["scope"].(string)
+		return variables, scope, records, nil
+	}
+
+	variables, _ := parseEnvScript(content)
+	if len(variables) == 0 {
+		return nil, "", nil, fmt.Errorf("no environment variables found in import file")
+	}
+	return variables, "", nil, nil
+}
+
+// parseEnvPackage 将内容解析为导出包，内容不是导出包时返回 false
+func parseEnvPackage(content []byte) (*core.ExportPackage, bool) {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil, false
+	}
+	var pkg core.ExportPackage
+	if err := json.Unmarshal(trimmed, &pkg); err != nil || pkg.Metadata.SourceType == "" {
+		return nil, false
+	}
+	return &pkg, true
+}
+
+// ValidateExport 验证导出配置
+func (s *EnvVariableStrategy) ValidateExport(config *core.MigrationConfig) error {
+	if config == nil {
+		return fmt.Errorf("config cannot be nil")
+	}
+	if len(config.Source.Variables) == 0 && config.Source.Filter.Pattern == "" {
+		return fmt.Errorf("at least one variable or filter pattern is required")
+	}
+	switch format := envExportFormat(config); format {
+	case constants.ExportFormatPackage, constants.ExportFormatDotenv, constants.ExportFormatShell, constants.ExportFormatPowerShell:
+		return nil
+	default:
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// ValidateImport 验证导入配置
+func (s *EnvVariableStrategy) ValidateImport(config *core.MigrationConfig) error {
+	if config == nil {
+		return fmt.Errorf("config cannot be nil")
+	}
+	importPath := config.Options.ImportPath
+	if importPath == "" {
+		importPath = config.Source.Path
+	}
+	if importPath == "" {
+		return fmt.Errorf("import path is required")
+	}
+	if _, err := os.Stat(importPath); os.IsNotExist(err) {
+		return fmt.Errorf("import file does not exist: %s", importPath)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package strategies
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+func TestEnvExportImportPackage(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("user scope is read from the shell profile on unix only")
+	}
+	dir := t.TempDir()
+	stubMachineValues(t, "/home/alice", "alice", "alice-laptop")
+	// user 作用域从用户的 shell 配置读取，而不是当前进程环境
+	t.Setenv("ENVCRAFT_TEST_GOPATH", "/tmp/process-only")
+	t.Setenv("ENVCRAFT_TEST_UNSET", "/tmp/process-only")
+	profile := filepath.Join(dir, ".profile")
+	if err := os.WriteFile(profile, []byte("export ENVCRAFT_TEST_GOPATH='/home/alice/go'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := core.NewMigrationConfig()
+	config.Source.Type = "user"
+	config.Source.Path = profile
+	config.Source.Variables = map[string]string{
+		"JAVA_HOME":            "/opt/jdk-17",
+		"ENVCRAFT_TEST_GOPATH": "",
+		"ENVCRAFT_TEST_UNSET":  "",
+	}
+	config.Options.ExportPath = filepath.Join(dir, "toolchain.export.json")
+
+	strategy := &EnvVariableStrategy{}
+	if err := strategy.ValidateExport(config); err != nil {
+		t.Fatalf("ValidateExport failed: %v", err)
+	}
+	result, err := strategy.Export(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	content, err := os.ReadFile(config.Options.ExportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pkg core.ExportPackage
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{"JAVA_HOME": "/opt/jdk-17", "ENVCRAFT_TEST_GOPATH": "${HOME}/go"}
+	if !valuesEqual(pkg.Content.Data, expected) {
+		t.Errorf("unexpected exported variables: %v", pkg.Content.Data)
+	}
+	if pkg.Content.FormatSpecificData["scope"] != "user" || pkg.Metadata.SourceType != string(constants.MigrationTypeEnvVariable) {
+		t.Errorf("unexpected package metadata: %+v %v", pkg.Metadata, pkg.Content.FormatSpecificData)
+	}
+	skipped := 0
+	for _, record := range result.Records {
+		if record.Status == constants.RecordStatusSkipped {
+			skipped++
+		}
+	}
+	if skipped != 1 {
+		t.Errorf("unset variable should be skipped: %+v", result.Records)
+	}
+
+	// 在另一台机器导入到 ~/.bashrc
+	stubMachineValues(t, "/home/bob", "bob", "bob-pc")
+	bashrc := filepath.Join(dir, ".bashrc")
+	importConfig := core.NewMigrationConfig()
+	importConfig.Options.ImportPath = config.Options.ExportPath
+	importConfig.Target.Type = constants.EnvTargetBashrc
+	importConfig.Target.Path = bashrc
+	if err := strategy.ValidateImport(importConfig); err != nil {
+		t.Fatalf("ValidateImport failed: %v", err)
+	}
+	importResult, err := strategy.Import(context.Background(), importConfig)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if importResult.SourcePackage == nil || importResult.Summary.Success != 2 {
+		t.Errorf("unexpected import result: %+v", importResult.Summary)
+	}
+	out, _ := os.ReadFile(bashrc)
+	if !strings.Contains(string(out), "export ENVCRAFT_TEST_GOPATH='/home/bob/go'\n") {
+		t.Errorf("placeholder not resolved on import:\n%s", out)
+	}
+}
+
+func TestEnvExportScripts(t *testing.T) {
+	dir := t.TempDir()
+	variables := map[string]string{"GREETING": "it's \"quoted\"", "NODE_PATH": `C:\node`}
+
+	for _, name := range []string{"toolchain.env", "toolchain.sh", "toolchain.ps1"} {
+		config := core.NewMigrationConfig()
+		config.Source.Type = "process"
+		config.Source.Variables = variables
+		config.Options.ExportPath = filepath.Join(dir, name)
+
+		strategy := &EnvVariableStrategy{}
+		if _, err := strategy.Export(context.Background(), config); err != nil {
+			t.Fatalf("%s: Export failed: %v", name, err)
+		}
+		content, err := os.ReadFile(config.Options.ExportPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, _ := parseEnvScript(content)
+		if len(parsed) != 2 || parsed["GREETING"] != variables["GREETING"] || parsed["NODE_PATH"] != variables["NODE_PATH"] {
+			t.Errorf("%s: round trip failed:\n%s\nparsed: %v", name, content, parsed)
+		}
+	}
+
+	out, _ := os.ReadFile(filepath.Join(dir, "toolchain.ps1"))
+	if !strings.Contains(string(out), "$env:GREETING = 'it''s \"quoted\"'\n") {
+		t.Errorf("unexpected PowerShell script:\n%s", out)
+	}
+}
+
+func TestEnvImportDotenvToProcess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("# team env\nENVCRAFT_TEST_IMPORTED=\"value with spaces\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ENVCRAFT_TEST_IMPORTED", "")
+
+	config := core.NewMigrationConfig()
+	config.Options.ImportPath = path
+	if _, err := (&EnvVariableStrategy{}).Import(context.Background(), config); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if got := os.Getenv("ENVCRAFT_TEST_IMPORTED"); got != "value with spaces" {
+		t.Errorf("unexpected imported value %q", got)
+	}
+}
@@ -0,0 +1,340 @@
+package strategies
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+// windowsDrivePattern 以盘符开头的 Windows 路径，其中的 : 不是列表分隔符
+var windowsDrivePattern = regexp.MustCompile(`^[A-Za-z]:([\\/]|$)`)
+
+// envListChange 列表型变量的条目级变更，记录在上下文中供回滚只恢复涉及的条目
+type envListChange struct {
+	Mode      string         `json:"mode"`
+	Separator string         `json:"separator"`
+	Existed   bool           `json:"existed"` // 迁移前变量（或受管块中的条目）已存在
+	Before    string         `json:"before"`
+	After     string         `json:"after"`
+	Added     []string       `json:"added"`
+	Removed   []envListEntry `json:"removed"`
+	Missing   []string       `json:"missing"` // 路径不存在而未写入的条目
+}
+
+// envListEntry 被移除的条目及其在原列表中的位置
+type envListEntry struct {
+	Index int    `json:"index"`
+	Value string `json:"value"`
+}
+
+// envListRule 返回变量对应的列表规则
+func envListRule(config *core.MigrationConfig, name string) (*core.EnvListRule, bool) {
+	for i := range config.Target.ListVariables {
+		rule := &config.Target.ListVariables[i]
+		if rule.Name == name || (runtime.GOOS == "windows" && strings.EqualFold(rule.Name, name)) {
+			return rule, true
+		}
+	}
+	return nil, false
+}
+
+// validateEnvListRules 校验列表规则
+func validateEnvListRules(rules []core.EnvListRule) error {
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return fmt.Errorf("list variable rule requires a name")
+		}
+		switch rule.Mode {
+		case "", constants.EnvListPrepend, constants.EnvListAppend, constants.EnvListRemove, constants.EnvListReplace:
+		default:
+			return fmt.Errorf("unsupported list mode %q for %s", rule.Mode, rule.Name)
+		}
+		if len(rule.Separator) > 1 {
+			return fmt.Errorf("list separator for %s must be a single character", rule.Name)
+		}
+	}
+	return nil
+}
+
+// envListSeparator 返回条目分隔符：规则指定时使用规则，持久化到 shell 配置时为 :，
+// Windows 用户/系统变量为 ;，进程变量按当前系统
+func envListSeparator(rule *core.EnvListRule, targetType string, persist bool) string {
+	switch {
+	case rule.Separator != "":
+		return rule.Separator
+	case persist:
+		return ":"
+	case targetType == "user" || targetType == "system":
+		return ";"
+	default:
+		return string(os.PathListSeparator)
+	}
+}
+
+// splitEnvList 拆分列表值并统一分隔符：含 ; 时按 ; 拆分（Windows 写法，路径中可能含盘符的 :），
+// 否则按 : 拆分，但以盘符开头的单个 Windows 路径不拆分
+func splitEnvList(value, separator string) []string {
+	sep := separator
+	switch {
+	case separator != ";" && separator != ":":
+		// 规则指定的其他分隔符按原样使用
+	case strings.Contains(value, ";"):
+		sep = ";"
+	case strings.Contains(value, ":") && !windowsDrivePattern.MatchString(value):
+		sep = ":"
+	}
+
+	var entries []string
+	for _, entry := range strings.Split(value, sep) {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// sameEnvListEntry 判断两个条目是否指向同一路径：忽略末尾的路径分隔符，Windows 风格列表不区分大小写
+func sameEnvListEntry(a, b, separator string) bool {
+	trim := func(entry string) string {
+		if len(entry) > 1 {
+			entry = strings.TrimRight(entry, `/\`)
+		}
+		return entry
+	}
+	a, b = trim(a), trim(b)
+	if separator == ";" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// indexEnvListEntry 返回条目在列表中首次出现的位置
+func indexEnvListEntry(list []string, entry, separator string) int {
+	for i, item := range list {
+		if sameEnvListEntry(item, entry, separator) {
+			return i
+		}
+	}
+	return -1
+}
+
+// envListEntryExists 检查条目指向的路径是否存在；含变量引用的条目无法判断，视为存在
+func envListEntryExists(entry string) bool {
+	if strings.ContainsAny(entry, "$%") {
+		return true
+	}
+	_, err := os.Stat(entry)
+	return err == nil
+}
+
+// applyEnvList 按规则将 value 中的条目合并到当前值 current
+// prepend/append 跳过已存在的条目，重复执行结果不变
+func applyEnvList(rule *core.EnvListRule, separator, current string, existed bool, value string) *envListChange {
+	mode := rule.Mode
+	if mode == "" {
+		mode = constants.EnvListAppend
+	}
+	change := &envListChange{Mode: mode, Separator: separator, Existed: existed, Before: current}
+	before := splitEnvList(current, separator)
+	items := splitEnvList(value, separator)
+
+	// 确定保留的原有条目
+	keep := make([]bool, len(before))
+	for i, entry := range before {
+		keep[i] = true
+		if rule.Dedupe && indexEnvListEntry(before[:i], entry, separator) >= 0 {
+			keep[i] = false
+		}
+		switch mode {
+		case constants.EnvListRemove:
+			if indexEnvListEntry(items, entry, separator) >= 0 {
+				keep[i] = false
+			}
+		case constants.EnvListReplace:
+			if indexEnvListEntry(items, entry, separator) < 0 {
+				keep[i] = false
+			}
+		}
+	}
+	var kept []string
+	for i, entry := range before {
+		if keep[i] {
+			kept = append(kept, entry)
+		} else {
+			change.Removed = append(change.Removed, envListEntry{Index: i, Value: entry})
+		}
+	}
+
+	// 确定新增条目
+	var fresh []string
+	if mode != constants.EnvListRemove {
+		for _, item := range items {
+			if indexEnvListEntry(fresh, item, separator) >= 0 {
+				continue
+			}
+			if mode != constants.EnvListReplace && indexEnvListEntry(kept, item, separator) >= 0 {
+				continue
+			}
+			if rule.CheckExists && indexEnvListEntry(before, item, separator) < 0 && !envListEntryExists(item) {
+				change.Missing = append(change.Missing, item)
+				continue
+			}
+			fresh = append(fresh, item)
+		}
+	}
+
+	var after []string
+	switch mode {
+	case constants.EnvListPrepend:
+		after = append(fresh, kept...)
+		change.Added = fresh
+	case constants.EnvListAppend:
+		after = append(kept, fresh...)
+		change.Added = fresh
+	case constants.EnvListReplace:
+		after = fresh
+		for _, item := range fresh {
+			if indexEnvListEntry(before, item, separator) < 0 {
+				change.Added = append(change.Added, item)
+			}
+		}
+	default:
+		after = kept
+	}
+	change.After = strings.Join(after, separator)
+	return change
+}
+
+// changed 判断是否修改了变量
+func (c *envListChange) changed() bool {
+	return c.After != c.Before
+}
+
+// revert 从当前值中撤销本次变更：移除新增的条目，并把移除的条目插回原位置，其余条目保持不变
+func (c *envListChange) revert(current string) string {
+	list := splitEnvList(current, c.Separator)
+	for _, added := range c.Added {
+		if i := indexEnvListEntry(list, added, c.Separator); i >= 0 {
+			list = append(list[:i], list[i+1:]...)
+		}
+	}
+	removed := append([]envListEntry(nil), c.Removed...)
+	sort.Slice(removed, func(i, j int) bool { return removed[i].Index < removed[j].Index })
+	for _, entry := range removed {
+		index := entry.Index
+		if index > len(list) {
+			index = len(list)
+		}
+		list = append(list[:index], append([]string{entry.Value}, list[index:]...)...)
+	}
+	return strings.Join(list, c.Separator)
+}
+
+// summary 描述条目级变更
+func (c *envListChange) summary() string {
+	parts := []string{fmt.Sprintf("新增 %d 个条目，移除 %d 个条目", len(c.Added), len(c.Removed))}
+	if len(c.Missing) > 0 {
+		parts = append(parts, fmt.Sprintf("跳过不存在的路径: %s", strings.Join(c.Missing, ", ")))
+	}
+	return strings.Join(parts, "；")
+}
+
+// resolveListVariable 计算列表型变量写入后的值，非列表型变量返回 nil
+func (s *EnvVariableStrategy) resolveListVariable(config *core.MigrationConfig, name, current string, existed, persist bool, value string) *envListChange {
+	rule, ok := envListRule(config, name)
+	if !ok {
+		return nil
+	}
+	return applyEnvList(rule, envListSeparator(rule, config.Target.Type, persist), current, existed, value)
+}
+
+// listBackup 获取迁移时记录的条目级变更；未记录时按配置的条目视为本次新增，回滚只移除这些条目
+func (s *EnvVariableStrategy) listBackup(config *core.MigrationConfig, name string, persist bool) (*envListChange, bool) {
+	rule, ok := envListRule(config, name)
+	if !ok {
+		return nil, false
+	}
+	if change, ok := s.savedListBackup(config, name); ok {
+		return change, true
+	}
+	separator := envListSeparator(rule, config.Target.Type, persist)
+	change := &envListChange{Separator: separator, Existed: true}
+	if rule.Mode != constants.EnvListRemove {
+		change.Added = splitEnvList(config.Source.Variables[name], separator)
+	}
+	return change, true
+}
+
+// savedListBackup 返回执行时记录在 Context 中的列表型变量变更
+func (s *EnvVariableStrategy) savedListBackup(config *core.MigrationConfig, name string) (*envListChange, bool) {
+	if config.Context == nil {
+		return nil, false
+	}
+	value, ok := config.Context.GetState(fmt.Sprintf("list_%s", name))
+	if !ok {
+		return nil, false
+	}
+	change, ok := value.(*envListChange)
+	return change, ok
+}
+
+// saveListBackup 记录条目级变更供回滚
+func (s *EnvVariableStrategy) saveListBackup(config *core.MigrationConfig, name string, change *envListChange) {
+	if config.Context != nil {
+		config.Context.SetState(fmt.Sprintf("list_%s", name), change)
+	}
+}
+
+// appendListPreview 添加列表型变量的条目级预览
+func appendListPreview(preview *core.MigrationPreview, name string, change *envListChange) {
+	position := ""
+	switch change.Mode {
+	case constants.EnvListPrepend:
+		position = "开头"
+	case constants.EnvListAppend:
+		position = "末尾"
+	}
+	for _, entry := range change.Added {
+		preview.Changes = append(preview.Changes, core.PreviewChange{
+			ActionType:  constants.ActionTypeCreate,
+			Key:         fmt.Sprintf("%s[%s]", name, entry),
+			AfterValue:  entry,
+			Impact:      "medium",
+			Description: fmt.Sprintf("将在 %s%s添加条目 %s", name, position, entry),
+		})
+		preview.Summary.Create++
+		preview.Summary.Total++
+	}
+	for _, entry := range change.Removed {
+		preview.Changes = append(preview.Changes, core.PreviewChange{
+			ActionType:  constants.ActionTypeDelete,
+			Key:         fmt.Sprintf("%s[%s]", name, entry.Value),
+			BeforeValue: entry.Value,
+			Impact:      "medium",
+			Description: fmt.Sprintf("将从 %s 移除第 %d 个条目 %s", name, entry.Index+1, entry.Value),
+		})
+		preview.Summary.Delete++
+		preview.Summary.Total++
+	}
+	if !change.changed() {
+		preview.Changes = append(preview.Changes, core.PreviewChange{
+			ActionType:  "none",
+			Key:         name,
+			BeforeValue: change.Before,
+			AfterValue:  change.After,
+			Impact:      "low",
+			Description: fmt.Sprintf("环境变量 %s 的条目无变化", name),
+		})
+		preview.Summary.Total++
+	}
+	if len(change.Missing) > 0 {
+		preview.Warnings = append(preview.Warnings,
+			fmt.Sprintf("%s 中以下路径不存在，将不会写入: %s", name, strings.Join(change.Missing, ", ")))
+	}
+}
@@ -0,0 +1,167 @@
+package strategies
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+func TestSplitEnvList(t *testing.T) {
+	cases := []struct {
+		value, separator, expected string
+	}{
+		{"/usr/bin:/bin", ":", "/usr/bin|/bin"},
+		{`C:\tools;C:\bin;`, ":", `C:\tools|C:\bin`},
+		{`C:\tools`, ";", `C:\tools`},
+		{"/opt/a:/opt/b", ";", "/opt/a|/opt/b"},
+		{"", ":", ""},
+	}
+	for _, c := range cases {
+		if got := strings.Join(splitEnvList(c.value, c.separator), "|"); got != c.expected {
+			t.Errorf("splitEnvList(%q, %q) = %q, expected %q", c.value, c.separator, got, c.expected)
+		}
+	}
+}
+
+func TestApplyEnvListAndRevert(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "bin")
+	if err := os.Mkdir(existing, 0755); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing")
+
+	cases := []struct {
+		name     string
+		rule     core.EnvListRule
+		current  string
+		value    string
+		expected string
+	}{
+		{"prepend", core.EnvListRule{Mode: constants.EnvListPrepend}, "/usr/bin:/bin", "/opt/go/bin:/bin", "/opt/go/bin:/usr/bin:/bin"},
+		{"append", core.EnvListRule{}, "/usr/bin", "/opt/go/bin", "/usr/bin:/opt/go/bin"},
+		{"remove", core.EnvListRule{Mode: constants.EnvListRemove}, "/a:/old:/b:/old/", "/old", "/a:/b"},
+		{"dedupe", core.EnvListRule{Mode: constants.EnvListAppend, Dedupe: true}, "/a:/b:/a:/c:/b", "/d", "/a:/b:/c:/d"},
+		{"replace", core.EnvListRule{Mode: constants.EnvListReplace}, "/a:/b", "/b;/c", "/b:/c"},
+		{"check exists", core.EnvListRule{Mode: constants.EnvListPrepend, CheckExists: true}, "/usr/bin", existing + ":" + missing, existing + ":/usr/bin"},
+	}
+	for _, c := range cases {
+		rule := c.rule
+		change := applyEnvList(&rule, ":", c.current, true, c.value)
+		if change.After != c.expected {
+			t.Errorf("%s: got %q, expected %q", c.name, change.After, c.expected)
+			continue
+		}
+		if got := change.revert(change.After); got != c.current {
+			t.Errorf("%s: revert gave %q, expected %q", c.name, got, c.current)
+		}
+	}
+
+	// 重复执行不再新增条目
+	rule := core.EnvListRule{Mode: constants.EnvListPrepend}
+	if change := applyEnvList(&rule, ":", "/opt/go/bin:/usr/bin", true, "/opt/go/bin"); change.changed() {
+		t.Errorf("prepend should be idempotent: %+v", change)
+	}
+
+	// 回滚只撤销涉及的条目，保留迁移后用户自行添加的条目
+	change := applyEnvList(&rule, ":", "/usr/bin", true, "/opt/go/bin")
+	if got := change.revert("/home/me/bin:/opt/go/bin:/usr/bin"); got != "/home/me/bin:/usr/bin" {
+		t.Errorf("revert should keep unrelated entries, got %q", got)
+	}
+
+	// 检查路径时记录不存在的条目
+	rule = core.EnvListRule{CheckExists: true}
+	if change := applyEnvList(&rule, ":", "", false, missing); len(change.Missing) != 1 || change.changed() {
+		t.Errorf("missing entry should be skipped: %+v", change)
+	}
+}
+
+func TestEnvListProcessVariable(t *testing.T) {
+	separator := string(os.PathListSeparator)
+	original := strings.Join([]string{"/usr/bin", "/bin"}, separator)
+	t.Setenv("ENVCRAFT_TEST_PATH", original)
+
+	config := core.NewMigrationConfig()
+	config.Context = core.NewMigrationContext("list")
+	config.Source.Type = "local"
+	config.Source.Variables = map[string]string{"ENVCRAFT_TEST_PATH": "/opt/tools/bin"}
+	config.Target.Type = "process"
+	config.Target.ListVariables = []core.EnvListRule{{Name: "ENVCRAFT_TEST_PATH", Mode: constants.EnvListPrepend}}
+
+	strategy := &EnvVariableStrategy{}
+	if err := strategy.Validate(config); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	preview, err := strategy.DryRun(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Changes) != 1 || preview.Changes[0].Key != "ENVCRAFT_TEST_PATH[/opt/tools/bin]" ||
+		preview.Changes[0].ActionType != constants.ActionTypeCreate {
+		t.Errorf("preview should list entry-level changes: %+v", preview.Changes)
+	}
+
+	if _, err := strategy.Execute(context.Background(), config); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	expected := "/opt/tools/bin" + separator + original
+	if got := os.Getenv("ENVCRAFT_TEST_PATH"); got != expected {
+		t.Fatalf("unexpected value %q, expected %q", got, expected)
+	}
+
+	// 迁移后追加的条目在回滚时保留
+	os.Setenv("ENVCRAFT_TEST_PATH", expected+separator+"/home/me/bin")
+	if err := strategy.Rollback(context.Background(), config); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if got := os.Getenv("ENVCRAFT_TEST_PATH"); got != original+separator+"/home/me/bin" {
+		t.Errorf("rollback should only remove the prepended entry, got %q", got)
+	}
+}
+
+func TestEnvListPersistProfile(t *testing.T) {
+	profile := filepath.Join(t.TempDir(), ".profile")
+	t.Setenv("ENVCRAFT_TEST_PATH", "/usr/bin:/bin")
+
+	config := core.NewMigrationConfig()
+	config.Context = core.NewMigrationContext("list")
+	config.Source.Type = "local"
+	config.Source.Variables = map[string]string{"ENVCRAFT_TEST_PATH": "/bin"}
+	config.Target.Type = constants.EnvTargetProfile
+	config.Target.Path = profile
+	config.Target.ListVariables = []core.EnvListRule{{Name: "ENVCRAFT_TEST_PATH", Mode: constants.EnvListAppend}}
+
+	strategy := &EnvVariableStrategy{}
+	result, err := strategy.Execute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.Summary.Skipped != 1 {
+		t.Errorf("existing entry should be skipped: %+v", result.Summary)
+	}
+	if _, err := os.Stat(profile); !os.IsNotExist(err) {
+		t.Error("profile should not be written when no entry changes")
+	}
+
+	config.Source.Variables["ENVCRAFT_TEST_PATH"] = "/opt/go/bin"
+	if _, err := strategy.Execute(context.Background(), config); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	out, _ := os.ReadFile(profile)
+	if !strings.Contains(string(out), "export ENVCRAFT_TEST_PATH='/usr/bin:/bin:/opt/go/bin'\n") {
+		t.Errorf("unexpected profile:\n%s", out)
+	}
+
+	if err := strategy.Rollback(context.Background(), config); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if out, _ := os.ReadFile(profile); strings.Contains(string(out), "ENVCRAFT_TEST_PATH") {
+		t.Errorf("rollback should remove the managed entry that did not exist before:\n%s", out)
+	}
+}
@@ -0,0 +1,792 @@
+package strategies
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+	"tsc/pkg/util/safefile"
+)
+
+// 受管块标记，块内内容由 EnvCraft 维护，块外内容保持不变
+const (
+	envBlockBegin = "# >>> envcraft >>>"
+	envBlockEnd   = "# <<< envcraft <<<"
+	envBlockNote  = "# 由 EnvCraft 管理，请勿手动修改"
+)
+
+// envNamePattern 可持久化的变量名
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// envSyntax 持久化文件中赋值行的写法
+type envSyntax interface {
+	// format 生成赋值行，值无法在该语法中表示时返回错误
+	format(name, value string) (string, error)
+	// parse 解析赋值行
+	parse(line string) (name, value string, ok bool)
+}
+
+// envPersistTarget 环境变量持久化目标（Linux/macOS）
+type envPersistTarget struct {
+	kind   string
+	path   string
+	syntax envSyntax
+	owned  bool // 文件由 EnvCraft 独占：受管块移除后文件为空时删除文件
+}
+
+// resolveEnvPersistTarget 根据 Target.Type 返回持久化目标，path 非空时覆盖默认文件路径
+// 非 Windows 系统上 user 对应当前系统的默认 shell 配置，system 对应 /etc/environment；
+// 其余不属于持久化目标的类型返回 false
+func resolveEnvPersistTarget(targetType, path string) (*envPersistTarget, bool, error) {
+	kind := targetType
+	if runtime.GOOS != "windows" {
+		switch kind {
+		case "user":
+			kind = constants.EnvTargetProfile
+			if runtime.GOOS == "darwin" {
+				kind = constants.EnvTargetZshenv
+			}
+		case "system":
+			kind = constants.EnvTargetEtcEnvironment
+		}
+	}
+
+	target := &envPersistTarget{kind: kind, path: path}
+	var defaultPath func() (string, error)
+	switch kind {
+	case constants.EnvTargetProfile:
+		target.syntax = shellEnvSyntax{}
+		defaultPath = homeFile(".profile")
+	case constants.EnvTargetBashrc:
+		target.syntax = shellEnvSyntax{}
+		defaultPath = homeFile(".bashrc")
+	case constants.EnvTargetZshenv:
+		target.syntax = shellEnvSyntax{}
+		defaultPath = func() (string, error) {
+			if dir := os.Getenv("ZDOTDIR"); dir != "" {
+				return filepath.Join(dir, ".zshenv"), nil
+			}
+			return homeFile(".zshenv")()
+		}
+	case constants.EnvTargetFish:
+		target.syntax = fishEnvSyntax{}
+		target.owned = true
+		defaultPath = configHomeFile("fish", "conf.d", "envcraft.fish")
+	case constants.EnvTargetEnvironmentD:
+		target.syntax = keyValueEnvSyntax{escape: true}
+		target.owned = true
+		defaultPath = configHomeFile("environment.d", "50-envcraft.conf")
+	case constants.EnvTargetEtcEnvironment:
+		target.syntax = keyValueEnvSyntax{}
+		defaultPath = func() (string, error) { return "/etc/environment", nil }
+	default:
+		return nil, false, nil
+	}
+
+	if target.path == "" {
+		var err error
+		if target.path, err = defaultPath(); err != nil {
+			return nil, true, fmt.Errorf("failed to locate %s file: %w", kind, err)
+		}
+	}
+	return target, true, nil
+}
+
+// homeFile 返回主目录下文件路径的获取函数
+func homeFile(name string) func() (string, error) {
+	return func() (string, error) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, name), nil
+	}
+}
+
+// configHomeFile 返回 XDG 配置目录（默认 ~/.config，macOS 上同样如此）下文件路径的获取函数
+func configHomeFile(elem ...string) func() (string, error) {
+	return func() (string, error) {
+		dir := os.Getenv("XDG_CONFIG_HOME")
+		if dir == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			dir = filepath.Join(home, ".config")
+		}
+		return filepath.Join(append([]string{dir}, elem...)...), nil
+	}
+}
+
+// read 读取目标文件，文件不存在时返回空内容
+func (t *envPersistTarget) read() ([]byte, error) {
+	content, err := os.ReadFile(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return content, err
+}
+
+// entries 解析受管块中的变量
+func (t *envPersistTarget) entries(content []byte) map[string]string {
+	entries := make(map[string]string)
+	lines, begin, end := splitEnvBlock(content)
+	if begin < 0 {
+		return entries
+	}
+	for _, line := range lines[begin+1 : end] {
+		if name, value, ok := t.syntax.parse(strings.TrimSpace(line)); ok {
+			entries[name] = value
+		}
+	}
+	return entries
+}
+
+// render 以 entries 重写受管块，返回新的文件内容；entries 为空时移除受管块
+// 受管块不存在时追加到文件末尾
+func (t *envPersistTarget) render(content []byte, entries map[string]string) ([]byte, error) {
+	var block []string
+	if len(entries) > 0 {
+		block = append(block, envBlockBegin, envBlockNote)
+		for _, name := range sortedKeys(entries) {
+			line, err := t.syntax.format(name, entries[name])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			block = append(block, line)
+		}
+		block = append(block, envBlockEnd)
+	}
+
+	lines, begin, end := splitEnvBlock(content)
+	switch {
+	case begin >= 0:
+		rest := append(append([]string{}, block...), lines[end+1:]...)
+		head := lines[:begin]
+		// 移除受管块时一并移除追加时插入的空行
+		if len(block) == 0 && len(head) > 0 && head[len(head)-1] == "" {
+			head = head[:len(head)-1]
+		}
+		lines = append(append([]string{}, head...), rest...)
+	case len(block) > 0:
+		if len(lines) > 0 && lines[len(lines)-1] != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, block...)
+	}
+
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	newline := detectNewline(content)
+	return []byte(strings.Join(lines, newline) + newline), nil
+}
+
+// write 写入新的文件内容；独占文件内容为空时删除文件
+// 调用方须已通过 safefile.Lock 持有目标文件的锁
+func (t *envPersistTarget) write(content []byte) error {
+	if t.owned && len(strings.TrimSpace(string(content))) == 0 {
+		if err := os.Remove(t.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	return safefile.WriteFileOptions(t.path, content, safefile.Options{NoLock: true})
+}
+
+// splitEnvBlock 按行拆分文件内容，返回受管块起止行号；不存在受管块时均为 -1
+func splitEnvBlock(content []byte) ([]string, int, int) {
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	var lines []string
+	if text != "" {
+		lines = strings.Split(text, "\n")
+	}
+
+	begin := -1
+	for i, line := range lines {
+		switch strings.TrimSpace(line) {
+		case envBlockBegin:
+			begin = i
+		case envBlockEnd:
+			if begin >= 0 {
+				return lines, begin, i
+			}
+		}
+	}
+	return lines, -1, -1
+}
+
+// envBlockDiff 以 -/+ 前缀列出受管块中删除与新增的行
+func envBlockDiff(before, after []byte) string {
+	blockLines := func(content []byte) []string {
+		lines, begin, end := splitEnvBlock(content)
+		if begin < 0 {
+			return nil
+		}
+		return lines[begin : end+1]
+	}
+	oldLines, newLines := blockLines(before), blockLines(after)
+	oldSet := make(map[string]bool, len(oldLines))
+	for _, line := range oldLines {
+		oldSet[line] = true
+	}
+	newSet := make(map[string]bool, len(newLines))
+	for _, line := range newLines {
+		newSet[line] = true
+	}
+
+	var diff []string
+	for _, line := range oldLines {
+		if !newSet[line] {
+			diff = append(diff, "- "+line)
+		}
+	}
+	for _, line := range newLines {
+		if !oldSet[line] {
+			diff = append(diff, "+ "+line)
+		}
+	}
+	return strings.Join(diff, "\n")
+}
+
+// supportsReferences 目标文件是否在加载时展开 ${VAR} 引用，/etc/environment（pam_env）不展开
+func (t *envPersistTarget) supportsReferences() bool {
+	return t.kind != constants.EnvTargetEtcEnvironment
+}
+
+// envValuePart 值中的一段：字面文本或 ${VAR} 引用
+type envValuePart struct {
+	text string
+	ref  bool
+}
+
+// splitBracedReferences 按 ${VAR} 引用拆分值，各持久化写法对引用与字面文本分别转义；$${ 表示字面的 ${
+func splitBracedReferences(value string) []envValuePart {
+	var parts []envValuePart
+	var text strings.Builder
+	last := 0
+	for _, m := range posixBracedReference.FindAllStringIndex(value, -1) {
+		text.WriteString(value[last:m[0]])
+		last = m[1]
+		if value[m[0]:m[1]] == "$${" {
+			text.WriteString("${")
+			continue
+		}
+		if text.Len() > 0 {
+			parts = append(parts, envValuePart{text: text.String()})
+			text.Reset()
+		}
+		parts = append(parts, envValuePart{text: value[m[0]:m[1]], ref: true})
+	}
+	text.WriteString(value[last:])
+	if text.Len() > 0 || len(parts) == 0 {
+		parts = append(parts, envValuePart{text: text.String()})
+	}
+	return parts
+}
+
+// escapeBracedReferences 将字面文本中的 ${ 转义为 $${，使其不被当作引用
+func escapeBracedReferences(text string) string {
+	return strings.ReplaceAll(text, "${", "$${")
+}
+
+// shellEnvSyntax POSIX shell 写法：export NAME='value'，${VAR} 引用写在双引号中由 shell 展开
+type shellEnvSyntax struct{}
+
+func (shellEnvSyntax) format(name, value string) (string, error) {
+	var b strings.Builder
+	for _, part := range splitBracedReferences(value) {
+		if part.ref {
+			b.WriteString(`"` + part.text + `"`)
+		} else {
+			b.WriteString("'" + strings.ReplaceAll(part.text, "'", `'\''`) + "'")
+		}
+	}
+	return fmt.Sprintf("export %s=%s", name, b.String()), nil
+}
+
+func (shellEnvSyntax) parse(line string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(line, "export ")
+	if !ok {
+		return "", "", false
+	}
+	name, raw, ok := strings.Cut(rest, "=")
+	if !ok || !envNamePattern.MatchString(name) {
+		return "", "", false
+	}
+
+	// 解析单引号、双引号与反斜杠转义组成的 shell 单词
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		switch c := raw[i]; c {
+		case '\'':
+			j := strings.IndexByte(raw[i+1:], '\'')
+			if j < 0 {
+				return "", "", false
+			}
+			b.WriteString(escapeBracedReferences(raw[i+1 : i+1+j]))
+			i += j + 1
+		case '"':
+			for i++; i < len(raw) && raw[i] != '"'; i++ {
+				if raw[i] == '\\' && i+1 < len(raw) && strings.IndexByte("\"\\$`", raw[i+1]) >= 0 {
+					i++
+				}
+				b.WriteByte(raw[i])
+			}
+		case '\\':
+			if i+1 < len(raw) {
+				i++
+				b.WriteString(escapeBracedReferences(raw[i : i+1]))
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return name, b.String(), true
+}
+
+// fishEnvSyntax fish 写法：set -gx NAME 'value'，${VAR} 引用写作 {$VAR}
+type fishEnvSyntax struct{}
+
+func (fishEnvSyntax) format(name, value string) (string, error) {
+	escaper := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	var b strings.Builder
+	for _, part := range splitBracedReferences(value) {
+		if part.ref {
+			b.WriteString("{$" + part.text[2:len(part.text)-1] + "}")
+		} else {
+			b.WriteString("'" + escaper.Replace(part.text) + "'")
+		}
+	}
+	return fmt.Sprintf("set -gx %s %s", name, b.String()), nil
+}
+
+func (fishEnvSyntax) parse(line string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(line, "set -gx ")
+	if !ok {
+		return "", "", false
+	}
+	name, raw, ok := strings.Cut(rest, " ")
+	if !ok || !envNamePattern.MatchString(name) || raw == "" {
+		return "", "", false
+	}
+
+	// 解析单引号字符串与 {$VAR}、$VAR 引用组成的单词，引用统一还原为 ${VAR}
+	var b strings.Builder
+	for i := 0; i < len(raw); {
+		switch {
+		case raw[i] == '\'':
+			var text strings.Builder
+			for i++; i < len(raw) && raw[i] != '\''; i++ {
+				if raw[i] == '\\' && i+1 < len(raw) && (raw[i+1] == '\\' || raw[i+1] == '\'') {
+					i++
+				}
+				text.WriteByte(raw[i])
+			}
+			if i >= len(raw) {
+				return "", "", false
+			}
+			b.WriteString(escapeBracedReferences(text.String()))
+			i++
+		case strings.HasPrefix(raw[i:], "{$"):
+			end := strings.IndexByte(raw[i:], '}')
+			if end < 0 || !envNamePattern.MatchString(raw[i+2:i+end]) {
+				return "", "", false
+			}
+			b.WriteString("${" + raw[i+2:i+end] + "}")
+			i += end + 1
+		case raw[i] == '$':
+			end := i + 1
+			for end < len(raw) && (raw[end] == '_' || isAlphaNumeric(raw[end])) {
+				end++
+			}
+			if end == i+1 {
+				return "", "", false
+			}
+			b.WriteString("${" + raw[i+1:end] + "}")
+			i = end
+		default:
+			return "", "", false
+		}
+	}
+	return name, b.String(), true
+}
+
+// isAlphaNumeric 判断字节是否为 ASCII 字母或数字
+func isAlphaNumeric(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// keyValueEnvSyntax KEY="value" 写法
+// escape 为 true 时按 environment.d 规则转义 \、" 与 $；/etc/environment（pam_env）不支持转义
+type keyValueEnvSyntax struct {
+	escape bool
+}
+
+func (s keyValueEnvSyntax) format(name, value string) (string, error) {
+	if strings.ContainsAny(value, "\r\n") {
+		return "", fmt.Errorf("value must not contain line breaks")
+	}
+	if s.escape {
+		// environment.d 展开 ${VAR} 引用，其余字面文本转义
+		escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`)
+		var b strings.Builder
+		for _, part := range splitBracedReferences(value) {
+			if part.ref {
+				b.WriteString(part.text)
+			} else {
+				b.WriteString(escaper.Replace(part.text))
+			}
+		}
+		value = b.String()
+	} else if strings.Contains(value, `"`) {
+		return "", fmt.Errorf("value must not contain double quotes")
+	}
+	return fmt.Sprintf(`%s="%s"`, name, value), nil
+}
+
+func (s keyValueEnvSyntax) parse(line string) (string, string, bool) {
+	name, raw, ok := strings.Cut(line, "=")
+	if !ok || !envNamePattern.MatchString(name) {
+		return "", "", false
+	}
+	if len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"' {
+		raw = raw[1 : len(raw)-1]
+		if s.escape {
+			var b strings.Builder
+			for i := 0; i < len(raw); i++ {
+				if raw[i] == '\\' && i+1 < len(raw) {
+					i++
+					if raw[i] == '$' && strings.HasPrefix(raw[i+1:], "{") {
+						b.WriteByte('$')
+					}
+				}
+				b.WriteByte(raw[i])
+			}
+			raw = b.String()
+		}
+	}
+	return name, raw, true
+}
+
+// validatePersistVariables 检查变量名与取值能否写入持久化目标
+func validatePersistVariables(target *envPersistTarget, variables map[string]string) error {
+	for _, name := range sortedKeys(variables) {
+		if !envNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid variable name %q for %s", name, target.kind)
+		}
+		if _, err := target.syntax.format(name, variables[name]); err != nil {
+			return fmt.Errorf("variable %s cannot be written to %s: %w", name, target.kind, err)
+		}
+	}
+	return nil
+}
+
+// resolvePersistBatch 按目标的引用写法计算待写入持久化目标的值，并检查能否写入
+// 按字面写入时转义值中的 ${，使其在支持引用的目标中不被展开
+func (s *EnvVariableStrategy) resolvePersistBatch(config *core.MigrationConfig, target *envPersistTarget) (*envBatch, error) {
+	style := envReferenceStyle(config, target)
+	batch, err := resolveEnvBatch(s.getVariablesToMigrate(config), style, false)
+	if err != nil {
+		return nil, err
+	}
+	if style == envReferenceLiteral && target.supportsReferences() {
+		values := make(map[string]string, len(batch.values))
+		for name, value := range batch.values {
+			values[name] = escapeBracedReferences(value)
+		}
+		batch.values, batch.effective = values, values
+	}
+	return batch, validatePersistVariables(target, batch.values)
+}
+
+// executePersist 将变量写入持久化目标的受管块，重复执行结果不变
+func (s *EnvVariableStrategy) executePersist(ctx context.Context, config *core.MigrationConfig, target *envPersistTarget) (*core.MigrationResult, error) {
+	result := core.NewMigrationResult(config.TaskID)
+	result.StartTime = time.Now()
+	defer func() {
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime).Milliseconds()
+	}()
+
+	batch, err := s.resolvePersistBatch(config, target)
+	if err != nil {
+		result.Status = constants.TaskStatusFailed
+		result.Message = err.Error()
+		return result, err
+	}
+
+	// 读取-修改-写入期间持有目标文件的锁，避免并发任务互相覆盖
+	unlock, err := safefile.Lock(target.path)
+	if err != nil {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("锁定 %s 失败: %v", target.path, err)
+		return result, err
+	}
+	defer unlock()
+
+	content, err := target.read()
+	if err != nil {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("读取 %s 失败: %v", target.path, err)
+		return result, err
+	}
+	entries := target.entries(content)
+	previous := target.entries(content)
+
+	for _, name := range batch.order {
+		select {
+		case <-ctx.Done():
+			result.Status = constants.TaskStatusFailed
+			result.Message = "migration cancelled"
+			return result, ctx.Err()
+		default:
+		}
+
+		value := batch.values[name]
+		oldValue, exists := entries[name]
+		listChange := s.resolvePersistListVariable(config, name, entries, value)
+		if listChange != nil {
+			value = listChange.After
+		}
+		record := core.MigrationRecord{
+			StepName:    fmt.Sprintf("写入环境变量 %s 到 %s", name, target.path),
+			ActionType:  constants.ActionTypeCreate,
+			Key:         name,
+			BeforeValue: oldValue,
+			AfterValue:  value,
+			Status:      constants.RecordStatusSuccess,
+			Timestamp:   time.Now(),
+		}
+		if exists {
+			record.ActionType = constants.ActionTypeUpdate
+		}
+		unchanged := exists && oldValue == value
+		if listChange != nil {
+			// 列表型变量的条目均已存在时不写入受管块
+			unchanged = !listChange.changed()
+			s.saveListBackup(config, name, listChange)
+		}
+		if unchanged {
+			record.Status = constants.RecordStatusSkipped
+			record.Message = "受管块中已是该值"
+			result.Summary.Skipped++
+		} else {
+			result.Summary.Success++
+		}
+		if listChange != nil {
+			record.Message = listChange.summary()
+		}
+		if !unchanged {
+			entries[name] = value
+		}
+		result.Records = append(result.Records, record)
+		result.Summary.Total++
+	}
+
+	newContent, err := target.render(content, entries)
+	if err == nil && string(newContent) != string(content) {
+		if err = s.savePersistBackup(config, target, previous); err != nil {
+			result.Status = constants.TaskStatusFailed
+			result.Message = fmt.Sprintf("记录 %s 的受管块失败: %v", target.path, err)
+			return result, err
+		}
+		err = target.write(newContent)
+	}
+	if err != nil {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("写入 %s 失败: %v", target.path, err)
+		return result, err
+	}
+
+	result.Status = constants.TaskStatusCompleted
+	result.Message = fmt.Sprintf("成功写入 %d 个环境变量到 %s", result.Summary.Success, target.path)
+	return result, nil
+}
+
+// resolvePersistListVariable 计算列表型变量写入受管块的值：受管块中已有该变量时在其基础上合并，
+// 否则以当前进程中的值为基础，非列表型变量返回 nil
+func (s *EnvVariableStrategy) resolvePersistListVariable(config *core.MigrationConfig, name string, entries map[string]string, value string) *envListChange {
+	current, exists := entries[name]
+	if !exists {
+		current = os.Getenv(name)
+	}
+	return s.resolveListVariable(config, name, current, exists, true, value)
+}
+
+// persistBackupPath 返回记录迁移前受管块的备份文件路径，未指定 BackupPath 时为目标文件旁的 .backup 文件
+func persistBackupPath(config *core.MigrationConfig, target *envPersistTarget) string {
+	if config.Target.BackupPath != "" {
+		return config.Target.BackupPath
+	}
+	return target.path + ".backup"
+}
+
+// savePersistBackup 在首次修改受管块前记录其中原有的变量，供回滚恢复
+// 备份已存在时保留，使重复执行后回滚仍恢复到首次迁移前的状态
+func (s *EnvVariableStrategy) savePersistBackup(config *core.MigrationConfig, target *envPersistTarget, previous map[string]string) error {
+	backupPath := persistBackupPath(config, target)
+	if _, err := os.Stat(backupPath); err == nil {
+		return nil
+	}
+	content, err := target.render(nil, previous)
+	if err != nil {
+		return err
+	}
+	return safefile.WriteFile(backupPath, content, 0600)
+}
+
+// rollbackPersist 按迁移前记录的受管块恢复迁移的变量：原本不在受管块中的变量移除，其余恢复原值
+// 没有记录时拒绝回滚，避免删除迁移前已由用户维护的变量
+func (s *EnvVariableStrategy) rollbackPersist(ctx context.Context, config *core.MigrationConfig, target *envPersistTarget) error {
+	unlock, err := safefile.Lock(target.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	backupPath := persistBackupPath(config, target)
+	saved, err := os.ReadFile(backupPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no record of %s before migration (%s), refusing to roll back", target.path, backupPath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", backupPath, err)
+	}
+	previous := target.entries(saved)
+
+	content, err := target.read()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", target.path, err)
+	}
+	entries := target.entries(content)
+
+	for name := range s.getVariablesToMigrate(config) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// 同一任务中记录了列表型变量的条目变更时只恢复涉及的条目，保留之后加入的其他条目
+		if change, ok := s.savedListBackup(config, name); ok && change.Existed {
+			if current, exists := entries[name]; exists {
+				entries[name] = change.revert(current)
+				continue
+			}
+		}
+		if value, ok := previous[name]; ok {
+			entries[name] = value
+		} else {
+			delete(entries, name)
+		}
+	}
+
+	newContent, err := target.render(content, entries)
+	if err != nil {
+		return err
+	}
+	if string(newContent) != string(content) {
+		if err := target.write(newContent); err != nil {
+			return fmt.Errorf("failed to write %s: %w", target.path, err)
+		}
+	}
+	if err := os.Remove(backupPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove %s: %w", backupPath, err)
+	}
+	return nil
+}
+
+// dryRunPersist 预览对持久化目标的修改：各变量的变更，以及目标文件修改前后的完整内容
+func (s *EnvVariableStrategy) dryRunPersist(config *core.MigrationConfig, target *envPersistTarget) (*core.MigrationPreview, error) {
+	preview := core.NewMigrationPreview(config.TaskID)
+
+	batch, err := s.resolvePersistBatch(config, target)
+	if err != nil {
+		preview.Errors = append(preview.Errors, err.Error())
+		return preview, nil
+	}
+	if config.Target.References != constants.EnvReferenceExpand && !target.supportsReferences() {
+		preview.Warnings = append(preview.Warnings,
+			fmt.Sprintf("%s 不支持变量引用，将写入展开后的值", target.path))
+	}
+
+	content, err := target.read()
+	if err != nil {
+		preview.Errors = append(preview.Errors, fmt.Sprintf("读取 %s 失败: %v", target.path, err))
+		return preview, nil
+	}
+	entries := target.entries(content)
+
+	for _, name := range batch.order {
+		newValue := batch.values[name]
+		oldValue, exists := entries[name]
+		if listChange := s.resolvePersistListVariable(config, name, entries, newValue); listChange != nil {
+			appendListPreview(preview, name, listChange)
+			if listChange.changed() {
+				entries[name] = listChange.After
+			}
+			continue
+		}
+		change := core.PreviewChange{
+			Key:         name,
+			BeforeValue: oldValue,
+			AfterValue:  newValue,
+			Impact:      "low",
+		}
+		switch {
+		case !exists:
+			change.ActionType = constants.ActionTypeCreate
+			change.Description = fmt.Sprintf("将在 %s 中创建环境变量 %s", target.path, name)
+			preview.Summary.Create++
+		case oldValue != newValue:
+			change.ActionType = constants.ActionTypeUpdate
+			change.Description = fmt.Sprintf("将在 %s 中更新环境变量 %s", target.path, name)
+			preview.Summary.Update++
+		default:
+			change.ActionType = "none"
+			change.Description = fmt.Sprintf("环境变量 %s 无变化", name)
+		}
+		change.Description = describeEffectiveValue(change.Description, batch, name)
+		if s.isHighImpactVariable(name) {
+			change.Impact = "high"
+			preview.Summary.HighImpact++
+		}
+		entries[name] = newValue
+		preview.Changes = append(preview.Changes, change)
+		preview.Summary.Total++
+	}
+
+	newContent, err := target.render(content, entries)
+	if err != nil {
+		preview.Errors = append(preview.Errors, err.Error())
+		return preview, nil
+	}
+	if string(newContent) != string(content) {
+		change := core.PreviewChange{
+			ActionType:  constants.ActionTypeUpdate,
+			Key:         target.path,
+			BeforeValue: string(content),
+			AfterValue:  string(newContent),
+			Impact:      "low",
+			Description: fmt.Sprintf("将修改 %s:\n%s", target.path, envBlockDiff(content, newContent)),
+		}
+		if content == nil {
+			change.ActionType = constants.ActionTypeCreate
+			change.Description = fmt.Sprintf("将创建 %s:\n%s", target.path, envBlockDiff(content, newContent))
+		}
+		preview.Changes = append(preview.Changes, change)
+	}
+
+	if preview.Summary.HighImpact > 0 {
+		preview.Warnings = append(preview.Warnings,
+			fmt.Sprintf("有 %d 个高影响环境变量将被修改", preview.Summary.HighImpact))
+	}
+	return preview, nil
+}
@@ -0,0 +1,193 @@
+package strategies
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+func TestEnvSyntaxRoundTrip(t *testing.T) {
+	values := []string{"plain", "it's here", `C:\tools\bin`, `say "hi" $HOME`, ""}
+	syntaxes := map[string]envSyntax{
+		"shell":          shellEnvSyntax{},
+		"fish":           fishEnvSyntax{},
+		"environment.d":  keyValueEnvSyntax{escape: true},
+		"etcEnvironment": keyValueEnvSyntax{},
+	}
+	for syntaxName, syntax := range syntaxes {
+		for _, value := range values {
+			line, err := syntax.format("APP_VALUE", value)
+			if err != nil {
+				// /etc/environment 不支持双引号
+				if syntaxName == "etcEnvironment" && strings.Contains(value, `"`) {
+					continue
+				}
+				t.Errorf("%s: format %q failed: %v", syntaxName, value, err)
+				continue
+			}
+			name, parsed, ok := syntax.parse(line)
+			if !ok || name != "APP_VALUE" || parsed != value {
+				t.Errorf("%s: round trip of %q via %q gave %q", syntaxName, value, line, parsed)
+			}
+		}
+	}
+}
+
+func TestEnvPersistProfileBlock(t *testing.T) {
+	profile := filepath.Join(t.TempDir(), ".profile")
+	original := "# ~/.profile\nexport EDITOR=vim\n"
+	if err := os.WriteFile(profile, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := core.NewMigrationConfig()
+	config.Context = core.NewMigrationContext("persist")
+	config.Source.Type = "local"
+	config.Source.Variables = map[string]string{"JAVA_HOME": "/opt/jdk-17", "GREETING": "it's me"}
+	config.Target.Type = constants.EnvTargetProfile
+	config.Target.Path = profile
+
+	strategy := &EnvVariableStrategy{}
+	if err := strategy.Validate(config); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	preview, err := strategy.DryRun(context.Background(), config)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	fileChange := preview.Changes[len(preview.Changes)-1]
+	if fileChange.Key != profile || fileChange.BeforeValue != original ||
+		!strings.Contains(fileChange.Description, "+ export JAVA_HOME='/opt/jdk-17'") {
+		t.Errorf("unexpected file change in preview: %+v", fileChange)
+	}
+
+	if _, err := strategy.Execute(context.Background(), config); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	expected := original + "\n" +
+		"# >>> envcraft >>>\n" +
+		"# 由 EnvCraft 管理，请勿手动修改\n" +
+		"export GREETING='it'\\''s me'\n" +
+		"export JAVA_HOME='/opt/jdk-17'\n" +
+		"# <<< envcraft <<<\n"
+	out, _ := os.ReadFile(profile)
+	if string(out) != expected {
+		t.Fatalf("unexpected profile:\n%s\nexpected:\n%s", out, expected)
+	}
+	if fileChange.AfterValue != expected {
+		t.Errorf("preview must show the exact content to be written:\n%s", fileChange.AfterValue)
+	}
+
+	// 重复执行结果不变，且不会追加第二个受管块
+	result, err := strategy.Execute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("second Execute failed: %v", err)
+	}
+	if out, _ := os.ReadFile(profile); string(out) != expected {
+		t.Errorf("Execute is not idempotent:\n%s", out)
+	}
+	if result.Summary.Skipped != 2 {
+		t.Errorf("unchanged variables should be skipped: %+v", result.Summary)
+	}
+
+	// 回滚移除受管块，恢复原始文件
+	config.Context = core.NewMigrationContext("persist")
+	if err := strategy.Rollback(context.Background(), config); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if out, _ := os.ReadFile(profile); string(out) != original {
+		t.Errorf("rollback should restore the original profile:\n%q", out)
+	}
+}
+
+func TestEnvPersistRollbackRestoresRecordedBlock(t *testing.T) {
+	profile := filepath.Join(t.TempDir(), ".profile")
+	original := "export EDITOR=vim\n\n" +
+		"# >>> envcraft >>>\n" +
+		"export GOPATH='/home/me/go'\n" +
+		"# <<< envcraft <<<\n"
+	if err := os.WriteFile(profile, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := core.NewMigrationConfig()
+	config.Source.Type = "local"
+	config.Source.Variables = map[string]string{"GOPATH": "/home/bob/go", "GOBIN": "/home/bob/go/bin"}
+	config.Target.Type = constants.EnvTargetProfile
+	config.Target.Path = profile
+
+	strategy := &EnvVariableStrategy{}
+	// 没有迁移前的记录时拒绝回滚，不删除用户已有的变量
+	if err := strategy.Rollback(context.Background(), config); err == nil {
+		t.Fatal("Rollback without a recorded block should fail")
+	}
+	if out, _ := os.ReadFile(profile); string(out) != original {
+		t.Fatalf("profile modified by refused rollback:\n%s", out)
+	}
+
+	// 重复执行不覆盖首次迁移前的记录
+	for i := 0; i < 2; i++ {
+		if _, err := strategy.Execute(context.Background(), config); err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		config.Source.Variables["GOBIN"] = "/opt/go/bin"
+	}
+	if err := strategy.Rollback(context.Background(), config); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	out, _ := os.ReadFile(profile)
+	if !strings.Contains(string(out), "export GOPATH='/home/me/go'\n") || strings.Contains(string(out), "GOBIN") {
+		t.Errorf("rollback should restore the recorded block:\n%s", out)
+	}
+	if _, err := os.Stat(profile + ".backup"); !os.IsNotExist(err) {
+		t.Error("record should be removed after rollback")
+	}
+}
+
+func TestEnvPersistOwnedFileRemovedOnRollback(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	config := core.NewMigrationConfig()
+	config.Source.Type = "local"
+	config.Source.Variables = map[string]string{"GOPATH": "/home/bob/go"}
+	config.Target.Type = constants.EnvTargetFish
+
+	strategy := &EnvVariableStrategy{}
+	if _, err := strategy.Execute(context.Background(), config); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	fishFile := filepath.Join(dir, "fish", "conf.d", "envcraft.fish")
+	out, err := os.ReadFile(fishFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "set -gx GOPATH '/home/bob/go'\n") {
+		t.Errorf("unexpected fish config:\n%s", out)
+	}
+
+	if err := strategy.Rollback(context.Background(), config); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if _, err := os.Stat(fishFile); !os.IsNotExist(err) {
+		t.Error("owned file should be removed once its managed block is empty")
+	}
+}
+
+func TestEnvPersistEtcEnvironmentRejectsQuotes(t *testing.T) {
+	config := core.NewMigrationConfig()
+	config.Source.Type = "local"
+	config.Source.Variables = map[string]string{"MOTD": `say "hi"`}
+	config.Target.Type = constants.EnvTargetEtcEnvironment
+	config.Target.Path = filepath.Join(t.TempDir(), "environment")
+
+	if err := (&EnvVariableStrategy{}).Validate(config); err == nil {
+		t.Error("double quotes cannot be represented in /etc/environment")
+	}
+}
@@ -0,0 +1,266 @@
+package strategies
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+// envReferencePattern 匹配变量引用：Windows 的 %VAR%（允许 ProgramFiles(x86) 这类名称）与 POSIX 的 ${VAR}、$VAR
+var envReferencePattern = regexp.MustCompile(`%([A-Za-z_][A-Za-z0-9_()]*)%|\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// posixBracedReference 匹配持久化文件中保留的 ${VAR} 引用，以及表示字面 ${ 的转义 $${
+var posixBracedReference = regexp.MustCompile(`\$\$\{|\$\{[A-Za-z_][A-Za-z0-9_]*\}`)
+
+// 引用的写法
+const (
+	envReferenceKeep    = ""        // 保持原样
+	envReferenceExpand  = "expand"  // 展开为生效值
+	envReferencePOSIX   = "posix"   // 统一为 ${VAR}
+	envReferenceWindows = "windows" // 统一为 %VAR%
+	envReferenceLiteral = "literal" // 按字面写入，不识别引用
+)
+
+// envReference 值中的一个变量引用
+type envReference struct {
+	start, end int
+	name       string
+	windows    bool // %VAR% 写法，按 Windows 规则不区分大小写
+}
+
+// windowsStyleValue 值是否为 Windows 写法：含反斜杠路径或以 ; 分隔的列表
+func windowsStyleValue(value string) bool {
+	return strings.ContainsAny(value, `\;`)
+}
+
+// findEnvReferences 查找值中的变量引用
+// %VAR% 仅在 windows（目标为 Windows 变量）或值本身为 Windows 写法时视为引用，
+// 避免把 %Y%m%d 这类格式字符串当作引用改写
+func findEnvReferences(value string, windows bool) []envReference {
+	windows = windows || windowsStyleValue(value)
+	var refs []envReference
+	for _, m := range envReferencePattern.FindAllStringSubmatchIndex(value, -1) {
+		ref := envReference{start: m[0], end: m[1]}
+		switch {
+		case m[2] >= 0:
+			if !windows {
+				continue
+			}
+			ref.name, ref.windows = value[m[2]:m[3]], true
+		case m[4] >= 0:
+			ref.name = value[m[4]:m[5]]
+		default:
+			ref.name = value[m[6]:m[7]]
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// replaceEnvReferences 用 replace 的结果替换值中的引用，replace 返回 false 时保留原文
+func replaceEnvReferences(value string, windows bool, replace func(ref envReference) (string, bool)) string {
+	refs := findEnvReferences(value, windows)
+	if len(refs) == 0 {
+		return value
+	}
+	var b strings.Builder
+	last := 0
+	for _, ref := range refs {
+		b.WriteString(value[last:ref.start])
+		if replacement, ok := replace(ref); ok {
+			b.WriteString(replacement)
+		} else {
+			b.WriteString(value[ref.start:ref.end])
+		}
+		last = ref.end
+	}
+	b.WriteString(value[last:])
+	return b.String()
+}
+
+// formatEnvReferences 将值中的引用统一为目标写法
+func formatEnvReferences(value, style string, windows bool) string {
+	return replaceEnvReferences(value, windows, func(ref envReference) (string, bool) {
+		switch style {
+		case envReferencePOSIX:
+			return "${" + ref.name + "}", true
+		case envReferenceWindows:
+			return "%" + ref.name + "%", true
+		}
+		return "", false
+	})
+}
+
+// lookupBatchName 在本批变量中查找引用的变量名，%VAR% 引用不区分大小写
+func lookupBatchName(variables map[string]string, ref envReference) (string, bool) {
+	if _, ok := variables[ref.name]; ok {
+		return ref.name, true
+	}
+	if ref.windows {
+		for _, name := range sortedKeys(variables) {
+			if strings.EqualFold(name, ref.name) {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// orderEnvVariables 按引用依赖排序：被引用的变量排在引用它的变量之前，无依赖关系时按名称排序。
+// 引用自身（如 PATH=$PATH:/opt/bin）指向迁移前的值，不构成依赖；存在循环引用时返回错误
+func orderEnvVariables(variables map[string]string, windows bool) ([]string, error) {
+	deps := make(map[string][]string, len(variables))
+	for name, value := range variables {
+		for _, ref := range findEnvReferences(value, windows) {
+			if dep, ok := lookupBatchName(variables, ref); ok && dep != name {
+				deps[name] = append(deps[name], dep)
+			}
+		}
+		sort.Strings(deps[name])
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(variables))
+	order := make([]string, 0, len(variables))
+	var stack []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			// 截取栈中从 name 开始的部分即为循环
+			for i, item := range stack {
+				if item == name {
+					cycle := append(append([]string(nil), stack[i:]...), name)
+					return fmt.Errorf("reference cycle between env variables: %s", strings.Join(cycle, " -> "))
+				}
+			}
+		}
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, dep := range deps[name] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = done
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range sortedKeys(variables) {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// envBatch 一次迁移的变量
+type envBatch struct {
+	order     []string          // 按引用依赖排序的变量名
+	values    map[string]string // 按引用写入方式处理后待写入的值
+	effective map[string]string // 展开引用后的生效值
+}
+
+// resolveEnvBatch 排序本批变量并计算生效值：引用本批变量时使用其生效值，否则使用当前环境中的值，
+// 无法解析的引用保留原文；literal 写法不识别引用，生效值即原值
+func resolveEnvBatch(variables map[string]string, style string, windows bool) (*envBatch, error) {
+	if style == envReferenceLiteral {
+		batch := &envBatch{order: sortedKeys(variables), values: variables, effective: variables}
+		return batch, nil
+	}
+	order, err := orderEnvVariables(variables, windows)
+	if err != nil {
+		return nil, err
+	}
+	batch := &envBatch{
+		order:     order,
+		values:    make(map[string]string, len(variables)),
+		effective: make(map[string]string, len(variables)),
+	}
+	for _, name := range order {
+		value := variables[name]
+		batch.effective[name] = replaceEnvReferences(value, windows, func(ref envReference) (string, bool) {
+			if dep, ok := lookupBatchName(variables, ref); ok && dep != name {
+				return batch.effective[dep], true
+			}
+			return os.LookupEnv(ref.name)
+		})
+		switch style {
+		case envReferenceKeep:
+			batch.values[name] = value
+		case envReferenceExpand:
+			batch.values[name] = batch.effective[name]
+		default:
+			batch.values[name] = formatEnvReferences(value, style, windows)
+		}
+	}
+	return batch, nil
+}
+
+// envReferenceStyle 返回写入目标时引用的写法：显式要求展开或目标不支持引用时展开，显式要求按字面写入时不识别引用；
+// preserve 按原文写入；未指定时持久化到 shell 配置统一为 ${VAR}，Windows 用户/系统变量统一为 %VAR%，进程变量展开
+func envReferenceStyle(config *core.MigrationConfig, target *envPersistTarget) string {
+	switch config.Target.References {
+	case constants.EnvReferenceExpand:
+		return envReferenceExpand
+	case constants.EnvReferenceLiteral:
+		return envReferenceLiteral
+	}
+	if target != nil && !target.supportsReferences() {
+		return envReferenceExpand
+	}
+	if config.Target.References == constants.EnvReferencePreserve {
+		return envReferenceKeep
+	}
+	if target != nil {
+		return envReferencePOSIX
+	}
+	switch config.Target.Type {
+	case "user", "system":
+		return envReferenceWindows
+	}
+	return envReferenceExpand
+}
+
+// envWindowsScope 目标是否为 Windows 上的用户、系统或进程变量，此时值中的 %VAR% 均视为引用
+func envWindowsScope(config *core.MigrationConfig) bool {
+	if runtime.GOOS != "windows" {
+		return false
+	}
+	_, persist, err := resolveEnvPersistTarget(config.Target.Type, config.Target.Path)
+	return err == nil && !persist
+}
+
+// validateEnvReferences 校验引用写入方式与引用关系
+func validateEnvReferences(config *core.MigrationConfig, variables map[string]string) error {
+	switch config.Target.References {
+	case "", constants.EnvReferencePreserve, constants.EnvReferenceExpand:
+	case constants.EnvReferenceLiteral:
+		return nil
+	default:
+		return fmt.Errorf("unsupported references mode %q", config.Target.References)
+	}
+	_, err := orderEnvVariables(variables, envWindowsScope(config))
+	return err
+}
+
+// describeEffectiveValue 值中含引用时在描述后附加生效值
+func describeEffectiveValue(description string, batch *envBatch, name string) string {
+	if effective := batch.effective[name]; effective != batch.values[name] {
+		return fmt.Sprintf("%s（生效值: %s）", description, effective)
+	}
+	return description
+}
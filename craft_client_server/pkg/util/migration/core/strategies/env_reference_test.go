@@ -0,0 +1,203 @@
+package strategies
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+func TestOrderEnvVariables(t *testing.T) {
+	variables := map[string]string{
+		"PATH":      "%JAVA_HOME%\\bin;$GOPATH/bin;$PATH",
+		"JAVA_HOME": "${TOOLS}/jdk",
+		"GOPATH":    "$HOME/go",
+		"TOOLS":     "/opt/tools",
+	}
+	order, err := orderEnvVariables(variables, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(order, ","); got != "GOPATH,TOOLS,JAVA_HOME,PATH" {
+		t.Errorf("unexpected order %s", got)
+	}
+
+	_, err = orderEnvVariables(map[string]string{"A": "$B/x", "B": "%c%", "C": "${A}", "D": "$D"}, true)
+	if err == nil || !strings.Contains(err.Error(), "A -> B -> C -> A") {
+		t.Errorf("expected reference cycle error, got %v", err)
+	}
+}
+
+func TestResolveEnvBatch(t *testing.T) {
+	t.Setenv("ENVCRAFT_TEST_HOME", "/home/bob")
+	variables := map[string]string{
+		"ENVCRAFT_TEST_GOPATH": "$ENVCRAFT_TEST_HOME/go",
+		"ENVCRAFT_TEST_BIN":    "%ENVCRAFT_TEST_GOPATH%/bin:${ENVCRAFT_TEST_UNKNOWN}",
+	}
+
+	batch, err := resolveEnvBatch(variables, envReferencePOSIX, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := batch.effective["ENVCRAFT_TEST_BIN"]; got != "/home/bob/go/bin:${ENVCRAFT_TEST_UNKNOWN}" {
+		t.Errorf("unexpected effective value %q", got)
+	}
+	if got := batch.values["ENVCRAFT_TEST_BIN"]; got != "${ENVCRAFT_TEST_GOPATH}/bin:${ENVCRAFT_TEST_UNKNOWN}" {
+		t.Errorf("references should be normalized to ${VAR}, got %q", got)
+	}
+
+	batch, _ = resolveEnvBatch(variables, envReferenceWindows, true)
+	if got := batch.values["ENVCRAFT_TEST_GOPATH"]; got != "%ENVCRAFT_TEST_HOME%/go" {
+		t.Errorf("references should be normalized to %%VAR%%, got %q", got)
+	}
+	batch, _ = resolveEnvBatch(variables, envReferenceExpand, true)
+	if got := batch.values["ENVCRAFT_TEST_GOPATH"]; got != "/home/bob/go" {
+		t.Errorf("expand should write the effective value, got %q", got)
+	}
+}
+
+func TestEnvSyntaxReferences(t *testing.T) {
+	value := "${GOPATH}/bin:it's ${HOME}"
+	expected := map[string]string{
+		"shell":         `export APP_PATH="${GOPATH}"'/bin:it'\''s '"${HOME}"`,
+		"fish":          `set -gx APP_PATH {$GOPATH}'/bin:it\'s '{$HOME}`,
+		"environment.d": `APP_PATH="${GOPATH}/bin:it's ${HOME}"`,
+	}
+	syntaxes := map[string]envSyntax{
+		"shell":         shellEnvSyntax{},
+		"fish":          fishEnvSyntax{},
+		"environment.d": keyValueEnvSyntax{escape: true},
+	}
+	for syntaxName, syntax := range syntaxes {
+		line, err := syntax.format("APP_PATH", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != expected[syntaxName] {
+			t.Errorf("%s: unexpected line %s", syntaxName, line)
+		}
+		if _, parsed, ok := syntax.parse(line); !ok || parsed != value {
+			t.Errorf("%s: round trip gave %q", syntaxName, parsed)
+		}
+	}
+}
+
+func TestEnvReferencesExecute(t *testing.T) {
+	t.Setenv("ENVCRAFT_TEST_HOME", "/home/bob")
+	t.Setenv("ENVCRAFT_TEST_GOPATH", "")
+	t.Setenv("ENVCRAFT_TEST_BIN", "")
+
+	config := core.NewMigrationConfig()
+	config.Source.Type = "local"
+	config.Source.Variables = map[string]string{
+		"ENVCRAFT_TEST_BIN":    "${ENVCRAFT_TEST_GOPATH}/bin",
+		"ENVCRAFT_TEST_GOPATH": "$ENVCRAFT_TEST_HOME/go",
+	}
+	config.Target.Type = "process"
+
+	strategy := &EnvVariableStrategy{}
+	if _, err := strategy.Execute(context.Background(), config); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if got := os.Getenv("ENVCRAFT_TEST_BIN"); got != "/home/bob/go/bin" {
+		t.Errorf("process variables should be expanded in dependency order, got %q", got)
+	}
+
+	// 持久化到 shell 配置时保留引用，预览展示生效值
+	profile := filepath.Join(t.TempDir(), ".profile")
+	config.Target.Type = constants.EnvTargetProfile
+	config.Target.Path = profile
+	preview, err := strategy.DryRun(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(preview.Changes[0].Description, "生效值: /home/bob/go") || preview.Changes[0].Key != "ENVCRAFT_TEST_GOPATH" {
+		t.Errorf("preview should show effective values in dependency order: %+v", preview.Changes[0])
+	}
+	if _, err := strategy.Execute(context.Background(), config); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	out, _ := os.ReadFile(profile)
+	for _, line := range []string{
+		"export ENVCRAFT_TEST_GOPATH=\"${ENVCRAFT_TEST_HOME}\"'/go'\n",
+		"export ENVCRAFT_TEST_BIN=\"${ENVCRAFT_TEST_GOPATH}\"'/bin'\n",
+	} {
+		if !strings.Contains(string(out), line) {
+			t.Errorf("references should be preserved in the profile:\n%s", out)
+		}
+	}
+
+	// 循环引用在校验时报错
+	config.Source.Variables = map[string]string{"A": "$B", "B": "$A"}
+	if err := strategy.Validate(config); err == nil {
+		t.Error("Validate should reject reference cycles")
+	}
+}
+
+func TestEnvReferencesLiteralText(t *testing.T) {
+	variables := map[string]string{
+		"HISTTIMEFORMAT": "%Y%m%d %T ",
+		"PW":             "ab$cd",
+		"GOBIN":          "${GOPATH}/bin",
+	}
+	config := core.NewMigrationConfig()
+	config.Source.Variables = variables
+	config.Target.Type = constants.EnvTargetProfile
+	config.Target.Path = filepath.Join(t.TempDir(), ".profile")
+
+	cases := []struct {
+		references string
+		lines      []string
+	}{
+		// 未指定时 %Y% 这类非 Windows 写法的文本不视为引用
+		{"", []string{"export HISTTIMEFORMAT='%Y%m%d %T '\n", "export GOBIN=\"${GOPATH}\"'/bin'\n"}},
+		// preserve 按原文写入，只有 ${VAR} 保留为引用
+		{constants.EnvReferencePreserve, []string{"export HISTTIMEFORMAT='%Y%m%d %T '\n", "export PW='ab$cd'\n", "export GOBIN=\"${GOPATH}\"'/bin'\n"}},
+		// literal 整个值按字面写入
+		{constants.EnvReferenceLiteral, []string{"export HISTTIMEFORMAT='%Y%m%d %T '\n", "export PW='ab$cd'\n", "export GOBIN='${GOPATH}/bin'\n"}},
+	}
+	strategy := &EnvVariableStrategy{}
+	for _, c := range cases {
+		config.Target.References = c.references
+		if _, err := strategy.Execute(context.Background(), config); err != nil {
+			t.Fatalf("%q: Execute failed: %v", c.references, err)
+		}
+		out, _ := os.ReadFile(config.Target.Path)
+		for _, line := range c.lines {
+			if !strings.Contains(string(out), line) {
+				t.Errorf("%q: expected %q in profile:\n%s", c.references, line, out)
+			}
+		}
+	}
+
+	// 按字面写入的值读回后与原值一致，重复执行不再修改
+	result, err := strategy.Execute(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Summary.Skipped != 3 {
+		t.Errorf("literal values should round-trip: %+v", result.Records)
+	}
+
+	// environment.d 与 fish 中同样按字面写入
+	for syntaxName, syntax := range map[string]envSyntax{
+		"environment.d": keyValueEnvSyntax{escape: true},
+		"fish":          fishEnvSyntax{},
+	} {
+		value := escapeBracedReferences("${GOPATH}/bin")
+		line, err := syntax.format("GOBIN", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(line, "{$") || strings.Contains(line, `"${`) {
+			t.Errorf("%s: literal value written as a reference: %s", syntaxName, line)
+		}
+		if _, parsed, ok := syntax.parse(line); !ok || parsed != value {
+			t.Errorf("%s: round trip of %s gave %q", syntaxName, line, parsed)
+		}
+	}
+}
@@ -34,7 +34,7 @@ func (s *EnvVariableStrategy) Type() core.MigrationType {
 
 // Description 返回策略描述
 func (s *EnvVariableStrategy) Description() string {
-	return "迁移 Windows 环境变量，支持用户变量和系统变量的迁移"
+	return "迁移环境变量，Windows 下支持用户变量和系统变量，Linux/macOS 下持久化到 shell 配置、fish、environment.d 或 /etc/environment，PATH 等列表型变量可按条目合并"
 }
 
 // Validate 验证配置是否有效
@@ -58,11 +58,38 @@ func (s *EnvVariableStrategy) Validate(config *core.MigrationConfig) error {
 		return fmt.Errorf("at least one variable or filter pattern is required")
 	}
 
+	if err := validateEnvListRules(config.Target.ListVariables); err != nil {
+		return err
+	}
+
+	// 验证引用写入方式，并检查变量之间是否存在循环引用
+	if err := validateEnvReferences(config, s.getVariablesToMigrate(config)); err != nil {
+		return err
+	}
+
+	// 验证持久化目标能否写入这些变量
+	target, ok, err := resolveEnvPersistTarget(config.Target.Type, config.Target.Path)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return validatePersistVariables(target, s.getVariablesToMigrate(config))
+	}
+
 	return nil
 }
 
 // Execute 执行环境变量迁移
 func (s *EnvVariableStrategy) Execute(ctx context.Context, config *core.MigrationConfig) (*core.MigrationResult, error) {
+	// Linux/macOS 持久化目标
+	target, ok, err := resolveEnvPersistTarget(config.Target.Type, config.Target.Path)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return s.executePersist(ctx, config, target)
+	}
+
 	result := core.NewMigrationResult(config.TaskID)
 	result.StartTime = time.Now()
 	defer func() {
@@ -70,16 +97,22 @@ func (s *EnvVariableStrategy) Execute(ctx context.Context, config *core.Migratio
 		result.Duration = result.EndTime.Sub(result.StartTime).Milliseconds()
 	}()
 
-	// 检查是否为 Windows 系统
-	if runtime.GOOS != "windows" {
-		return nil, fmt.Errorf("env variable migration is only supported on Windows")
+	// 检查是否为 Windows 系统（进程级变量不限系统）
+	if runtime.GOOS != "windows" && config.Target.Type != "process" {
+		return nil, fmt.Errorf("env variable migration on %s requires a persistence target type", runtime.GOOS)
 	}
 
-	// 获取要迁移的变量
-	variables := s.getVariablesToMigrate(config)
+	// 获取要迁移的变量，按引用依赖排序
+	batch, err := resolveEnvBatch(s.getVariablesToMigrate(config), envReferenceStyle(config, nil), envWindowsScope(config))
+	if err != nil {
+		result.Status = constants.TaskStatusFailed
+		result.Message = err.Error()
+		return result, err
+	}
 
-	// 遍历并设置环境变量
-	for name, value := range variables {
+	// 依次设置环境变量
+	for _, name := range batch.order {
+		value := batch.values[name]
 		record := core.MigrationRecord{
 			StepName:   fmt.Sprintf("设置环境变量 %s", name),
 			ActionType: constants.ActionTypeUpdate,
@@ -88,9 +121,23 @@ func (s *EnvVariableStrategy) Execute(ctx context.Context, config *core.Migratio
 		}
 
 		// 获取当前值
-		oldValue := os.Getenv(name)
+		oldValue, exists := os.LookupEnv(name)
 		record.BeforeValue = oldValue
 
+		// 列表型变量按条目合并到当前值
+		if change := s.resolveListVariable(config, name, oldValue, exists, false, value); change != nil {
+			record.Message = change.summary()
+			s.saveListBackup(config, name, change)
+			if !change.changed() {
+				record.Status = constants.RecordStatusSkipped
+				result.Summary.Skipped++
+				result.Records = append(result.Records, record)
+				result.Summary.Total++
+				continue
+			}
+			value = change.After
+		}
+
 		// 根据目标类型设置环境变量
 		var err error
 		switch config.Target.Type {
@@ -135,9 +182,18 @@ func (s *EnvVariableStrategy) Execute(ctx context.Context, config *core.Migratio
 
 // Rollback 回滚环境变量迁移
 func (s *EnvVariableStrategy) Rollback(ctx context.Context, config *core.MigrationConfig) error {
-	// 检查是否为 Windows 系统
-	if runtime.GOOS != "windows" {
-		return fmt.Errorf("env variable migration is only supported on Windows")
+	// Linux/macOS 持久化目标
+	target, ok, err := resolveEnvPersistTarget(config.Target.Type, config.Target.Path)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return s.rollbackPersist(ctx, config, target)
+	}
+
+	// 检查是否为 Windows 系统（进程级变量不限系统）
+	if runtime.GOOS != "windows" && config.Target.Type != "process" {
+		return fmt.Errorf("env variable migration on %s requires a persistence target type", runtime.GOOS)
 	}
 
 	// 获取要回滚的变量
@@ -145,8 +201,10 @@ func (s *EnvVariableStrategy) Rollback(ctx context.Context, config *core.Migrati
 
 	// 遍历并恢复环境变量
 	for name, originalValue := range variables {
-		// 从备份或配置中获取原始值
-		if backupValue, ok := config.Context.GetState(fmt.Sprintf("backup_%s", name)); ok {
+		// 列表型变量只恢复涉及的条目，其余从备份或配置中获取原始值
+		if change, ok := s.listBackup(config, name, false); ok {
+			originalValue = change.revert(os.Getenv(name))
+		} else if backupValue, ok := s.backupValue(config, name); ok {
 			originalValue = backupValue.(string)
 		}
 
@@ -192,18 +250,39 @@ func (s *EnvVariableStrategy) Rollback(ctx context.Context, config *core.Migrati
 func (s *EnvVariableStrategy) DryRun(ctx context.Context, config *core.MigrationConfig) (*core.MigrationPreview, error) {
 	preview := core.NewMigrationPreview(config.TaskID)
 
-	// 检查是否为 Windows 系统
-	if runtime.GOOS != "windows" {
-		preview.Errors = append(preview.Errors, "环境变量迁移仅支持 Windows 系统")
+	// Linux/macOS 持久化目标
+	target, ok, err := resolveEnvPersistTarget(config.Target.Type, config.Target.Path)
+	if err != nil {
+		preview.Errors = append(preview.Errors, err.Error())
 		return preview, nil
 	}
+	if ok {
+		return s.dryRunPersist(config, target)
+	}
 
-	// 获取要迁移的变量
-	variables := s.getVariablesToMigrate(config)
+	// 检查是否为 Windows 系统（进程级变量不限系统）
+	if runtime.GOOS != "windows" && config.Target.Type != "process" {
+		preview.Errors = append(preview.Errors, fmt.Sprintf("%s 上迁移环境变量需指定持久化目标类型", runtime.GOOS))
+		return preview, nil
+	}
 
-	// 遍历并生成预览
-	for name, newValue := range variables {
-		oldValue := os.Getenv(name)
+	// 获取要迁移的变量，按引用依赖排序
+	batch, err := resolveEnvBatch(s.getVariablesToMigrate(config), envReferenceStyle(config, nil), envWindowsScope(config))
+	if err != nil {
+		preview.Errors = append(preview.Errors, err.Error())
+		return preview, nil
+	}
+
+	// 依次生成预览
+	for _, name := range batch.order {
+		newValue := batch.values[name]
+		oldValue, exists := os.LookupEnv(name)
+
+		// 列表型变量按条目预览
+		if change := s.resolveListVariable(config, name, oldValue, exists, false, newValue); change != nil {
+			appendListPreview(preview, name, change)
+			continue
+		}
 
 		change := core.PreviewChange{
 			Key:         name,
@@ -221,6 +300,7 @@ func (s *EnvVariableStrategy) DryRun(ctx context.Context, config *core.Migration
 			change.ActionType = "none"
 			change.Description = fmt.Sprintf("环境变量 %s 无变化", name)
 		}
+		change.Description = describeEffectiveValue(change.Description, batch, name)
 
 		// 评估影响程度
 		if s.isHighImpactVariable(name) {
@@ -349,22 +429,10 @@ func (s *EnvVariableStrategy) deleteSystemEnvVar(name string) error {
 	return os.Unsetenv(name)
 }
 
-// Export 导出环境变量（暂不支持）
-func (s *EnvVariableStrategy) Export(ctx context.Context, config *core.MigrationConfig) (*core.ExportResult, error) {
-	return nil, fmt.Errorf("export is not supported for env_variable strategy")
-}
-
-// Import 导入环境变量（暂不支持）
-func (s *EnvVariableStrategy) Import(ctx context.Context, config *core.MigrationConfig) (*core.ImportResult, error) {
-	return nil, fmt.Errorf("import is not supported for env_variable strategy")
-}
-
-// ValidateExport 验证导出配置（暂不支持）
-func (s *EnvVariableStrategy) ValidateExport(config *core.MigrationConfig) error {
-	return fmt.Errorf("export is not supported for env_variable strategy")
-}
-
-// ValidateImport 验证导入配置（暂不支持）
-func (s *EnvVariableStrategy) ValidateImport(config *core.MigrationConfig) error {
-	return fmt.Errorf("import is not supported for env_variable strategy")
+// backupValue 获取迁移前记录的变量原值，未记录时返回 false
+func (s *EnvVariableStrategy) backupValue(config *core.MigrationConfig, name string) (interface{}, bool) {
+	if config.Context == nil {
+		return nil, false
+	}
+	return config.Context.GetState(fmt.Sprintf("backup_%s", name))
 }
@@ -0,0 +1,526 @@
+package strategies
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+func init() {
+	// 自动注册到全局注册表
+	if err := core.RegisterStrategy(&JetBrainsStrategy{}); err != nil {
+		panic(fmt.Sprintf("failed to register jetbrains strategy: %v", err))
+	}
+}
+
+// jetbrainsRules 版本升级时迁移的内容（gitignore 语法）：只迁移选项、快捷键映射、配色方案、代码样式、
+// 实时模板、文件模板、检查配置、外部工具与禁用插件列表，跳过缓存、日志、锁文件、插件本体与记录窗口和统计状态等与版本相关的选项文件
+var jetbrainsRules = []string{
+	"/*",
+	"!/options/",
+	"!/keymaps/",
+	"!/colors/",
+	"!/codestyles/",
+	"!/templates/",
+	"!/fileTemplates/",
+	"!/inspection/",
+	"!/tools/",
+	"!/quicklists/",
+	"!/disabled_plugins.txt",
+	"options/window.state.xml",
+	"options/window.layouts.xml",
+	"options/updates.xml",
+	"options/usage.statistics.xml",
+	"options/statistics.*.xml",
+	"options/features.usage.statistics.xml",
+	"options/actionSummary.xml",
+	"options/log-categories.xml",
+	"options/runner.layout.xml",
+	"*.lock",
+	"*.log",
+}
+
+// jetbrainsAbsentMarker 迁移前目标目录不存在时写入备份目录的标记文件，回滚时删除目标目录
+const jetbrainsAbsentMarker = ".envcraft-absent"
+
+// jetbrainsVersionPattern 版本目录名：产品名加 年份.版本，如 IntelliJIdea2024.1、GoLand2023.3
+var jetbrainsVersionPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z-]*?)(\d{4})\.(\d+)$`)
+
+// jetbrainsVersion 一个版本配置目录
+type jetbrainsVersion struct {
+	dir     string // 目录路径
+	version string // 版本号，如 2024.1
+	year    int
+	minor   int
+}
+
+// JetBrainsStrategy JetBrains IDE 版本升级迁移策略：在同一产品的版本配置目录之间迁移设置
+//
+// Source.Path 为 JetBrains 配置根目录（默认为用户配置目录下的 JetBrains）或某个版本目录；
+// Source.Variables 中 product 为产品目录名前缀（如 IntelliJIdea、PyCharm、GoLand），源为版本目录时可省略；
+// from_version、to_version 指定源与目标版本，默认从次新版本迁移到最新版本；Target.Path 可直接指定目标版本目录
+type JetBrainsStrategy struct{}
+
+// Name 返回策略名称
+func (s *JetBrainsStrategy) Name() string {
+	return "JetBrains IDE 版本升级迁移策略"
+}
+
+// Type 返回策略类型
+func (s *JetBrainsStrategy) Type() core.MigrationType {
+	return constants.MigrationTypeJetBrains
+}
+
+// Description 返回策略描述
+func (s *JetBrainsStrategy) Description() string {
+	return "在 JetBrains IDE 的版本配置目录之间迁移选项、快捷键映射、配色方案、代码样式、实时模板与插件列表，跳过缓存与版本相关文件"
+}
+
+// Validate 验证配置是否有效
+func (s *JetBrainsStrategy) Validate(config *core.MigrationConfig) error {
+	if config == nil {
+		return fmt.Errorf("config cannot be nil")
+	}
+	from, to, err := resolveJetBrainsUpgrade(config)
+	if err != nil {
+		return err
+	}
+	return (&SoftwareStrategy{}).Validate(jetbrainsSoftwareConfig(config, from, to.dir))
+}
+
+// Execute 执行版本升级迁移：备份目标版本目录后增量复制源版本的设置，并列出需在新版本中重新安装的插件
+func (s *JetBrainsStrategy) Execute(ctx context.Context, config *core.MigrationConfig) (*core.MigrationResult, error) {
+	from, to, err := resolveJetBrainsUpgrade(config)
+	if err != nil {
+		result := core.NewMigrationResult(config.TaskID)
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("查找 IDE 配置目录失败: %v", err)
+		return result, err
+	}
+
+	backup, err := s.backupTarget(config, to.dir)
+	if err != nil {
+		result := core.NewMigrationResult(config.TaskID)
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("备份目标目录失败: %v", err)
+		return result, err
+	}
+
+	result, err := (&SoftwareStrategy{}).Execute(ctx, jetbrainsSoftwareConfig(config, from, to.dir))
+	result.Records = append([]core.MigrationRecord{*backup}, result.Records...)
+	if err != nil {
+		return result, err
+	}
+
+	for _, plugin := range jetbrainsPlugins(from.dir) {
+		result.Records = append(result.Records, core.MigrationRecord{
+			StepName:   fmt.Sprintf("插件 %s", plugin),
+			ActionType: constants.ActionTypeCopy,
+			Key:        plugin,
+			Status:     constants.RecordStatusSkipped,
+			Message:    "插件与 IDE 版本相关，需在新版本中重新安装或更新",
+			Timestamp:  time.Now(),
+		})
+		result.Summary.Skipped++
+	}
+	result.Summary.Total = result.Summary.Success + result.Summary.Failed + result.Summary.Skipped
+	result.Message = fmt.Sprintf("成功将 %s 的设置迁移到 %s，共处理 %d 项", filepath.Base(from.dir), filepath.Base(to.dir), result.Summary.Success)
+	return result, nil
+}
+
+// Rollback 恢复迁移前的目标版本目录并删除备份；迁移前目标不存在时删除目标目录
+func (s *JetBrainsStrategy) Rollback(ctx context.Context, config *core.MigrationConfig) error {
+	_, to, err := resolveJetBrainsUpgrade(config)
+	if err != nil {
+		return err
+	}
+	backupPath := jetbrainsBackupPath(config, to.dir)
+	if _, err := os.Stat(backupPath); err != nil {
+		return fmt.Errorf("backup not found: %s", backupPath)
+	}
+
+	if err := os.RemoveAll(to.dir); err != nil {
+		return fmt.Errorf("failed to remove current files: %w", err)
+	}
+	if _, err := os.Stat(filepath.Join(backupPath, jetbrainsAbsentMarker)); os.IsNotExist(err) {
+		if err := (&SoftwareStrategy{}).copyDirectory(backupPath, to.dir); err != nil {
+			return fmt.Errorf("failed to restore backup: %w", err)
+		}
+	}
+
+	// 恢复后删除备份，下次迁移重新备份；默认备份目录为空时一并删除
+	if err := os.RemoveAll(backupPath); err != nil {
+		return err
+	}
+	if config.Target.BackupPath == "" {
+		os.Remove(filepath.Dir(backupPath))
+	}
+	return nil
+}
+
+// DryRun 预览版本升级迁移：逐个列出将新建或覆盖的文件与需重新安装的插件
+func (s *JetBrainsStrategy) DryRun(ctx context.Context, config *core.MigrationConfig) (*core.MigrationPreview, error) {
+	from, to, err := resolveJetBrainsUpgrade(config)
+	if err != nil {
+		preview := core.NewMigrationPreview(config.TaskID)
+		preview.Errors = append(preview.Errors, err.Error())
+		return preview, nil
+	}
+
+	preview, err := (&SoftwareStrategy{}).DryRun(ctx, jetbrainsSoftwareConfig(config, from, to.dir))
+	if err != nil {
+		return preview, err
+	}
+	for _, dir := range []string{from.dir, to.dir} {
+		if jetbrainsRunning(dir) {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("%s 可能正在使用，请先关闭 IDE", filepath.Base(dir)))
+		}
+	}
+	if plugins := jetbrainsPlugins(from.dir); len(plugins) > 0 {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("以下插件需在新版本中重新安装或更新: %s", strings.Join(plugins, ", ")))
+	}
+	return preview, nil
+}
+
+// Export 将版本目录中的设置导出为软件配置归档，默认导出最新版本
+func (s *JetBrainsStrategy) Export(ctx context.Context, config *core.MigrationConfig) (*core.ExportResult, error) {
+	from, err := resolveJetBrainsExport(config)
+	if err != nil {
+		result := core.NewExportResult(config.TaskID)
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("查找 IDE 配置目录失败: %v", err)
+		return result, err
+	}
+	return (&SoftwareStrategy{}).Export(ctx, jetbrainsSoftwareConfig(config, from, ""))
+}
+
+// Import 将归档中的设置恢复到目标版本目录，默认恢复到最新版本
+// 未指定 Target.Path 时 Source.Path 为 JetBrains 配置根目录，归档路径须通过 Options.ImportPath 指定
+func (s *JetBrainsStrategy) Import(ctx context.Context, config *core.MigrationConfig) (*core.ImportResult, error) {
+	to, err := resolveJetBrainsTarget(config, nil)
+	if err != nil {
+		result := core.NewImportResult(config.TaskID)
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("查找 IDE 配置目录失败: %v", err)
+		return result, err
+	}
+	return (&SoftwareStrategy{}).Import(ctx, jetbrainsSoftwareConfig(config, nil, to.dir))
+}
+
+// ValidateExport 验证导出配置
+func (s *JetBrainsStrategy) ValidateExport(config *core.MigrationConfig) error {
+	if config == nil {
+		return fmt.Errorf("config cannot be nil")
+	}
+	from, err := resolveJetBrainsExport(config)
+	if err != nil {
+		return err
+	}
+	return (&SoftwareStrategy{}).ValidateExport(jetbrainsSoftwareConfig(config, from, ""))
+}
+
+// ValidateImport 验证导入配置
+func (s *JetBrainsStrategy) ValidateImport(config *core.MigrationConfig) error {
+	if config == nil {
+		return fmt.Errorf("config cannot be nil")
+	}
+	to, err := resolveJetBrainsTarget(config, nil)
+	if err != nil {
+		return err
+	}
+	return (&SoftwareStrategy{}).ValidateImport(jetbrainsSoftwareConfig(config, nil, to.dir))
+}
+
+// backupTarget 备份目标版本目录，目标不存在时在备份目录中写入标记，返回备份记录
+// 备份已存在时（上次迁移后未回滚）保留该备份，回滚恢复到第一次迁移前的状态
+func (s *JetBrainsStrategy) backupTarget(config *core.MigrationConfig, target string) (*core.MigrationRecord, error) {
+	backupPath := jetbrainsBackupPath(config, target)
+	record := &core.MigrationRecord{
+		StepName:    "备份目标目录",
+		ActionType:  constants.ActionTypeCopy,
+		Key:         backupPath,
+		BeforeValue: target,
+		AfterValue:  backupPath,
+		Status:      constants.RecordStatusSuccess,
+		Timestamp:   time.Now(),
+	}
+	if _, err := os.Stat(backupPath); err == nil {
+		record.Status = constants.RecordStatusSkipped
+		record.Message = "备份已存在，保留迁移前的备份"
+		return record, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(backupPath), 0755); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(target); os.IsNotExist(err) {
+		if err := os.MkdirAll(backupPath, 0755); err != nil {
+			return nil, err
+		}
+		record.Message = "目标目录不存在，回滚时将删除"
+		return record, os.WriteFile(filepath.Join(backupPath, jetbrainsAbsentMarker), nil, 0644)
+	}
+	return record, (&SoftwareStrategy{}).copyDirectory(target, backupPath)
+}
+
+// jetbrainsBackupPath 返回目标版本目录的备份路径，未指定 BackupPath 时为配置根目录之外的 <根目录>.backup/<版本目录名>，
+// 避免备份被产品目录的通配符（如 IntelliJIdea*）匹配为已安装的版本
+func jetbrainsBackupPath(config *core.MigrationConfig, target string) string {
+	if config.Target.BackupPath != "" {
+		return config.Target.BackupPath
+	}
+	return filepath.Join(filepath.Dir(target)+".backup", filepath.Base(target))
+}
+
+// jetbrainsSoftwareConfig 生成在两个版本目录之间迁移的软件配置迁移配置：按 jetbrainsRules 过滤，默认增量同步，备份由本策略完成
+// from 为空时不设置源（导入），to 为空时不设置目标（导出）
+func jetbrainsSoftwareConfig(config *core.MigrationConfig, from *jetbrainsVersion, to string) *core.MigrationConfig {
+	c := *config
+	c.Type = constants.MigrationTypeSoftware
+	c.Source.Filter.Exclude = append(append([]string{}, jetbrainsRules...), config.Source.Filter.Exclude...)
+	c.Source.Variables = map[string]string{"app_category": "IDE"}
+	if from != nil {
+		c.Source.Path = from.dir
+		c.Source.Variables["app_name"] = jetbrainsProduct(config, from.dir)
+		c.Source.Variables["app_version"] = from.version
+	}
+	if to != "" {
+		c.Target.Path = to
+	}
+	c.Target.Backup = false
+	if c.Target.Sync == "" {
+		c.Target.Sync = constants.SyncModeIncremental
+	}
+	return &c
+}
+
+// jetbrainsRoot 返回 JetBrains 配置根目录与产品名；Source.Path 为版本目录时同时返回该版本
+func jetbrainsRoot(config *core.MigrationConfig) (string, string, *jetbrainsVersion, error) {
+	root := config.Source.Path
+	if root == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", "", nil, err
+		}
+		root = filepath.Join(dir, "JetBrains")
+	}
+	product := config.Source.Variables["product"]
+
+	if v, ok := parseJetBrainsVersion(root); ok {
+		name := filepath.Base(root)
+		if p := jetbrainsVersionPattern.FindStringSubmatch(name)[1]; product == "" || product == p {
+			return filepath.Dir(root), p, v, nil
+		}
+	}
+	if product == "" {
+		return "", "", nil, fmt.Errorf("product is required when source path is not a version directory")
+	}
+	return root, product, nil, nil
+}
+
+// jetbrainsProduct 返回产品名，无法确定时返回目录名
+func jetbrainsProduct(config *core.MigrationConfig, dir string) string {
+	if product := config.Source.Variables["product"]; product != "" {
+		return product
+	}
+	if m := jetbrainsVersionPattern.FindStringSubmatch(filepath.Base(dir)); m != nil {
+		return m[1]
+	}
+	return filepath.Base(dir)
+}
+
+// parseJetBrainsVersion 解析版本目录名
+func parseJetBrainsVersion(dir string) (*jetbrainsVersion, bool) {
+	m := jetbrainsVersionPattern.FindStringSubmatch(filepath.Base(dir))
+	if m == nil {
+		return nil, false
+	}
+	year, _ := strconv.Atoi(m[2])
+	minor, _ := strconv.Atoi(m[3])
+	return &jetbrainsVersion{dir: dir, version: m[2] + "." + m[3], year: year, minor: minor}, true
+}
+
+// jetbrainsVersions 列出根目录下产品的版本目录，按版本从旧到新排序
+func jetbrainsVersions(root, product string) ([]*jetbrainsVersion, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var versions []*jetbrainsVersion
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), product) {
+			continue
+		}
+		v, ok := parseJetBrainsVersion(filepath.Join(root, entry.Name()))
+		if ok && jetbrainsVersionPattern.FindStringSubmatch(entry.Name())[1] == product {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].less(versions[j]) })
+	return versions, nil
+}
+
+// less 判断版本是否早于 other
+func (v *jetbrainsVersion) less(other *jetbrainsVersion) bool {
+	if v.year != other.year {
+		return v.year < other.year
+	}
+	return v.minor < other.minor
+}
+
+// findJetBrainsVersion 按版本号查找版本目录
+func findJetBrainsVersion(versions []*jetbrainsVersion, version string) *jetbrainsVersion {
+	for _, v := range versions {
+		if v.version == version {
+			return v
+		}
+	}
+	return nil
+}
+
+// resolveJetBrainsTarget 确定目标版本目录：Target.Path、to_version 或最新版本；目标目录可以不存在
+// source 不为空时，最新版本即为源版本视为没有可升级的目标
+func resolveJetBrainsTarget(config *core.MigrationConfig, source *jetbrainsVersion) (*jetbrainsVersion, error) {
+	if config.Target.Path != "" {
+		if v, ok := parseJetBrainsVersion(config.Target.Path); ok {
+			return v, nil
+		}
+		return &jetbrainsVersion{dir: config.Target.Path}, nil
+	}
+	root, product, _, err := jetbrainsRoot(config)
+	if err != nil {
+		return nil, err
+	}
+	if version := config.Source.Variables["to_version"]; version != "" {
+		v, _ := parseJetBrainsVersion(filepath.Join(root, product+version))
+		if v == nil {
+			return nil, fmt.Errorf("invalid to_version %q", version)
+		}
+		return v, nil
+	}
+	versions, err := jetbrainsVersions(root, product)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no %s config directory found in %s", product, root)
+	}
+	newest := versions[len(versions)-1]
+	if source != nil && newest.dir == source.dir {
+		return nil, fmt.Errorf("%s is the newest version, target path or to_version is required", filepath.Base(source.dir))
+	}
+	return newest, nil
+}
+
+// resolveJetBrainsExport 确定导出的版本目录：Source.Path 指定的版本目录、from_version 或最新版本
+func resolveJetBrainsExport(config *core.MigrationConfig) (*jetbrainsVersion, error) {
+	root, product, source, err := jetbrainsRoot(config)
+	if err != nil || source != nil {
+		return source, err
+	}
+	versions, err := jetbrainsVersions(root, product)
+	if err != nil {
+		return nil, err
+	}
+	if version := config.Source.Variables["from_version"]; version != "" {
+		if v := findJetBrainsVersion(versions, version); v != nil {
+			return v, nil
+		}
+		return nil, fmt.Errorf("%s%s not found in %s", product, version, root)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no %s config directory found in %s", product, root)
+	}
+	return versions[len(versions)-1], nil
+}
+
+// resolveJetBrainsUpgrade 确定升级迁移的源与目标版本目录
+// 源为 Source.Path 指定的版本目录、from_version 或早于目标的最新版本；目标见 resolveJetBrainsTarget
+func resolveJetBrainsUpgrade(config *core.MigrationConfig) (*jetbrainsVersion, *jetbrainsVersion, error) {
+	root, product, source, err := jetbrainsRoot(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	versions, err := jetbrainsVersions(root, product)
+	if err != nil {
+		return nil, nil, err
+	}
+	if source == nil {
+		if version := config.Source.Variables["from_version"]; version != "" {
+			if source = findJetBrainsVersion(versions, version); source == nil {
+				return nil, nil, fmt.Errorf("%s%s not found in %s", product, version, root)
+			}
+		}
+	}
+
+	target, err := resolveJetBrainsTarget(config, source)
+	if err != nil {
+		return nil, nil, err
+	}
+	if source == nil {
+		// 默认源为早于目标的最新版本；目标不是版本目录时为最新的其他版本
+		for i := len(versions) - 1; i >= 0; i-- {
+			v := versions[i]
+			if v.dir != target.dir && (target.version == "" || v.less(target)) {
+				source = v
+				break
+			}
+		}
+		if source == nil {
+			return nil, nil, fmt.Errorf("no previous %s config directory found in %s", product, root)
+		}
+	}
+	if filepath.Clean(source.dir) == filepath.Clean(target.dir) {
+		return nil, nil, fmt.Errorf("source and target are the same directory: %s", source.dir)
+	}
+	return source, target, nil
+}
+
+// jetbrainsPlugins 列出版本目录中安装的插件（plugins 子目录，Linux 上还有数据目录 ~/.local/share/JetBrains/<版本目录名>）
+func jetbrainsPlugins(dir string) []string {
+	dirs := []string{filepath.Join(dir, "plugins")}
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+		dirs = append(dirs, filepath.Join(dataHome, "JetBrains", filepath.Base(dir)))
+	} else if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".local", "share", "JetBrains", filepath.Base(dir)))
+	}
+
+	seen := make(map[string]bool)
+	var plugins []string
+	for _, d := range dirs {
+		entries, err := os.ReadDir(d)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if strings.HasPrefix(name, ".") || (!entry.IsDir() && !strings.HasSuffix(name, ".jar")) || seen[name] {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, strings.TrimSuffix(name, ".jar"))
+		}
+	}
+	sort.Strings(plugins)
+	return plugins
+}
+
+// jetbrainsRunning 判断版本目录是否可能正被运行中的 IDE 使用（存在 .lock 或 port.lock）
+func jetbrainsRunning(dir string) bool {
+	for _, name := range []string{".lock", "port.lock"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
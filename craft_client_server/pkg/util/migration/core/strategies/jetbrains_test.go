@@ -0,0 +1,189 @@
+package strategies
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+// newJetBrainsConfig 创建在配置根目录中迁移 IntelliJIdea 的配置
+func newJetBrainsConfig(root string) *core.MigrationConfig {
+	config := core.NewMigrationConfig()
+	config.Type = constants.MigrationTypeJetBrains
+	config.Source.Path = root
+	config.Source.Variables = map[string]string{"product": "IntelliJIdea"}
+	return config
+}
+
+func TestJetBrainsUpgrade(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"IntelliJIdea2023.3/options/editor.xml":        "<old/>",
+		"IntelliJIdea2024.1/options/editor.xml":        "<editor/>",
+		"IntelliJIdea2024.1/options/window.state.xml":  "<window/>",
+		"IntelliJIdea2024.1/options/updates.xml":       "<updates/>",
+		"IntelliJIdea2024.1/keymaps/Mine.xml":          "<keymap/>",
+		"IntelliJIdea2024.1/colors/Dark.icls":          "<scheme/>",
+		"IntelliJIdea2024.1/templates/Go.xml":          "<templates/>",
+		"IntelliJIdea2024.1/disabled_plugins.txt":      "org.jetbrains.plugins.yaml\n",
+		"IntelliJIdea2024.1/plugins/lombok/lib/a.jar":  "jar",
+		"IntelliJIdea2024.1/port.lock":                 "",
+		"IntelliJIdea2024.1/tasks/intellij.tasks.zip":  "zip",
+		"IntelliJIdea2024.2/options/other.xml":         "<other/>",
+		"IntelliJIdea2024.2/options/window.state.xml":  "<new window/>",
+		"IntelliJIdea2024.10-backup/options/other.xml": "<backup/>",
+		"PyCharm2024.3/options/editor.xml":             "<pycharm/>",
+	})
+	config := newJetBrainsConfig(root)
+	s := &JetBrainsStrategy{}
+
+	// 默认从次新版本迁移到最新版本
+	from, to, err := resolveJetBrainsUpgrade(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(from.dir) != "IntelliJIdea2024.1" || filepath.Base(to.dir) != "IntelliJIdea2024.2" {
+		t.Fatalf("unexpected versions: %s -> %s", from.dir, to.dir)
+	}
+	if err := s.Validate(config); err != nil {
+		t.Fatal(err)
+	}
+
+	preview, err := s.DryRun(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Summary.Create != 5 || len(preview.Warnings) != 3 || !strings.Contains(preview.Warnings[2], "lombok") {
+		t.Errorf("unexpected preview: %+v %v", preview.Summary, preview.Warnings)
+	}
+
+	result, err := s.Execute(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newDir := filepath.Join(root, "IntelliJIdea2024.2")
+	for _, name := range []string{"options/editor.xml", "keymaps/Mine.xml", "colors/Dark.icls", "templates/Go.xml", "disabled_plugins.txt", "options/other.xml"} {
+		if _, err := os.Stat(filepath.Join(newDir, name)); err != nil {
+			t.Errorf("%s not migrated: %v", name, err)
+		}
+	}
+	for _, name := range []string{"options/updates.xml", "plugins", "port.lock", "tasks"} {
+		if _, err := os.Stat(filepath.Join(newDir, name)); err == nil {
+			t.Errorf("%s should not be migrated", name)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(newDir, "options/window.state.xml")); string(data) != "<new window/>" {
+		t.Errorf("version-specific file overwritten: %s", data)
+	}
+	if n := countRecordStatus(result.Records, constants.ActionTypeCopy, constants.RecordStatusSkipped); n != 1 || result.Summary.Skipped != 1 {
+		t.Errorf("expected lombok plugin to be reported, got %d skipped: %+v", n, result.Summary)
+	}
+
+	// 备份位于配置根目录之外，不会被识别为版本目录
+	backupDir := filepath.Join(root+".backup", "IntelliJIdea2024.2")
+	if data, _ := os.ReadFile(filepath.Join(backupDir, "options/other.xml")); string(data) != "<other/>" {
+		t.Errorf("unexpected backup: %s", data)
+	}
+	if versions, _ := jetbrainsVersions(root, "IntelliJIdea"); len(versions) != 3 {
+		t.Errorf("unexpected versions after backup: %d", len(versions))
+	}
+
+	// 再次迁移保留第一次迁移前的备份
+	os.WriteFile(filepath.Join(newDir, "options/other.xml"), []byte("<changed/>"), 0644)
+	if _, err := s.Execute(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(backupDir, "options/other.xml")); string(data) != "<other/>" {
+		t.Errorf("backup overwritten by second execute: %s", data)
+	}
+
+	// 回滚恢复迁移前的目标版本目录并删除备份
+	if err := s.Rollback(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(newDir, "options/editor.xml")); !os.IsNotExist(err) {
+		t.Errorf("rollback did not remove migrated file: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(newDir, "options/other.xml")); string(data) != "<other/>" {
+		t.Errorf("rollback did not restore target: %s", data)
+	}
+	if _, err := os.Stat(root + ".backup"); !os.IsNotExist(err) {
+		t.Errorf("backup should be removed after rollback: %v", err)
+	}
+}
+
+func TestJetBrainsUpgradeToNewVersion(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"GoLand2023.3/options/editor.xml": "<old/>",
+		"GoLand2024.1/options/editor.xml": "<editor/>",
+	})
+
+	// 源为版本目录时从目录名推断产品，目标版本目录尚不存在
+	config := core.NewMigrationConfig()
+	config.Source.Path = filepath.Join(root, "GoLand2023.3")
+	config.Source.Variables = map[string]string{"to_version": "2024.2"}
+	s := &JetBrainsStrategy{}
+	if _, err := s.Execute(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(root, "GoLand2024.2")
+	if data, _ := os.ReadFile(filepath.Join(target, "options/editor.xml")); string(data) != "<old/>" {
+		t.Errorf("unexpected migrated content: %s", data)
+	}
+
+	// 迁移前目标不存在，回滚时删除目标目录
+	if err := s.Rollback(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("target should be removed: %v", err)
+	}
+
+	// 最新版本没有可升级的目标
+	config.Source.Path = filepath.Join(root, "GoLand2024.1")
+	config.Source.Variables = nil
+	if err := s.Validate(config); err == nil {
+		t.Error("expected error when source is the newest version")
+	}
+}
+
+func TestJetBrainsExportImport(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"PyCharm2024.1/options/editor.xml": "<editor/>",
+		"PyCharm2024.1/system/cache.bin":   "cache",
+	})
+	archive := filepath.Join(t.TempDir(), "pycharm.zip")
+
+	config := newJetBrainsConfig(root)
+	config.Source.Variables["product"] = "PyCharm"
+	config.Options.ExportPath = archive
+	s := &JetBrainsStrategy{}
+	if err := s.ValidateExport(config); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Export(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	importConfig := core.NewMigrationConfig()
+	importConfig.Options.ImportPath = archive
+	importConfig.Target.Path = filepath.Join(t.TempDir(), "PyCharm2024.2")
+	if _, err := s.Import(context.Background(), importConfig); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(importConfig.Target.Path, "options/editor.xml")); err != nil {
+		t.Errorf("settings not imported: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(importConfig.Target.Path, "system")); err == nil {
+		t.Error("cache should not be exported")
+	}
+}
@@ -0,0 +1,490 @@
+package strategies
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/user"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+// machineValue 本机相关值及其占位符名称
+type machineValue struct {
+	name  string // 占位符名称，如 HOME
+	value string // 本机取值
+	word  bool   // 是否为名称（用户名、主机名）；否则为路径
+}
+
+// 用户名与主机名只在路径中替换：用户名须位于用户目录之下，主机名须位于 UNC 路径开头；
+// 作为普通单词出现时（URL、注册表数据、配置键等）不替换
+var (
+	userPathPrefixes = []string{"/home/", "/Users/", `\Users\`}
+	hostPathPrefixes = []string{`\\`}
+)
+
+// minMachineWordLength 用户名与主机名替换为占位符的最小长度，更短的值容易与无关内容重合
+const minMachineWordLength = 4
+
+// lookupMachineValues 获取本机相关值，测试中可替换
+var lookupMachineValues = machineValues
+
+// machineValues 收集本机的主目录、常用目录、用户名与主机名
+func machineValues() []machineValue {
+	var values []machineValue
+	addDir := func(name string, dir string, err error) {
+		if err == nil {
+			values = append(values, machineValue{name: name, value: dir})
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	addDir("HOME", home, err)
+	configDir, err := os.UserConfigDir()
+	addDir("CONFIG_DIR", configDir, err)
+	cacheDir, err := os.UserCacheDir()
+	addDir("CACHE_DIR", cacheDir, err)
+	addDir("TEMP", os.TempDir(), nil)
+	if runtime.GOOS == "windows" {
+		for _, name := range []string{"APPDATA", "LOCALAPPDATA", "PROGRAMDATA"} {
+			if dir := os.Getenv(name); dir != "" {
+				addDir(name, dir, nil)
+			}
+		}
+	}
+
+	username := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+	// Windows 用户名形如 DOMAIN\alice
+	if i := strings.LastIndex(username, `\`); i >= 0 {
+		username = username[i+1:]
+	}
+	if username != "" {
+		values = append(values, machineValue{name: "USER", value: username, word: true})
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		values = append(values, machineValue{name: "HOSTNAME", value: hostname, word: true})
+	}
+	return values
+}
+
+// genericMachineValues 在各台机器上都相同的通用取值（系统临时目录、localhost、常见的系统与服务账户、共享用户目录），
+// 替换为占位符会误伤无关内容，不作为占位符
+var genericMachineValues = map[string]bool{
+	"/tmp": true, "/var/tmp": true, "/usr/tmp": true, "/dev/shm": true, `C:\Windows\Temp`: true,
+	"localhost": true, "localhost.localdomain": true, "ip6-localhost": true,
+	"root": true, "admin": true, "administrator": true, "user": true, "guest": true,
+	"dev": true, "git": true, "test": true, "ubuntu": true, "debian": true, "centos": true, "ec2-user": true,
+	"vagrant": true, "docker": true, "runner": true, "jenkins": true, "nobody": true, "www-data": true,
+	"default": true, "public": true, "shared": true,
+}
+
+// placeholderSet 一组可替换的本机相关值
+// 替换时路径按长度从长到短处理，使 ${CONFIG_DIR} 优先于其上级的 ${HOME}
+type placeholderSet struct {
+	values []machineValue
+	escape func(string) string // 原始内容中值的转义方式（如 JSON 中的反斜杠）
+}
+
+// newPlaceholderSet 由本机相关值创建占位符集合，忽略过短、通用或重复的值
+func newPlaceholderSet(values []machineValue) *placeholderSet {
+	set := &placeholderSet{escape: func(s string) string { return s }}
+	seen := make(map[string]bool)
+	for _, v := range values {
+		value := v.value
+		if !v.word {
+			value = strings.TrimRight(value, `/\`)
+		}
+		// 根目录、盘符或过短的用户名替换后会误伤无关内容
+		minLength := 3
+		if v.word {
+			minLength = minMachineWordLength
+		}
+		if len(value) < minLength || seen[value] || genericMachineValues[strings.ToLower(value)] || genericMachineValues[value] {
+			continue
+		}
+		seen[value] = true
+		set.values = append(set.values, machineValue{name: v.name, value: value, word: v.word})
+	}
+	sort.SliceStable(set.values, func(i, j int) bool {
+		if set.values[i].word != set.values[j].word {
+			return !set.values[i].word
+		}
+		return len(set.values[i].value) > len(set.values[j].value)
+	})
+	return set
+}
+
+// withEscape 返回在原始内容中按转义形式匹配与还原值的副本
+func (p *placeholderSet) withEscape(escape func(string) string) *placeholderSet {
+	return &placeholderSet{values: p.values, escape: escape}
+}
+
+// placeholderEscape 返回格式的原始内容中路径值的转义方式
+func placeholderEscape(format string) func(string) string {
+	switch strings.ToLower(format) {
+	case "json", "jsonc", "json5":
+		return func(s string) string { return strings.ReplaceAll(s, `\`, `\\`) }
+	default:
+		return func(s string) string { return s }
+	}
+}
+
+// placeholderMark 替换过程中标记占位符名称的分隔符，文本内容不会包含（isTextContent 拒绝 NUL）
+const placeholderMark = "\x00"
+
+// encode 将文本中的本机相关值替换为 ${名称}，usage 记录实际使用的占位符与位置 location
+// 原有的 $ 在其后紧跟 {、$ 或占位符时转义为 $$，使原有的 ${VAR} 在导入时不会被当作占位符还原
+func (p *placeholderSet) encode(text, location string, usage *placeholderUsage) string {
+	for _, v := range p.values {
+		mark := placeholderMark + v.name + placeholderMark
+		before := strings.Count(text, mark)
+		if v.word {
+			for _, prefix := range wordPathPrefixes(v.name) {
+				text = replaceBounded(text, p.escape(prefix+v.value), p.escape(prefix)+mark)
+			}
+		} else {
+			text = replaceBounded(text, p.escape(v.value), mark)
+		}
+		if n := strings.Count(text, mark) - before; n > 0 {
+			usage.add(v.name, v.value, location, n)
+		}
+	}
+	if !strings.Contains(text, "$") && !strings.Contains(text, placeholderMark) {
+		return text
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case placeholderMark[0]:
+			end := strings.IndexByte(text[i+1:], placeholderMark[0])
+			b.WriteString("${" + text[i+1:i+1+end] + "}")
+			i += end + 1
+		case '$':
+			b.WriteByte('$')
+			if i+1 < len(text) && (text[i+1] == '{' || text[i+1] == '$' || text[i+1] == placeholderMark[0]) {
+				b.WriteByte('$')
+			}
+		default:
+			b.WriteByte(text[i])
+		}
+	}
+	return b.String()
+}
+
+// wordPathPrefixes 返回名称类取值所在路径的前缀
+func wordPathPrefixes(name string) []string {
+	if name == "HOSTNAME" {
+		return hostPathPrefixes
+	}
+	return userPathPrefixes
+}
+
+// encodeData 替换 data 中字符串值里的本机相关值，返回副本；位置记录为顶层键
+func (p *placeholderSet) encodeData(data map[string]interface{}, usage *placeholderUsage) map[string]interface{} {
+	result := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		result[key] = mapStrings(value, func(s string) string { return p.encode(s, key, usage) })
+	}
+	return result
+}
+
+// placeholderUsage 导出时替换为占位符的值，以及每个占位符的替换位置与次数
+type placeholderUsage struct {
+	values    map[string]string         // 占位符名称 → 导出机器上的取值
+	locations map[string][]string       // 占位符名称 → 位置（配置键、文件或原始内容），按首次出现的顺序
+	counts    map[string]map[string]int // 占位符名称 → 位置 → 替换次数
+}
+
+// newPlaceholderUsage 创建空的替换记录
+func newPlaceholderUsage() *placeholderUsage {
+	return &placeholderUsage{values: make(map[string]string), locations: make(map[string][]string), counts: make(map[string]map[string]int)}
+}
+
+// add 记录在 location 中将 value 替换为 ${name} 共 n 处
+func (u *placeholderUsage) add(name, value, location string, n int) {
+	u.values[name] = value
+	if u.counts[name] == nil {
+		u.counts[name] = make(map[string]int)
+	}
+	if u.counts[name][location] == 0 {
+		u.locations[name] = append(u.locations[name], location)
+	}
+	u.counts[name][location] += n
+}
+
+// records 生成每个占位符在每个位置的替换记录，按名称排序，便于导出后逐项核对
+func (u *placeholderUsage) records() []core.MigrationRecord {
+	names := make([]string, 0, len(u.values))
+	for name := range u.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var records []core.MigrationRecord
+	for _, name := range names {
+		for _, location := range u.locations[name] {
+			records = append(records, core.MigrationRecord{
+				StepName:    fmt.Sprintf("替换为占位符 ${%s}", name),
+				ActionType:  constants.ActionTypeRewrite,
+				Key:         location,
+				BeforeValue: u.values[name],
+				AfterValue:  "${" + name + "}",
+				Status:      constants.RecordStatusSuccess,
+				Message:     fmt.Sprintf("替换 %d 处", u.counts[name][location]),
+				Timestamp:   time.Now(),
+			})
+		}
+	}
+	return records
+}
+
+// localPlaceholderValues 返回导入机器上各占位符的取值
+func localPlaceholderValues() map[string]string {
+	values := make(map[string]string)
+	for _, v := range lookupMachineValues() {
+		value := v.value
+		if !v.word {
+			value = strings.TrimRight(value, `/\`)
+		}
+		if _, exists := values[v.name]; !exists && value != "" {
+			values[v.name] = value
+		}
+	}
+	return values
+}
+
+// resolvePlaceholders 将文本中的 ${名称} 还原为 values 中的取值，没有取值的占位符保持原样
+// unescape 为 true 时（导出包记录了 PlaceholderEscape）同时将 $$ 还原为 $；否则按旧导出包的方式直接替换
+func resolvePlaceholders(text string, values map[string]string, escape func(string) string, unescape bool) string {
+	if !unescape {
+		if !strings.Contains(text, "${") {
+			return text
+		}
+		for name, value := range values {
+			text = strings.ReplaceAll(text, "${"+name+"}", escape(value))
+		}
+		return text
+	}
+	if !strings.Contains(text, "$") {
+		return text
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '$' {
+			b.WriteByte(text[i])
+			continue
+		}
+		if strings.HasPrefix(text[i:], "$$") {
+			b.WriteByte('$')
+			i++
+			continue
+		}
+		if strings.HasPrefix(text[i:], "${") {
+			if end := strings.IndexByte(text[i:], '}'); end > 0 {
+				if value, ok := values[text[i+2:i+end]]; ok {
+					b.WriteString(escape(value))
+					i += end
+					continue
+				}
+			}
+		}
+		b.WriteByte('$')
+	}
+	return b.String()
+}
+
+// mapStrings 对值中的所有字符串应用 fn，返回副本
+func mapStrings(value interface{}, fn func(string) string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = mapStrings(item, fn)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = mapStrings(item, fn)
+		}
+		return result
+	case string:
+		return fn(v)
+	default:
+		return value
+	}
+}
+
+// replaceBounded 替换 text 中的 old，要求其后不紧跟标识符字符（避免 /home/al 匹配 /home/alice）
+func replaceBounded(text, old, repl string) string {
+	if old == "" {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for start := 0; ; {
+		i := strings.Index(text[start:], old)
+		if i < 0 {
+			break
+		}
+		i += start
+		end := i + len(old)
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !isIdentRune(after) {
+			b.WriteString(text[last:i])
+			b.WriteString(repl)
+			last = end
+		}
+		start = end
+	}
+	if last == 0 {
+		return text
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// isIdentRune 判断是否为标识符字符（字母、数字、_、-、.）
+func isIdentRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.')
+}
+
+// placeholderRecords 生成占位符还原的迁移记录，按名称排序
+func placeholderRecords(stepName string, values map[string]string) []core.MigrationRecord {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	records := make([]core.MigrationRecord, 0, len(names))
+	for _, name := range names {
+		record := core.MigrationRecord{
+			StepName:    fmt.Sprintf("%s ${%s}", stepName, name),
+			ActionType:  constants.ActionTypeRewrite,
+			Key:         "${" + name + "}",
+			BeforeValue: "${" + name + "}",
+			AfterValue:  values[name],
+			Status:      constants.RecordStatusSuccess,
+			Timestamp:   time.Now(),
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// rawContentLocation 替换记录中原始内容的位置名称
+const rawContentLocation = "原始内容"
+
+// encodePackagePlaceholders 将导出包数据与原始内容中的本机相关值替换为占位符，并记录到元数据
+// 返回每个占位符在每个配置键与原始内容中的替换记录
+func encodePackagePlaceholders(pkg *core.ExportPackage) []core.MigrationRecord {
+	set := newPlaceholderSet(lookupMachineValues())
+	usage := newPlaceholderUsage()
+	data := set.encodeData(pkg.Content.Data, usage)
+	raw, hasRaw := decodeRawContent(pkg)
+	if hasRaw {
+		raw = []byte(set.withEscape(placeholderEscape(pkg.Metadata.OriginalFormat)).encode(string(raw), rawContentLocation, usage))
+	}
+
+	// 没有替换任何值时保持原样，不转义原有的 $
+	if len(usage.values) == 0 {
+		return nil
+	}
+	pkg.Content.Data = data
+	if hasRaw {
+		encodeRawContent(pkg, raw)
+	}
+	pkg.Metadata.Placeholders = usage.values
+	pkg.Metadata.PlaceholderEscape = true
+	return usage.records()
+}
+
+// resolvePackagePlaceholders 按导入机器的取值还原导出包中的占位符
+// 本机没有对应取值的占位符保持原样，并记录为跳过
+func resolvePackagePlaceholders(pkg *core.ExportPackage) []core.MigrationRecord {
+	if len(pkg.Metadata.Placeholders) == 0 {
+		return nil
+	}
+
+	resolved, records := resolvePlaceholderValues(pkg.Metadata.Placeholders)
+	identity := func(s string) string { return s }
+	if data, ok := mapStrings(pkg.Content.Data, func(s string) string {
+		return resolvePlaceholders(s, resolved, identity, pkg.Metadata.PlaceholderEscape)
+	}).(map[string]interface{}); ok {
+		pkg.Content.Data = data
+	}
+	if raw, ok := decodeRawContent(pkg); ok {
+		text := resolvePlaceholders(string(raw), resolved, placeholderEscape(pkg.Metadata.OriginalFormat), pkg.Metadata.PlaceholderEscape)
+		encodeRawContent(pkg, []byte(text))
+	}
+	return records
+}
+
+// resolvePlaceholderValues 返回导出时记录的占位符在导入机器上的取值，以及还原记录；
+// 本机没有对应取值的占位符不在返回值中，记录为跳过
+func resolvePlaceholderValues(placeholders map[string]string) (map[string]string, []core.MigrationRecord) {
+	local := localPlaceholderValues()
+	resolved := make(map[string]string)
+	var missing []string
+	for name := range placeholders {
+		if value, ok := local[name]; ok {
+			resolved[name] = value
+		} else {
+			missing = append(missing, name)
+		}
+	}
+
+	records := placeholderRecords("还原占位符", resolved)
+	sort.Strings(missing)
+	for _, name := range missing {
+		records = append(records, core.MigrationRecord{
+			StepName:    fmt.Sprintf("还原占位符 ${%s}", name),
+			ActionType:  constants.ActionTypeRewrite,
+			Key:         "${" + name + "}",
+			BeforeValue: "${" + name + "}",
+			Status:      constants.RecordStatusSkipped,
+			Message:     "导入机器上没有对应取值，保留占位符",
+			Timestamp:   time.Now(),
+		})
+	}
+	return resolved, records
+}
+
+// decodeRawContent 解码导出包中的原始内容为 UTF-8 文本，无原始内容或解码失败时返回 false
+func decodeRawContent(pkg *core.ExportPackage) ([]byte, bool) {
+	if pkg.Content.RawContent == "" {
+		return nil, false
+	}
+	raw, err := base64.StdEncoding.DecodeString(pkg.Content.RawContent)
+	if err != nil {
+		return nil, false
+	}
+	text, _, err := decodeText(raw, pkg.Metadata.OriginalEncoding)
+	if err != nil || !isTextContent(text) {
+		return nil, false
+	}
+	return text, true
+}
+
+// encodeRawContent 将 UTF-8 文本按原始编码写回导出包
+func encodeRawContent(pkg *core.ExportPackage, text []byte) {
+	encoded, err := encodeText(text, pkg.Metadata.OriginalEncoding)
+	if err != nil {
+		return
+	}
+	pkg.Content.RawContent = base64.StdEncoding.EncodeToString(encoded)
+}
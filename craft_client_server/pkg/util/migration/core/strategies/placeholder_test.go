@@ -0,0 +1,185 @@
+package strategies
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tsc/pkg/util/migration/core"
+)
+
+// stubMachineValues 在测试期间替换本机相关值
+func stubMachineValues(t *testing.T, home, user, host string) {
+	original := lookupMachineValues
+	lookupMachineValues = func() []machineValue {
+		return []machineValue{
+			{name: "HOME", value: home + "/"},
+			{name: "CONFIG_DIR", value: home + "/.config"},
+			{name: "USER", value: user, word: true},
+			{name: "HOSTNAME", value: host, word: true},
+		}
+	}
+	t.Cleanup(func() { lookupMachineValues = original })
+}
+
+func TestReplaceBounded(t *testing.T) {
+	cases := []struct {
+		text, old string
+		want      string
+	}{
+		{"/home/alice/x:/home/alice", "/home/alice", "${X}/x:${X}"},
+		{"/home/alice2/x", "/home/alice", "/home/alice2/x"},
+	}
+	for _, c := range cases {
+		if got := replaceBounded(c.text, c.old, "${X}"); got != c.want {
+			t.Errorf("replaceBounded(%q, %q) = %q, want %q", c.text, c.old, got, c.want)
+		}
+	}
+}
+
+func TestConfigFileExportImportPlaceholders(t *testing.T) {
+	dir := t.TempDir()
+	sourcePath := filepath.Join(dir, "settings.json")
+	source := "{\n  \"workspace\": \"/home/alice/work\",\n  \"plugins\": \"/home/alice/.config/app/plugins\",\n  \"author\": \"alice\",\n  \"server\": \"alice-laptop:8080\",\n  \"backup\": \"/Users/alice/backup\",\n  \"share\": \"\\\\\\\\alice-laptop\\\\docs\"\n}\n"
+	if err := os.WriteFile(sourcePath, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stubMachineValues(t, "/home/alice", "alice", "alice-laptop")
+	exportPath := filepath.Join(dir, "settings.export.json")
+	config := core.NewMigrationConfig()
+	config.Source.Path = sourcePath
+	config.Options.ExportPath = exportPath
+	config.Options.IncludeRawContent = true
+
+	strategy := &ConfigFileStrategy{}
+	result, err := strategy.Export(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	pkg := result.Package
+	// 用户名与主机名只在路径中替换，作为普通单词（作者名、URL 中的主机）保持原样
+	expected := map[string]interface{}{
+		"workspace": "${HOME}/work",
+		"plugins":   "${CONFIG_DIR}/app/plugins",
+		"author":    "alice",
+		"server":    "alice-laptop:8080",
+		"backup":    "/Users/${USER}/backup",
+		"share":     `\\${HOSTNAME}\docs`,
+	}
+	if !valuesEqual(pkg.Content.Data, expected) {
+		t.Errorf("unexpected exported data: %v", pkg.Content.Data)
+	}
+	if len(pkg.Metadata.Placeholders) != 4 || pkg.Metadata.Placeholders["HOME"] != "/home/alice" {
+		t.Errorf("unexpected placeholders: %v", pkg.Metadata.Placeholders)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(pkg.Content.RawContent)
+	if want := "{\n  \"workspace\": \"${HOME}/work\",\n  \"plugins\": \"${CONFIG_DIR}/app/plugins\",\n  \"author\": \"alice\",\n  \"server\": \"alice-laptop:8080\",\n  \"backup\": \"/Users/${USER}/backup\",\n  \"share\": \"\\\\\\\\${HOSTNAME}\\\\docs\"\n}\n"; string(raw) != want {
+		t.Errorf("unexpected raw content:\n%s", raw)
+	}
+
+	// 每个占位符在每个配置键与原始内容中的替换都有记录
+	locations := make(map[string]bool)
+	for _, record := range result.Records {
+		if record.AfterValue == "${USER}" {
+			locations[record.Key] = true
+		}
+	}
+	if len(locations) != 2 || !locations["backup"] || !locations[rawContentLocation] {
+		t.Errorf("unexpected USER substitution records: %v", locations)
+	}
+
+	// 在另一台机器上导入，主机名不可用时保留占位符
+	original := lookupMachineValues
+	lookupMachineValues = func() []machineValue {
+		return []machineValue{
+			{name: "HOME", value: `C:\Users\bob`},
+			{name: "CONFIG_DIR", value: `C:\Users\bob\AppData\Roaming`},
+			{name: "USER", value: "bob", word: true},
+		}
+	}
+	defer func() { lookupMachineValues = original }()
+
+	targetPath := filepath.Join(dir, "imported.json")
+	importConfig := core.NewMigrationConfig()
+	importConfig.Options.ImportPath = exportPath
+	importConfig.Options.PreserveFormat = true
+	importConfig.Target.Path = targetPath
+	importResult, err := strategy.Import(context.Background(), importConfig)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	out, err := os.ReadFile(targetPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"workspace\": \"C:\\\\Users\\\\bob/work\",\n  \"plugins\": \"C:\\\\Users\\\\bob\\\\AppData\\\\Roaming/app/plugins\",\n  \"author\": \"alice\",\n  \"server\": \"alice-laptop:8080\",\n  \"backup\": \"/Users/bob/backup\",\n  \"share\": \"\\\\\\\\${HOSTNAME}\\\\docs\"\n}\n"
+	if string(out) != want {
+		t.Errorf("unexpected imported content:\n%s\nexpected:\n%s", out, want)
+	}
+
+	skipped := 0
+	for _, record := range importResult.Records {
+		if record.Key == "${HOSTNAME}" && record.Status == "skipped" {
+			skipped++
+		}
+	}
+	if skipped != 1 {
+		t.Errorf("expected unresolved HOSTNAME to be recorded as skipped: %+v", importResult.Records)
+	}
+}
+
+func TestPlaceholderEscapeRoundTrip(t *testing.T) {
+	set := newPlaceholderSet([]machineValue{{name: "HOME", value: "/home/alice"}})
+	local := map[string]string{"HOME": "/home/bob"}
+	identity := func(s string) string { return s }
+	for text, want := range map[string]string{
+		// 原有的变量引用保持原样，只有导出机器上的值被还原为导入机器上的值
+		"PATH=${HOME}/bin:/home/alice/go/bin": "PATH=${HOME}/bin:/home/bob/go/bin",
+		"cd $HOME && ls $$":                   "cd $HOME && ls $$",
+		"x=$/home/alice y=$${HOME}":           "x=$/home/bob y=$${HOME}",
+		"price: $5":                           "price: $5",
+	} {
+		encoded := set.encode(text, "", newPlaceholderUsage())
+		if got := resolvePlaceholders(encoded, local, identity, true); got != want {
+			t.Errorf("round trip of %q via %q = %q, want %q", text, encoded, got, want)
+		}
+	}
+}
+
+func TestPlaceholderSetSkipsGenericValues(t *testing.T) {
+	set := newPlaceholderSet([]machineValue{
+		{name: "TEMP", value: "/tmp/"},
+		{name: "HOSTNAME", value: "localhost", word: true},
+		{name: "USER", value: "root", word: true},
+		{name: "HOME", value: "/root"},
+	})
+	usage := newPlaceholderUsage()
+	if got := set.encode("TMPDIR=/tmp user=root host=localhost dir=/root/x", "", usage); got != "TMPDIR=/tmp user=root host=localhost dir=${HOME}/x" {
+		t.Errorf("unexpected encoding: %q", got)
+	}
+	if len(usage.values) != 1 {
+		t.Errorf("unexpected placeholders: %v", usage.values)
+	}
+}
+
+func TestPlaceholderUserOnlyInPaths(t *testing.T) {
+	set := newPlaceholderSet([]machineValue{
+		{name: "HOME", value: "/home/carol"},
+		{name: "USER", value: "carol", word: true},
+		{name: "HOSTNAME", value: "dev", word: true},
+	})
+	usage := newPlaceholderUsage()
+	text := `owner=carol url=https://carol.example.com/carol key=carol.name path=/Users/carol/x win=C:\Users\carol\y host=dev`
+	want := `owner=carol url=https://carol.example.com/carol key=carol.name path=/Users/${USER}/x win=C:\Users\${USER}\y host=dev`
+	if got := set.encode(text, "settings", usage); got != want {
+		t.Errorf("unexpected encoding:\n%s\nwant:\n%s", got, want)
+	}
+	if records := usage.records(); len(records) != 1 || records[0].Key != "settings" || records[0].Message != "替换 2 处" {
+		t.Errorf("unexpected records: %+v", records)
+	}
+}
@@ -1,16 +1,21 @@
 package strategies
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
 
 	"tsc/pkg/util/migration/constants"
 	"tsc/pkg/util/migration/core"
+	"tsc/pkg/util/regfile"
+	"tsc/pkg/util/safefile"
 )
 
 func init() {
@@ -35,7 +40,7 @@ func (s *RegistryStrategy) Type() core.MigrationType {
 
 // Description 返回策略描述
 func (s *RegistryStrategy) Description() string {
-	return "迁移 Windows 注册表项，支持递归导出/导入"
+	return "迁移 Windows 注册表项，支持递归导出/导入与回滚，可读写 Wine 前缀中的注册表文件，可在任意系统上将 .reg 文件与标准导出包互相转换"
 }
 
 // Validate 验证配置是否有效
@@ -56,7 +61,102 @@ func (s *RegistryStrategy) Validate(config *core.MigrationConfig) error {
 	return nil
 }
 
-// Execute 执行注册表迁移
+// registryBackend 注册表读写后端：Windows 上通过 reg 命令读写系统注册表，指定 Wine 前缀时读写前缀中的注册表文件
+type registryBackend interface {
+	// Name 后端名称，用于提示信息
+	Name() string
+	// Export 导出项及其所有子项，项不存在时返回的错误满足 errors.Is(err, os.ErrNotExist)
+	Export(path string) (*regfile.File, error)
+	// Apply 写入 .reg 文件中的项与值，包括 [-项] 与 "值"=- 删除条目
+	Apply(file *regfile.File) error
+}
+
+// errRegistryUnavailable 非 Windows 系统且未指定 Wine 前缀时返回
+var errRegistryUnavailable = fmt.Errorf("registry access requires Windows or a Wine prefix (type %q or wine_prefix)", constants.RegistryBackendWine)
+
+// registryBackendFor 按类型与 Wine 前缀选择后端
+func (s *RegistryStrategy) registryBackendFor(backendType, winePrefix string) (registryBackend, error) {
+	if winePrefix != "" {
+		return &wineRegistry{prefix: winePrefix}, nil
+	}
+	if backendType == constants.RegistryBackendWine {
+		return &wineRegistry{prefix: defaultWinePrefix()}, nil
+	}
+	if runtime.GOOS != "windows" {
+		return nil, errRegistryUnavailable
+	}
+	return &regCommand{s: s}, nil
+}
+
+// sourceBackend 返回读取源注册表的后端
+func (s *RegistryStrategy) sourceBackend(config *core.MigrationConfig) (registryBackend, error) {
+	return s.registryBackendFor(config.Source.Type, config.Source.WinePrefix)
+}
+
+// targetBackend 返回写入目标注册表的后端
+func (s *RegistryStrategy) targetBackend(config *core.MigrationConfig) (registryBackend, error) {
+	return s.registryBackendFor(config.Target.Type, config.Target.WinePrefix)
+}
+
+// usesWine 判断源或目标是否为 Wine 前缀
+func usesWine(typ, prefix string) bool {
+	return prefix != "" || typ == constants.RegistryBackendWine
+}
+
+// registryTargetPath 返回复制的目标项路径：Target.Path 为注册表路径时使用该路径，
+// 未设置但目标为 Wine 前缀时复制到前缀中的同一路径；导出到 .reg 文件或仅读取时返回空
+func (s *RegistryStrategy) registryTargetPath(config *core.MigrationConfig) string {
+	target := config.Target.Path
+	if target == "" && usesWine(config.Target.Type, config.Target.WinePrefix) {
+		target = config.Source.Path
+	}
+	if target == "" || isRegFile(target) {
+		return ""
+	}
+	sameBackend := usesWine(config.Source.Type, config.Source.WinePrefix) == usesWine(config.Target.Type, config.Target.WinePrefix) &&
+		filepath.Clean(config.Source.WinePrefix) == filepath.Clean(config.Target.WinePrefix)
+	if sameBackend && strings.EqualFold(canonicalRegistryPath(target), canonicalRegistryPath(config.Source.Path)) {
+		return ""
+	}
+	return target
+}
+
+// registryBackupPath 返回复制前目标项的备份文件，未指定 BackupPath 时按目标项路径生成
+func registryBackupPath(config *core.MigrationConfig, target string) string {
+	if config.Target.BackupPath != "" {
+		return config.Target.BackupPath
+	}
+	return strings.NewReplacer(`\`, "_", "/", "_").Replace(canonicalRegistryPath(target)) + ".backup.reg"
+}
+
+// canonicalRegistryPath 将根键缩写替换为全称并去掉多余的分隔符，无法解析时原样返回
+func canonicalRegistryPath(path string) string {
+	rootKey, subPath, err := (&RegistryStrategy{}).parseRegistryPath(path)
+	if err != nil {
+		return path
+	}
+	subPath = strings.Trim(subPath, `\`)
+	if subPath == "" {
+		return registryRootNames[rootKey]
+	}
+	return registryRootNames[rootKey] + `\` + subPath
+}
+
+// rebaseRegFile 将 .reg 文件中 from 下的项移动到 to 下
+func rebaseRegFile(file *regfile.File, from, to string) *regfile.File {
+	from, to = canonicalRegistryPath(from), canonicalRegistryPath(to)
+	rebased := &regfile.File{Header: file.Header, Encoding: file.Encoding}
+	for _, key := range file.Keys {
+		path := canonicalRegistryPath(key.Path)
+		if regfile.IsSubKey(path, from) {
+			path = to + path[len(from):]
+		}
+		rebased.Keys = append(rebased.Keys, &regfile.Key{Path: path, Delete: key.Delete, Values: key.Values})
+	}
+	return rebased
+}
+
+// Execute 执行注册表迁移：Target.Path 为 .reg 文件时导出，为注册表路径（或目标为 Wine 前缀）时复制，否则读取并记录值
 func (s *RegistryStrategy) Execute(ctx context.Context, config *core.MigrationConfig) (*core.MigrationResult, error) {
 	result := core.NewMigrationResult(config.TaskID)
 	result.StartTime = time.Now()
@@ -65,57 +165,42 @@ func (s *RegistryStrategy) Execute(ctx context.Context, config *core.MigrationCo
 		result.Duration = result.EndTime.Sub(result.StartTime).Milliseconds()
 	}()
 
-	// 检查是否为 Windows 系统
-	if runtime.GOOS != "windows" {
+	fail := func(message string, err error) (*core.MigrationResult, error) {
 		result.Status = constants.TaskStatusFailed
-		result.Message = "注册表迁移仅支持 Windows 系统"
-		return result, fmt.Errorf("registry migration is only supported on Windows")
+		result.Message = fmt.Sprintf("%s: %v", message, err)
+		return result, err
 	}
 
-	// 获取注册表根键和子路径
-	rootKey, subPath, err := s.parseRegistryPath(config.Source.Path)
+	source, err := s.sourceBackend(config)
 	if err != nil {
-		result.Status = constants.TaskStatusFailed
-		result.Message = err.Error()
-		return result, err
+		return fail("无法访问注册表", err)
+	}
+	file, err := source.Export(config.Source.Path)
+	if err != nil {
+		return fail("读取注册表失败", err)
 	}
 
-	// 导出注册表项
-	exportPath := ""
-	if config.Target.Path != "" && config.Target.Path != config.Source.Path {
-		// 导出到文件
-		exportPath = config.Target.Path
-		if strings.HasSuffix(exportPath, ".reg") {
-			if err := s.exportRegistry(rootKey, subPath, exportPath); err != nil {
-				result.Status = constants.TaskStatusFailed
-				result.Message = fmt.Sprintf("导出注册表失败: %v", err)
-				return result, err
-			}
-			record := core.MigrationRecord{
-				StepName:   "导出注册表",
-				ActionType: constants.ActionTypeExport,
-				Key:        config.Source.Path,
-				AfterValue: exportPath,
-				Status:     constants.RecordStatusSuccess,
-				Timestamp:  time.Now(),
-			}
-			result.Records = append(result.Records, record)
-			result.Summary.Success++
-		} else {
-			// 导入到另一个注册表位置
-			if err := s.copyRegistryKey(ctx, rootKey, subPath, config.Target.Path, result); err != nil {
-				result.Status = constants.TaskStatusFailed
-				result.Message = fmt.Sprintf("复制注册表项失败: %v", err)
-				return result, err
-			}
+	if target := s.registryTargetPath(config); target != "" {
+		if err := s.copyRegistryKey(file, target, config, result); err != nil {
+			return fail("复制注册表项失败", err)
 		}
-	} else {
-		// 读取注册表值并记录
-		if err := s.readRegistryValues(ctx, rootKey, subPath, result, config); err != nil {
-			result.Status = constants.TaskStatusFailed
-			result.Message = fmt.Sprintf("读取注册表失败: %v", err)
-			return result, err
+	} else if isRegFile(config.Target.Path) {
+		if err := safefile.WriteFile(config.Target.Path, file.Marshal(), 0); err != nil {
+			return fail("导出注册表失败", err)
 		}
+		result.Records = append(result.Records, core.MigrationRecord{
+			StepName:   "导出注册表",
+			ActionType: constants.ActionTypeExport,
+			Key:        config.Source.Path,
+			AfterValue: config.Target.Path,
+			Status:     constants.RecordStatusSuccess,
+			Timestamp:  time.Now(),
+		})
+		result.Summary.Success++
+	} else {
+		records := regValueRecords(file, "读取注册表值", constants.ActionTypeExport)
+		result.Records = append(result.Records, records...)
+		result.Summary.Success += len(records)
 	}
 
 	result.Status = constants.TaskStatusCompleted
@@ -125,29 +210,34 @@ func (s *RegistryStrategy) Execute(ctx context.Context, config *core.MigrationCo
 	return result, nil
 }
 
-// Rollback 回滚注册表迁移
+// Rollback 回滚注册表迁移：复制时将备份文件写回目标，导出到 .reg 文件时删除导出的文件
 func (s *RegistryStrategy) Rollback(ctx context.Context, config *core.MigrationConfig) error {
-	// 检查是否为 Windows 系统
-	if runtime.GOOS != "windows" {
-		return fmt.Errorf("registry migration is only supported on Windows")
+	target := s.registryTargetPath(config)
+	if target == "" {
+		if isRegFile(config.Target.Path) {
+			if err := os.Remove(config.Target.Path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove exported file: %w", err)
+			}
+		}
+		return nil
 	}
 
-	// 如果有备份文件，导入恢复
-	backupPath := config.Target.BackupPath
-	if backupPath == "" {
-		backupPath = config.Source.Path + ".reg"
+	backend, err := s.targetBackend(config)
+	if err != nil {
+		return err
 	}
-
-	if _, err := exec.LookPath("reg"); err != nil {
-		return fmt.Errorf("reg command not found")
+	backupPath := registryBackupPath(config, target)
+	content, err := os.ReadFile(backupPath)
+	if err != nil {
+		return fmt.Errorf("backup file not found: %s", backupPath)
 	}
-
-	// 导入备份注册表文件
-	cmd := exec.CommandContext(ctx, "reg", "import", backupPath)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to import registry backup: %v, output: %s", err, string(output))
+	backup, err := regfile.Parse(content)
+	if err != nil {
+		return fmt.Errorf("failed to parse registry backup: %w", err)
+	}
+	if err := backend.Apply(backup); err != nil {
+		return fmt.Errorf("failed to import registry backup: %w", err)
 	}
-
 	return nil
 }
 
@@ -155,51 +245,54 @@ func (s *RegistryStrategy) Rollback(ctx context.Context, config *core.MigrationC
 func (s *RegistryStrategy) DryRun(ctx context.Context, config *core.MigrationConfig) (*core.MigrationPreview, error) {
 	preview := core.NewMigrationPreview(config.TaskID)
 
-	// 检查是否为 Windows 系统
-	if runtime.GOOS != "windows" {
-		preview.Errors = append(preview.Errors, "注册表迁移仅支持 Windows 系统")
-		return preview, nil
-	}
-
-	// 解析注册表路径
-	rootKey, subPath, err := s.parseRegistryPath(config.Source.Path)
+	source, err := s.sourceBackend(config)
 	if err != nil {
 		preview.Errors = append(preview.Errors, err.Error())
 		return preview, nil
 	}
+	if usesWine(config.Source.Type, config.Source.WinePrefix) || usesWine(config.Target.Type, config.Target.WinePrefix) {
+		preview.Warnings = append(preview.Warnings, "Wine 运行时会在退出时覆盖注册表文件，执行前请确保 wineserver 已退出（wineserver -k）")
+	}
 
 	// 查询注册表项是否存在
-	exists, err := s.registryKeyExists(rootKey, subPath)
-	if err != nil {
-		preview.Warnings = append(preview.Warnings, fmt.Sprintf("无法验证注册表项是否存在: %v", err))
-	} else if !exists {
+	file, err := source.Export(config.Source.Path)
+	if errors.Is(err, os.ErrNotExist) {
 		preview.Warnings = append(preview.Warnings, fmt.Sprintf("注册表项不存在: %s", config.Source.Path))
+	} else if err != nil {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("无法验证注册表项是否存在: %v", err))
 	}
 
-	// 添加预览变更
-	change := core.PreviewChange{
-		Key:         config.Source.Path,
-		Description: fmt.Sprintf("将迁移注册表项: %s", config.Source.Path),
-	}
-
-	if config.Target.Path != "" {
-		if strings.HasSuffix(config.Target.Path, ".reg") {
-			change.ActionType = constants.ActionTypeExport
-			change.AfterValue = config.Target.Path
-			preview.Summary.Create++
-		} else {
-			change.ActionType = constants.ActionTypeCopy
-			change.AfterValue = config.Target.Path
-			preview.Summary.Create++
-		}
-	} else {
-		change.ActionType = constants.ActionTypeExport
-		change.Description = "将读取并记录注册表值"
+	target := s.registryTargetPath(config)
+	switch {
+	case target != "" && file != nil:
+		s.previewCopy(file, target, config, preview)
+	case target != "":
+		preview.Changes = append(preview.Changes, core.PreviewChange{
+			ActionType:  constants.ActionTypeCopy,
+			Key:         config.Source.Path,
+			AfterValue:  target,
+			Description: fmt.Sprintf("将迁移注册表项: %s", config.Source.Path),
+		})
+		preview.Summary.Create++
+		preview.Summary.Total++
+	case isRegFile(config.Target.Path):
+		preview.Changes = append(preview.Changes, core.PreviewChange{
+			ActionType:  constants.ActionTypeExport,
+			Key:         config.Source.Path,
+			AfterValue:  config.Target.Path,
+			Description: fmt.Sprintf("将迁移注册表项: %s", config.Source.Path),
+		})
+		preview.Summary.Create++
+		preview.Summary.Total++
+	default:
+		preview.Changes = append(preview.Changes, core.PreviewChange{
+			ActionType:  constants.ActionTypeExport,
+			Key:         config.Source.Path,
+			Description: "将读取并记录注册表值",
+		})
+		preview.Summary.Total++
 	}
 
-	preview.Changes = append(preview.Changes, change)
-	preview.Summary.Total++
-
 	// 添加影响评估
 	if s.isHighImpactPath(config.Source.Path) {
 		preview.Summary.HighImpact++
@@ -209,6 +302,59 @@ func (s *RegistryStrategy) DryRun(ctx context.Context, config *core.MigrationCon
 	return preview, nil
 }
 
+// previewCopy 逐个值比较源与目标，生成复制的预览
+func (s *RegistryStrategy) previewCopy(file *regfile.File, target string, config *core.MigrationConfig, preview *core.MigrationPreview) {
+	backend, err := s.targetBackend(config)
+	if err != nil {
+		preview.Errors = append(preview.Errors, err.Error())
+		return
+	}
+	current, err := backend.Export(target)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("无法读取目标注册表项: %v", err))
+	}
+
+	for _, key := range rebaseRegFile(file, config.Source.Path, target).Keys {
+		var existing *regfile.Key
+		if current != nil {
+			existing = current.Key(key.Path)
+		}
+		for _, value := range key.Values {
+			name := value.Name
+			if name == "" {
+				name = regDefaultValueName
+			}
+			change := core.PreviewChange{
+				ActionType: constants.ActionTypeCreate,
+				Key:        key.Path + `\` + name,
+				AfterValue: toString(regValueData(value)),
+				Impact:     "low",
+			}
+			var before *regfile.Value
+			if existing != nil {
+				before = existing.Value(value.Name)
+			}
+			switch {
+			case before == nil:
+				change.Description = fmt.Sprintf("新建值（%s）", value.Type)
+				preview.Summary.Create++
+			case before.Type == value.Type && bytes.Equal(before.Data, value.Data):
+				continue
+			default:
+				change.ActionType = constants.ActionTypeUpdate
+				change.BeforeValue = toString(regValueData(before))
+				change.Description = fmt.Sprintf("更新值（%s）", value.Type)
+				preview.Summary.Update++
+			}
+			if s.isHighImpactPath(key.Path) {
+				change.Impact = "high"
+			}
+			preview.Changes = append(preview.Changes, change)
+			preview.Summary.Total++
+		}
+	}
+}
+
 // parseRegistryPath 解析注册表路径
 func (s *RegistryStrategy) parseRegistryPath(path string) (string, string, error) {
 	// 标准化路径格式
@@ -254,11 +400,62 @@ func (s *RegistryStrategy) isValidRegistryPath(path string) bool {
 	return err == nil
 }
 
+// regCommand 通过 reg 命令读写 Windows 注册表
+type regCommand struct {
+	s *RegistryStrategy
+}
+
+// Name 返回后端名称
+func (r *regCommand) Name() string {
+	return "Windows 注册表"
+}
+
+// Export 通过 reg export 导出到临时文件后解析
+func (r *regCommand) Export(path string) (*regfile.File, error) {
+	rootKey, subPath, err := r.s.parseRegistryPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if !r.s.registryKeyExists(rootKey, subPath) {
+		return nil, fmt.Errorf("registry key %s: %w", path, os.ErrNotExist)
+	}
+	dir, err := os.MkdirTemp("", "envcraft-reg-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	tempFile := filepath.Join(dir, "export.reg")
+	if err := r.s.exportRegistry(rootKey, subPath, tempFile); err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(tempFile)
+	if err != nil {
+		return nil, err
+	}
+	return regfile.Parse(content)
+}
+
+// Apply 写入临时 .reg 文件后通过 reg import 导入
+func (r *regCommand) Apply(file *regfile.File) error {
+	dir, err := os.MkdirTemp("", "envcraft-reg-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	tempFile := filepath.Join(dir, "import.reg")
+	if err := os.WriteFile(tempFile, file.Marshal(), 0600); err != nil {
+		return err
+	}
+	return r.s.importRegistry(tempFile)
+}
+
 // registryKeyExists 检查注册表项是否存在
-func (s *RegistryStrategy) registryKeyExists(rootKey, subPath string) (bool, error) {
-	cmd := exec.Command("reg", "query", rootKey+"\\"+subPath)
-	err := cmd.Run()
-	return err == nil, nil
+func (s *RegistryStrategy) registryKeyExists(rootKey, subPath string) bool {
+	fullPath := rootKey
+	if subPath != "" {
+		fullPath = rootKey + "\\" + subPath
+	}
+	return exec.Command("reg", "query", fullPath).Run() == nil
 }
 
 // exportRegistry 导出注册表项到文件
@@ -288,85 +485,45 @@ func (s *RegistryStrategy) importRegistry(importPath string) error {
 	return nil
 }
 
-// copyRegistryKey 复制注册表项
-func (s *RegistryStrategy) copyRegistryKey(ctx context.Context, srcRoot, srcPath, dstPath string, result *core.MigrationResult) error {
-	// 先导出到临时文件
-	tempFile := fmt.Sprintf("%s_temp.reg", srcPath)
-	tempFile = strings.ReplaceAll(tempFile, "\\", "_")
-	tempFile = fmt.Sprintf("%s\\%s", os.TempDir(), tempFile)
-
-	// 确保清理临时文件
-	defer func() {
-		exec.Command("del", tempFile).Run()
-	}()
-
-	// 导出源注册表
-	if err := s.exportRegistry(srcRoot, srcPath, tempFile); err != nil {
-		return err
-	}
-
-	// 修改文件中的路径并导入
-	// 这里简化处理，实际需要修改 .reg 文件中的路径
-	if err := s.importRegistry(tempFile); err != nil {
-		return err
-	}
-
-	record := core.MigrationRecord{
-		StepName:   "复制注册表项",
-		ActionType: constants.ActionTypeCopy,
-		Key:        srcRoot + "\\" + srcPath,
-		AfterValue: dstPath,
-		Status:     constants.RecordStatusSuccess,
-		Timestamp:  time.Now(),
-	}
-	result.Records = append(result.Records, record)
-	result.Summary.Success++
-
-	return nil
-}
-
-// readRegistryValues 读取注册表值
-func (s *RegistryStrategy) readRegistryValues(ctx context.Context, rootKey, subPath string, result *core.MigrationResult, config *core.MigrationConfig) error {
-	fullPath := rootKey
-	if subPath != "" {
-		fullPath = rootKey + "\\" + subPath
-	}
-
-	// 查询注册表项
-	cmd := exec.CommandContext(ctx, "reg", "query", fullPath, "/s")
-	output, err := cmd.Output()
+// copyRegistryKey 将源项复制到目标路径；需要备份时先导出目标项，备份文件以 [-目标项] 开头，
+// 回滚时先删除复制后的目标项再恢复原有内容
+func (s *RegistryStrategy) copyRegistryKey(file *regfile.File, target string, config *core.MigrationConfig, result *core.MigrationResult) error {
+	backend, err := s.targetBackend(config)
 	if err != nil {
-		return fmt.Errorf("query failed: %v", err)
+		return err
 	}
 
-	// 解析输出
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, fullPath) || strings.HasPrefix(line, "HKEY_") {
-			continue
+	if config.Target.Backup {
+		backup := regfile.New()
+		backup.Keys = append(backup.Keys, &regfile.Key{Path: canonicalRegistryPath(target), Delete: true})
+		current, err := backend.Export(target)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("backup target key: %w", err)
 		}
-
-		// 解析键值对
-		// 格式:    名称    类型    值
-		parts := strings.Fields(line)
-		if len(parts) >= 3 {
-			record := core.MigrationRecord{
-				StepName:   "读取注册表值",
-				ActionType: constants.ActionTypeExport,
-				Key:        parts[0],
-				AfterValue: strings.Join(parts[2:], " "),
-				Status:     constants.RecordStatusSuccess,
-				Timestamp:  time.Now(),
-			}
-			if len(parts) > 1 {
-				record.Message = fmt.Sprintf("类型: %s", parts[1])
-			}
-			result.Records = append(result.Records, record)
-			result.Summary.Success++
+		if current != nil {
+			backup.Keys = append(backup.Keys, current.Keys...)
+		}
+		backupPath := registryBackupPath(config, target)
+		if err := safefile.WriteFile(backupPath, backup.Marshal(), 0); err != nil {
+			return fmt.Errorf("write backup: %w", err)
 		}
+		result.Records = append(result.Records, core.MigrationRecord{
+			StepName:   "备份注册表项",
+			ActionType: constants.ActionTypeCopy,
+			Key:        target,
+			AfterValue: backupPath,
+			Status:     constants.RecordStatusSuccess,
+			Timestamp:  time.Now(),
+		})
+	}
+
+	rebased := rebaseRegFile(file, config.Source.Path, target)
+	if err := backend.Apply(rebased); err != nil {
+		return err
 	}
-
+	records := regValueRecords(rebased, "复制注册表值", constants.ActionTypeCopy)
+	result.Records = append(result.Records, records...)
+	result.Summary.Success += len(records)
 	return nil
 }
 
@@ -388,23 +545,3 @@ func (s *RegistryStrategy) isHighImpactPath(path string) bool {
 	}
 	return false
 }
-
-// Export 导出注册表（暂不支持）
-func (s *RegistryStrategy) Export(ctx context.Context, config *core.MigrationConfig) (*core.ExportResult, error) {
-	return nil, fmt.Errorf("export is not supported for registry strategy")
-}
-
-// Import 导入注册表（暂不支持）
-func (s *RegistryStrategy) Import(ctx context.Context, config *core.MigrationConfig) (*core.ImportResult, error) {
-	return nil, fmt.Errorf("import is not supported for registry strategy")
-}
-
-// ValidateExport 验证导出配置（暂不支持）
-func (s *RegistryStrategy) ValidateExport(config *core.MigrationConfig) error {
-	return fmt.Errorf("export is not supported for registry strategy")
-}
-
-// ValidateImport 验证导入配置（暂不支持）
-func (s *RegistryStrategy) ValidateImport(config *core.MigrationConfig) error {
-	return fmt.Errorf("import is not supported for registry strategy")
-}
@@ -0,0 +1,473 @@
+package strategies
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+	"tsc/pkg/util/regfile"
+	"tsc/pkg/util/safefile"
+)
+
+// regDefaultValueName 导出包中默认值（.reg 文件中的 @）使用的名称
+const regDefaultValueName = "@"
+
+// maxExactNumber float64 可精确表示的最大整数，超出时 QWORD 以十进制字符串导出
+const maxExactNumber = 1 << 53
+
+// regFileToPackage 将 .reg 文件转换为标准导出包
+// Data 以项路径为键，值为「值名称 → 数据」：字符串为 string，REG_MULTI_SZ 为字符串数组，
+// DWORD/QWORD 为数值，其余类型为逗号分隔的十六进制字节；删除的项或值为 null。
+// 值类型记录在 FormatSpecificData["value_types"] 中，导入时据此还原；
+// 先删除再重建的项（[-X] 之后出现 [X]）记录在 FormatSpecificData["recreated_keys"] 中，导入时先删除再写入
+func regFileToPackage(file *regfile.File, originalPath string) *core.ExportPackage {
+	pkg := core.NewExportPackage()
+	pkg.Metadata.SourceType = string(constants.MigrationTypeRegistry)
+	pkg.Metadata.OriginalFormat = "reg"
+	pkg.Metadata.OriginalPath = originalPath
+	pkg.Metadata.OriginalEncoding = file.Encoding
+	pkg.Metadata.Tags = append(pkg.Metadata.Tags, "windows", "registry")
+
+	types := make(map[string]interface{})
+	deleted := make(map[string]bool)
+	for _, key := range file.Keys {
+		if key.Delete {
+			// 删除项时一并丢弃此前写入的该项及其子项
+			for path := range pkg.Content.Data {
+				if regfile.IsSubKey(path, key.Path) {
+					delete(pkg.Content.Data, path)
+					delete(types, path)
+				}
+			}
+			pkg.Content.Data[key.Path] = nil
+			deleted[strings.ToLower(key.Path)] = true
+			continue
+		}
+		// 同一项出现多次时合并各处的值
+		values, ok := pkg.Content.Data[key.Path].(map[string]interface{})
+		valueTypes, _ := types[key.Path].(map[string]interface{})
+		if !ok {
+			for path, data := range pkg.Content.Data {
+				if data == nil && strings.EqualFold(path, key.Path) {
+					delete(pkg.Content.Data, path)
+				}
+			}
+			values = make(map[string]interface{}, len(key.Values))
+			valueTypes = make(map[string]interface{}, len(key.Values))
+		}
+		for _, value := range key.Values {
+			name := value.Name
+			if name == "" {
+				name = regDefaultValueName
+			}
+			if value.Delete {
+				values[name] = nil
+				delete(valueTypes, name)
+				continue
+			}
+			values[name] = regValueData(value)
+			valueTypes[name] = value.Type.String()
+		}
+		pkg.Content.Data[key.Path] = values
+		types[key.Path] = valueTypes
+	}
+
+	var recreated []interface{}
+	for _, path := range sortedKeys(pkg.Content.Data) {
+		if pkg.Content.Data[path] != nil && deleted[strings.ToLower(path)] {
+			recreated = append(recreated, path)
+		}
+	}
+	if len(recreated) > 0 {
+		pkg.Content.FormatSpecificData["recreated_keys"] = recreated
+	}
+	pkg.Content.FormatSpecificData["value_types"] = types
+	pkg.Content.FormatSpecificData["header"] = file.Header
+	return pkg
+}
+
+// regValueData 将注册表值转换为导出包中的数据
+func regValueData(value *regfile.Value) interface{} {
+	if s, err := value.AsString(); err == nil {
+		return s
+	}
+	if items, err := value.AsStrings(); err == nil {
+		list := make([]interface{}, len(items))
+		for i, item := range items {
+			list[i] = item
+		}
+		return list
+	}
+	if n, err := value.AsUint64(); err == nil {
+		if n > maxExactNumber {
+			return strconv.FormatUint(n, 10)
+		}
+		return n
+	}
+	return regfile.FormatHex(value.Data)
+}
+
+// packageToRegFile 将导出包还原为 .reg 文件，是 regFileToPackage 的逆过程
+func packageToRegFile(pkg *core.ExportPackage) (*regfile.File, error) {
+	file := regfile.New()
+	if header, ok := pkg.Content.FormatSpecificData["header"].(string); ok && header != "" {
+		file.Header = header
+	}
+	types, _ := pkg.Content.FormatSpecificData["value_types"].(map[string]interface{})
+
+	for _, path := range sortedKeys(pkg.Content.Data) {
+		key := file.AddKey(path)
+		values, ok := pkg.Content.Data[path].(map[string]interface{})
+		if !ok {
+			if pkg.Content.Data[path] != nil {
+				return nil, fmt.Errorf("registry key %s: values must be an object", path)
+			}
+			key.Delete = true
+			continue
+		}
+		valueTypes, _ := types[path].(map[string]interface{})
+		for _, name := range sortedKeys(values) {
+			valueName := name
+			if name == regDefaultValueName {
+				valueName = ""
+			}
+			if values[name] == nil {
+				key.Set(regfile.DeleteValue(valueName))
+				continue
+			}
+			typeName, _ := valueTypes[name].(string)
+			value, err := regDataValue(valueName, typeName, values[name])
+			if err != nil {
+				return nil, fmt.Errorf("registry value %s\\%s: %w", path, name, err)
+			}
+			key.Set(value)
+		}
+	}
+
+	// 先删除再重建的项：删除排在所有写入之前
+	var deletions []*regfile.Key
+	recreated, _ := pkg.Content.FormatSpecificData["recreated_keys"].([]interface{})
+	for _, item := range recreated {
+		path, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("recreated registry keys must be strings")
+		}
+		deletions = append(deletions, &regfile.Key{Path: path, Delete: true})
+	}
+	file.Keys = append(deletions, file.Keys...)
+	return file, nil
+}
+
+// regDataValue 按值类型将导出包中的数据还原为注册表值，未记录类型时按数据推断
+func regDataValue(name, typeName string, data interface{}) (*regfile.Value, error) {
+	valueType, ok := regfile.ParseValueType(typeName)
+	if !ok {
+		switch data.(type) {
+		case string:
+			valueType = regfile.TypeString
+		case []interface{}:
+			valueType = regfile.TypeMultiString
+		default:
+			valueType = regfile.TypeDWord
+		}
+	}
+
+	switch valueType {
+	case regfile.TypeString, regfile.TypeExpandString, regfile.TypeLink:
+		s, ok := data.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string for %s", valueType)
+		}
+		value := regfile.StringValue(name, s)
+		value.Type = valueType
+		return value, nil
+	case regfile.TypeMultiString:
+		list, ok := data.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected a list of strings for %s", valueType)
+		}
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = toString(item)
+		}
+		return regfile.MultiStringValue(name, items), nil
+	case regfile.TypeDWord, regfile.TypeDWordBigEndian, regfile.TypeQWord:
+		n, err := regNumber(data)
+		if err != nil {
+			return nil, err
+		}
+		switch valueType {
+		case regfile.TypeQWord:
+			return regfile.QWordValue(name, n), nil
+		case regfile.TypeDWordBigEndian:
+			value := regfile.DWordValue(name, 0)
+			value.Type = valueType
+			value.Data = []byte{byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+			return value, nil
+		}
+		if n > 0xFFFFFFFF {
+			return nil, fmt.Errorf("value %d overflows REG_DWORD", n)
+		}
+		return regfile.DWordValue(name, uint32(n)), nil
+	default:
+		s, ok := data.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected hex bytes for %s", valueType)
+		}
+		raw, err := regfile.ParseHex(s)
+		if err != nil {
+			return nil, err
+		}
+		value := regfile.BinaryValue(name, raw)
+		value.Type = valueType
+		return value, nil
+	}
+}
+
+// regNumber 解析导出包中的数值，JSON 解码后的数值为 float64，超大 QWORD 为十进制字符串
+func regNumber(data interface{}) (uint64, error) {
+	switch n := data.(type) {
+	case float64:
+		if n < 0 || n != float64(uint64(n)) {
+			return 0, fmt.Errorf("invalid registry number %v", n)
+		}
+		return uint64(n), nil
+	case uint64:
+		return n, nil
+	case int:
+		if n < 0 {
+			return 0, fmt.Errorf("invalid registry number %d", n)
+		}
+		return uint64(n), nil
+	case json.Number:
+		return strconv.ParseUint(n.String(), 10, 64)
+	case string:
+		return strconv.ParseUint(n, 10, 64)
+	}
+	return 0, fmt.Errorf("expected a number, got %T", data)
+}
+
+// isRegFile 判断路径是否为 .reg 文件
+func isRegFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".reg")
+}
+
+// readRegistrySource 读取要导出的注册表内容：Source.Path 为 .reg 文件时直接解析，
+// 否则通过源后端（Windows 的 reg 命令或 Wine 前缀）导出
+func (s *RegistryStrategy) readRegistrySource(config *core.MigrationConfig) (*regfile.File, error) {
+	if isRegFile(config.Source.Path) {
+		content, err := os.ReadFile(config.Source.Path)
+		if err != nil {
+			return nil, err
+		}
+		return regfile.Parse(content)
+	}
+	backend, err := s.sourceBackend(config)
+	if err != nil {
+		return nil, err
+	}
+	return backend.Export(config.Source.Path)
+}
+
+// regValueRecords 为 .reg 文件中的每个值生成记录
+func regValueRecords(file *regfile.File, stepName, actionType string) []core.MigrationRecord {
+	var records []core.MigrationRecord
+	for _, key := range file.Keys {
+		if key.Delete {
+			records = append(records, core.MigrationRecord{
+				StepName:   stepName,
+				ActionType: constants.ActionTypeDelete,
+				Key:        key.Path,
+				Status:     constants.RecordStatusSuccess,
+				Timestamp:  time.Now(),
+			})
+			continue
+		}
+		for _, value := range key.Values {
+			name := value.Name
+			if name == "" {
+				name = regDefaultValueName
+			}
+			record := core.MigrationRecord{
+				StepName:   stepName,
+				ActionType: actionType,
+				Key:        key.Path + `\` + name,
+				Status:     constants.RecordStatusSuccess,
+				Timestamp:  time.Now(),
+			}
+			if value.Delete {
+				record.ActionType = constants.ActionTypeDelete
+			} else {
+				record.AfterValue = toString(regValueData(value))
+				record.Message = fmt.Sprintf("类型: %s", value.Type)
+			}
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// Export 导出注册表为标准导出包；源为 .reg 文件时不依赖 Windows
+func (s *RegistryStrategy) Export(ctx context.Context, config *core.MigrationConfig) (*core.ExportResult, error) {
+	result := core.NewExportResult(config.TaskID)
+	result.ExportID = generateExportID()
+	defer func() {
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime).Milliseconds()
+	}()
+
+	file, err := s.readRegistrySource(config)
+	if err != nil {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("读取注册表失败: %v", err)
+		return result, err
+	}
+
+	pkg := regFileToPackage(file, config.Source.Path)
+	pkg.Metadata.ExportID = result.ExportID
+	if !config.Options.NoPlaceholders {
+		result.Records = append(result.Records, encodePackagePlaceholders(pkg)...)
+	}
+	pkg.Metadata.Checksum = calculateDataChecksum(pkg.Content.Data)
+
+	exportPath := config.Options.ExportPath
+	if exportPath == "" {
+		exportPath = "registry.export.json"
+	}
+	data, err := json.MarshalIndent(pkg, "", "  ")
+	if err == nil {
+		err = safefile.WriteFile(exportPath, data, 0)
+	}
+	if err != nil {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("写入导出文件失败: %v", err)
+		return result, err
+	}
+
+	result.Records = append(result.Records, regValueRecords(file, "导出注册表值", constants.ActionTypeExport)...)
+	result.Status = constants.TaskStatusCompleted
+	result.Message = fmt.Sprintf("成功导出 %d 个注册表项到: %s", len(file.Keys), exportPath)
+	result.ExportPath = exportPath
+	result.Package = pkg
+	return result, nil
+}
+
+// Import 导入注册表：读取导出包或 .reg 文件，Target.Path 为 .reg 文件时写入该文件，
+// 否则通过目标后端（Windows 的 reg 命令或 Wine 前缀）写入注册表
+func (s *RegistryStrategy) Import(ctx context.Context, config *core.MigrationConfig) (*core.ImportResult, error) {
+	result := core.NewImportResult(config.TaskID)
+	defer func() {
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime).Milliseconds()
+	}()
+
+	fail := func(message string, err error) (*core.ImportResult, error) {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("%s: %v", message, err)
+		return result, err
+	}
+
+	content, err := os.ReadFile(registryImportPath(config))
+	if err != nil {
+		return fail("读取导入文件失败", err)
+	}
+	file, err := s.readImportFile(content, result)
+	if err != nil {
+		return fail("解析导入文件失败", err)
+	}
+
+	if isRegFile(config.Target.Path) {
+		if err := safefile.WriteFile(config.Target.Path, file.Marshal(), 0); err != nil {
+			return fail("写入 .reg 文件失败", err)
+		}
+	} else {
+		backend, err := s.targetBackend(config)
+		if err != nil {
+			return fail("导入注册表失败", err)
+		}
+		if err := backend.Apply(file); err != nil {
+			return fail("导入注册表失败", err)
+		}
+	}
+
+	records := regValueRecords(file, "导入注册表值", constants.ActionTypeImport)
+	result.Records = append(result.Records, records...)
+	result.Summary.Total += len(records)
+	result.Summary.Success += len(records)
+	result.Status = constants.TaskStatusCompleted
+	result.Message = fmt.Sprintf("成功导入 %d 个注册表项", len(file.Keys))
+	return result, nil
+}
+
+// readImportFile 解析导入文件：导出包校验后还原占位符并转换为 .reg 文件，其余按 .reg 文件解析
+func (s *RegistryStrategy) readImportFile(content []byte, result *core.ImportResult) (*regfile.File, error) {
+	pkg, ok := parseEnvPackage(content)
+	if !ok {
+		return regfile.Parse(content)
+	}
+	if pkg.Metadata.SourceType != string(constants.MigrationTypeRegistry) {
+		return nil, fmt.Errorf("export package source type %q is not %s", pkg.Metadata.SourceType, constants.MigrationTypeRegistry)
+	}
+	if pkg.Metadata.Checksum != "" && calculateDataChecksum(pkg.Content.Data) != pkg.Metadata.Checksum {
+		return nil, fmt.Errorf("export package checksum mismatch")
+	}
+	result.Records = append(result.Records, resolvePackagePlaceholders(pkg)...)
+	result.SourcePackage = pkg
+	return packageToRegFile(pkg)
+}
+
+// registryImportPath 返回导入文件路径，未指定 ImportPath 时使用 Source.Path
+func registryImportPath(config *core.MigrationConfig) string {
+	if config.Options.ImportPath != "" {
+		return config.Options.ImportPath
+	}
+	return config.Source.Path
+}
+
+// ValidateExport 验证导出配置
+func (s *RegistryStrategy) ValidateExport(config *core.MigrationConfig) error {
+	if config == nil {
+		return fmt.Errorf("config cannot be nil")
+	}
+	if config.Source.Path == "" {
+		return fmt.Errorf("source registry path is required")
+	}
+	if isRegFile(config.Source.Path) {
+		if _, err := os.Stat(config.Source.Path); err != nil {
+			return fmt.Errorf("source .reg file is not accessible: %w", err)
+		}
+		return nil
+	}
+	if !s.isValidRegistryPath(config.Source.Path) {
+		return fmt.Errorf("invalid registry path format: %s", config.Source.Path)
+	}
+	_, err := s.sourceBackend(config)
+	return err
+}
+
+// ValidateImport 验证导入配置
+func (s *RegistryStrategy) ValidateImport(config *core.MigrationConfig) error {
+	if config == nil {
+		return fmt.Errorf("config cannot be nil")
+	}
+	importPath := registryImportPath(config)
+	if importPath == "" {
+		return fmt.Errorf("import path is required")
+	}
+	if _, err := os.Stat(importPath); os.IsNotExist(err) {
+		return fmt.Errorf("import file does not exist: %s", importPath)
+	}
+	if !isRegFile(config.Target.Path) {
+		if _, err := s.targetBackend(config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package strategies
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tsc/pkg/util/migration/core"
+	"tsc/pkg/util/regfile"
+)
+
+const sampleRegFile = `Windows Registry Editor Version 5.00
+
+[HKEY_CURRENT_USER\Software\EnvCraft]
+@="default"
+"Workspace"="C:\\Users\\alice\\workspace"
+"Count"=dword:0000001f
+"Huge"=hex(b):ff,ff,ff,ff,ff,ff,ff,ff
+"Path"=hex(2):25,00,55,00,53,00,45,00,52,00,50,00,52,00,4f,00,46,00,49,00,4c,\
+  00,45,00,25,00,00,00
+"Recent"=hex(7):61,00,00,00,62,00,00,00,00,00
+"Blob"=hex:de,ad
+"Obsolete"=-
+
+[-HKEY_CURRENT_USER\Software\EnvCraft\Legacy]
+`
+
+func TestRegFilePackageRoundTrip(t *testing.T) {
+	file, err := regfile.Parse([]byte(sampleRegFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := regFileToPackage(file, "sample.reg")
+	values := pkg.Content.Data[`HKEY_CURRENT_USER\Software\EnvCraft`].(map[string]interface{})
+	if values["@"] != "default" || values["Count"] != uint64(31) || values["Huge"] != "18446744073709551615" ||
+		values["Blob"] != "de,ad" || values["Obsolete"] != nil {
+		t.Errorf("unexpected package data: %v", values)
+	}
+	if _, ok := pkg.Content.Data[`HKEY_CURRENT_USER\Software\EnvCraft\Legacy`]; !ok {
+		t.Error("deleted key should be kept as null")
+	}
+
+	// 经过 JSON 序列化后仍能还原为相同的 .reg 内容
+	content, err := json.Marshal(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded core.ExportPackage
+	if err := json.Unmarshal(content, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := packageToRegFile(&decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := restored.Key(`HKEY_CURRENT_USER\Software\EnvCraft`)
+	for _, original := range file.Keys[0].Values {
+		value := key.Value(original.Name)
+		if value == nil || value.Type != original.Type || string(value.Data) != string(original.Data) || value.Delete != original.Delete {
+			t.Errorf("value %q not restored: %+v", original.Name, value)
+		}
+	}
+	if legacy := restored.Key(`HKEY_CURRENT_USER\Software\EnvCraft\Legacy`); legacy == nil || !legacy.Delete {
+		t.Error("deleted key not restored")
+	}
+}
+
+func TestRegistryExportImportRegFile(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "envcraft.reg")
+	file, err := regfile.Parse([]byte(sampleRegFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// regedit 导出的文件为 UTF-16LE
+	if err := os.WriteFile(source, file.Marshal(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stubMachineValues(t, "/home/alice", "alice", "alice-pc")
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = source
+	config.Options.ExportPath = filepath.Join(dir, "envcraft.export.json")
+
+	strategy := &RegistryStrategy{}
+	if err := strategy.ValidateExport(config); err != nil {
+		t.Fatalf("ValidateExport failed: %v", err)
+	}
+	result, err := strategy.Export(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	values := result.Package.Content.Data[`HKEY_CURRENT_USER\Software\EnvCraft`].(map[string]interface{})
+	if values["Workspace"] != `C:\Users\${USER}\workspace` {
+		t.Errorf("user name should be replaced by a placeholder: %v", values["Workspace"])
+	}
+
+	// 在另一台机器上导入为 .reg 文件
+	stubMachineValues(t, "/home/bob", "bob", "bob-pc")
+	importConfig := core.NewMigrationConfig()
+	importConfig.Options.ImportPath = config.Options.ExportPath
+	importConfig.Target.Path = filepath.Join(dir, "out", "envcraft.reg")
+	if err := strategy.ValidateImport(importConfig); err != nil {
+		t.Fatalf("ValidateImport failed: %v", err)
+	}
+	importResult, err := strategy.Import(context.Background(), importConfig)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if importResult.SourcePackage == nil || importResult.Summary.Success != 9 {
+		t.Errorf("unexpected import result: %+v", importResult.Summary)
+	}
+
+	out, err := os.ReadFile(importConfig.Target.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := regfile.Parse(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Encoding != regfile.EncodingUTF16LE {
+		t.Errorf("imported file should use regedit's encoding, got %s", imported.Encoding)
+	}
+	workspace, _ := imported.Key(`HKEY_CURRENT_USER\Software\EnvCraft`).Value("Workspace").AsString()
+	if workspace != `C:\Users\bob\workspace` {
+		t.Errorf("placeholder not resolved: %q", workspace)
+	}
+}
+
+func TestRegFilePackageDeleteThenRecreate(t *testing.T) {
+	file, err := regfile.Parse([]byte(`Windows Registry Editor Version 5.00
+
+[HKEY_CURRENT_USER\Software\EnvCraft\Old]
+"Stale"="x"
+
+[-HKEY_CURRENT_USER\Software\EnvCraft]
+
+[HKEY_CURRENT_USER\Software\EnvCraft]
+"Fresh"="y"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := regFileToPackage(file, "recreate.reg")
+	if _, ok := pkg.Content.Data[`HKEY_CURRENT_USER\Software\EnvCraft\Old`]; ok {
+		t.Error("subkey written before the deletion should be dropped")
+	}
+	if values, _ := pkg.Content.Data[`HKEY_CURRENT_USER\Software\EnvCraft`].(map[string]interface{}); values["Fresh"] != "y" {
+		t.Errorf("recreated key values lost: %v", pkg.Content.Data)
+	}
+
+	// 经过 JSON 序列化后仍先删除再重建
+	content, _ := json.Marshal(pkg)
+	var decoded core.ExportPackage
+	if err := json.Unmarshal(content, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := packageToRegFile(&decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restored.Keys) != 2 || !restored.Keys[0].Delete || restored.Keys[1].Delete ||
+		restored.Keys[0].Path != restored.Keys[1].Path || restored.Keys[1].Value("Fresh") == nil {
+		t.Errorf("unexpected restored keys:\n%s", restored.Text())
+	}
+}
@@ -0,0 +1,216 @@
+package strategies
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tsc/pkg/util/regfile"
+	"tsc/pkg/util/safefile"
+)
+
+// wineDefaultUserSID Wine 为当前用户使用的 SID，新建 user.reg 时写入文件头
+const wineDefaultUserSID = `S-1-5-21-0-0-0-1000`
+
+// wineHive 注册表根键在 Wine 前缀中对应的文件
+type wineHive struct {
+	file     string // 文件名，如 user.reg
+	relative string // 文件头中记录的相对位置
+	base     string // 根键在文件中对应的路径，HKEY_CLASSES_ROOT 为 Software\Classes
+}
+
+// wineHives 支持的根键（parseRegistryPath 返回的缩写）与 Wine 注册表文件的对应关系
+var wineHives = map[string]wineHive{
+	"HKLM": {file: "system.reg", relative: `\Machine`},
+	"HKCR": {file: "system.reg", relative: `\Machine`, base: `Software\Classes`},
+	"HKCU": {file: "user.reg", relative: `\User\` + wineDefaultUserSID},
+}
+
+// wineDefaultUserHive HKEY_USERS\.Default 对应的文件
+var wineDefaultUserHive = wineHive{file: "userdef.reg", relative: `\User\.Default`}
+
+// registryRootNames 根键缩写对应的全称，与 reg export 的输出一致
+var registryRootNames = map[string]string{
+	"HKLM": "HKEY_LOCAL_MACHINE",
+	"HKCU": "HKEY_CURRENT_USER",
+	"HKCR": "HKEY_CLASSES_ROOT",
+	"HKU":  "HKEY_USERS",
+	"HKCC": "HKEY_CURRENT_CONFIG",
+}
+
+// wineRegistry 读写 Wine 前缀中的注册表文件（system.reg、user.reg、userdef.reg）
+// Wine 运行时注册表由 wineserver 保存在内存中并在退出时写回文件，写入前需确保 wineserver 已退出
+type wineRegistry struct {
+	prefix string
+}
+
+// Name 返回后端名称
+func (w *wineRegistry) Name() string {
+	return "Wine 前缀 " + w.prefix
+}
+
+// locate 返回注册表路径对应的文件、文件中的相对路径，以及路径中根键部分的全称
+func (w *wineRegistry) locate(path string) (wineHive, string, string, error) {
+	rootKey, subPath, err := (&RegistryStrategy{}).parseRegistryPath(path)
+	if err != nil {
+		return wineHive{}, "", "", err
+	}
+	subPath = strings.Trim(subPath, `\`)
+	root := registryRootNames[rootKey]
+
+	if rootKey == "HKU" {
+		user, rest, _ := strings.Cut(subPath, `\`)
+		if !strings.EqualFold(user, ".Default") {
+			return wineHive{}, "", "", fmt.Errorf("wine prefix only supports HKEY_USERS\\.Default, got %s", path)
+		}
+		return wineDefaultUserHive, rest, root + `\.Default`, nil
+	}
+	hive, ok := wineHives[rootKey]
+	if !ok {
+		return wineHive{}, "", "", fmt.Errorf("wine prefix does not support registry root %s", root)
+	}
+	relative := subPath
+	if hive.base != "" {
+		relative = strings.TrimSuffix(hive.base+`\`+subPath, `\`)
+	}
+	return hive, relative, root, nil
+}
+
+// loadHive 读取并解析注册表文件，文件不存在时返回的错误满足 os.IsNotExist
+func (w *wineRegistry) loadHive(hive wineHive) (*regfile.Hive, error) {
+	data, err := os.ReadFile(filepath.Join(w.prefix, hive.file))
+	if err != nil {
+		return nil, err
+	}
+	h, err := regfile.ParseHive(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", hive.file, err)
+	}
+	return h, nil
+}
+
+// Export 导出项及其所有子项，项路径转换为以根键全称开头的完整路径
+func (w *wineRegistry) Export(path string) (*regfile.File, error) {
+	hive, relative, root, err := w.locate(path)
+	if err != nil {
+		return nil, err
+	}
+	h, err := w.loadHive(hive)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("registry key %s: %w", path, os.ErrNotExist)
+		}
+		return nil, err
+	}
+
+	file := regfile.New()
+	for _, k := range h.Keys {
+		if !regfile.IsSubKey(k.Path, relative) {
+			continue
+		}
+		key := file.AddKey(wineFullPath(root, hive.base, k.Path))
+		for _, v := range k.Values {
+			copied := *v
+			copied.Data = append([]byte(nil), v.Data...)
+			key.Values = append(key.Values, &copied)
+		}
+	}
+	if len(file.Keys) == 0 {
+		return nil, fmt.Errorf("registry key %s: %w", path, os.ErrNotExist)
+	}
+	return file, nil
+}
+
+// wineFullPath 将文件中的相对路径转换为以根键全称开头的路径
+func wineFullPath(root, base, relative string) string {
+	if base != "" {
+		relative = strings.TrimPrefix(relative[len(base):], `\`)
+	}
+	if relative == "" {
+		return root
+	}
+	return root + `\` + relative
+}
+
+// Apply 将 .reg 文件中的项与值写入对应的注册表文件，包括删除条目
+// 每个文件在锁内读取、修改并原子替换，被修改的项更新修改时间
+func (w *wineRegistry) Apply(file *regfile.File) error {
+	type pending struct {
+		hive wineHive
+		keys []*regfile.Key // Path 已转换为文件中的相对路径
+	}
+	var order []string
+	groups := make(map[string]*pending)
+	for _, key := range file.Keys {
+		hive, relative, _, err := w.locate(key.Path)
+		if err != nil {
+			return err
+		}
+		group := groups[hive.file]
+		if group == nil {
+			group = &pending{hive: hive}
+			groups[hive.file] = group
+			order = append(order, hive.file)
+		}
+		group.keys = append(group.keys, &regfile.Key{Path: relative, Delete: key.Delete, Values: key.Values})
+	}
+
+	for _, name := range order {
+		if err := w.applyHive(groups[name].hive, groups[name].keys); err != nil {
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// applyHive 修改单个注册表文件，文件不存在时新建
+func (w *wineRegistry) applyHive(hive wineHive, keys []*regfile.Key) error {
+	path := filepath.Join(w.prefix, hive.file)
+	unlock, err := safefile.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	h, err := w.loadHive(hive)
+	if os.IsNotExist(err) {
+		h, err = regfile.NewHive(hive.relative), nil
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, key := range keys {
+		if key.Delete {
+			h.DeleteKey(key.Path)
+			continue
+		}
+		k := h.AddKey(key.Path)
+		for _, v := range key.Values {
+			if v.Delete {
+				k.Remove(v.Name)
+				continue
+			}
+			copied := *v
+			copied.Data = append([]byte(nil), v.Data...)
+			k.Set(&copied)
+		}
+		k.Touch(now)
+	}
+	return safefile.WriteFileOptions(path, h.Marshal(), safefile.Options{NoLock: true})
+}
+
+// defaultWinePrefix 返回默认的 Wine 前缀：$WINEPREFIX，未设置时为 ~/.wine
+func defaultWinePrefix() string {
+	if prefix := os.Getenv("WINEPREFIX"); prefix != "" {
+		return prefix
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".wine"
+	}
+	return filepath.Join(home, ".wine")
+}
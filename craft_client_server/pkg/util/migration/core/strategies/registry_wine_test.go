@@ -0,0 +1,222 @@
+package strategies
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tsc/pkg/util/migration/core"
+	"tsc/pkg/util/regfile"
+)
+
+const sampleUserHive = `WINE REGISTRY Version 2
+;; All keys relative to \\User\\S-1-5-21-0-0-0-1000
+
+#arch=win64
+
+[Software\\Vendor\\App] 1700000000
+#time=1da1b2c3d4e5f60
+"Workspace"="C:\\users\\alice\\workspace"
+"Count"=dword:00000002
+
+[Software\\Vendor\\App\\Recent] 1700000000
+#time=1da1b2c3d4e5f60
+"1"=str(2):"%USERPROFILE%\\a.txt"
+`
+
+// newWinePrefix 创建包含 user.reg 的 Wine 前缀
+func newWinePrefix(t *testing.T, userHive string) string {
+	t.Helper()
+	prefix := t.TempDir()
+	if userHive != "" {
+		if err := os.WriteFile(filepath.Join(prefix, "user.reg"), []byte(userHive), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return prefix
+}
+
+func TestWineRegistryExport(t *testing.T) {
+	w := &wineRegistry{prefix: newWinePrefix(t, sampleUserHive)}
+
+	file, err := w.Export(`HKCU\Software\Vendor\App`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file.Keys) != 2 || file.Keys[1].Path != `HKEY_CURRENT_USER\Software\Vendor\App\Recent` {
+		t.Fatalf("unexpected keys: %+v", file.Keys)
+	}
+	if s, _ := file.Keys[0].Value("Workspace").AsString(); s != `C:\users\alice\workspace` {
+		t.Errorf("Workspace = %q", s)
+	}
+
+	if _, err := w.Export(`HKCU\Software\Missing`); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing key error = %v", err)
+	}
+	if _, err := w.Export(`HKLM\Software`); err == nil {
+		t.Error("missing system.reg should be reported")
+	}
+	if _, err := w.Export(`HKU\S-1-5-18\Software`); err == nil {
+		t.Error("unsupported HKEY_USERS subkey should be rejected")
+	}
+}
+
+func TestWineRegistryApply(t *testing.T) {
+	prefix := newWinePrefix(t, sampleUserHive)
+	w := &wineRegistry{prefix: prefix}
+
+	file := regfile.New()
+	key := file.AddKey(`HKEY_CURRENT_USER\Software\Vendor\App`)
+	key.Set(regfile.StringValue("Theme", "dark"))
+	key.Set(regfile.DeleteValue("Count"))
+	file.Keys = append(file.Keys, &regfile.Key{Path: `HKEY_CURRENT_USER\Software\Vendor\App\Recent`, Delete: true})
+	file.AddKey(`HKCR\.envcraft`).Set(regfile.StringValue("", "EnvCraft.File"))
+	if err := w.Apply(file); err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(prefix, "user.reg"))
+	user, err := regfile.ParseHive(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := user.Key(`Software\Vendor\App`)
+	if app.Value("Theme") == nil || app.Value("Count") != nil || app.Value("Workspace") == nil {
+		t.Errorf("unexpected values: %+v", app.Values)
+	}
+	if user.Key(`Software\Vendor\App\Recent`) != nil {
+		t.Error("deleted key still present")
+	}
+
+	// HKEY_CLASSES_ROOT 写入新建的 system.reg 的 Software\Classes 下
+	content, err = os.ReadFile(filepath.Join(prefix, "system.reg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	system, err := regfile.ParseHive(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if system.Relative() != `\Machine` || system.Key(`Software\Classes\.envcraft`) == nil {
+		t.Errorf("unexpected system.reg:\n%s", content)
+	}
+	exported, err := w.Export(`HKEY_CLASSES_ROOT\.envcraft`)
+	if err != nil || exported.Keys[0].Path != `HKEY_CLASSES_ROOT\.envcraft` {
+		t.Errorf("export HKCR = %+v, %v", exported, err)
+	}
+}
+
+func TestRegistryCopyBetweenWinePrefixes(t *testing.T) {
+	source := newWinePrefix(t, sampleUserHive)
+	target := newWinePrefix(t, strings.Replace(sampleUserHive, `"Count"=dword:00000002`, `"Count"=dword:00000005
+"Extra"="keep"`, 1))
+	dir := t.TempDir()
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = `HKCU\Software\Vendor\App`
+	config.Source.WinePrefix = source
+	config.Target.WinePrefix = target
+	config.Target.Path = `HKCU\Software\Vendor\Copied`
+	config.Target.Backup = true
+	config.Target.BackupPath = filepath.Join(dir, "backup.reg")
+
+	s := &RegistryStrategy{}
+	preview, err := s.DryRun(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Summary.Create != 3 || len(preview.Errors) != 0 {
+		t.Errorf("unexpected preview: %+v", preview)
+	}
+
+	if _, err := s.Execute(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	copied, err := (&wineRegistry{prefix: target}).Export(config.Target.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(copied.Keys) != 2 || copied.Keys[1].Path != `HKEY_CURRENT_USER\Software\Vendor\Copied\Recent` {
+		t.Fatalf("unexpected copied keys: %+v", copied.Keys)
+	}
+
+	// 同一路径复制到另一个前缀时合并到已有项
+	config.Target.Path = ""
+	config.Target.BackupPath = filepath.Join(dir, "app.reg")
+	preview, err = s.DryRun(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Summary.Update != 1 || preview.Summary.Total != 1 {
+		t.Errorf("unexpected preview: %+v", preview.Summary)
+	}
+	if _, err := s.Execute(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	app, _ := (&wineRegistry{prefix: target}).Export(config.Source.Path)
+	if n, _ := app.Keys[0].Value("Count").AsUint64(); n != 2 || app.Keys[0].Value("Extra") == nil {
+		t.Errorf("unexpected merged values: %+v", app.Keys[0].Values)
+	}
+
+	// 回滚恢复复制前的内容
+	if err := s.Rollback(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	app, _ = (&wineRegistry{prefix: target}).Export(config.Source.Path)
+	if n, _ := app.Keys[0].Value("Count").AsUint64(); n != 5 {
+		t.Errorf("rollback did not restore Count: %+v", app.Keys[0].Values)
+	}
+	config.Target.Path = `HKCU\Software\Vendor\Copied`
+	config.Target.BackupPath = filepath.Join(dir, "backup.reg")
+	if err := s.Rollback(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&wineRegistry{prefix: target}).Export(config.Target.Path); err == nil {
+		t.Error("rollback should remove the copied key")
+	}
+}
+
+func TestRegistryWineExport(t *testing.T) {
+	prefix := newWinePrefix(t, sampleUserHive)
+	dir := t.TempDir()
+
+	config := core.NewMigrationConfig()
+	config.Source.Type = "wine"
+	config.Source.Path = `HKEY_CURRENT_USER\Software\Vendor\App`
+	config.Options.ExportPath = filepath.Join(dir, "app.json")
+	t.Setenv("WINEPREFIX", prefix)
+
+	s := &RegistryStrategy{}
+	if err := s.ValidateExport(config); err != nil {
+		t.Fatal(err)
+	}
+	result, err := s.Export(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := result.Package.Content.Data[`HKEY_CURRENT_USER\Software\Vendor\App\Recent`]; !ok {
+		t.Errorf("unexpected package data: %v", result.Package.Content.Data)
+	}
+
+	// 导出包导入到另一个前缀
+	target := newWinePrefix(t, "")
+	importConfig := core.NewMigrationConfig()
+	importConfig.Options.ImportPath = config.Options.ExportPath
+	importConfig.Target.WinePrefix = target
+	if err := s.ValidateImport(importConfig); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Import(context.Background(), importConfig); err != nil {
+		t.Fatal(err)
+	}
+	imported, err := (&wineRegistry{prefix: target}).Export(config.Source.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := imported.Keys[0].Value("Workspace").AsString(); s != `C:\users\alice\workspace` {
+		t.Errorf("Workspace = %q", s)
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"tsc/pkg/util/migration/constants"
 	"tsc/pkg/util/migration/core"
+	"tsc/pkg/util/safefile"
 )
 
 func init() {
@@ -34,7 +35,7 @@ func (s *SoftwareStrategy) Type() core.MigrationType {
 
 // Description 返回策略描述
 func (s *SoftwareStrategy) Description() string {
-	return "迁移软件配置，包括配置目录、数据文件和注册表项"
+	return "迁移软件配置，包括配置目录、数据文件和注册表项，支持打包为带哈希清单的归档并在其他机器上恢复"
 }
 
 // Validate 验证配置是否有效
@@ -51,6 +52,22 @@ func (s *SoftwareStrategy) Validate(config *core.MigrationConfig) error {
 		return fmt.Errorf("target path is required")
 	}
 
+	if _, err := newValueRewriter(config.Rewrites); err != nil {
+		return err
+	}
+
+	if err := validateSync(config.Target); err != nil {
+		return err
+	}
+
+	if err := validateSymlinks(config.Target); err != nil {
+		return err
+	}
+
+	if _, err := loadIgnoreMatcher(config.Source); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -78,27 +95,10 @@ func (s *SoftwareStrategy) Execute(ctx context.Context, config *core.MigrationCo
 	}
 
 	// 备份目标路径（如果需要）
-	if config.Target.Backup {
-		backupPath := config.Target.BackupPath
-		if backupPath == "" {
-			backupPath = config.Target.Path + ".backup"
-		}
-		if _, err := os.Stat(config.Target.Path); err == nil {
-			if err := s.backupDirectory(config.Target.Path, backupPath); err != nil {
-				result.Warnings = append(result.Warnings, fmt.Sprintf("备份失败: %v", err))
-			} else {
-				record := core.MigrationRecord{
-					StepName:    "备份目标目录",
-					ActionType:  constants.ActionTypeCopy,
-					Key:         backupPath,
-					BeforeValue: config.Target.Path,
-					AfterValue:  backupPath,
-					Status:      constants.RecordStatusSuccess,
-					Timestamp:   time.Now(),
-				}
-				result.Records = append(result.Records, record)
-			}
-		}
+	if record, err := s.backupTarget(config); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("备份失败: %v", err))
+	} else if record != nil {
+		result.Records = append(result.Records, *record)
 	}
 
 	// 根据源类型执行迁移
@@ -132,10 +132,7 @@ func (s *SoftwareStrategy) Execute(ctx context.Context, config *core.MigrationCo
 // Rollback 回滚软件配置迁移
 func (s *SoftwareStrategy) Rollback(ctx context.Context, config *core.MigrationConfig) error {
 	// 检查备份是否存在
-	backupPath := config.Target.BackupPath
-	if backupPath == "" {
-		backupPath = config.Target.Path + ".backup"
-	}
+	backupPath := s.backupPath(config)
 
 	if _, err := os.Stat(backupPath); err != nil {
 		return fmt.Errorf("backup not found: %s", backupPath)
@@ -147,7 +144,7 @@ func (s *SoftwareStrategy) Rollback(ctx context.Context, config *core.MigrationC
 	}
 
 	// 恢复备份
-	if err := s.copyDirectory(backupPath, config.Target.Path); err != nil {
+	if err := s.backupDirectory(backupPath, config.Target.Path); err != nil {
 		return fmt.Errorf("failed to restore backup: %w", err)
 	}
 
@@ -158,6 +155,12 @@ func (s *SoftwareStrategy) Rollback(ctx context.Context, config *core.MigrationC
 func (s *SoftwareStrategy) DryRun(ctx context.Context, config *core.MigrationConfig) (*core.MigrationPreview, error) {
 	preview := core.NewMigrationPreview(config.TaskID)
 
+	// 导入模式预览归档的恢复
+	if config.Options.OperationMode == "import" {
+		s.previewImport(config, preview)
+		return preview, nil
+	}
+
 	// 检查源路径
 	sourceInfo, err := os.Stat(config.Source.Path)
 	if err != nil {
@@ -172,16 +175,35 @@ func (s *SoftwareStrategy) DryRun(ctx context.Context, config *core.MigrationCon
 		preview.Warnings = append(preview.Warnings, fmt.Sprintf("目标路径已存在，可能会覆盖: %s", config.Target.Path))
 	}
 
-	// 计算要迁移的文件数量
+	ignore, err := loadIgnoreMatcher(config.Source)
+	if err != nil {
+		preview.Errors = append(preview.Errors, err.Error())
+		return preview, nil
+	}
+
+	// 计算要迁移的文件数量，不计被忽略的路径、跳过的符号链接与特殊文件
 	var fileCount, dirCount int64
 	if sourceInfo.IsDir() {
-		filepath.Walk(config.Source.Path, func(path string, info os.FileInfo, err error) error {
+		symlinks := symlinkMode(config)
+		walkTree(config.Source.Path, symlinks == constants.SymlinkFollow, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			rel, err := filepath.Rel(config.Source.Path, path)
 			if err != nil {
 				return nil
 			}
-			if info.IsDir() {
+			if skip, err := ignore.skip(rel, info); skip {
+				return err
+			}
+			switch {
+			case info.IsDir():
 				dirCount++
-			} else {
+			case isSpecialFile(info):
+				preview.Warnings = append(preview.Warnings, fmt.Sprintf("特殊文件无法复制，将跳过: %s", rel))
+			case isSymlink(info) && symlinks != constants.SymlinkPreserve:
+				preview.Warnings = append(preview.Warnings, fmt.Sprintf("符号链接将跳过: %s", rel))
+			default:
 				fileCount++
 			}
 			return nil
@@ -190,8 +212,10 @@ func (s *SoftwareStrategy) DryRun(ctx context.Context, config *core.MigrationCon
 		fileCount = 1
 	}
 
-	// 添加预览变更
-	if fileCount > 0 {
+	// 增量或镜像同步逐个列出变化的文件
+	if sourceInfo.IsDir() && syncMode(config) != constants.SyncModeFull {
+		s.previewSync(config, ignore, preview)
+	} else if fileCount > 0 {
 		change := core.PreviewChange{
 			ActionType:  constants.ActionTypeCopy,
 			Key:         config.Source.Path,
@@ -225,13 +249,27 @@ func (s *SoftwareStrategy) DryRun(ctx context.Context, config *core.MigrationCon
 
 // migrateDirectory 迁移目录
 func (s *SoftwareStrategy) migrateDirectory(ctx context.Context, config *core.MigrationConfig, result *core.MigrationResult) error {
+	rewriter, err := newValueRewriter(config.Rewrites)
+	if err != nil {
+		return err
+	}
+
+	ignore, err := loadIgnoreMatcher(config.Source)
+	if err != nil {
+		return err
+	}
+
 	// 确保目标目录存在
 	if err := os.MkdirAll(config.Target.Path, 0755); err != nil {
 		return fmt.Errorf("failed to create target directory: %w", err)
 	}
 
+	mode := syncMode(config)
+	incremental := mode != constants.SyncModeFull
+	links := newHardLinks()
+
 	// 遍历源目录
-	return filepath.Walk(config.Source.Path, func(path string, info os.FileInfo, err error) error {
+	err = walkTree(config.Source.Path, symlinkMode(config) == constants.SymlinkFollow, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -249,6 +287,11 @@ func (s *SoftwareStrategy) migrateDirectory(ctx context.Context, config *core.Mi
 			return err
 		}
 
+		// 跳过被忽略的文件与目录
+		if skip, err := ignore.skip(relPath, info); skip {
+			return err
+		}
+
 		targetPath := filepath.Join(config.Target.Path, relPath)
 
 		record := core.MigrationRecord{
@@ -257,7 +300,30 @@ func (s *SoftwareStrategy) migrateDirectory(ctx context.Context, config *core.Mi
 			Timestamp: time.Now(),
 		}
 
+		if isSymlink(info) {
+			s.migrateSymlink(config, path, relPath, targetPath, rewriter, incremental, record, result)
+			return nil
+		}
+
+		if isSpecialFile(info) {
+			// 套接字、命名管道与设备文件无法复制
+			record.ActionType = constants.ActionTypeCopy
+			record.BeforeValue = path
+			record.Status = constants.RecordStatusSkipped
+			record.Message = fmt.Sprintf("特殊文件（%s）无法复制，已跳过", info.Mode().Type())
+			result.Summary.Skipped++
+			result.Warnings = append(result.Warnings, fmt.Sprintf("跳过特殊文件: %s", relPath))
+			result.Records = append(result.Records, record)
+			return nil
+		}
+
 		if info.IsDir() {
+			// 增量同步时已存在的目录不再记录
+			if incremental {
+				if existing, err := os.Stat(targetPath); err == nil && existing.IsDir() {
+					return nil
+				}
+			}
 			// 创建目录
 			record.ActionType = constants.ActionTypeCreate
 			if err := os.MkdirAll(targetPath, info.Mode()); err != nil {
@@ -274,19 +340,114 @@ func (s *SoftwareStrategy) migrateDirectory(ctx context.Context, config *core.Mi
 			record.BeforeValue = path
 			record.AfterValue = targetPath
 
-			if err := s.copyFile(path, targetPath); err != nil {
+			// 同一文件的其他硬链接已复制时在目标中创建硬链接
+			if first, ok := links.lookup(info); ok {
+				created, err := linkFile(first.dst, targetPath)
+				switch {
+				case err != nil:
+					record.Status = constants.RecordStatusFailed
+					record.Message = err.Error()
+					result.Summary.Failed++
+				case !created:
+					record.Status = constants.RecordStatusSkipped
+					record.Message = "硬链接未变化"
+					result.Summary.Skipped++
+				default:
+					record.Status = constants.RecordStatusSuccess
+					record.Message = fmt.Sprintf("保留硬链接，与 %s 为同一文件", first.rel)
+					result.Summary.Success++
+				}
+				result.Records = append(result.Records, record)
+				return nil
+			}
+
+			// 增量同步跳过未变化的文件
+			if incremental && s.fileUnchanged(path, targetPath, relPath, info, rewriter, config.Target.SyncCompare) {
+				record.Status = constants.RecordStatusSkipped
+				record.Message = "文件未变化"
+				result.Summary.Skipped++
+				result.Records = append(result.Records, record)
+				links.add(info, relPath, targetPath)
+				return nil
+			}
+
+			var rewrites []core.MigrationRecord
+			if rewrites, err = s.copyFileRewriting(path, targetPath, relPath, rewriter); err != nil {
 				record.Status = constants.RecordStatusFailed
 				record.Message = err.Error()
 				result.Summary.Failed++
 			} else {
 				record.Status = constants.RecordStatusSuccess
 				result.Summary.Success++
+				links.add(info, relPath, targetPath)
 			}
+			// 改写记录紧随文件的复制记录
+			result.Records = append(result.Records, record)
+			result.Records = append(result.Records, rewrites...)
+			return nil
 		}
 
 		result.Records = append(result.Records, record)
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	// 镜像同步删除目标中多余的文件
+	if mode == constants.SyncModeMirror {
+		return s.removeExtraneous(config, ignore, result)
+	}
+	return nil
+}
+
+// migrateSymlink 按符号链接处理方式迁移目录中的符号链接；follow 方式下到达这里的是无法解析或形成循环的链接
+func (s *SoftwareStrategy) migrateSymlink(config *core.MigrationConfig, path, relPath, targetPath string, rewriter *valueRewriter, incremental bool, record core.MigrationRecord, result *core.MigrationResult) {
+	record.ActionType = constants.ActionTypeCopy
+	var rewrites []core.MigrationRecord
+
+	switch symlinkMode(config) {
+	case constants.SymlinkSkip:
+		record.Status = constants.RecordStatusSkipped
+		record.Message = "按配置跳过符号链接"
+		result.Summary.Skipped++
+	case constants.SymlinkFollow:
+		record.Status = constants.RecordStatusSkipped
+		record.Message = "符号链接无法解析或形成循环，已跳过"
+		result.Summary.Skipped++
+		result.Warnings = append(result.Warnings, fmt.Sprintf("跳过符号链接: %s", relPath))
+	default:
+		link, target, records, err := symlinkTarget(config, path, relPath, rewriter)
+		record.BeforeValue = link
+		record.AfterValue = target
+		switch {
+		case err != nil:
+			record.Status = constants.RecordStatusFailed
+			record.Message = err.Error()
+			result.Summary.Failed++
+		case incremental && symlinkUnchanged(targetPath, target):
+			record.Status = constants.RecordStatusSkipped
+			record.Message = "链接未变化"
+			result.Summary.Skipped++
+		default:
+			if err := createSymlink(targetPath, target); err != nil {
+				record.Status = constants.RecordStatusFailed
+				record.Message = err.Error()
+				result.Summary.Failed++
+				break
+			}
+			record.Status = constants.RecordStatusSuccess
+			record.Message = "保留符号链接"
+			if target != link {
+				record.Message = "保留符号链接，链接目标已按目标位置改写"
+			}
+			result.Summary.Success++
+			rewrites = records
+		}
+	}
+
+	result.Records = append(result.Records, record)
+	result.Records = append(result.Records, rewrites...)
 }
 
 // migrateFile 迁移单个文件
@@ -297,6 +458,11 @@ func (s *SoftwareStrategy) migrateFile(ctx context.Context, config *core.Migrati
 		return fmt.Errorf("failed to create target directory: %w", err)
 	}
 
+	rewriter, err := newValueRewriter(config.Rewrites)
+	if err != nil {
+		return err
+	}
+
 	record := core.MigrationRecord{
 		StepName:    "迁移文件",
 		ActionType:  constants.ActionTypeCopy,
@@ -306,7 +472,8 @@ func (s *SoftwareStrategy) migrateFile(ctx context.Context, config *core.Migrati
 		Timestamp:   time.Now(),
 	}
 
-	if err := s.copyFile(config.Source.Path, config.Target.Path); err != nil {
+	rewrites, err := s.copyFileRewriting(config.Source.Path, config.Target.Path, record.Key, rewriter)
+	if err != nil {
 		record.Status = constants.RecordStatusFailed
 		record.Message = err.Error()
 		result.Summary.Failed++
@@ -316,6 +483,7 @@ func (s *SoftwareStrategy) migrateFile(ctx context.Context, config *core.Migrati
 	}
 
 	result.Records = append(result.Records, record)
+	result.Records = append(result.Records, rewrites...)
 	return nil
 }
 
@@ -351,13 +519,56 @@ func (s *SoftwareStrategy) migrateRegistry(ctx context.Context, config *core.Mig
 	return nil
 }
 
-// backupDirectory 备份目录
+// backupPath 返回目标的备份路径，未指定 BackupPath 时为目标路径加 .backup
+func (s *SoftwareStrategy) backupPath(config *core.MigrationConfig) string {
+	if config.Target.BackupPath != "" {
+		return config.Target.BackupPath
+	}
+	return config.Target.Path + ".backup"
+}
+
+// backupTarget 在 Target.Backup 为 true 且目标存在时备份目标，返回备份记录；无需备份时返回 nil
+func (s *SoftwareStrategy) backupTarget(config *core.MigrationConfig) (*core.MigrationRecord, error) {
+	if !config.Target.Backup {
+		return nil, nil
+	}
+	if _, err := os.Stat(config.Target.Path); err != nil {
+		return nil, nil
+	}
+	backupPath := s.backupPath(config)
+	if err := s.backupDirectory(config.Target.Path, backupPath); err != nil {
+		return nil, err
+	}
+	return &core.MigrationRecord{
+		StepName:    "备份目标目录",
+		ActionType:  constants.ActionTypeCopy,
+		Key:         backupPath,
+		BeforeValue: config.Target.Path,
+		AfterValue:  backupPath,
+		Status:      constants.RecordStatusSuccess,
+		Timestamp:   time.Now(),
+	}, nil
+}
+
+// backupDirectory 备份目录，src 为文件时复制文件
 func (s *SoftwareStrategy) backupDirectory(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return s.copyFile(src, dst)
+	}
 	return s.copyDirectory(src, dst)
 }
 
-// copyDirectory 复制目录
+// copyDirectory 复制目录，原样保留符号链接与硬链接，跳过特殊文件
 func (s *SoftwareStrategy) copyDirectory(src, dst string) error {
+	return s.copyTree(src, dst, newHardLinks())
+}
+
+// copyTree 递归复制目录，links 记录已复制的多链接文件
+func (s *SoftwareStrategy) copyTree(src, dst string, links *hardLinks) error {
 	// 获取源目录信息
 	info, err := os.Stat(src)
 	if err != nil {
@@ -379,53 +590,77 @@ func (s *SoftwareStrategy) copyDirectory(src, dst string) error {
 		srcPath := filepath.Join(src, entry.Name())
 		dstPath := filepath.Join(dst, entry.Name())
 
-		if entry.IsDir() {
-			if err := s.copyDirectory(srcPath, dstPath); err != nil {
+		info, err := os.Lstat(srcPath)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case info.IsDir():
+			if err := s.copyTree(srcPath, dstPath, links); err != nil {
 				return err
 			}
-		} else {
+		case isSymlink(info):
+			link, err := os.Readlink(srcPath)
+			if err != nil {
+				return err
+			}
+			if err := createSymlink(dstPath, link); err != nil {
+				return err
+			}
+		case isSpecialFile(info):
+			continue
+		default:
+			if first, ok := links.lookup(info); ok {
+				if _, err := linkFile(first.dst, dstPath); err != nil {
+					return err
+				}
+				continue
+			}
 			if err := s.copyFile(srcPath, dstPath); err != nil {
 				return err
 			}
+			links.add(info, entry.Name(), dstPath)
 		}
 	}
 
 	return nil
 }
 
-// copyFile 复制文件
+// copyFile 复制文件，保留权限与修改时间
+// 目标按源目录重建，已存在的符号链接或硬链接被替换为普通文件，而不是写入链接指向的文件
 func (s *SoftwareStrategy) copyFile(src, dst string) error {
-	sourceFile, err := os.Open(src)
-	if err != nil {
-		return err
+	return safefile.CopyFileOptions(src, dst, safefile.Options{Replace: true})
+}
+
+// copyFileRewriting 复制文件，文本文件按改写规则改写内容，返回改写记录；改写后的文件保留源文件的权限与修改时间
+// relPath 为相对源目录的路径，用于匹配规则的 KeyPattern
+func (s *SoftwareStrategy) copyFileRewriting(src, dst, relPath string, rewriter *valueRewriter) ([]core.MigrationRecord, error) {
+	if len(rewriter.rules) == 0 {
+		return nil, s.copyFile(src, dst)
 	}
-	defer sourceFile.Close()
 
-	sourceInfo, err := sourceFile.Stat()
+	content, err := os.ReadFile(src)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if !isTextContent(content) {
+		return nil, s.copyFile(src, dst)
+	}
+	rewritten, records := rewriter.rewriteFileContent(relPath, content)
+	if len(records) == 0 {
+		return nil, s.copyFile(src, dst)
 	}
 
-	destFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, sourceInfo.Mode())
+	info, err := os.Stat(src)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer destFile.Close()
-
-	buf := make([]byte, 32*1024)
-	for {
-		n, err := sourceFile.Read(buf)
-		if n > 0 {
-			if _, writeErr := destFile.Write(buf[:n]); writeErr != nil {
-				return writeErr
-			}
-		}
-		if err != nil {
-			break
-		}
+	// 保留源文件的修改时间，使增量同步能识别未变化的文件
+	if err := safefile.WriteFileOptions(dst, rewritten, safefile.Options{Perm: info.Mode().Perm(), ModTime: info.ModTime(), Replace: true}); err != nil {
+		return nil, err
 	}
-
-	return nil
+	return records, nil
 }
 
 // getImpactLevel 获取影响级别
@@ -443,23 +678,3 @@ func (s *SoftwareStrategy) getImpactLevel(path string) string {
 	}
 	return "low"
 }
-
-// Export 导出软件配置（暂不支持）
-func (s *SoftwareStrategy) Export(ctx context.Context, config *core.MigrationConfig) (*core.ExportResult, error) {
-	return nil, fmt.Errorf("export is not supported for software strategy")
-}
-
-// Import 导入软件配置（暂不支持）
-func (s *SoftwareStrategy) Import(ctx context.Context, config *core.MigrationConfig) (*core.ImportResult, error) {
-	return nil, fmt.Errorf("import is not supported for software strategy")
-}
-
-// ValidateExport 验证导出配置（暂不支持）
-func (s *SoftwareStrategy) ValidateExport(config *core.MigrationConfig) error {
-	return fmt.Errorf("export is not supported for software strategy")
-}
-
-// ValidateImport 验证导入配置（暂不支持）
-func (s *SoftwareStrategy) ValidateImport(config *core.MigrationConfig) error {
-	return fmt.Errorf("import is not supported for software strategy")
-}
@@ -0,0 +1,867 @@
+package strategies
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+	"tsc/pkg/util/safefile"
+)
+
+// 软件配置归档（zip）中的条目
+const (
+	softwareManifestName = "manifest.json" // 清单，标准导出包格式
+	softwareRegistryName = "registry.json" // 注册表导出包（配置了 registry_path 时）
+	softwareFilesDir     = "files/"        // 文件内容所在目录
+)
+
+// 软件配置归档的源类型，记录在清单的 Content.Data["source_kind"] 中
+const (
+	softwareSourceDir  = "dir"
+	softwareSourceFile = "file"
+)
+
+// softwareArchiveEntry 清单中的文件条目，记录在 Content.Data["files"] 中
+type softwareArchiveEntry struct {
+	Path    string    `json:"path"`             // 相对源目录的路径，以 / 分隔；源为单个文件时为文件名
+	Dir     bool      `json:"dir,omitempty"`    // 目录条目
+	Mode    string    `json:"mode"`             // 八进制权限，如 0644
+	ModTime time.Time `json:"mtime"`            // 修改时间
+	Size    int64     `json:"size,omitempty"`   // 归档中内容的字节数
+	SHA256  string    `json:"sha256,omitempty"` // 归档中内容的 SHA-256（文本文件为替换占位符后的内容）
+	Text    bool      `json:"text,omitempty"`   // 文本文件，导入时还原占位符并应用改写规则
+}
+
+// perm 解析条目记录的权限，无法解析时目录为 0755、文件为 0644
+func (e softwareArchiveEntry) perm() os.FileMode {
+	if n, err := strconv.ParseUint(e.Mode, 8, 32); err == nil && n != 0 {
+		return os.FileMode(n).Perm()
+	}
+	if e.Dir {
+		return 0755
+	}
+	return 0644
+}
+
+// softwareArchive 已读取并校验的软件配置归档
+type softwareArchive struct {
+	reader   *zip.ReadCloser
+	manifest *core.ExportPackage
+	kind     string
+	entries  []softwareArchiveEntry
+	files    map[string]*zip.File // 条目路径 → 归档中的文件
+	registry *zip.File            // 注册表导出包，可能为 nil
+}
+
+// Close 关闭归档
+func (a *softwareArchive) Close() error {
+	return a.reader.Close()
+}
+
+// open 打开条目在归档中的内容
+func (a *softwareArchive) open(entry softwareArchiveEntry) (io.ReadCloser, error) {
+	f, ok := a.files[entry.Path]
+	if !ok {
+		return nil, fmt.Errorf("archive entry %s is missing", entry.Path)
+	}
+	return f.Open()
+}
+
+// read 读取条目在归档中的内容，仅用于需要整体处理的文本文件
+func (a *softwareArchive) read(entry softwareArchiveEntry) ([]byte, error) {
+	rc, err := a.open(entry)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// hash 流式计算条目在归档中的内容的大小与 SHA-256
+func (a *softwareArchive) hash(entry softwareArchiveEntry) (int64, string, error) {
+	rc, err := a.open(entry)
+	if err != nil {
+		return 0, "", err
+	}
+	defer rc.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, rc)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// softwareAppInfo 由 Source.Variables 中的 app_name、app_version、app_category 生成应用信息，
+// 未设置 app_name 时使用任务名称或源路径的最后一段
+func softwareAppInfo(config *core.MigrationConfig) *core.AppInfo {
+	info := &core.AppInfo{
+		Name:     config.Source.Variables["app_name"],
+		Version:  config.Source.Variables["app_version"],
+		Category: config.Source.Variables["app_category"],
+	}
+	if info.Name == "" {
+		info.Name = config.Name
+	}
+	if info.Name == "" {
+		info.Name = filepath.Base(config.Source.Path)
+	}
+	return info
+}
+
+// softwareExportPath 返回归档路径，未指定 ExportPath 时为源路径旁的 <源路径>.zip（绝对路径，不依赖工作目录）
+func softwareExportPath(config *core.MigrationConfig) (string, error) {
+	if config.Options.ExportPath != "" {
+		return config.Options.ExportPath, nil
+	}
+	source, err := filepath.Abs(config.Source.Path)
+	if err != nil {
+		return "", err
+	}
+	return source + ".zip", nil
+}
+
+// softwareImportPath 返回归档路径，未指定 ImportPath 时使用 Source.Path
+func softwareImportPath(config *core.MigrationConfig) string {
+	if config.Options.ImportPath != "" {
+		return config.Options.ImportPath
+	}
+	return config.Source.Path
+}
+
+// collectSoftwareEntries 遍历源路径，返回条目与对应的本地路径
+// 符号链接、设备文件等非普通文件不导出，记录为跳过；被忽略的路径不导出也不记录
+func collectSoftwareEntries(source string, info os.FileInfo, ignore *ignoreMatcher) ([]softwareArchiveEntry, []string, []core.MigrationRecord, error) {
+	if !info.IsDir() {
+		entry := softwareArchiveEntry{Path: info.Name(), Mode: fmt.Sprintf("%04o", info.Mode().Perm()), ModTime: info.ModTime()}
+		return []softwareArchiveEntry{entry}, []string{source}, nil, nil
+	}
+
+	var entries []softwareArchiveEntry
+	var paths []string
+	var skipped []core.MigrationRecord
+	err := filepath.Walk(source, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		if skip, err := ignore.skip(rel, fi); skip {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !fi.IsDir() && !fi.Mode().IsRegular() {
+			skipped = append(skipped, core.MigrationRecord{
+				StepName:   fmt.Sprintf("导出 %s", rel),
+				ActionType: constants.ActionTypeExport,
+				Key:        rel,
+				Status:     constants.RecordStatusSkipped,
+				Message:    fmt.Sprintf("不支持的文件类型: %s", fi.Mode().Type()),
+				Timestamp:  time.Now(),
+			})
+			return nil
+		}
+		entries = append(entries, softwareArchiveEntry{
+			Path:    rel,
+			Dir:     fi.IsDir(),
+			Mode:    fmt.Sprintf("%04o", fi.Mode().Perm()),
+			ModTime: fi.ModTime(),
+		})
+		paths = append(paths, p)
+		return nil
+	})
+	return entries, paths, skipped, err
+}
+
+// jsonData 将值经 JSON 序列化后还原为通用结构，使校验和与导入后重新计算的结果一致
+func jsonData(v interface{}) (interface{}, error) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// sha256Hex 返回内容的 SHA-256 十六进制
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Export 将源目录或文件打包为 zip 归档：清单 manifest.json 为标准导出包，记录每个文件的哈希、权限与修改时间、
+// 应用信息与注册表路径；文本文件中的本机相关值替换为占位符；配置了 registry_path 时附带注册表导出包
+func (s *SoftwareStrategy) Export(ctx context.Context, config *core.MigrationConfig) (*core.ExportResult, error) {
+	result := core.NewExportResult(config.TaskID)
+	result.ExportID = generateExportID()
+	defer func() {
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime).Milliseconds()
+	}()
+
+	fail := func(message string, err error) (*core.ExportResult, error) {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("%s: %v", message, err)
+		return result, err
+	}
+
+	info, err := os.Stat(config.Source.Path)
+	if err != nil {
+		return fail("源路径不存在或无法访问", err)
+	}
+	ignore, err := loadIgnoreMatcher(config.Source)
+	if err != nil {
+		return fail("读取忽略规则失败", err)
+	}
+	entries, paths, skipped, err := collectSoftwareEntries(config.Source.Path, info, ignore)
+	if err != nil {
+		return fail("遍历源目录失败", err)
+	}
+	result.Records = append(result.Records, skipped...)
+
+	exportPath, err := softwareExportPath(config)
+	if err != nil {
+		return fail("确定导出路径失败", err)
+	}
+	archive, err := safefile.Create(exportPath, safefile.Options{})
+	if err != nil {
+		return fail("创建归档失败", err)
+	}
+	defer archive.Abort()
+	zw := zip.NewWriter(archive)
+
+	set := newPlaceholderSet(lookupMachineValues())
+	usage := newPlaceholderUsage()
+	for i := range entries {
+		select {
+		case <-ctx.Done():
+			return fail("导出已取消", ctx.Err())
+		default:
+		}
+		if err := s.writeArchiveEntry(zw, &entries[i], paths[i], set, usage, config.Options.NoPlaceholders); err != nil {
+			return fail(fmt.Sprintf("写入 %s 失败", entries[i].Path), err)
+		}
+		if !entries[i].Dir {
+			result.Records = append(result.Records, core.MigrationRecord{
+				StepName:   fmt.Sprintf("导出 %s", entries[i].Path),
+				ActionType: constants.ActionTypeExport,
+				Key:        entries[i].Path,
+				AfterValue: "sha256:" + entries[i].SHA256,
+				Status:     constants.RecordStatusSuccess,
+				Timestamp:  time.Now(),
+			})
+		}
+	}
+
+	pkg := core.NewExportPackage()
+	pkg.Metadata.ExportID = result.ExportID
+	pkg.Metadata.SourceType = string(constants.MigrationTypeSoftware)
+	pkg.Metadata.OriginalFormat = "zip"
+	pkg.Metadata.OriginalPath = config.Source.Path
+	pkg.Metadata.AppInfo = softwareAppInfo(config)
+	pkg.Metadata.Tags = append(pkg.Metadata.Tags, "software")
+	pkg.Metadata.Description = config.Name
+
+	kind := softwareSourceFile
+	if info.IsDir() {
+		kind = softwareSourceDir
+	}
+	files, err := jsonData(entries)
+	if err != nil {
+		return fail("生成清单失败", err)
+	}
+	pkg.Content.Data["source_kind"] = kind
+	pkg.Content.Data["files"] = files
+
+	if registryPath := config.Source.Variables["registry_path"]; registryPath != "" {
+		pkg.Content.Data["registry_path"] = registryPath
+		record := s.exportRegistryPackage(zw, config, registryPath, result.ExportID)
+		result.Records = append(result.Records, record)
+	}
+
+	// 文本文件中原有的 $ 已按占位符规则转义
+	pkg.Metadata.PlaceholderEscape = !config.Options.NoPlaceholders
+	if len(usage.values) > 0 {
+		pkg.Metadata.Placeholders = usage.values
+		result.Records = append(result.Records, usage.records()...)
+	}
+	pkg.Metadata.Checksum = calculateDataChecksum(pkg.Content.Data)
+
+	manifest, err := json.MarshalIndent(pkg, "", "  ")
+	if err == nil {
+		err = writeZipEntry(zw, softwareManifestName, manifest, 0644, time.Now())
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		err = archive.Commit()
+	}
+	if err != nil {
+		return fail("写入归档失败", err)
+	}
+
+	result.Status = constants.TaskStatusCompleted
+	result.Message = fmt.Sprintf("成功导出软件配置 %s 到: %s，共 %d 项", pkg.Metadata.AppInfo.Name, exportPath, len(entries))
+	result.ExportPath = exportPath
+	result.Package = pkg
+	return result, nil
+}
+
+// placeholderSizeLimit 替换占位符的文本文件大小上限；更大的文件按二进制文件原样流式写入
+const placeholderSizeLimit = 8 << 20
+
+// writeArchiveEntry 写入一个条目并补全其大小与哈希
+// 文本文件整体读入后替换本机相关值；其余文件边读边写入归档并计算哈希，不整体读入内存
+func (s *SoftwareStrategy) writeArchiveEntry(zw *zip.Writer, entry *softwareArchiveEntry, localPath string, set *placeholderSet, usage *placeholderUsage, noPlaceholders bool) error {
+	name := softwareFilesDir + entry.Path
+	if entry.Dir {
+		return writeZipEntry(zw, name+"/", nil, os.ModeDir|entry.perm(), entry.ModTime)
+	}
+
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	reader := bufio.NewReaderSize(f, 8192)
+	head, err := reader.Peek(8192)
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	if info.Size() <= placeholderSizeLimit && isTextContent(head) {
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		entry.Text = true
+		if !noPlaceholders {
+			content = []byte(set.encode(string(content), entry.Path, usage))
+		}
+		entry.Size = int64(len(content))
+		entry.SHA256 = sha256Hex(content)
+		return writeZipEntry(zw, name, content, entry.perm(), entry.ModTime)
+	}
+
+	w, err := createZipEntry(zw, name, entry.perm(), entry.ModTime)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	if entry.Size, err = io.Copy(io.MultiWriter(w, h), reader); err != nil {
+		return err
+	}
+	entry.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return nil
+}
+
+// writeZipEntry 写入 zip 条目，保留权限与修改时间
+func writeZipEntry(zw *zip.Writer, name string, content []byte, mode os.FileMode, modTime time.Time) error {
+	w, err := createZipEntry(zw, name, mode, modTime)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// createZipEntry 创建 zip 条目并返回其写入端，保留权限与修改时间
+func createZipEntry(zw *zip.Writer, name string, mode os.FileMode, modTime time.Time) (io.Writer, error) {
+	header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modTime}
+	if mode.IsDir() {
+		header.Method = zip.Store
+	}
+	header.SetMode(mode)
+	return zw.CreateHeader(header)
+}
+
+// exportRegistryPackage 导出注册表项并写入归档，失败时记录为跳过，不影响文件导出
+func (s *SoftwareStrategy) exportRegistryPackage(zw *zip.Writer, config *core.MigrationConfig, registryPath, exportID string) core.MigrationRecord {
+	record := core.MigrationRecord{
+		StepName:   "导出注册表",
+		ActionType: constants.ActionTypeExport,
+		Key:        registryPath,
+		AfterValue: softwareRegistryName,
+		Status:     constants.RecordStatusSuccess,
+		Timestamp:  time.Now(),
+	}
+
+	registryConfig := &core.MigrationConfig{
+		TaskID: config.TaskID,
+		Type:   constants.MigrationTypeRegistry,
+		Source: core.MigrationSource{Type: config.Source.Type, Path: registryPath, WinePrefix: config.Source.WinePrefix},
+	}
+	file, err := (&RegistryStrategy{}).readRegistrySource(registryConfig)
+	if err == nil {
+		pkg := regFileToPackage(file, registryPath)
+		pkg.Metadata.ExportID = exportID
+		if !config.Options.NoPlaceholders {
+			encodePackagePlaceholders(pkg)
+		}
+		pkg.Metadata.Checksum = calculateDataChecksum(pkg.Content.Data)
+		var content []byte
+		if content, err = json.MarshalIndent(pkg, "", "  "); err == nil {
+			err = writeZipEntry(zw, softwareRegistryName, content, 0644, time.Now())
+		}
+	}
+	if err != nil {
+		record.Status = constants.RecordStatusSkipped
+		record.AfterValue = ""
+		record.Message = fmt.Sprintf("注册表导出失败: %v", err)
+	}
+	return record
+}
+
+// openSoftwareArchive 打开归档并校验清单的校验和与每个文件的哈希
+func openSoftwareArchive(archivePath string) (*softwareArchive, error) {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	a := &softwareArchive{reader: reader, files: make(map[string]*zip.File)}
+	if err := a.load(); err != nil {
+		reader.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+// load 解析清单并校验归档内容
+func (a *softwareArchive) load() error {
+	var manifest *zip.File
+	for _, f := range a.reader.File {
+		switch {
+		case f.Name == softwareManifestName:
+			manifest = f
+		case f.Name == softwareRegistryName:
+			a.registry = f
+		case strings.HasPrefix(f.Name, softwareFilesDir):
+			a.files[strings.TrimSuffix(strings.TrimPrefix(f.Name, softwareFilesDir), "/")] = f
+		}
+	}
+	if manifest == nil {
+		return fmt.Errorf("archive has no %s", softwareManifestName)
+	}
+
+	rc, err := manifest.Open()
+	if err != nil {
+		return err
+	}
+	content, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return err
+	}
+	pkg, ok := parseEnvPackage(content)
+	if !ok {
+		return fmt.Errorf("invalid %s", softwareManifestName)
+	}
+	if pkg.Metadata.SourceType != string(constants.MigrationTypeSoftware) {
+		return fmt.Errorf("export package source type %q is not %s", pkg.Metadata.SourceType, constants.MigrationTypeSoftware)
+	}
+	if pkg.Metadata.Checksum != "" && calculateDataChecksum(pkg.Content.Data) != pkg.Metadata.Checksum {
+		return fmt.Errorf("manifest checksum mismatch")
+	}
+	a.manifest = pkg
+	a.kind, _ = pkg.Content.Data["source_kind"].(string)
+
+	files, err := json.Marshal(pkg.Content.Data["files"])
+	if err == nil {
+		err = json.Unmarshal(files, &a.entries)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid file list in manifest: %w", err)
+	}
+
+	for _, entry := range a.entries {
+		if !validArchivePath(entry.Path) {
+			return fmt.Errorf("unsafe path in manifest: %s", entry.Path)
+		}
+		if entry.Dir {
+			continue
+		}
+		size, sum, err := a.hash(entry)
+		if err != nil {
+			return err
+		}
+		if size != entry.Size || sum != entry.SHA256 {
+			return fmt.Errorf("hash mismatch for %s", entry.Path)
+		}
+	}
+	return nil
+}
+
+// validArchivePath 判断清单中的路径是否为不含 .. 的相对路径，避免写到目标目录之外
+func validArchivePath(p string) bool {
+	if p == "" || strings.HasPrefix(p, "/") || strings.Contains(p, `\`) || filepath.IsAbs(p) {
+		return false
+	}
+	for _, part := range strings.Split(p, "/") {
+		if part == ".." {
+			return false
+		}
+	}
+	return path.Clean(p) == p
+}
+
+// targetPath 返回条目的写入路径：源为单个文件时为 Target.Path 本身
+func (a *softwareArchive) targetPath(config *core.MigrationConfig, entry softwareArchiveEntry) string {
+	if a.kind == softwareSourceFile {
+		return config.Target.Path
+	}
+	return filepath.Join(config.Target.Path, filepath.FromSlash(entry.Path))
+}
+
+// softwareImportAction 按合并模式判断条目的处理方式，返回操作类型（为空表示跳过）与原因
+// overwrite（默认）覆盖已有文件；merge 仅在归档中的文件较新时覆盖；skip 保留已有文件
+func softwareImportAction(mergeMode string, entry softwareArchiveEntry, target string) (string, string) {
+	info, err := os.Stat(target)
+	if err != nil {
+		return constants.ActionTypeCreate, ""
+	}
+	if entry.Dir {
+		return "", "目录已存在"
+	}
+	switch mergeMode {
+	case "skip":
+		return "", "目标文件已存在"
+	case "merge":
+		if !entry.ModTime.After(info.ModTime()) {
+			return "", "目标文件不比归档中的旧"
+		}
+	}
+	return constants.ActionTypeUpdate, ""
+}
+
+// restore 将条目写入 target：文本文件还原占位符并应用改写规则后写入，其余文件从归档流式写入
+func (a *softwareArchive) restore(entry softwareArchiveEntry, target string, resolved map[string]string, rewriter *valueRewriter) ([]core.MigrationRecord, error) {
+	opts := safefile.Options{Perm: entry.perm(), ModTime: entry.ModTime, Replace: true}
+	if entry.Text {
+		content, err := a.read(entry)
+		if err != nil {
+			return nil, err
+		}
+		content, rewrites := restoreContent(entry, content, resolved, a.manifest.Metadata.PlaceholderEscape, rewriter)
+		return rewrites, safefile.WriteFileOptions(target, content, opts)
+	}
+	rc, err := a.open(entry)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	_, err = safefile.Write(target, rc, opts)
+	return nil, err
+}
+
+// restoredHash 返回条目写入目标后内容的 SHA-256；非文本文件写入的内容与归档一致，直接使用清单中的哈希
+func (a *softwareArchive) restoredHash(entry softwareArchiveEntry, resolved map[string]string, rewriter *valueRewriter) (string, error) {
+	if !entry.Text {
+		return entry.SHA256, nil
+	}
+	content, err := a.read(entry)
+	if err != nil {
+		return "", err
+	}
+	content, _ = restoreContent(entry, content, resolved, a.manifest.Metadata.PlaceholderEscape, rewriter)
+	return sha256Hex(content), nil
+}
+
+// restoreContent 返回写入目标的内容：文本文件还原占位符并应用改写规则
+func restoreContent(entry softwareArchiveEntry, content []byte, resolved map[string]string, unescape bool, rewriter *valueRewriter) ([]byte, []core.MigrationRecord) {
+	if !entry.Text {
+		return content, nil
+	}
+	content = []byte(resolvePlaceholders(string(content), resolved, func(s string) string { return s }, unescape))
+	if len(rewriter.rules) == 0 {
+		return content, nil
+	}
+	return rewriter.rewriteFileContent(entry.Path, content)
+}
+
+// Import 将归档恢复到 Target.Path：先校验清单与文件哈希，按 Target.MergeMode 写入，
+// Target.Backup 为 true 时先备份目标，归档附带注册表导出包时一并导入注册表
+func (s *SoftwareStrategy) Import(ctx context.Context, config *core.MigrationConfig) (*core.ImportResult, error) {
+	result := core.NewImportResult(config.TaskID)
+	defer func() {
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime).Milliseconds()
+	}()
+
+	fail := func(message string, err error) (*core.ImportResult, error) {
+		result.Status = constants.TaskStatusFailed
+		result.Message = fmt.Sprintf("%s: %v", message, err)
+		return result, err
+	}
+
+	rewriter, err := newValueRewriter(config.Rewrites)
+	if err != nil {
+		return fail("改写规则无效", err)
+	}
+	archive, err := openSoftwareArchive(softwareImportPath(config))
+	if err != nil {
+		return fail("读取归档失败", err)
+	}
+	defer archive.Close()
+	result.SourcePackage = archive.manifest
+
+	if record, err := s.backupTarget(config); err != nil {
+		return fail("备份失败", err)
+	} else if record != nil {
+		result.Records = append(result.Records, *record)
+	}
+
+	resolved, placeholderRecords := resolvePlaceholderValues(archive.manifest.Metadata.Placeholders)
+	result.Records = append(result.Records, placeholderRecords...)
+
+	for _, entry := range archive.entries {
+		select {
+		case <-ctx.Done():
+			return fail("导入已取消", ctx.Err())
+		default:
+		}
+		target := archive.targetPath(config, entry)
+		record := core.MigrationRecord{
+			StepName:   fmt.Sprintf("导入 %s", entry.Path),
+			Key:        entry.Path,
+			AfterValue: target,
+			Timestamp:  time.Now(),
+		}
+		action, reason := softwareImportAction(config.Target.MergeMode, entry, target)
+		if action == "" {
+			if !entry.Dir {
+				record.ActionType = constants.ActionTypeImport
+				record.Status = constants.RecordStatusSkipped
+				record.Message = reason
+				result.Records = append(result.Records, record)
+				result.Summary.Skipped++
+			}
+			continue
+		}
+		record.ActionType = action
+
+		var rewrites []core.MigrationRecord
+		if entry.Dir {
+			err = os.MkdirAll(target, entry.perm())
+		} else {
+			rewrites, err = archive.restore(entry, target, resolved, rewriter)
+		}
+		if err != nil {
+			record.Status = constants.RecordStatusFailed
+			record.Message = err.Error()
+			result.Summary.Failed++
+			if config.Options.StopOnError {
+				result.Records = append(result.Records, record)
+				return fail(fmt.Sprintf("导入 %s 失败", entry.Path), err)
+			}
+		} else {
+			record.Status = constants.RecordStatusSuccess
+			result.Summary.Success++
+		}
+		result.Records = append(result.Records, record)
+		result.Records = append(result.Records, rewrites...)
+	}
+
+	// 目录的修改时间在写入其中的文件后才能恢复
+	if archive.kind == softwareSourceDir {
+		for i := len(archive.entries) - 1; i >= 0; i-- {
+			if entry := archive.entries[i]; entry.Dir {
+				os.Chtimes(archive.targetPath(config, entry), entry.ModTime, entry.ModTime)
+			}
+		}
+	}
+
+	if archive.registry != nil {
+		result.Records = append(result.Records, s.importRegistryPackage(archive, config, result))
+	}
+
+	result.Summary.Total = result.Summary.Success + result.Summary.Failed + result.Summary.Skipped
+	result.Status = constants.TaskStatusCompleted
+	if result.Summary.Failed > 0 {
+		result.Status = constants.TaskStatusFailed
+	}
+	name := filepath.Base(config.Target.Path)
+	if info := archive.manifest.Metadata.AppInfo; info != nil && info.Name != "" {
+		name = info.Name
+	}
+	result.Message = fmt.Sprintf("导入软件配置 %s：成功 %d 项，跳过 %d 项，失败 %d 项",
+		name, result.Summary.Success, result.Summary.Skipped, result.Summary.Failed)
+	return result, nil
+}
+
+// importRegistryPackage 导入归档中的注册表导出包，失败时记录为失败，不影响已恢复的文件
+func (s *SoftwareStrategy) importRegistryPackage(archive *softwareArchive, config *core.MigrationConfig, result *core.ImportResult) core.MigrationRecord {
+	registryPath, _ := archive.manifest.Content.Data["registry_path"].(string)
+	record := core.MigrationRecord{
+		StepName:   "导入注册表",
+		ActionType: constants.ActionTypeImport,
+		Key:        registryPath,
+		Status:     constants.RecordStatusSuccess,
+		Timestamp:  time.Now(),
+	}
+
+	registry := &RegistryStrategy{}
+	registryConfig := &core.MigrationConfig{
+		TaskID: config.TaskID,
+		Type:   constants.MigrationTypeRegistry,
+		Target: core.MigrationTarget{Type: config.Target.Type, WinePrefix: config.Target.WinePrefix},
+	}
+	rc, err := archive.registry.Open()
+	if err == nil {
+		var content []byte
+		content, err = io.ReadAll(rc)
+		rc.Close()
+		if err == nil {
+			importResult := core.NewImportResult(config.TaskID)
+			file, parseErr := registry.readImportFile(content, importResult)
+			err = parseErr
+			if err == nil {
+				var backend registryBackend
+				if backend, err = registry.targetBackend(registryConfig); err == nil {
+					err = backend.Apply(file)
+				}
+			}
+		}
+	}
+	if err != nil {
+		record.Status = constants.RecordStatusFailed
+		record.Message = fmt.Sprintf("注册表导入失败: %v", err)
+		result.Summary.Failed++
+		return record
+	}
+	result.Summary.Success++
+	return record
+}
+
+// previewImport 预览归档导入：校验归档，并按合并模式列出将创建、更新的文件
+func (s *SoftwareStrategy) previewImport(config *core.MigrationConfig, preview *core.MigrationPreview) {
+	archive, err := openSoftwareArchive(softwareImportPath(config))
+	if err != nil {
+		preview.Errors = append(preview.Errors, fmt.Sprintf("归档无效: %v", err))
+		return
+	}
+	defer archive.Close()
+
+	resolved := make(map[string]string)
+	for name, value := range localPlaceholderValues() {
+		if _, ok := archive.manifest.Metadata.Placeholders[name]; ok {
+			resolved[name] = value
+		}
+	}
+	rewriter, err := newValueRewriter(config.Rewrites)
+	if err != nil {
+		preview.Errors = append(preview.Errors, err.Error())
+		return
+	}
+
+	for _, entry := range archive.entries {
+		if entry.Dir {
+			continue
+		}
+		target := archive.targetPath(config, entry)
+		action, _ := softwareImportAction(config.Target.MergeMode, entry, target)
+		if action == "" {
+			continue
+		}
+		change := core.PreviewChange{
+			ActionType:  action,
+			Key:         entry.Path,
+			AfterValue:  target,
+			Impact:      s.getImpactLevel(entry.Path),
+			Description: fmt.Sprintf("将写入 %d 字节", entry.Size),
+		}
+		if action == constants.ActionTypeUpdate {
+			// 内容与目标一致的文件不列出
+			if sum, err := archive.restoredHash(entry, resolved, rewriter); err == nil {
+				if current, err := fileHash(target); err == nil && hex.EncodeToString(current) == sum {
+					continue
+				}
+			}
+			change.BeforeValue = "已存在"
+			preview.Summary.Update++
+		} else {
+			preview.Summary.Create++
+		}
+		if change.Impact == "high" {
+			preview.Summary.HighImpact++
+		}
+		preview.Changes = append(preview.Changes, change)
+		preview.Summary.Total++
+	}
+
+	if archive.registry != nil {
+		registryPath, _ := archive.manifest.Content.Data["registry_path"].(string)
+		preview.Changes = append(preview.Changes, core.PreviewChange{
+			ActionType:  constants.ActionTypeImport,
+			Key:         registryPath,
+			Description: fmt.Sprintf("将导入注册表项: %s", registryPath),
+			Impact:      "medium",
+		})
+		preview.Summary.Total++
+	}
+	if config.Target.Backup {
+		if _, err := os.Stat(config.Target.Path); err == nil {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("将先备份目标到: %s", s.backupPath(config)))
+		}
+	}
+}
+
+// ValidateExport 验证导出配置
+func (s *SoftwareStrategy) ValidateExport(config *core.MigrationConfig) error {
+	if config == nil {
+		return fmt.Errorf("config cannot be nil")
+	}
+	if config.Source.Path == "" {
+		return fmt.Errorf("source path is required")
+	}
+	if _, err := os.Stat(config.Source.Path); err != nil {
+		return fmt.Errorf("source path is not accessible: %w", err)
+	}
+	if _, err := loadIgnoreMatcher(config.Source); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ValidateImport 验证导入配置
+func (s *SoftwareStrategy) ValidateImport(config *core.MigrationConfig) error {
+	if config == nil {
+		return fmt.Errorf("config cannot be nil")
+	}
+	importPath := softwareImportPath(config)
+	if importPath == "" {
+		return fmt.Errorf("import path is required")
+	}
+	if _, err := os.Stat(importPath); os.IsNotExist(err) {
+		return fmt.Errorf("import file does not exist: %s", importPath)
+	}
+	if config.Target.Path == "" {
+		return fmt.Errorf("target path is required")
+	}
+	if _, err := newValueRewriter(config.Rewrites); err != nil {
+		return err
+	}
+	return nil
+}
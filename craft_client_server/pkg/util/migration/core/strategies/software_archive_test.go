@@ -0,0 +1,273 @@
+package strategies
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tsc/pkg/util/migration/core"
+)
+
+// exportSoftwareArchive 将 source 导出为归档，返回归档路径
+func exportSoftwareArchive(t *testing.T, source string, variables map[string]string) (string, *core.ExportResult) {
+	t.Helper()
+	config := core.NewMigrationConfig()
+	config.Source.Path = source
+	for name, value := range variables {
+		config.Source.Variables[name] = value
+	}
+	config.Options.ExportPath = filepath.Join(t.TempDir(), "profile.zip")
+
+	s := &SoftwareStrategy{}
+	if err := s.ValidateExport(config); err != nil {
+		t.Fatal(err)
+	}
+	result, err := s.Export(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config.Options.ExportPath, result
+}
+
+func TestSoftwareArchiveRoundTrip(t *testing.T) {
+	stubMachineValues(t, "/home/alice", "alice", "alice-laptop")
+	source := t.TempDir()
+	writeTree(t, source, map[string]string{
+		"settings.json":     `{"workspace": "/home/alice/projects"}`,
+		"plugins/list.txt":  "a\nb\n",
+		"cache/index.bin":   "\x00\x01/home/alice",
+		"scripts/launch.sh": "#!/bin/sh\n",
+	})
+	os.Chmod(filepath.Join(source, "scripts/launch.sh"), 0755)
+	mtime := time.Date(2024, 5, 1, 8, 30, 0, 123, time.UTC)
+	os.Chtimes(filepath.Join(source, "settings.json"), mtime, mtime)
+
+	archive, result := exportSoftwareArchive(t, source, map[string]string{"app_name": "Demo", "app_version": "1.2"})
+	pkg := result.Package
+	if pkg.Metadata.AppInfo.Name != "Demo" || pkg.Metadata.AppInfo.Version != "1.2" || pkg.Metadata.Placeholders["HOME"] != "/home/alice" {
+		t.Errorf("unexpected metadata: %+v", pkg.Metadata)
+	}
+
+	// 导入机器上的主目录不同，文本文件还原为新的主目录，二进制文件原样恢复
+	stubMachineValues(t, "/home/bob", "bob", "bob-desktop")
+	target := filepath.Join(t.TempDir(), "profile")
+	config := core.NewMigrationConfig()
+	config.Options.ImportPath = archive
+	config.Target.Path = target
+
+	s := &SoftwareStrategy{}
+	if err := s.ValidateImport(config); err != nil {
+		t.Fatal(err)
+	}
+	imported, err := s.Import(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Summary.Success != 7 || imported.Summary.Failed != 0 {
+		t.Errorf("unexpected summary: %+v", imported.Summary)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(target, "settings.json"))
+	if string(content) != `{"workspace": "/home/bob/projects"}` {
+		t.Errorf("settings.json = %s", content)
+	}
+	content, _ = os.ReadFile(filepath.Join(target, "cache/index.bin"))
+	if string(content) != "\x00\x01/home/alice" {
+		t.Errorf("binary file changed: %q", content)
+	}
+	info, _ := os.Stat(filepath.Join(target, "scripts/launch.sh"))
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("launch.sh mode = %v", info.Mode())
+	}
+	info, _ = os.Stat(filepath.Join(target, "settings.json"))
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("settings.json mtime = %v, want %v", info.ModTime(), mtime)
+	}
+}
+
+func TestSoftwareArchiveMergeModes(t *testing.T) {
+	stubMachineValues(t, "/home/alice", "alice", "alice-laptop")
+	source := t.TempDir()
+	writeTree(t, source, map[string]string{"a.conf": "archived a", "b.conf": "archived b"})
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(filepath.Join(source, "a.conf"), old, old)
+	archive, _ := exportSoftwareArchive(t, source, nil)
+
+	target := t.TempDir()
+	writeTree(t, target, map[string]string{"a.conf": "local a", "b.conf": "local b", "extra.conf": "keep"})
+	older := old.Add(-time.Hour)
+	os.Chtimes(filepath.Join(target, "b.conf"), older, older)
+
+	config := core.NewMigrationConfig()
+	config.Options.ImportPath = archive
+	config.Options.OperationMode = "import"
+	config.Target.Path = target
+	config.Target.Backup = true
+	config.Target.MergeMode = "merge"
+
+	// merge 仅覆盖比归档旧的 b.conf
+	s := &SoftwareStrategy{}
+	preview, err := s.DryRun(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Summary.Update != 1 || len(preview.Changes) != 1 || preview.Changes[0].Key != "b.conf" || len(preview.Warnings) != 1 {
+		t.Errorf("unexpected preview: %+v", preview)
+	}
+	result, err := s.Import(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Summary.Success != 1 || result.Summary.Skipped != 1 {
+		t.Errorf("unexpected summary: %+v", result.Summary)
+	}
+	for name, want := range map[string]string{"a.conf": "local a", "b.conf": "archived b", "extra.conf": "keep"} {
+		if content, _ := os.ReadFile(filepath.Join(target, name)); string(content) != want {
+			t.Errorf("%s = %q, want %q", name, content, want)
+		}
+	}
+
+	// 回滚恢复导入前的目标
+	if err := s.Rollback(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(target, "b.conf")); string(content) != "local b" {
+		t.Errorf("rollback b.conf = %q", content)
+	}
+
+	// skip 不覆盖任何已有文件
+	config.Target.MergeMode = "skip"
+	config.Target.Backup = false
+	preview, _ = s.DryRun(context.Background(), config)
+	if preview.Summary.Total != 0 {
+		t.Errorf("skip mode should not change anything: %+v", preview.Changes)
+	}
+}
+
+func TestSoftwareArchiveSingleFile(t *testing.T) {
+	stubMachineValues(t, "/home/alice", "alice", "alice-laptop")
+	source := filepath.Join(t.TempDir(), "app.ini")
+	os.WriteFile(source, []byte("[main]\nuser=alice\nshared=/Users/alice/shared\n"), 0600)
+	archive, _ := exportSoftwareArchive(t, source, nil)
+
+	stubMachineValues(t, "/home/bob", "bob", "bob-desktop")
+	target := filepath.Join(t.TempDir(), "conf", "app.ini")
+	config := core.NewMigrationConfig()
+	config.Options.ImportPath = archive
+	config.Target.Path = target
+	config.Rewrites = []core.RewriteRule{{Match: "[main]", Replace: "[primary]"}}
+	if _, err := (&SoftwareStrategy{}).Import(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile(target)
+	if string(content) != "[primary]\nuser=alice\nshared=/Users/bob/shared\n" {
+		t.Errorf("app.ini = %q", content)
+	}
+	if info, _ := os.Stat(target); info.Mode().Perm() != 0600 {
+		t.Errorf("app.ini mode = %v", info.Mode())
+	}
+}
+
+func TestSoftwareArchiveLargeBinaryDefaultPath(t *testing.T) {
+	base := t.TempDir()
+	source := filepath.Join(base, "app")
+	// 超过文本检测窗口的二进制文件，流式写入归档
+	blob := strings.Repeat("/home/alice\x00", 4096)
+	writeTree(t, source, map[string]string{"data/blob.bin": blob, "app.conf": "name=app\n"})
+
+	// 未指定导出路径时写到源目录旁，与工作目录无关
+	wd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(wd) })
+	os.Chdir(t.TempDir())
+	config := core.NewMigrationConfig()
+	config.Source.Path = source
+	result, err := (&SoftwareStrategy{}).Export(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExportPath != source+".zip" {
+		t.Errorf("unexpected export path %q", result.ExportPath)
+	}
+
+	target := filepath.Join(base, "restored")
+	config = core.NewMigrationConfig()
+	config.Options.ImportPath = result.ExportPath
+	config.Target.Path = target
+	s := &SoftwareStrategy{}
+	if _, err := s.Import(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(target, "data", "blob.bin")); string(content) != blob {
+		t.Errorf("binary file not restored verbatim (%d bytes)", len(content))
+	}
+
+	// 内容一致的文件不出现在预览中
+	config.Options.OperationMode = "import"
+	preview, err := s.DryRun(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Changes) != 0 {
+		t.Errorf("unchanged files should not be previewed: %+v", preview.Changes)
+	}
+	os.WriteFile(filepath.Join(target, "data", "blob.bin"), []byte("changed"), 0644)
+	if preview, _ = s.DryRun(context.Background(), config); len(preview.Changes) != 1 {
+		t.Errorf("changed binary file should be previewed: %+v", preview.Changes)
+	}
+}
+
+func TestSoftwareArchiveHashMismatch(t *testing.T) {
+	source := t.TempDir()
+	writeTree(t, source, map[string]string{"a.conf": "original"})
+	archive, _ := exportSoftwareArchive(t, source, nil)
+
+	// 篡改归档中的文件内容，保留原清单
+	tampered := filepath.Join(t.TempDir(), "tampered.zip")
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := os.Create(tampered)
+	zw := zip.NewWriter(out)
+	for _, f := range reader.File {
+		w, _ := zw.Create(f.Name)
+		if f.Name == softwareFilesDir+"a.conf" {
+			io.WriteString(w, "modified")
+			continue
+		}
+		rc, _ := f.Open()
+		io.Copy(w, rc)
+		rc.Close()
+	}
+	zw.Close()
+	out.Close()
+	reader.Close()
+
+	target := t.TempDir()
+	config := core.NewMigrationConfig()
+	config.Options.ImportPath = tampered
+	config.Target.Path = target
+	_, err = (&SoftwareStrategy{}).Import(context.Background(), config)
+	if err == nil || !strings.Contains(err.Error(), "hash mismatch") {
+		t.Errorf("expected hash mismatch, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(target, "a.conf")); !os.IsNotExist(err) {
+		t.Error("nothing should be written when verification fails")
+	}
+}
+
+func TestValidArchivePath(t *testing.T) {
+	for p, want := range map[string]bool{
+		"a/b.txt": true, "a.txt": true,
+		"../a": false, "a/../../b": false, "/etc/passwd": false, `a\b`: false, "a//b": false, "": false,
+	} {
+		if got := validArchivePath(p); got != want {
+			t.Errorf("validArchivePath(%q) = %v, want %v", p, got, want)
+		}
+	}
+}
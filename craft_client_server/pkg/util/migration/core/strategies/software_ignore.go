@@ -0,0 +1,184 @@
+package strategies
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"tsc/pkg/util/migration/core"
+)
+
+// softwareIgnoreFile 源目录下默认读取的忽略规则文件
+const softwareIgnoreFile = ".envcraftignore"
+
+// ignoreRule 一条 gitignore 语法的忽略规则
+type ignoreRule struct {
+	pattern string         // 原始规则
+	negate  bool           // ! 开头，重新包含已忽略的路径
+	dirOnly bool           // / 结尾，只匹配目录
+	re      *regexp.Regexp // 匹配相对源目录、以 / 分隔的路径
+}
+
+// ignoreMatcher 按顺序匹配忽略规则，后出现的规则优先
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// parseIgnoreRules 解析 gitignore 语法的规则行，忽略空行与 # 注释
+func parseIgnoreRules(lines []string) (*ignoreMatcher, error) {
+	m := &ignoreMatcher{}
+	for _, line := range lines {
+		line = strings.TrimRight(strings.TrimSuffix(line, "\r"), " \t")
+		if strings.HasSuffix(line, `\`) {
+			// 末尾的 \ 转义被去掉的空格
+			line += " "
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{pattern: line}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		// 含 / 的规则相对源目录匹配，否则匹配任意层级的名称
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		expr, err := ignorePatternRegexp(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern %q: %w", rule.pattern, err)
+		}
+		if !anchored {
+			expr = "(?:.*/)?" + expr
+		}
+		if rule.re, err = regexp.Compile("^" + expr + "$"); err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern %q: %w", rule.pattern, err)
+		}
+		m.rules = append(m.rules, rule)
+	}
+	return m, nil
+}
+
+// ignorePatternRegexp 将通配符模式转换为正则表达式
+// * 与 ? 不匹配 /，**/ 匹配零或多层目录，末尾的 /** 匹配目录下的所有内容
+func ignorePatternRegexp(pattern string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**") && (i == 0 || pattern[i-1] == '/'):
+			rest := pattern[i+2:]
+			switch {
+			case rest == "":
+				b.WriteString(".*")
+			case rest[0] == '/':
+				b.WriteString("(?:.*/)?")
+				i++
+			default:
+				b.WriteString("[^/]*")
+			}
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated character class")
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String(), nil
+}
+
+// ignored 判断相对源目录的路径是否被忽略；与 gitignore 一致，父目录被忽略时其中的路径不能被重新包含
+func (m *ignoreMatcher) ignored(rel string, isDir bool) bool {
+	if m == nil || len(m.rules) == 0 {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.match(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.match(rel, isDir)
+}
+
+// match 按最后一条匹配的规则判断路径本身是否被忽略
+func (m *ignoreMatcher) match(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(rel) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// skip 供 filepath.Walk 使用：路径被忽略时返回 true，被忽略的目录同时返回 filepath.SkipDir
+func (m *ignoreMatcher) skip(rel string, info os.FileInfo) (bool, error) {
+	if rel == "." || !m.ignored(rel, info.IsDir()) {
+		return false, nil
+	}
+	if info.IsDir() {
+		return true, filepath.SkipDir
+	}
+	return true, nil
+}
+
+// loadIgnoreMatcher 读取源目录的忽略规则文件与 Filter.Exclude 中的规则
+// 显式指定的 IgnoreFile 不存在时返回错误，默认的 .envcraftignore 不存在时忽略
+// 源路径不是目录时不应用忽略规则
+func loadIgnoreMatcher(source core.MigrationSource) (*ignoreMatcher, error) {
+	if info, err := os.Stat(source.Path); err != nil || !info.IsDir() {
+		return nil, nil
+	}
+	var lines []string
+	ignoreFile := source.Filter.IgnoreFile
+	explicit := ignoreFile != ""
+	if !explicit {
+		ignoreFile = softwareIgnoreFile
+	}
+	if !filepath.IsAbs(ignoreFile) {
+		ignoreFile = filepath.Join(source.Path, ignoreFile)
+	}
+	content, err := os.ReadFile(ignoreFile)
+	switch {
+	case err == nil:
+		lines = strings.Split(string(content), "\n")
+	case explicit || !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to read ignore file: %w", err)
+	}
+	lines = append(lines, source.Filter.Exclude...)
+	return parseIgnoreRules(lines)
+}
@@ -0,0 +1,138 @@
+package strategies
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	m, err := parseIgnoreRules([]string{
+		"# 缓存",
+		"*.log",
+		"!keep.log",
+		"cache/",
+		"/lock",
+		"**/index/**",
+		"a/**/b.txt",
+		"tmp?.dat",
+		"[!a]x.bin",
+		`\#hash`,
+		"",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"app.log", false, true},
+		{"logs/deep/app.log", false, true},
+		{"keep.log", false, false},
+		{"sub/keep.log", false, false},
+		{"cache", true, true},
+		{"cache", false, false},
+		{"sub/cache/x.json", false, true},
+		{"lock", false, true},
+		{"sub/lock", false, false},
+		{"index/segments", false, true},
+		{"x/index/y/z", false, true},
+		{"index", true, false},
+		{"a/b.txt", false, true},
+		{"a/x/y/b.txt", false, true},
+		{"b/a/b.txt", false, false},
+		{"tmp1.dat", false, true},
+		{"tmp10.dat", false, false},
+		{"bx.bin", false, true},
+		{"ax.bin", false, false},
+		{"#hash", false, true},
+	} {
+		if got := m.ignored(tc.path, tc.isDir); got != tc.want {
+			t.Errorf("ignored(%q, %v) = %v, want %v", tc.path, tc.isDir, got, tc.want)
+		}
+	}
+
+	// 父目录被忽略时不能重新包含其中的文件
+	m, _ = parseIgnoreRules([]string{"build/", "!build/keep.txt"})
+	if !m.ignored("build/keep.txt", false) {
+		t.Error("file in ignored directory should stay ignored")
+	}
+
+	if _, err := parseIgnoreRules([]string{"[abc"}); err == nil {
+		t.Error("unterminated character class should be rejected")
+	}
+}
+
+func TestSoftwareIgnoreRules(t *testing.T) {
+	source, target := t.TempDir(), t.TempDir()
+	writeTree(t, source, map[string]string{
+		softwareIgnoreFile:     "# 不迁移缓存与日志\ncaches/\n*.log\n!important.log\n",
+		"options/editor.xml":   "<editor/>",
+		"caches/index/a.bin":   "bin",
+		"logs/idea.log":        "log",
+		"logs/important.log":   "keep",
+		"plugins/p/plugin.jar": "jar",
+		"plugins/p/.lock":      "",
+	})
+	writeTree(t, target, map[string]string{"caches/local.bin": "local", "stale.txt": "x"})
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = source
+	config.Source.Filter.Exclude = []string{"**/.lock"}
+	config.Target.Path = target
+	config.Target.Sync = constants.SyncModeMirror
+	s := &SoftwareStrategy{}
+	if err := s.Validate(config); err != nil {
+		t.Fatal(err)
+	}
+
+	// 预览不计被忽略的文件，镜像同步只删除未被忽略的多余文件
+	preview, err := s.DryRun(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Summary.Create != 4 || preview.Summary.Delete != 1 {
+		t.Errorf("unexpected preview: %+v", preview.Changes)
+	}
+
+	if _, err := s.Execute(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	for rel, want := range map[string]bool{
+		"options/editor.xml":   true,
+		"logs/important.log":   true,
+		"plugins/p/plugin.jar": true,
+		softwareIgnoreFile:     true,
+		"caches/local.bin":     true,
+		"caches/index/a.bin":   false,
+		"logs/idea.log":        false,
+		"plugins/p/.lock":      false,
+		"stale.txt":            false,
+	} {
+		if _, err := os.Stat(filepath.Join(target, rel)); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", rel, err == nil, want)
+		}
+	}
+
+	// 导出同样应用忽略规则（未设置内联规则，.lock 会导出）
+	_, result := exportSoftwareArchive(t, source, nil)
+	exported := map[string]bool{}
+	for _, record := range result.Records {
+		exported[record.Key] = true
+	}
+	if len(exported) != 5 || exported["logs/idea.log"] || exported["caches/index/a.bin"] || !exported["plugins/p/.lock"] {
+		t.Errorf("unexpected exported files: %v", exported)
+	}
+
+	// 显式指定的规则文件不存在时校验失败
+	config.Source.Filter.IgnoreFile = "missing.ignore"
+	if err := s.Validate(config); err == nil {
+		t.Error("missing ignore file should be rejected")
+	}
+}
@@ -0,0 +1,235 @@
+package strategies
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+// symlinkMode 返回符号链接处理方式，未设置时为 preserve
+func symlinkMode(config *core.MigrationConfig) string {
+	if config.Target.Symlinks == "" {
+		return constants.SymlinkPreserve
+	}
+	return config.Target.Symlinks
+}
+
+// validateSymlinks 校验符号链接处理方式
+func validateSymlinks(target core.MigrationTarget) error {
+	switch target.Symlinks {
+	case "", constants.SymlinkPreserve, constants.SymlinkFollow, constants.SymlinkSkip:
+		return nil
+	default:
+		return fmt.Errorf("unknown symlinks mode %q", target.Symlinks)
+	}
+}
+
+// isSymlink 判断是否为符号链接
+func isSymlink(info os.FileInfo) bool {
+	return info.Mode()&os.ModeSymlink != 0
+}
+
+// isSpecialFile 判断是否为套接字、命名管道、设备等无法复制的特殊文件
+func isSpecialFile(info os.FileInfo) bool {
+	return info.Mode()&(os.ModeNamedPipe|os.ModeSocket|os.ModeDevice|os.ModeCharDevice|os.ModeIrregular) != 0
+}
+
+// walkTree 遍历目录，回调语义与 filepath.Walk 相同；根路径为符号链接时总是解析
+// follow 为 true 时解析目录中的符号链接并进入链接指向的目录，回调收到的 path 仍为链接路径；
+// 无法解析或指向祖先目录（形成循环）的链接以链接本身的信息回调
+func walkTree(root string, follow bool, fn filepath.WalkFunc) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkTreeEntry(root, info, follow, make(map[string]bool), fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// walkTreeEntry 遍历一个条目，ancestors 为 follow 时当前路径上各目录的实际路径
+func walkTreeEntry(path string, info os.FileInfo, follow bool, ancestors map[string]bool, fn filepath.WalkFunc) error {
+	real := path
+	if follow {
+		if isSymlink(info) {
+			if resolved, err := os.Stat(path); err == nil {
+				info = resolved
+			}
+		}
+		if info.IsDir() {
+			if r, err := filepath.EvalSymlinks(path); err == nil {
+				real = r
+			}
+			if ancestors[real] {
+				if linkInfo, err := os.Lstat(path); err == nil {
+					return fn(path, linkInfo, nil)
+				}
+			}
+		}
+	}
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+
+	if err := fn(path, info, nil); err != nil {
+		if err == filepath.SkipDir {
+			return nil
+		}
+		return err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if err := fn(path, info, err); err != nil && err != filepath.SkipDir {
+			return err
+		}
+		return nil
+	}
+
+	ancestors[real] = true
+	defer delete(ancestors, real)
+	for _, entry := range entries {
+		child := filepath.Join(path, entry.Name())
+		childInfo, err := os.Lstat(child)
+		if err != nil {
+			err = fn(child, nil, err)
+		} else {
+			err = walkTreeEntry(child, childInfo, follow, ancestors, fn)
+		}
+		if err == filepath.SkipDir {
+			// 文件返回 SkipDir 时跳过所在目录的其余条目
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// symlinkTarget 返回在目标中重建符号链接时使用的链接目标与改写记录
+// 指向源目录内的相对链接保持不变；指向源目录外的相对链接按目标位置重新计算，使其仍指向同一路径；
+// 指向源目录内的绝对链接改为指向目标目录中的对应路径；最后对链接目标应用改写规则
+func symlinkTarget(config *core.MigrationConfig, path, relPath string, rewriter *valueRewriter) (string, string, []core.MigrationRecord, error) {
+	link, err := os.Readlink(path)
+	if err != nil {
+		return "", "", nil, err
+	}
+	sourceRoot, err := filepath.Abs(config.Source.Path)
+	if err != nil {
+		return "", "", nil, err
+	}
+	targetRoot, err := filepath.Abs(config.Target.Path)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	target := link
+	if filepath.IsAbs(link) {
+		if rel, ok := withinDir(sourceRoot, link); ok {
+			target = filepath.Join(targetRoot, rel)
+		}
+	} else {
+		resolved := filepath.Join(sourceRoot, filepath.Dir(relPath), link)
+		if _, ok := withinDir(sourceRoot, resolved); !ok {
+			target = resolved
+		}
+	}
+
+	rewritten, records := rewriter.rewriteFileContent(relPath, []byte(target))
+	target = string(rewritten)
+
+	// 指向目录外的相对链接改写后重新转换为相对于目标位置的路径
+	if !filepath.IsAbs(link) && filepath.IsAbs(target) {
+		if rel, err := filepath.Rel(filepath.Join(targetRoot, filepath.Dir(relPath)), target); err == nil {
+			target = rel
+		}
+	}
+	return link, target, records, nil
+}
+
+// withinDir 判断 path 是否位于 dir 内，返回相对路径
+func withinDir(dir, path string) (string, bool) {
+	rel, err := filepath.Rel(dir, filepath.Clean(path))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// symlinkUnchanged 判断目标是否已是指向 target 的符号链接
+func symlinkUnchanged(dst, target string) bool {
+	link, err := os.Readlink(dst)
+	return err == nil && link == target
+}
+
+// createSymlink 在 dst 创建指向 target 的符号链接，替换已存在的文件或链接；已存在的目录不替换
+func createSymlink(dst, target string) error {
+	if existing, err := os.Lstat(dst); err == nil {
+		if existing.IsDir() {
+			return fmt.Errorf("target %s is a directory", dst)
+		}
+		if err := os.Remove(dst); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.Symlink(target, dst)
+}
+
+// hardLinks 记录复制过程中遇到的多链接文件，同一文件的后续路径在目标中创建指向首个目标文件的硬链接
+type hardLinks struct {
+	copied map[fileID]hardLink
+}
+
+// hardLink 首次复制的源与目标路径
+type hardLink struct {
+	rel string
+	dst string
+}
+
+// newHardLinks 创建硬链接记录
+func newHardLinks() *hardLinks {
+	return &hardLinks{copied: make(map[fileID]hardLink)}
+}
+
+// lookup 返回与 info 为同一文件、已复制到目标的路径；未复制过或不是多链接文件时返回 false
+func (h *hardLinks) lookup(info os.FileInfo) (hardLink, bool) {
+	id, ok := hardLinkID(info)
+	if !ok {
+		return hardLink{}, false
+	}
+	link, ok := h.copied[id]
+	return link, ok
+}
+
+// add 记录多链接文件已复制到 dst
+func (h *hardLinks) add(info os.FileInfo, rel, dst string) {
+	if id, ok := hardLinkID(info); ok {
+		h.copied[id] = hardLink{rel: rel, dst: dst}
+	}
+}
+
+// linkFile 在 dst 创建指向 existing 的硬链接；dst 已是同一文件时返回 false
+func linkFile(existing, dst string) (bool, error) {
+	if info, err := os.Lstat(dst); err == nil {
+		if first, err := os.Lstat(existing); err == nil && os.SameFile(info, first) {
+			return false, nil
+		}
+		if info.IsDir() {
+			return false, fmt.Errorf("target %s is a directory", dst)
+		}
+		if err := os.Remove(dst); err != nil {
+			return false, err
+		}
+	}
+	return true, os.Link(existing, dst)
+}
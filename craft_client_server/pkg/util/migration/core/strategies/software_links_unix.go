@@ -0,0 +1,23 @@
+//go:build !windows
+
+package strategies
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID 文件的设备号与 inode
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// hardLinkID 返回多链接普通文件的标识；链接数为 1 时返回 false
+func hardLinkID(info os.FileInfo) (fileID, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || !info.Mode().IsRegular() || stat.Nlink <= 1 {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true
+}
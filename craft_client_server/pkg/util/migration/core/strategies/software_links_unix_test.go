@@ -0,0 +1,197 @@
+//go:build !windows
+
+package strategies
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+// newLinkTree 创建包含 stow 风格符号链接、硬链接与命名管道的源目录
+//
+//	home/.vimrc        -> ../dotfiles/vim/.vimrc（指向目录外）
+//	home/.config/nvim  -> ../../dotfiles/nvim（指向目录外的目录）
+//	home/current       -> notes/a.txt（目录内）
+//	home/abs           -> <home>/notes/a.txt（目录内的绝对链接）
+//	home/notes/b.txt   与 a.txt 为硬链接
+//	home/fifo          命名管道
+func newLinkTree(t *testing.T) (string, string) {
+	t.Helper()
+	base := t.TempDir()
+	home := filepath.Join(base, "home")
+	writeTree(t, base, map[string]string{
+		"dotfiles/vim/.vimrc":        "set nu",
+		"dotfiles/nvim/init.lua":     "vim.o.nu = true",
+		"home/notes/a.txt":           "a",
+		"home/.config/git/config.in": "[user]",
+	})
+	for link, target := range map[string]string{
+		".vimrc":       "../dotfiles/vim/.vimrc",
+		".config/nvim": "../../dotfiles/nvim",
+		"current":      "notes/a.txt",
+		"abs":          filepath.Join(home, "notes/a.txt"),
+	} {
+		if err := os.Symlink(target, filepath.Join(home, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Link(filepath.Join(home, "notes/a.txt"), filepath.Join(home, "notes/b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Mkfifo(filepath.Join(home, "fifo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return base, home
+}
+
+func TestSoftwarePreserveSymlinks(t *testing.T) {
+	base, home := newLinkTree(t)
+	target := filepath.Join(base, "moved/home")
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = home
+	config.Target.Path = target
+	s := &SoftwareStrategy{}
+	if err := s.Validate(config); err != nil {
+		t.Fatal(err)
+	}
+	result, err := s.Execute(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 指向目录外的相对链接按新位置改写，目录内的相对链接保持不变，目录内的绝对链接指向目标目录
+	for link, want := range map[string]string{
+		".vimrc":       "../../dotfiles/vim/.vimrc",
+		".config/nvim": "../../../dotfiles/nvim",
+		"current":      "notes/a.txt",
+		"abs":          filepath.Join(target, "notes/a.txt"),
+	} {
+		got, err := os.Readlink(filepath.Join(target, link))
+		if err != nil || got != want {
+			t.Errorf("%s -> %q (%v), want %q", link, got, err, want)
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(target, ".vimrc")); err != nil || string(data) != "set nu" {
+		t.Errorf("rewritten link does not resolve: %q %v", data, err)
+	}
+
+	// 硬链接在目标中仍为同一文件，命名管道跳过并警告
+	a, _ := os.Stat(filepath.Join(target, "notes/a.txt"))
+	b, _ := os.Stat(filepath.Join(target, "notes/b.txt"))
+	if a == nil || b == nil || !os.SameFile(a, b) {
+		t.Error("hard link not preserved")
+	}
+	if _, err := os.Lstat(filepath.Join(target, "fifo")); err == nil {
+		t.Error("fifo should be skipped")
+	}
+	if len(result.Warnings) != 1 || countRecordStatus(result.Records, constants.ActionTypeCopy, constants.RecordStatusSkipped) != 1 {
+		t.Errorf("unexpected warnings %v or records %+v", result.Warnings, result.Records)
+	}
+
+	// 增量同步时未变化的链接记录为跳过
+	config.Target.Sync = constants.SyncModeIncremental
+	preview, err := s.DryRun(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Summary.Total != 0 {
+		t.Errorf("unexpected preview: %+v", preview.Changes)
+	}
+	result, err = s.Execute(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Summary.Success != 0 {
+		t.Errorf("expected nothing to change, got %+v", result.Summary)
+	}
+}
+
+func TestSoftwareSymlinkRewrite(t *testing.T) {
+	_, home := newLinkTree(t)
+	target := filepath.Join(t.TempDir(), "home")
+
+	// 改写规则同样作用于链接目标
+	config := core.NewMigrationConfig()
+	config.Source.Path = home
+	config.Target.Path = target
+	config.Rewrites = []core.RewriteRule{{Match: "dotfiles", Replace: "dots"}}
+	if _, err := (&SoftwareStrategy{}).Execute(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.Readlink(filepath.Join(target, ".vimrc"))
+	if want, _ := filepath.Rel(target, filepath.Join(filepath.Dir(home), "dots/vim/.vimrc")); got != want {
+		t.Errorf(".vimrc -> %q, want %q", got, want)
+	}
+}
+
+func TestSoftwareFollowAndSkipSymlinks(t *testing.T) {
+	_, home := newLinkTree(t)
+	// 指向祖先目录的链接在 follow 方式下形成循环
+	if err := os.Symlink("..", filepath.Join(home, "notes/up")); err != nil {
+		t.Fatal(err)
+	}
+
+	follow := core.NewMigrationConfig()
+	follow.Source.Path = home
+	follow.Target.Path = filepath.Join(t.TempDir(), "home")
+	follow.Target.Symlinks = constants.SymlinkFollow
+	result, err := (&SoftwareStrategy{}).Execute(context.Background(), follow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Lstat(filepath.Join(follow.Target.Path, ".config/nvim/init.lua"))
+	if err != nil || !info.Mode().IsRegular() {
+		t.Errorf("linked directory not copied: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(follow.Target.Path, "notes/up")); err == nil {
+		t.Error("symlink loop should be skipped")
+	}
+	if len(result.Warnings) != 2 {
+		t.Errorf("unexpected warnings: %v", result.Warnings)
+	}
+
+	skip := core.NewMigrationConfig()
+	skip.Source.Path = home
+	skip.Target.Path = filepath.Join(t.TempDir(), "home")
+	skip.Target.Symlinks = constants.SymlinkSkip
+	result, err = (&SoftwareStrategy{}).Execute(context.Background(), skip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, link := range []string{".vimrc", ".config/nvim", "current", "abs", "notes/up"} {
+		if _, err := os.Lstat(filepath.Join(skip.Target.Path, link)); err == nil {
+			t.Errorf("%s should be skipped", link)
+		}
+	}
+	if n := countRecordStatus(result.Records, constants.ActionTypeCopy, constants.RecordStatusSkipped); n != 6 {
+		t.Errorf("expected 5 links and 1 fifo to be skipped, got %d", n)
+	}
+
+	skip.Target.Symlinks = "copy"
+	if err := (&SoftwareStrategy{}).Validate(skip); err == nil {
+		t.Error("expected error for unknown symlinks mode")
+	}
+}
+
+func TestSoftwareBackupPreservesLinks(t *testing.T) {
+	_, home := newLinkTree(t)
+	backup := filepath.Join(t.TempDir(), "backup")
+	if err := (&SoftwareStrategy{}).copyDirectory(home, backup); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.Readlink(filepath.Join(backup, ".vimrc")); got != "../dotfiles/vim/.vimrc" {
+		t.Errorf("backup link changed: %q", got)
+	}
+	a, _ := os.Stat(filepath.Join(backup, "notes/a.txt"))
+	b, _ := os.Stat(filepath.Join(backup, "notes/b.txt"))
+	if a == nil || b == nil || !os.SameFile(a, b) {
+		t.Error("hard link not preserved in backup")
+	}
+}
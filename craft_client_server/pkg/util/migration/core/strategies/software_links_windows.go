@@ -0,0 +1,13 @@
+//go:build windows
+
+package strategies
+
+import "os"
+
+// fileID Windows 下不识别硬链接
+type fileID struct{}
+
+// hardLinkID Windows 的 FileInfo 不包含文件索引，多链接文件按普通文件复制
+func hardLinkID(info os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
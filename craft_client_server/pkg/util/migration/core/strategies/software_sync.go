@@ -0,0 +1,236 @@
+package strategies
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+// syncMode 返回目录同步方式，未设置时为 full
+func syncMode(config *core.MigrationConfig) string {
+	if config.Target.Sync == "" {
+		return constants.SyncModeFull
+	}
+	return config.Target.Sync
+}
+
+// validateSync 校验同步方式与变化判断方式
+func validateSync(target core.MigrationTarget) error {
+	switch target.Sync {
+	case "", constants.SyncModeFull, constants.SyncModeIncremental, constants.SyncModeMirror:
+	default:
+		return fmt.Errorf("unknown sync mode %q", target.Sync)
+	}
+	switch target.SyncCompare {
+	case "", constants.SyncCompareMtime, constants.SyncCompareHash:
+	default:
+		return fmt.Errorf("unknown sync compare %q", target.SyncCompare)
+	}
+	return nil
+}
+
+// fileUnchanged 判断目标文件是否已与源文件同步，增量同步时跳过这些文件
+// 有改写规则的文本文件与改写后的内容比较；mtime 方式比较大小与修改时间（秒），hash 方式比较大小与内容
+func (s *SoftwareStrategy) fileUnchanged(src, dst, relPath string, info os.FileInfo, rewriter *valueRewriter, compare string) bool {
+	target, err := os.Lstat(dst)
+	if err != nil || !target.Mode().IsRegular() {
+		return false
+	}
+
+	var expected []byte
+	if len(rewriter.rules) > 0 {
+		content, err := os.ReadFile(src)
+		if err != nil {
+			return false
+		}
+		if isTextContent(content) {
+			expected, _ = rewriter.rewriteFileContent(relPath, content)
+		}
+	}
+	size := info.Size()
+	if expected != nil {
+		size = int64(len(expected))
+	}
+	if target.Size() != size {
+		return false
+	}
+
+	if compare != constants.SyncCompareHash {
+		return target.ModTime().Unix() == info.ModTime().Unix()
+	}
+	want, err := fileHash(src)
+	if expected != nil {
+		sum := sha256.Sum256(expected)
+		want, err = sum[:], nil
+	}
+	if err != nil {
+		return false
+	}
+	got, err := fileHash(dst)
+	return err == nil && bytes.Equal(got, want)
+}
+
+// fileHash 计算文件内容的 SHA-256
+func fileHash(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// extraneousPaths 返回目标目录中源目录没有的路径（相对路径），目录只返回最上层，按路径排序
+// 被忽略的路径不在同步范围内，不会返回
+func extraneousPaths(source, target string, ignore *ignoreMatcher) ([]string, error) {
+	var extra []string
+	err := filepath.Walk(target, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(target, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if skip, err := ignore.skip(rel, info); skip {
+			return err
+		}
+		if _, err := os.Lstat(filepath.Join(source, rel)); os.IsNotExist(err) {
+			extra = append(extra, rel)
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	sort.Strings(extra)
+	return extra, err
+}
+
+// removeExtraneous 镜像同步时删除目标中源目录没有的文件与目录
+func (s *SoftwareStrategy) removeExtraneous(config *core.MigrationConfig, ignore *ignoreMatcher, result *core.MigrationResult) error {
+	extra, err := extraneousPaths(config.Source.Path, config.Target.Path, ignore)
+	if err != nil {
+		return err
+	}
+	for _, rel := range extra {
+		record := core.MigrationRecord{
+			StepName:    fmt.Sprintf("删除 %s", rel),
+			ActionType:  constants.ActionTypeDelete,
+			Key:         rel,
+			BeforeValue: filepath.Join(config.Target.Path, rel),
+			Timestamp:   time.Now(),
+		}
+		if err := os.RemoveAll(filepath.Join(config.Target.Path, rel)); err != nil {
+			record.Status = constants.RecordStatusFailed
+			record.Message = err.Error()
+			result.Summary.Failed++
+		} else {
+			record.Status = constants.RecordStatusSuccess
+			result.Summary.Success++
+		}
+		result.Records = append(result.Records, record)
+	}
+	return nil
+}
+
+// previewSync 预览增量或镜像同步：逐个列出将新建、更新与删除的文件，未变化的文件不列出
+func (s *SoftwareStrategy) previewSync(config *core.MigrationConfig, ignore *ignoreMatcher, preview *core.MigrationPreview) {
+	rewriter, err := newValueRewriter(config.Rewrites)
+	if err != nil {
+		preview.Errors = append(preview.Errors, err.Error())
+		return
+	}
+
+	symlinks := symlinkMode(config)
+	walkTree(config.Source.Path, symlinks == constants.SymlinkFollow, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(config.Source.Path, path)
+		if err != nil {
+			return nil
+		}
+		if skip, err := ignore.skip(rel, info); skip || info.IsDir() {
+			return err
+		}
+		if isSpecialFile(info) {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("特殊文件无法复制，将跳过: %s", rel))
+			return nil
+		}
+		target := filepath.Join(config.Target.Path, rel)
+		change := core.PreviewChange{
+			ActionType:  constants.ActionTypeCreate,
+			Key:         rel,
+			AfterValue:  target,
+			Impact:      s.getImpactLevel(rel),
+			Description: "将复制新文件",
+		}
+		if isSymlink(info) {
+			if symlinks != constants.SymlinkPreserve {
+				preview.Warnings = append(preview.Warnings, fmt.Sprintf("符号链接将跳过: %s", rel))
+				return nil
+			}
+			_, link, _, err := symlinkTarget(config, path, rel, rewriter)
+			if err != nil || symlinkUnchanged(target, link) {
+				return nil
+			}
+			change.AfterValue = link
+			change.Description = "将创建符号链接"
+		} else if s.fileUnchanged(path, target, rel, info, rewriter, config.Target.SyncCompare) {
+			return nil
+		}
+		if _, err := os.Lstat(target); err == nil {
+			change.ActionType = constants.ActionTypeUpdate
+			change.Description = "文件已变化，将覆盖"
+			if isSymlink(info) {
+				change.Description = "链接已变化，将替换"
+			}
+			preview.Summary.Update++
+		} else {
+			preview.Summary.Create++
+		}
+		if change.Impact == "high" {
+			preview.Summary.HighImpact++
+		}
+		preview.Changes = append(preview.Changes, change)
+		preview.Summary.Total++
+		return nil
+	})
+
+	if syncMode(config) == constants.SyncModeMirror {
+		extra, err := extraneousPaths(config.Source.Path, config.Target.Path, ignore)
+		if err != nil {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("无法读取目标目录: %v", err))
+		}
+		for _, rel := range extra {
+			preview.Changes = append(preview.Changes, core.PreviewChange{
+				ActionType:  constants.ActionTypeDelete,
+				Key:         rel,
+				BeforeValue: filepath.Join(config.Target.Path, rel),
+				Impact:      "medium",
+				Description: "源目录中不存在，将删除",
+			})
+			preview.Summary.Delete++
+			preview.Summary.Total++
+		}
+		if len(extra) > 0 && !config.Target.Backup {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("镜像同步将删除目标中的 %d 项，且未启用备份", len(extra)))
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package strategies
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+// countRecordStatus 统计指定状态的记录数
+func countRecordStatus(records []core.MigrationRecord, actionType, status string) int {
+	n := 0
+	for _, r := range records {
+		if r.ActionType == actionType && r.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSoftwareIncrementalSync(t *testing.T) {
+	source, target := t.TempDir(), filepath.Join(t.TempDir(), "profile")
+	writeTree(t, source, map[string]string{
+		"options/editor.xml": "<editor/>",
+		"options/ui.xml":     "<ui/>",
+		"plugins/a.jar":      "jar",
+	})
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = source
+	config.Target.Path = target
+	config.Target.Sync = constants.SyncModeIncremental
+	s := &SoftwareStrategy{}
+	if err := s.Validate(config); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Execute(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	// 仅修改一个文件后再次同步，其余文件记录为跳过
+	later := time.Now().Add(time.Minute)
+	os.WriteFile(filepath.Join(source, "options/ui.xml"), []byte("<ui dark/>"), 0644)
+	os.Chtimes(filepath.Join(source, "options/ui.xml"), later, later)
+
+	preview, err := s.DryRun(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Summary.Update != 1 || preview.Summary.Total != 1 || preview.Changes[0].Key != filepath.Join("options", "ui.xml") {
+		t.Errorf("unexpected preview: %+v", preview.Changes)
+	}
+
+	result, err := s.Execute(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied := countRecordStatus(result.Records, constants.ActionTypeCopy, constants.RecordStatusSuccess); copied != 1 {
+		t.Errorf("copied %d files, want 1", copied)
+	}
+	if result.Summary.Skipped != 2 || result.Summary.Success != 1 {
+		t.Errorf("unexpected summary: %+v", result.Summary)
+	}
+	if content, _ := os.ReadFile(filepath.Join(target, "options/ui.xml")); string(content) != "<ui dark/>" {
+		t.Errorf("ui.xml = %s", content)
+	}
+}
+
+func TestSoftwareSyncHashCompare(t *testing.T) {
+	source, target := t.TempDir(), t.TempDir()
+	writeTree(t, source, map[string]string{"a.txt": "same", "b.txt": "new!"})
+	writeTree(t, target, map[string]string{"a.txt": "same", "b.txt": "old!"})
+
+	// 修改时间不同但内容相同的文件在 hash 方式下视为未变化；大小相同内容不同的文件会被复制
+	config := core.NewMigrationConfig()
+	config.Source.Path = source
+	config.Target.Path = target
+	config.Target.Sync = constants.SyncModeIncremental
+	config.Target.SyncCompare = constants.SyncCompareHash
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(filepath.Join(target, "a.txt"), old, old)
+
+	result, err := (&SoftwareStrategy{}).Execute(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Summary.Skipped != 1 || countRecordStatus(result.Records, constants.ActionTypeCopy, constants.RecordStatusSuccess) != 1 {
+		t.Errorf("unexpected records: %+v", result.Records)
+	}
+	if content, _ := os.ReadFile(filepath.Join(target, "b.txt")); string(content) != "new!" {
+		t.Errorf("b.txt = %s", content)
+	}
+}
+
+func TestSoftwareMirrorSync(t *testing.T) {
+	source, target := t.TempDir(), t.TempDir()
+	writeTree(t, source, map[string]string{"keep/a.txt": "a"})
+	writeTree(t, target, map[string]string{"keep/old.txt": "x", "stale/b.txt": "b", "c.txt": "c"})
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = source
+	config.Target.Path = target
+	config.Target.Sync = constants.SyncModeMirror
+	s := &SoftwareStrategy{}
+
+	preview, err := s.DryRun(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Summary.Delete != 3 || preview.Summary.Create != 1 {
+		t.Errorf("unexpected preview: %+v", preview.Summary)
+	}
+
+	result, err := s.Execute(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := countRecordStatus(result.Records, constants.ActionTypeDelete, constants.RecordStatusSuccess); n != 3 {
+		t.Errorf("deleted %d paths, want 3", n)
+	}
+	for _, rel := range []string{"keep/old.txt", "stale", "c.txt"} {
+		if _, err := os.Stat(filepath.Join(target, rel)); !os.IsNotExist(err) {
+			t.Errorf("%s should be deleted", rel)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(target, "keep/a.txt")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSoftwareSyncWithRewrites(t *testing.T) {
+	source, target := t.TempDir(), t.TempDir()
+	writeTree(t, source, map[string]string{"paths.conf": "root=C:/Users/alice"})
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = source
+	config.Target.Path = target
+	config.Target.Sync = constants.SyncModeIncremental
+	config.Rewrites = []core.RewriteRule{{Match: "C:/Users/alice", Replace: "/home/bob"}}
+	s := &SoftwareStrategy{}
+	if _, err := s.Execute(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	// 改写后的文件保留源文件的修改时间，再次同步时视为未变化
+	result, err := s.Execute(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Summary.Skipped != 1 {
+		t.Errorf("rewritten file should be skipped: %+v", result.Records)
+	}
+	if content, _ := os.ReadFile(filepath.Join(target, "paths.conf")); string(content) != "root=/home/bob" {
+		t.Errorf("paths.conf = %s", content)
+	}
+}
+
+func TestValidateSync(t *testing.T) {
+	if err := validateSync(core.MigrationTarget{Sync: "rsync"}); err == nil {
+		t.Error("unknown sync mode should be rejected")
+	}
+	if err := validateSync(core.MigrationTarget{Sync: constants.SyncModeMirror, SyncCompare: "size"}); err == nil {
+		t.Error("unknown sync compare should be rejected")
+	}
+}
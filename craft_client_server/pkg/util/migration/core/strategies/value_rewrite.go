@@ -0,0 +1,310 @@
+package strategies
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+// valueRewriter 按 MigrationConfig.Rewrites 改写配置值与文件内容，每处改写生成一条迁移记录
+type valueRewriter struct {
+	rules []compiledRewrite
+}
+
+// compiledRewrite 预编译的改写规则
+type compiledRewrite struct {
+	core.RewriteRule
+	re *regexp.Regexp
+}
+
+// newValueRewriter 校验并编译改写规则
+func newValueRewriter(rules []core.RewriteRule) (*valueRewriter, error) {
+	w := &valueRewriter{}
+	for i, rule := range rules {
+		if rule.Match == "" {
+			return nil, fmt.Errorf("rewrite rule %d: match is required", i+1)
+		}
+
+		compiled := compiledRewrite{RewriteRule: rule}
+		switch rule.Mode {
+		case "", constants.RewriteModeLiteral:
+		case constants.RewriteModeRegex:
+			re, err := regexp.Compile(rule.Match)
+			if err != nil {
+				return nil, fmt.Errorf("rewrite rule %d: invalid regex: %w", i+1, err)
+			}
+			compiled.re = re
+		default:
+			return nil, fmt.Errorf("rewrite rule %d: unsupported mode %q", i+1, rule.Mode)
+		}
+
+		switch rule.Scope {
+		case "", constants.RewriteScopeValue, constants.RewriteScopeContent:
+		default:
+			return nil, fmt.Errorf("rewrite rule %d: unsupported scope %q", i+1, rule.Scope)
+		}
+
+		if rule.KeyPattern != "" {
+			if _, err := path.Match(rule.KeyPattern, ""); err != nil {
+				return nil, fmt.Errorf("rewrite rule %d: invalid key pattern: %w", i+1, err)
+			}
+		}
+		w.rules = append(w.rules, compiled)
+	}
+	return w, nil
+}
+
+// isContentRule 判断规则是否改写文件内容
+func (r *compiledRewrite) isContentRule() bool {
+	return r.Scope == constants.RewriteScopeContent
+}
+
+// replace 对 s 应用规则，返回结果与替换次数
+func (r *compiledRewrite) replace(s string) (string, int) {
+	if r.re != nil {
+		n := len(r.re.FindAllStringIndex(s, -1))
+		if n == 0 {
+			return s, 0
+		}
+		return r.re.ReplaceAllString(s, r.Replace), n
+	}
+	n := strings.Count(s, r.Match)
+	if n == 0 {
+		return s, 0
+	}
+	return strings.ReplaceAll(s, r.Match, r.Replace), n
+}
+
+// describe 返回规则的简要描述，用于记录消息
+func (r *compiledRewrite) describe() string {
+	mode := r.Mode
+	if mode == "" {
+		mode = constants.RewriteModeLiteral
+	}
+	return fmt.Sprintf("%s 规则 %q → %q", mode, r.Match, r.Replace)
+}
+
+// rewriteData 改写 data 中的字符串值，返回改写后的副本（data 本身不变）
+// 配置项路径与数组合并规则一致：以 . 分隔，数组元素以 [] 表示
+func (w *valueRewriter) rewriteData(data map[string]interface{}, prefix string) (map[string]interface{}, []core.MigrationRecord) {
+	if len(w.rules) == 0 {
+		return data, nil
+	}
+
+	var records []core.MigrationRecord
+	result := make(map[string]interface{}, len(data))
+	for _, key := range sortedKeys(data) {
+		var recs []core.MigrationRecord
+		result[key], recs = w.rewriteValue(data[key], joinConfigPath(prefix, key))
+		records = append(records, recs...)
+	}
+	return result, records
+}
+
+// rewriteValue 递归改写单个值
+func (w *valueRewriter) rewriteValue(value interface{}, keyPath string) (interface{}, []core.MigrationRecord) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return w.rewriteData(v, keyPath)
+	case []interface{}:
+		var records []core.MigrationRecord
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			var recs []core.MigrationRecord
+			result[i], recs = w.rewriteValue(item, keyPath+"[]")
+			records = append(records, recs...)
+		}
+		return result, records
+	case string:
+		return w.rewriteString(v, keyPath)
+	default:
+		return value, nil
+	}
+}
+
+// rewriteString 依次应用作用于 keyPath 的值规则
+func (w *valueRewriter) rewriteString(value, keyPath string) (string, []core.MigrationRecord) {
+	var records []core.MigrationRecord
+	for i := range w.rules {
+		rule := &w.rules[i]
+		if rule.isContentRule() || !matchKeyScope(rule.KeyPattern, keyPath) {
+			continue
+		}
+		rewritten, n := rule.replace(value)
+		if n == 0 || rewritten == value {
+			continue
+		}
+		records = append(records, core.MigrationRecord{
+			StepName:    fmt.Sprintf("改写配置项 %s", keyPath),
+			ActionType:  constants.ActionTypeRewrite,
+			Key:         keyPath,
+			BeforeValue: value,
+			AfterValue:  rewritten,
+			Status:      constants.RecordStatusSuccess,
+			Message:     rule.describe(),
+			Timestamp:   time.Now(),
+		})
+		value = rewritten
+	}
+	return value, records
+}
+
+// rewriteContent 对文件内容应用 content 规则，name 为用于匹配 KeyPattern 的相对路径
+func (w *valueRewriter) rewriteContent(name string, content []byte) ([]byte, []core.MigrationRecord) {
+	return w.rewriteText(name, content, func(rule *compiledRewrite) bool { return rule.isContentRule() })
+}
+
+// rewriteFileContent 对文件内容应用全部规则，用于不解析文件的软件配置迁移
+func (w *valueRewriter) rewriteFileContent(name string, content []byte) ([]byte, []core.MigrationRecord) {
+	return w.rewriteText(name, content, func(*compiledRewrite) bool { return true })
+}
+
+// rewriteText 对文本应用满足 accept 且 KeyPattern 匹配 name 的规则，每条生效的规则生成一条记录
+func (w *valueRewriter) rewriteText(name string, content []byte, accept func(*compiledRewrite) bool) ([]byte, []core.MigrationRecord) {
+	var records []core.MigrationRecord
+	text := string(content)
+	for i := range w.rules {
+		rule := &w.rules[i]
+		if !accept(rule) || !matchFileScope(rule.KeyPattern, name) {
+			continue
+		}
+		rewritten, n := rule.replace(text)
+		if n == 0 || rewritten == text {
+			continue
+		}
+		records = append(records, core.MigrationRecord{
+			StepName:    fmt.Sprintf("改写文件内容 %s", name),
+			ActionType:  constants.ActionTypeRewrite,
+			Key:         name,
+			BeforeValue: rule.Match,
+			AfterValue:  rule.Replace,
+			Status:      constants.RecordStatusSuccess,
+			Message:     fmt.Sprintf("%s，替换 %d 处", rule.describe(), n),
+			Timestamp:   time.Now(),
+		})
+		text = rewritten
+	}
+	if len(records) == 0 {
+		return content, nil
+	}
+	return []byte(text), records
+}
+
+// rewriteXML 改写 XML 文档中的属性值与文本，保持其余内容原样
+// 配置项路径与 readXML 的 map 结构一致，如 application.component.option.@value
+func (w *valueRewriter) rewriteXML(content []byte) ([]byte, []core.MigrationRecord) {
+	if len(w.rules) == 0 {
+		return content, nil
+	}
+	doc, err := parseXMLDocument(content)
+	if err != nil {
+		return content, nil
+	}
+
+	var records []core.MigrationRecord
+	var walk func(n *xmlNode, keyPath string)
+	walk = func(n *xmlNode, keyPath string) {
+		for _, attr := range n.attrs {
+			if rewritten, recs := w.rewriteString(attr.value, keyPath+".@"+attr.name); len(recs) > 0 {
+				n.setAttr(attr.name, rewritten)
+				records = append(records, recs...)
+			}
+		}
+		for _, child := range n.children {
+			switch child.kind {
+			case xmlElementNode:
+				walk(child, keyPath+"."+child.name)
+			case xmlTextNode, xmlCDataNode:
+				if strings.TrimSpace(child.text) == "" {
+					continue
+				}
+				if rewritten, recs := w.rewriteString(child.text, keyPath+".#text"); len(recs) > 0 {
+					child.text = rewritten
+					child.raw = ""
+					records = append(records, recs...)
+				}
+			}
+		}
+	}
+	root := doc.root()
+	walk(root, root.name)
+
+	if len(records) == 0 {
+		return content, nil
+	}
+	return doc.Bytes(), records
+}
+
+// matchKeyScope 判断配置项路径是否在 pattern 范围内：pattern 匹配路径本身或其任一上级路径
+// 数组元素的 [] 后缀可省略，如 recent 同时作用于 recent[]
+func matchKeyScope(pattern, keyPath string) bool {
+	if pattern == "" {
+		return true
+	}
+	patterns := strings.Split(pattern, ".")
+	segments := strings.Split(keyPath, ".")
+	for i := 1; i <= len(segments); i++ {
+		scope := append([]string{}, segments[:i]...)
+		if matchPathSegments(patterns, scope) {
+			return true
+		}
+		if last := scope[i-1]; strings.HasSuffix(last, "[]") {
+			scope[i-1] = strings.TrimSuffix(last, "[]")
+			if matchPathSegments(patterns, scope) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchFileScope 判断相对文件路径是否匹配 pattern；pattern 不含 / 时仅匹配文件名
+func matchFileScope(pattern, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	name = filepath.ToSlash(name)
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	return matchPathSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchPathSegments 逐段匹配路径，** 匹配任意多段（含零段），其余段按 path.Match 匹配
+func matchPathSegments(patterns, segments []string) bool {
+	for len(patterns) > 0 {
+		if patterns[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchPathSegments(patterns[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(patterns[0], segments[0]); !ok {
+			return false
+		}
+		patterns, segments = patterns[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
+
+// isTextContent 粗略判断文件内容是否为文本：前 8KB 内不含 NUL 字节
+func isTextContent(content []byte) bool {
+	head := content
+	if len(head) > 8192 {
+		head = head[:8192]
+	}
+	return !bytes.Contains(head, []byte{0})
+}
@@ -0,0 +1,163 @@
+package strategies
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tsc/pkg/util/migration/constants"
+	"tsc/pkg/util/migration/core"
+)
+
+func TestValueRewriterScopes(t *testing.T) {
+	rewriter, err := newValueRewriter([]core.RewriteRule{
+		{Match: `C:\Users\alice`, Replace: "/home/bob"},
+		{Mode: constants.RewriteModeRegex, Match: `\\`, Replace: "/", KeyPattern: "paths"},
+		{Mode: constants.RewriteModeRegex, Match: `^alice-(\w+)$`, Replace: "bob-$1", KeyPattern: "**.host"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := map[string]interface{}{
+		"paths": map[string]interface{}{
+			"home":   `C:\Users\alice\projects`,
+			"recent": []interface{}{`C:\Users\alice\a.txt`, `D:\b.txt`},
+		},
+		"note": `C:\Users\alice\notes`,
+		"db":   map[string]interface{}{"host": "alice-pc", "port": 5432},
+	}
+	rewritten, records := rewriter.rewriteData(data, "")
+	expected := map[string]interface{}{
+		"paths": map[string]interface{}{
+			"home":   "/home/bob/projects",
+			"recent": []interface{}{"/home/bob/a.txt", "D:/b.txt"},
+		},
+		"note": `/home/bob\notes`,
+		"db":   map[string]interface{}{"host": "bob-pc", "port": 5432},
+	}
+	if !valuesEqual(rewritten, expected) {
+		t.Errorf("unexpected rewrite result:\n%v\nexpected:\n%v", rewritten, expected)
+	}
+	if data["note"] != `C:\Users\alice\notes` {
+		t.Error("rewriteData must not modify its input")
+	}
+
+	// 每个配置项上每条生效的规则各一条记录
+	if len(records) != 7 {
+		t.Errorf("expected 7 rewrite records, got %d: %+v", len(records), records)
+	}
+	for _, record := range records {
+		if record.ActionType != constants.ActionTypeRewrite || record.BeforeValue == record.AfterValue {
+			t.Errorf("unexpected record %+v", record)
+		}
+	}
+
+	if _, err := newValueRewriter([]core.RewriteRule{{Mode: constants.RewriteModeRegex, Match: "("}}); err == nil {
+		t.Error("invalid regex should be rejected")
+	}
+}
+
+func TestConfigFileExecuteRewritesValues(t *testing.T) {
+	dir := t.TempDir()
+	sourcePath := filepath.Join(dir, "source.json")
+	targetPath := filepath.Join(dir, "target.json")
+	source := "{\n  // 工作区\n  \"workspace\": \"C:\\\\Users\\\\alice\\\\work\",\n  \"theme\": \"dark\"\n}\n"
+	if err := os.WriteFile(sourcePath, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = sourcePath
+	config.Target.Path = targetPath
+	config.Rewrites = []core.RewriteRule{
+		{Match: `C:\\Users\\alice\\`, Replace: "/home/bob/", Scope: constants.RewriteScopeContent},
+		{Match: "dark", Replace: "light", KeyPattern: "theme"},
+	}
+
+	strategy := &ConfigFileStrategy{}
+	if err := strategy.Validate(config); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	result, err := strategy.Execute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	out, err := os.ReadFile(targetPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\n  // 工作区\n  \"workspace\": \"/home/bob/work\",\n  \"theme\": \"light\"\n}\n"
+	if string(out) != expected {
+		t.Errorf("unexpected target content:\n%s\nexpected:\n%s", out, expected)
+	}
+
+	var rewrites []string
+	for _, record := range result.Records {
+		if record.ActionType == constants.ActionTypeRewrite {
+			rewrites = append(rewrites, record.Key)
+		}
+	}
+	if len(rewrites) != 2 || rewrites[0] != "source.json" || rewrites[1] != "theme" {
+		t.Errorf("unexpected rewrite records: %v", rewrites)
+	}
+}
+
+func TestSoftwareExecuteRewritesFileContent(t *testing.T) {
+	dir := t.TempDir()
+	sourceDir := filepath.Join(dir, "source")
+	targetDir := filepath.Join(dir, "target")
+	if err := os.MkdirAll(filepath.Join(sourceDir, "options"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"options/recentProjects.xml": []byte(`<option value="C:\Users\alice\demo" />`),
+		"icon.bin":                   []byte("C:\\Users\\alice\x00"),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(sourceDir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := core.NewMigrationConfig()
+	config.Source.Path = sourceDir
+	config.Target.Path = targetDir
+	config.Rewrites = []core.RewriteRule{{Match: `C:\Users\alice`, Replace: "/home/bob"}}
+
+	strategy := &SoftwareStrategy{}
+	result, err := strategy.Execute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	out, err := os.ReadFile(filepath.Join(targetDir, "options", "recentProjects.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `<option value="/home/bob\demo" />` {
+		t.Errorf("text file not rewritten: %s", out)
+	}
+	binary, err := os.ReadFile(filepath.Join(targetDir, "icon.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(binary) != string(files["icon.bin"]) {
+		t.Errorf("binary file must be copied unchanged: %q", binary)
+	}
+
+	count := 0
+	for _, record := range result.Records {
+		if record.ActionType == constants.ActionTypeRewrite {
+			count++
+			if record.Key != filepath.Join("options", "recentProjects.xml") {
+				t.Errorf("unexpected rewrite record %+v", record)
+			}
+		}
+	}
+	if count != 1 {
+		t.Errorf("expected 1 rewrite record, got %d", count)
+	}
+}
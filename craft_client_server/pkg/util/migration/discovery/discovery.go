@@ -0,0 +1,355 @@
+// Package discovery 扫描本机已安装的应用及其版本：应用目录中的配置目录、PATH 中的可执行文件与包管理器元数据
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"tsc/pkg/util/migration/catalog"
+	"tsc/pkg/util/migration/core"
+	"tsc/pkg/util/server_command"
+	scconstants "tsc/pkg/util/server_command/constants"
+	sccore "tsc/pkg/util/server_command/core"
+)
+
+// 检测来源
+const (
+	SourceConfig  = "config"  // 应用目录中的配置目录存在
+	SourceBinary  = "binary"  // PATH 中的可执行文件
+	SourcePackage = "package" // 包管理器元数据
+)
+
+// defaultProbeTimeout 单个版本检测命令的超时时间
+const defaultProbeTimeout = 10 * time.Second
+
+// versionPattern 探测命令未指定 Pattern 时提取的版本号
+var versionPattern = regexp.MustCompile(`\d+(?:\.\d+)+(?:[-+.]?[0-9A-Za-z]+)*`)
+
+// globVersionPattern 配置目录名中通配符匹配部分作为版本号时的形式，只含数字与点（如 2024.1、2023.3.2）
+var globVersionPattern = regexp.MustCompile(`^\d+(?:\.\d+)*$`)
+
+// backupSuffix 迁移前备份目录的后缀，不作为已安装的配置目录
+const backupSuffix = ".backup"
+
+// Runner 执行版本检测命令，由 server_command 的 Executor 实现
+type Runner interface {
+	Execute(req *sccore.ExecuteRequest, opts *sccore.ExecuteOptions) (*sccore.ExecuteResponse, error)
+}
+
+// Inventory 本机已安装应用清单
+type Inventory struct {
+	// Hostname 主机名
+	Hostname string `json:"hostname" example:"alice-laptop"`
+
+	// OS 操作系统
+	OS string `json:"os" example:"linux"`
+
+	// Arch 处理器架构
+	Arch string `json:"arch" example:"amd64"`
+
+	// ScannedAt 扫描时间
+	ScannedAt time.Time `json:"scanned_at"`
+
+	// Apps 已安装的应用，按 id 排序
+	Apps []InstalledApp `json:"apps"`
+
+	// Warnings 扫描过程中的警告（如包管理器元数据无法读取）
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// InstalledApp 已安装的应用
+type InstalledApp struct {
+	// ID 应用目录中的应用标识
+	ID string `json:"id" example:"intellij-idea"`
+
+	// Name 应用名称
+	Name string `json:"name" example:"IntelliJ IDEA"`
+
+	// Category 应用类别
+	Category string `json:"category" example:"IDE"`
+
+	// Version 检测到的最高版本，未能确定版本时为空
+	Version string `json:"version" example:"2024.1"`
+
+	// Installations 各来源的检测结果
+	Installations []Installation `json:"installations"`
+}
+
+// Installation 一条检测结果
+type Installation struct {
+	// Source 检测来源 (config, binary, package)
+	Source string `json:"source" example:"config"`
+
+	// Detail 配置位置名称、可执行文件名或包管理器与包名
+	Detail string `json:"detail" example:"config"`
+
+	// Path 配置目录、可执行文件或包的路径
+	Path string `json:"path,omitempty"`
+
+	// Version 版本号，未能确定时为空
+	Version string `json:"version,omitempty" example:"2024.1"`
+}
+
+// Scanner 已安装应用扫描器
+type Scanner struct {
+	// Catalog 应用目录
+	Catalog *catalog.Catalog
+
+	// Runner 执行版本检测命令
+	Runner Runner
+
+	// LookPath 在 PATH 中查找可执行文件
+	LookPath func(file string) (string, error)
+
+	// Vars 配置目录的路径变量，默认为本机
+	Vars map[string]string
+
+	// ProbeTimeout 单个版本检测命令的超时时间
+	ProbeTimeout time.Duration
+
+	// DpkgStatus dpkg 状态文件
+	DpkgStatus string
+
+	// HomebrewDirs Homebrew 的 Cellar 与 Caskroom 目录
+	HomebrewDirs []string
+}
+
+// NewScanner 创建使用本机命令执行器与默认包管理器路径的扫描器
+func NewScanner(c *catalog.Catalog) *Scanner {
+	return &Scanner{
+		Catalog:      c,
+		Runner:       server_command.New(),
+		LookPath:     exec.LookPath,
+		ProbeTimeout: defaultProbeTimeout,
+		DpkgStatus:   "/var/lib/dpkg/status",
+		HomebrewDirs: []string{
+			"/opt/homebrew/Cellar", "/opt/homebrew/Caskroom",
+			"/usr/local/Cellar", "/usr/local/Caskroom",
+			"/home/linuxbrew/.linuxbrew/Cellar",
+		},
+	}
+}
+
+// Scan 扫描应用目录中在本机有配置位置的应用，返回检测到的应用清单
+func (s *Scanner) Scan(ctx context.Context) (*Inventory, error) {
+	inventory := &Inventory{OS: runtime.GOOS, Arch: runtime.GOARCH, ScannedAt: time.Now()}
+	inventory.Hostname, _ = os.Hostname()
+
+	packages, warnings := s.installedPackages()
+	inventory.Warnings = append(inventory.Warnings, warnings...)
+	vars := catalog.PathVars(runtime.GOOS, s.Vars)
+
+	for _, app := range s.Catalog.List("") {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var installations []Installation
+		found, err := s.configDirs(app, vars)
+		if err != nil {
+			inventory.Warnings = append(inventory.Warnings, err.Error())
+		}
+		installations = append(installations, found...)
+		installations = append(installations, s.probe(ctx, app)...)
+		for _, name := range app.Packages {
+			installations = append(installations, packages[name]...)
+		}
+		if len(installations) == 0 {
+			continue
+		}
+
+		installed := InstalledApp{ID: app.ID, Name: app.Name, Category: app.Category, Installations: installations}
+		for _, inst := range installations {
+			if inst.Version != "" && catalog.CompareVersions(inst.Version, installed.Version) > 0 {
+				installed.Version = inst.Version
+			}
+		}
+		inventory.Apps = append(inventory.Apps, installed)
+	}
+	return inventory, nil
+}
+
+// configDirs 检查应用在本机的配置位置，通配符匹配的每个目录记录一次，版本号取自通配符匹配的部分（如 IntelliJIdea2024.1 中的 2024.1）
+func (s *Scanner) configDirs(app *catalog.App, vars map[string]string) ([]Installation, error) {
+	var installations []Installation
+	for _, loc := range app.LocationsFor(runtime.GOOS) {
+		path, err := loc.Expand(runtime.GOOS, vars)
+		if err != nil {
+			return installations, fmt.Errorf("%s/%s: %w", app.ID, loc.Name, err)
+		}
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return installations, fmt.Errorf("%s/%s: %w", app.ID, loc.Name, err)
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			if strings.HasSuffix(match, backupSuffix) {
+				continue
+			}
+			installations = append(installations, Installation{
+				Source:  SourceConfig,
+				Detail:  loc.Name,
+				Path:    match,
+				Version: globVersion(filepath.Base(path), filepath.Base(match)),
+			})
+		}
+	}
+	return installations, nil
+}
+
+// globVersion 返回名称中与通配符模式 * 对应的部分，该部分完全由数字与点组成时作为版本号
+func globVersion(pattern, name string) string {
+	i := strings.IndexAny(pattern, "*?[")
+	if i < 0 || strings.Count(pattern, "*") != 1 || strings.ContainsAny(pattern, "?[") {
+		return ""
+	}
+	prefix, suffix := pattern[:i], pattern[i+1:]
+	if len(name) < len(prefix)+len(suffix) {
+		return ""
+	}
+	version := name[len(prefix) : len(name)-len(suffix)]
+	if !globVersionPattern.MatchString(version) {
+		return ""
+	}
+	return version
+}
+
+// probe 依次执行应用的版本检测命令，同名命令只执行一次；命令不在 PATH 中时跳过
+func (s *Scanner) probe(ctx context.Context, app *catalog.App) []Installation {
+	var installations []Installation
+	seen := make(map[string]bool)
+	for _, probe := range app.Probes {
+		path, err := s.LookPath(probe.Command)
+		if err != nil || seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		installation := Installation{Source: SourceBinary, Detail: probe.Command, Path: path}
+		timeout := s.ProbeTimeout
+		if timeout <= 0 {
+			timeout = defaultProbeTimeout
+		}
+		resp, _ := s.Runner.Execute(&sccore.ExecuteRequest{
+			Type:          scconstants.TypeCommand,
+			Command:       path,
+			Args:          probe.Args,
+			Timeout:       timeout,
+			CaptureOutput: true,
+		}, &sccore.ExecuteOptions{Context: ctx})
+		if resp != nil {
+			// 部分命令（如 ssh -V）将版本输出到标准错误
+			installation.Version = parseProbeVersion(probe.Pattern, resp.Stdout+"\n"+resp.Stderr)
+		}
+		installations = append(installations, installation)
+	}
+	return installations
+}
+
+// parseProbeVersion 从命令输出中提取版本号
+func parseProbeVersion(pattern, output string) string {
+	if pattern == "" {
+		return versionPattern.FindString(output)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return ""
+	}
+	m := re.FindStringSubmatch(output)
+	if len(m) < 2 {
+		return ""
+	}
+	return m[1]
+}
+
+// AppInfo 返回导出包中记录的应用信息
+func (a InstalledApp) AppInfo() core.AppInfo {
+	return core.AppInfo{Name: a.Name, Version: a.Version, Category: a.Category}
+}
+
+// Get 按 id 查找已安装的应用
+func (inv *Inventory) Get(id string) (InstalledApp, bool) {
+	for _, app := range inv.Apps {
+		if app.ID == id {
+			return app, true
+		}
+	}
+	return InstalledApp{}, false
+}
+
+// Configs 将清单中的应用按应用目录展开为迁移配置，并在 Source.Variables 中记录检测到的版本（app_version）
+// 配置目录的版本优先于应用的最高版本，使导出包记录实际迁移的版本；
+// 应用仅通过 PATH 或包管理器检测到时必需的配置位置可能不存在，此时跳过该位置并记录到 Warnings
+func (inv *Inventory) Configs(c *catalog.Catalog, opts catalog.ExpandOptions) ([]*core.MigrationConfig, error) {
+	var configs []*core.MigrationConfig
+	for _, installed := range inv.Apps {
+		app, ok := c.Get(installed.ID)
+		if !ok {
+			continue
+		}
+		expanded, err := inv.expandExisting(app, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, config := range expanded {
+			version := installed.Version
+			for _, inst := range installed.Installations {
+				if inst.Source == SourceConfig && inst.Path == config.Source.Path && inst.Version != "" {
+					version = inst.Version
+				}
+			}
+			if version != "" {
+				config.Source.Variables["app_version"] = version
+			}
+		}
+		configs = append(configs, expanded...)
+	}
+	return configs, nil
+}
+
+// expandExisting 展开应用的配置位置，跳过源路径不存在的位置
+func (inv *Inventory) expandExisting(app *catalog.App, opts catalog.ExpandOptions) ([]*core.MigrationConfig, error) {
+	expanded, err := app.Expand(opts)
+	if !errors.Is(err, os.ErrNotExist) {
+		return expanded, err
+	}
+
+	// 逐个位置展开，找出不存在的位置
+	expanded = nil
+	for _, loc := range app.Locations {
+		if len(opts.Locations) > 0 && !slices.Contains(opts.Locations, loc.Name) {
+			continue
+		}
+		locOpts := opts
+		locOpts.Locations = []string{loc.Name}
+		configs, err := app.Expand(locOpts)
+		if errors.Is(err, os.ErrNotExist) {
+			inv.Warnings = append(inv.Warnings, fmt.Sprintf("%s 的配置位置 %s 不存在，已跳过", app.ID, loc.Name))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, configs...)
+	}
+	return expanded, nil
+}
+
+// isDigits 判断字符串是否全为数字
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
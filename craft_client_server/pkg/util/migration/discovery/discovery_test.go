@@ -0,0 +1,169 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"tsc/pkg/util/migration/catalog"
+	sccore "tsc/pkg/util/server_command/core"
+)
+
+// fakeRunner 按可执行文件名返回预设输出
+type fakeRunner struct {
+	outputs map[string]*sccore.ExecuteResponse
+	calls   []string
+}
+
+func (r *fakeRunner) Execute(req *sccore.ExecuteRequest, opts *sccore.ExecuteOptions) (*sccore.ExecuteResponse, error) {
+	name := filepath.Base(req.Command)
+	r.calls = append(r.calls, name+" "+strings.Join(req.Args, " "))
+	if resp, ok := r.outputs[name]; ok {
+		return resp, nil
+	}
+	return nil, fmt.Errorf("not found")
+}
+
+// newTestScanner 创建只检查给定目录的扫描器，PATH 中只有 binaries
+func newTestScanner(t *testing.T, home string, binaries []string, runner *fakeRunner) *Scanner {
+	t.Helper()
+	c, err := catalog.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewScanner(c)
+	s.Runner = runner
+	s.Vars = map[string]string{"HOME": home}
+	s.DpkgStatus = filepath.Join(home, "dpkg-status")
+	s.HomebrewDirs = []string{filepath.Join(home, "Cellar")}
+	s.LookPath = func(file string) (string, error) {
+		for _, b := range binaries {
+			if b == file {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", fmt.Errorf("%s: not found", file)
+	}
+	return s
+}
+
+func TestScan(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("config paths below are for unix")
+	}
+	home := t.TempDir()
+	configDir := filepath.Join(home, ".config/JetBrains")
+	if runtime.GOOS == "darwin" {
+		configDir = filepath.Join(home, "Library/Application Support/JetBrains")
+	}
+	os.MkdirAll(filepath.Join(configDir, "IntelliJIdea2023.3"), 0755)
+	os.MkdirAll(filepath.Join(configDir, "IntelliJIdea2024.1"), 0755)
+	os.MkdirAll(filepath.Join(configDir, "IntelliJIdea2024.1.backup"), 0755)
+	os.MkdirAll(filepath.Join(home, "Cellar/tmux/3.4_1"), 0755)
+	os.WriteFile(filepath.Join(home, "dpkg-status"), []byte(`Package: git
+Status: install ok installed
+Version: 1:2.43.0-1ubuntu7
+Description: fast, scalable, distributed revision control system
+ Git is popular.
+
+Package: vim
+Status: deinstall ok config-files
+Version: 2:9.1.0016-1ubuntu7
+`), 0644)
+
+	runner := &fakeRunner{outputs: map[string]*sccore.ExecuteResponse{
+		"git": {Stdout: "git version 2.44.1\n"},
+		"ssh": {Stderr: "OpenSSH_9.6p1 Ubuntu-3ubuntu13, OpenSSL 3.0.13 30 Jan 2024\n"},
+		"npm": {Stdout: "10.5.0\n"},
+	}}
+	s := newTestScanner(t, home, []string{"git", "ssh", "npm"}, runner)
+
+	inventory, err := s.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inventory.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", inventory.Warnings)
+	}
+
+	want := map[string]string{"intellij-idea": "2024.1", "git": "2.44.1", "ssh": "9.6p1", "npm": "10.5.0", "tmux": "3.4"}
+	got := make(map[string]string)
+	for _, app := range inventory.Apps {
+		got[app.ID] = app.Version
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// 配置目录的每个版本与包管理器的版本都记录在检测结果中
+	idea, _ := inventory.Get("intellij-idea")
+	if len(idea.Installations) != 2 || idea.Installations[0].Version != "2023.3" || idea.Installations[0].Source != SourceConfig {
+		t.Errorf("unexpected idea installations: %+v", idea.Installations)
+	}
+	git, _ := inventory.Get("git")
+	if len(git.Installations) != 2 || git.Installations[1].Detail != "dpkg:git" || git.Installations[1].Version != "2.43.0" {
+		t.Errorf("unexpected git installations: %+v", git.Installations)
+	}
+	if info := git.AppInfo(); info.Name != "Git" || info.Version != "2.44.1" {
+		t.Errorf("unexpected app info: %+v", info)
+	}
+
+	// 展开为迁移配置时记录配置目录的版本；仅在 PATH 中检测到的 maven 没有 settings.xml，跳过并记录警告
+	c, _ := catalog.Builtin()
+	maven := InstalledApp{ID: "maven", Name: "Apache Maven", Installations: []Installation{{Source: SourceBinary, Detail: "mvn"}}}
+	inventory.Apps = []InstalledApp{idea, maven}
+	configs, err := inventory.Configs(c, catalog.ExpandOptions{SourceVars: s.Vars, TargetVars: map[string]string{"HOME": t.TempDir()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 1 || configs[0].Source.Variables["app_version"] != "2024.1" {
+		t.Errorf("unexpected configs: %+v", configs)
+	}
+	if len(inventory.Warnings) != 1 || !strings.Contains(inventory.Warnings[0], "maven") {
+		t.Errorf("unexpected warnings: %v", inventory.Warnings)
+	}
+}
+
+func TestScanProbeFallbacks(t *testing.T) {
+	home := t.TempDir()
+	runner := &fakeRunner{outputs: map[string]*sccore.ExecuteResponse{
+		"pip": {Stdout: "pip 24.0 from /usr/lib/python3/dist-packages/pip (python 3.12)\n"},
+	}}
+	// pip3 不在 PATH 中时使用 pip；vim 输出无法解析时记录为未知版本
+	s := newTestScanner(t, home, []string{"pip", "vim"}, runner)
+	runner.outputs["vim"] = &sccore.ExecuteResponse{Stdout: "unexpected\n"}
+
+	inventory, err := s.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pip, ok := inventory.Get("pip")
+	if !ok || pip.Version != "24.0" {
+		t.Errorf("unexpected pip: %+v", pip)
+	}
+	vim, ok := inventory.Get("vim")
+	if !ok || vim.Version != "" || len(vim.Installations) != 1 {
+		t.Errorf("unexpected vim: %+v", vim)
+	}
+	if len(runner.calls) != 2 {
+		t.Errorf("unexpected probe calls: %v", runner.calls)
+	}
+}
+
+func TestGlobVersion(t *testing.T) {
+	for pattern, cases := range map[string]map[string]string{
+		"IntelliJIdea*": {"IntelliJIdea2024.1": "2024.1", "IntelliJIdea-backup": "", "IntelliJIdea2024.1.backup": "", "IntelliJIdea2024.1-eap": ""},
+		"GoLand*":       {"GoLand2023.3.2": "2023.3.2"},
+		"Code":          {"Code": ""},
+	} {
+		for name, want := range cases {
+			if got := globVersion(pattern, name); got != want {
+				t.Errorf("globVersion(%q, %q) = %q, want %q", pattern, name, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// installedPackages 读取包管理器元数据，返回包名到检测结果的映射与读取失败的警告
+// 元数据不存在（未安装该包管理器）时不产生警告
+func (s *Scanner) installedPackages() (map[string][]Installation, []string) {
+	packages := make(map[string][]Installation)
+	var warnings []string
+
+	if s.DpkgStatus != "" {
+		if err := readDpkgStatus(s.DpkgStatus, packages); err != nil && !os.IsNotExist(err) {
+			warnings = append(warnings, fmt.Sprintf("读取 dpkg 状态失败: %v", err))
+		}
+	}
+	for _, dir := range s.HomebrewDirs {
+		if err := readHomebrewDir(dir, packages); err != nil && !os.IsNotExist(err) {
+			warnings = append(warnings, fmt.Sprintf("读取 Homebrew 目录失败: %v", err))
+		}
+	}
+	return packages, warnings
+}
+
+// readDpkgStatus 解析 dpkg 状态文件中已安装的包，版本号去掉 epoch 与 Debian 修订号（1:2.43.0-1ubuntu7 记为 2.43.0）
+func readDpkgStatus(path string, packages map[string][]Installation) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	for _, paragraph := range bytes.Split(content, []byte("\n\n")) {
+		fields := make(map[string]string)
+		scanner := bufio.NewScanner(bytes.NewReader(paragraph))
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			// 以空白开头的续行属于多行字段（如 Description），不需要
+			if line == "" || line[0] == ' ' || line[0] == '\t' {
+				continue
+			}
+			if name, value, ok := strings.Cut(line, ":"); ok {
+				fields[name] = strings.TrimSpace(value)
+			}
+		}
+		name := fields["Package"]
+		if name == "" || !strings.HasSuffix(fields["Status"], " installed") {
+			continue
+		}
+		version := fields["Version"]
+		if _, v, ok := strings.Cut(version, ":"); ok {
+			version = v
+		}
+		if i := strings.LastIndex(version, "-"); i > 0 {
+			version = version[:i]
+		}
+		packages[name] = append(packages[name], Installation{
+			Source:  SourcePackage,
+			Detail:  "dpkg:" + name,
+			Path:    path,
+			Version: version,
+		})
+	}
+	return nil
+}
+
+// readHomebrewDir 读取 Homebrew 的 Cellar 或 Caskroom 目录：<dir>/<包名>/<版本>，版本号去掉 _N 修订号
+func readHomebrewDir(dir string, packages map[string][]Installation) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		versions, err := os.ReadDir(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		var names []string
+		for _, v := range versions {
+			if v.IsDir() && !strings.HasPrefix(v.Name(), ".") {
+				names = append(names, v.Name())
+			}
+		}
+		sort.Strings(names)
+		for _, v := range names {
+			version := v
+			if i := strings.LastIndex(version, "_"); i > 0 && isDigits(version[i+1:]) {
+				version = version[:i]
+			}
+			packages[entry.Name()] = append(packages[entry.Name()], Installation{
+				Source:  SourcePackage,
+				Detail:  "homebrew:" + entry.Name(),
+				Path:    filepath.Join(dir, entry.Name(), v),
+				Version: version,
+			})
+		}
+	}
+	return nil
+}
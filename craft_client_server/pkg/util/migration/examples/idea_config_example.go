@@ -55,80 +55,50 @@ func main() {
 	fmt.Println("=== 测试完成 ===")
 }
 
-// exportIDEAConfig 导出 IDEA 配置文件
+// exportIDEAConfig 导出 IDEA 配置文件，每个子目录一次导出
 func exportIDEAConfig() int {
 	count := 0
 	subDirs := []string{"options", "codestyles", "colors", "keymaps", "inspection", "fileTemplates"}
 
+	strategy, err := migration.GetStrategy(migration.MigrationType.ConfigFile)
+	if err != nil {
+		fmt.Printf("获取迁移策略失败: %v\n", err)
+		return 0
+	}
+
 	for _, subDir := range subDirs {
 		subPath := filepath.Join(ideaSourceDir, subDir)
 		if _, err := os.Stat(subPath); os.IsNotExist(err) {
 			fmt.Printf("跳过不存在的目录: %s\n", subPath)
 			continue
 		}
-
-		// 创建导出子目录
-		exportSubDir := filepath.Join(exportTargetDir, subDir)
-		if err := os.MkdirAll(exportSubDir, 0755); err != nil {
-			fmt.Printf("创建导出子目录失败: %v\n", err)
+		fmt.Printf("导出目录: %s\n", subPath)
+
+		// 源路径为目录时逐个文件推断格式，导出包按相对路径写入导出目录
+		config := migration.NewConfig()
+		config.TaskID = fmt.Sprintf("export_%s_%d", subDir, time.Now().Unix())
+		config.Name = fmt.Sprintf("导出 IDEA 配置目录: %s", subDir)
+		config.Type = migration.MigrationType.ConfigFile
+		config.Source.Path = subPath
+		config.Options.OperationMode = "export"
+		config.Options.ExportPath = filepath.Join(exportTargetDir, subDir)
+		config.Options.IncludeRawContent = true // 包含原始内容
+		config.Options.Verbose = true
+
+		// 验证导出配置
+		if err := strategy.ValidateExport(config); err != nil {
+			fmt.Printf("验证导出配置失败: %v\n", err)
 			continue
 		}
 
-		// 遍历目录中的文件
-		files, err := os.ReadDir(subPath)
+		// 执行导出，单个文件失败不影响其余文件
+		result, err := strategy.Export(context.Background(), config)
 		if err != nil {
-			fmt.Printf("读取目录失败: %v\n", err)
-			continue
+			fmt.Printf("导出失败: %v\n", err)
 		}
 
-		for _, file := range files {
-			if file.IsDir() {
-				continue
-			}
-
-			filePath := filepath.Join(subPath, file.Name())
-			fmt.Printf("导出文件: %s\n", filePath)
-
-			// 创建迁移配置
-			config := migration.NewConfig()
-			config.TaskID = fmt.Sprintf("export_%s_%d", file.Name(), time.Now().Unix())
-			config.Name = fmt.Sprintf("导出 IDEA 配置文件: %s", file.Name())
-			config.Type = migration.MigrationType.ConfigFile
-
-			// 设置源配置
-			config.Source.Path = filePath
-			config.Source.Format = detectFileFormat(filePath)
-			config.Source.Encoding = "utf-8"
-
-			// 设置导出选项
-			config.Options.OperationMode = "export"
-			config.Options.ExportPath = filepath.Join(exportSubDir, file.Name()+".export.json")
-			config.Options.IncludeRawContent = true // 包含原始内容
-			config.Options.Verbose = true
-
-			// 获取策略并执行导出
-			strategy, err := migration.GetStrategy(migration.MigrationType.ConfigFile)
-			if err != nil {
-				fmt.Printf("获取迁移策略失败: %v\n", err)
-				continue
-			}
-
-			// 验证导出配置
-			if err := strategy.ValidateExport(config); err != nil {
-				fmt.Printf("验证导出配置失败: %v\n", err)
-				continue
-			}
-
-			// 执行导出
-			result, err := strategy.Export(context.Background(), config)
-			if err != nil {
-				fmt.Printf("导出失败: %v\n", err)
-				continue
-			}
-
-			fmt.Printf("  -> 状态: %s, 路径: %s\n", result.Status, result.ExportPath)
-			count++
-		}
+		fmt.Printf("  -> 状态: %s, %s\n", result.Status, result.Message)
+		count += countRecords(result.Records, migration.ActionType.Export)
 	}
 
 	// 导出目录结构信息
@@ -137,94 +107,64 @@ func exportIDEAConfig() int {
 	return count
 }
 
-// importIDEAConfig 导入 IDEA 配置文件
+// importIDEAConfig 导入 IDEA 配置文件，每个子目录一次导入
 func importIDEAConfig() int {
 	count := 0
 	subDirs := []string{"options", "codestyles", "colors", "keymaps", "inspection", "fileTemplates"}
 
+	strategy, err := migration.GetStrategy(migration.MigrationType.ConfigFile)
+	if err != nil {
+		fmt.Printf("获取迁移策略失败: %v\n", err)
+		return 0
+	}
+
 	for _, subDir := range subDirs {
 		exportSubDir := filepath.Join(exportTargetDir, subDir)
 		if _, err := os.Stat(exportSubDir); os.IsNotExist(err) {
 			continue
 		}
-
-		// 创建导入子目录
-		importSubDir := filepath.Join(importTargetDir, subDir)
-		if err := os.MkdirAll(importSubDir, 0755); err != nil {
-			fmt.Printf("创建导入子目录失败: %v\n", err)
+		fmt.Printf("导入目录: %s\n", exportSubDir)
+
+		// 导入路径为目录时导入其中全部 .export.json，目标路径镜像导出目录结构
+		config := migration.NewConfig()
+		config.TaskID = fmt.Sprintf("import_%s_%d", subDir, time.Now().Unix())
+		config.Name = fmt.Sprintf("导入 IDEA 配置目录: %s", subDir)
+		config.Type = migration.MigrationType.ConfigFile
+		config.Options.OperationMode = "import"
+		config.Options.ImportPath = exportSubDir
+		config.Options.PreserveFormat = true // 保持原始格式
+		config.Target.Path = filepath.Join(importTargetDir, subDir)
+		config.Target.Backup = true
+		config.Target.MergeMode = "overwrite"
+		config.Target.CreateIfNotExists = true
+
+		// 验证导入配置
+		if err := strategy.ValidateImport(config); err != nil {
+			fmt.Printf("验证导入配置失败: %v\n", err)
 			continue
 		}
 
-		// 遍历导出的文件
-		files, err := os.ReadDir(exportSubDir)
+		// 执行导入
+		result, err := strategy.Import(context.Background(), config)
 		if err != nil {
-			fmt.Printf("读取导出目录失败: %v\n", err)
-			continue
+			fmt.Printf("导入失败: %v\n", err)
 		}
 
-		for _, file := range files {
-			if file.IsDir() {
-				continue
-			}
-
-			// 只处理导出文件
-			if filepath.Ext(file.Name()) != ".json" {
-				continue
-			}
-
-			exportFilePath := filepath.Join(exportSubDir, file.Name())
-
-			// 从导出包中获取原始文件名
-			originalName, err := getOriginalFileName(exportFilePath)
-			if err != nil {
-				fmt.Printf("获取原始文件名失败: %v\n", err)
-				continue
-			}
-
-			fmt.Printf("导入文件: %s -> %s\n", exportFilePath, originalName)
-
-			// 创建迁移配置
-			config := migration.NewConfig()
-			config.TaskID = fmt.Sprintf("import_%s_%d", originalName, time.Now().Unix())
-			config.Name = fmt.Sprintf("导入 IDEA 配置文件: %s", originalName)
-			config.Type = migration.MigrationType.ConfigFile
-
-			// 设置导入选项
-			config.Options.OperationMode = "import"
-			config.Options.ImportPath = exportFilePath
-			config.Options.PreserveFormat = true // 保持原始格式
-
-			// 设置目标配置
-			config.Target.Path = filepath.Join(importSubDir, originalName)
-			config.Target.Backup = true
-			config.Target.MergeMode = "overwrite"
-			config.Target.CreateIfNotExists = true
-
-			// 获取策略并执行导入
-			strategy, err := migration.GetStrategy(migration.MigrationType.ConfigFile)
-			if err != nil {
-				fmt.Printf("获取迁移策略失败: %v\n", err)
-				continue
-			}
-
-			// 验证导入配置
-			if err := strategy.ValidateImport(config); err != nil {
-				fmt.Printf("验证导入配置失败: %v\n", err)
-				continue
-			}
+		fmt.Printf("  -> 状态: %s, %s\n", result.Status, result.Message)
+		count += countRecords(result.Records, migration.ActionType.Import)
+	}
 
-			// 执行导入
-			result, err := strategy.Import(context.Background(), config)
-			if err != nil {
-				fmt.Printf("导入失败: %v\n", err)
-				continue
-			}
+	return count
+}
 
-			fmt.Printf("  -> 状态: %s, 成功: %d\n", result.Status, result.Summary.Success)
+// countRecords 统计指定操作类型的成功记录数
+func countRecords(records []core.MigrationRecord, actionType string) int {
+	count := 0
+	for _, record := range records {
+		if record.ActionType == actionType && record.Status == "success" {
 			count++
 		}
 	}
-
 	return count
 }
 
@@ -306,37 +246,3 @@ func verifyResult() {
 
 	fmt.Printf("\n验证结果: 总计=%d, 成功=%d, 失败=%d\n", totalFiles, successFiles, totalFiles-successFiles)
 }
-
-// detectFileFormat 检测文件格式
-func detectFileFormat(path string) string {
-	ext := filepath.Ext(path)
-	switch ext {
-	case ".json":
-		return "json"
-	case ".yaml", ".yml":
-		return "yaml"
-	case ".ini":
-		return "ini"
-	case ".toml":
-		return "toml"
-	case ".xml":
-		return "xml"
-	default:
-		return "unknown"
-	}
-}
-
-// getOriginalFileName 从导出包获取原始文件名
-func getOriginalFileName(exportPath string) (string, error) {
-	data, err := os.ReadFile(exportPath)
-	if err != nil {
-		return "", err
-	}
-
-	var exportPkg core.ExportPackage
-	if err := json.Unmarshal(data, &exportPkg); err != nil {
-		return "", err
-	}
-
-	return filepath.Base(exportPkg.Metadata.OriginalPath), nil
-}
@@ -12,11 +12,15 @@ var MigrationType = struct {
 	ConfigFile  core.MigrationType
 	Software    core.MigrationType
 	Registry    core.MigrationType
+	JetBrains   core.MigrationType
+	Dotfiles    core.MigrationType
 }{
 	EnvVariable: constants.MigrationTypeEnvVariable,
 	ConfigFile:  constants.MigrationTypeConfigFile,
 	Software:    constants.MigrationTypeSoftware,
 	Registry:    constants.MigrationTypeRegistry,
+	JetBrains:   constants.MigrationTypeJetBrains,
+	Dotfiles:    constants.MigrationTypeDotfiles,
 }
 
 // TaskStatus 任务状态常量
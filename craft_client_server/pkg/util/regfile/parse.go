@@ -0,0 +1,256 @@
+package regfile
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Parse 解析 .reg 文件内容
+// 支持 UTF-16LE（带或不带 BOM）与 UTF-8 编码、以 \ 结尾的续行、; 注释，
+// 以及 [-项] 与 "值"=- 形式的删除条目
+func Parse(data []byte) (*File, error) {
+	text, encoding := decodeText(data)
+	f := &File{Encoding: encoding}
+
+	lines := logicalLines(text)
+	var key *Key
+	for _, line := range lines {
+		content := strings.TrimSpace(line.text)
+		if content == "" || strings.HasPrefix(content, ";") {
+			continue
+		}
+
+		if f.Header == "" {
+			switch content {
+			case HeaderV5, HeaderV4:
+				f.Header = content
+				continue
+			}
+			return nil, fmt.Errorf("line %d: missing registry file header", line.number)
+		}
+
+		if strings.HasPrefix(content, "[") {
+			if !strings.HasSuffix(content, "]") {
+				return nil, fmt.Errorf("line %d: unterminated key path", line.number)
+			}
+			path := content[1 : len(content)-1]
+			k := &Key{Path: path}
+			if strings.HasPrefix(path, "-") {
+				k.Path, k.Delete = path[1:], true
+			}
+			if k.Path == "" {
+				return nil, fmt.Errorf("line %d: empty key path", line.number)
+			}
+			f.Keys = append(f.Keys, k)
+			key = k
+			continue
+		}
+
+		if key == nil {
+			return nil, fmt.Errorf("line %d: value outside of a key", line.number)
+		}
+		value, err := parseValue(content, f.Header == HeaderV4)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.number, err)
+		}
+		key.Values = append(key.Values, value)
+	}
+
+	if f.Header == "" {
+		return nil, fmt.Errorf("missing registry file header")
+	}
+	return f, nil
+}
+
+// decodeText 按 BOM 或内容判断编码并解码为字符串
+func decodeText(data []byte) (string, string) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return decodeUTF16(data[2:]), EncodingUTF16LE
+	case len(data) >= 2 && data[0] != 0 && data[1] == 0:
+		// 无 BOM 的 UTF-16LE：文件头均为 ASCII 字符，高字节为 0
+		return decodeUTF16(data), EncodingUTF16LE
+	}
+	data = bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})
+	if utf8.Valid(data) {
+		return string(data), EncodingUTF8
+	}
+	// REGEDIT4 的 ANSI 文件按 Latin-1 逐字节解码
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes), EncodingUTF8
+}
+
+// logicalLine 合并续行后的逻辑行
+type logicalLine struct {
+	number int // 起始行号
+	text   string
+}
+
+// logicalLines 拆分行，并将以 \ 结尾的行与下一行合并
+func logicalLines(text string) []logicalLine {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var lines []logicalLine
+	var current *logicalLine
+	for i, raw := range strings.Split(text, "\n") {
+		raw = strings.TrimRight(raw, " \t\r")
+		if current != nil {
+			current.text += strings.TrimLeft(raw, " \t")
+		} else {
+			lines = append(lines, logicalLine{number: i + 1, text: raw})
+			current = &lines[len(lines)-1]
+		}
+		if strings.HasSuffix(current.text, `\`) && !strings.HasPrefix(strings.TrimSpace(current.text), ";") {
+			current.text = current.text[:len(current.text)-1]
+			continue
+		}
+		current = nil
+	}
+	return lines
+}
+
+// parseValue 解析值行，如 "Name"="data"、@=dword:00000001、"Bin"=hex:01,02
+func parseValue(line string, ansi bool) (*Value, error) {
+	v := &Value{}
+	var rest string
+	if strings.HasPrefix(line, "@") {
+		rest = strings.TrimSpace(line[1:])
+	} else {
+		name, n, err := parseQuoted(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value name: %w", err)
+		}
+		v.Name, rest = name, strings.TrimSpace(line[n:])
+	}
+	data, ok := strings.CutPrefix(rest, "=")
+	if !ok {
+		return nil, fmt.Errorf("missing '=' after value name %q", v.Name)
+	}
+	data = strings.TrimSpace(data)
+
+	switch {
+	case data == "-":
+		v.Delete = true
+	case strings.HasPrefix(data, `"`):
+		s, n, err := parseQuoted(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string value %q: %w", v.Name, err)
+		}
+		if tail := strings.TrimSpace(data[n:]); tail != "" && !strings.HasPrefix(tail, ";") {
+			return nil, fmt.Errorf("unexpected content after string value %q", v.Name)
+		}
+		v.Type, v.Data = TypeString, encodeUTF16(s)
+	case hasPrefixFold(data, "dword:"):
+		n, err := strconv.ParseUint(strings.TrimSpace(data[len("dword:"):]), 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dword value %q: %w", v.Name, err)
+		}
+		*v = *DWordValue(v.Name, uint32(n))
+	case hasPrefixFold(data, "hex"):
+		typ, bytesText, err := splitHexPrefix(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex value %q: %w", v.Name, err)
+		}
+		raw, err := ParseHex(bytesText)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex value %q: %w", v.Name, err)
+		}
+		// REGEDIT4 中的字符串数据为 ANSI，统一转换为 UTF-16LE
+		if ansi && typ.isString() {
+			raw = ansiToUTF16(raw)
+		}
+		v.Type, v.Data = typ, raw
+	default:
+		return nil, fmt.Errorf("unsupported data for value %q: %s", v.Name, data)
+	}
+	return v, nil
+}
+
+// parseQuoted 解析以 " 开头的字符串，处理 \\ 与 \" 转义，返回字符串与消耗的字节数
+func parseQuoted(s string) (string, int, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", 0, fmt.Errorf("expected '\"'")
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			if i+1 < len(s) && (s[i+1] == '\\' || s[i+1] == '"') {
+				i++
+				b.WriteByte(s[i])
+			} else {
+				b.WriteByte(c)
+			}
+		case '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+// splitHexPrefix 解析 hex: 或 hex(N): 前缀，返回值类型与其后的字节文本
+func splitHexPrefix(data string) (ValueType, string, error) {
+	rest := data[len("hex"):]
+	if strings.HasPrefix(rest, ":") {
+		return TypeBinary, rest[1:], nil
+	}
+	if !strings.HasPrefix(rest, "(") {
+		return 0, "", fmt.Errorf("expected 'hex:' or 'hex(type):'")
+	}
+	end := strings.Index(rest, "):")
+	if end < 0 {
+		return 0, "", fmt.Errorf("unterminated hex type")
+	}
+	n, err := strconv.ParseUint(rest[1:end], 16, 32)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid hex type %q", rest[1:end])
+	}
+	return ValueType(n), rest[end+2:], nil
+}
+
+// ParseHex 解析逗号分隔的十六进制字节，如 de,ad,be,ef
+func ParseHex(text string) ([]byte, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return []byte{}, nil
+	}
+	parts := strings.Split(text, ",")
+	data := make([]byte, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if len(part) == 1 {
+			part = "0" + part
+		}
+		b, err := hex.DecodeString(part)
+		if err != nil || len(b) != 1 {
+			return nil, fmt.Errorf("invalid byte %q", part)
+		}
+		data = append(data, b[0])
+	}
+	return data, nil
+}
+
+// ansiToUTF16 将单字节字符数据按 Latin-1 转换为 UTF-16LE
+func ansiToUTF16(data []byte) []byte {
+	out := make([]byte, 0, len(data)*2)
+	for _, b := range data {
+		out = append(out, b, 0)
+	}
+	return out
+}
+
+// hasPrefixFold 不区分大小写的前缀判断
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
@@ -0,0 +1,260 @@
+// Package regfile 解析和生成 regedit 导出的 .reg 文件，不依赖 Windows 的 reg 命令
+package regfile
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// 文件头
+const (
+	HeaderV5 = "Windows Registry Editor Version 5.00" // regedit 默认格式，字符串数据为 UTF-16LE
+	HeaderV4 = "REGEDIT4"                             // 旧格式，字符串数据为 ANSI
+)
+
+// 文件编码
+const (
+	EncodingUTF16LE = "utf-16le" // regedit 默认，带 BOM
+	EncodingUTF8    = "utf-8"
+)
+
+// ValueType 注册表值类型，取值与 Windows 的 REG_* 常量一致
+type ValueType uint32
+
+const (
+	TypeNone                     ValueType = 0  // REG_NONE
+	TypeString                   ValueType = 1  // REG_SZ
+	TypeExpandString             ValueType = 2  // REG_EXPAND_SZ
+	TypeBinary                   ValueType = 3  // REG_BINARY
+	TypeDWord                    ValueType = 4  // REG_DWORD
+	TypeDWordBigEndian           ValueType = 5  // REG_DWORD_BIG_ENDIAN
+	TypeLink                     ValueType = 6  // REG_LINK
+	TypeMultiString              ValueType = 7  // REG_MULTI_SZ
+	TypeResourceList             ValueType = 8  // REG_RESOURCE_LIST
+	TypeFullResourceDescriptor   ValueType = 9  // REG_FULL_RESOURCE_DESCRIPTOR
+	TypeResourceRequirementsList ValueType = 10 // REG_RESOURCE_REQUIREMENTS_LIST
+	TypeQWord                    ValueType = 11 // REG_QWORD
+)
+
+var typeNames = map[ValueType]string{
+	TypeNone:                     "REG_NONE",
+	TypeString:                   "REG_SZ",
+	TypeExpandString:             "REG_EXPAND_SZ",
+	TypeBinary:                   "REG_BINARY",
+	TypeDWord:                    "REG_DWORD",
+	TypeDWordBigEndian:           "REG_DWORD_BIG_ENDIAN",
+	TypeLink:                     "REG_LINK",
+	TypeMultiString:              "REG_MULTI_SZ",
+	TypeResourceList:             "REG_RESOURCE_LIST",
+	TypeFullResourceDescriptor:   "REG_FULL_RESOURCE_DESCRIPTOR",
+	TypeResourceRequirementsList: "REG_RESOURCE_REQUIREMENTS_LIST",
+	TypeQWord:                    "REG_QWORD",
+}
+
+// String 返回类型名称，如 REG_SZ；未知类型返回 REG_0x 加十六进制编号
+func (t ValueType) String() string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("REG_0x%x", uint32(t))
+}
+
+// ParseValueType 解析 String 返回的类型名称
+func ParseValueType(name string) (ValueType, bool) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	for t, typeName := range typeNames {
+		if typeName == name {
+			return t, true
+		}
+	}
+	var n uint32
+	if _, err := fmt.Sscanf(name, "REG_0X%x", &n); err == nil {
+		return ValueType(n), true
+	}
+	return 0, false
+}
+
+// isString 判断类型的数据是否为 UTF-16LE 字符串
+func (t ValueType) isString() bool {
+	return t == TypeString || t == TypeExpandString || t == TypeLink || t == TypeMultiString
+}
+
+// Value 注册表值
+type Value struct {
+	Name   string    // 值名称，空字符串表示默认值（文件中写作 @）
+	Type   ValueType // 值类型
+	Data   []byte    // 注册表中存储的原始数据：字符串为以 0 结尾的 UTF-16LE，DWORD/QWORD 为小端序
+	Delete bool      // 删除该值（文件中写作 "name"=-）
+}
+
+// StringValue 创建 REG_SZ 值
+func StringValue(name, s string) *Value {
+	return &Value{Name: name, Type: TypeString, Data: encodeUTF16(s)}
+}
+
+// ExpandStringValue 创建 REG_EXPAND_SZ 值
+func ExpandStringValue(name, s string) *Value {
+	return &Value{Name: name, Type: TypeExpandString, Data: encodeUTF16(s)}
+}
+
+// MultiStringValue 创建 REG_MULTI_SZ 值
+func MultiStringValue(name string, items []string) *Value {
+	var data []byte
+	for _, item := range items {
+		data = append(data, encodeUTF16(item)...)
+	}
+	return &Value{Name: name, Type: TypeMultiString, Data: append(data, 0, 0)}
+}
+
+// DWordValue 创建 REG_DWORD 值
+func DWordValue(name string, n uint32) *Value {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, n)
+	return &Value{Name: name, Type: TypeDWord, Data: data}
+}
+
+// QWordValue 创建 REG_QWORD 值
+func QWordValue(name string, n uint64) *Value {
+	data := make([]byte, 8)
+	binary.LittleEndian.PutUint64(data, n)
+	return &Value{Name: name, Type: TypeQWord, Data: data}
+}
+
+// BinaryValue 创建 REG_BINARY 值
+func BinaryValue(name string, data []byte) *Value {
+	return &Value{Name: name, Type: TypeBinary, Data: append([]byte(nil), data...)}
+}
+
+// DeleteValue 创建删除值的条目
+func DeleteValue(name string) *Value {
+	return &Value{Name: name, Delete: true}
+}
+
+// AsString 返回 REG_SZ、REG_EXPAND_SZ、REG_LINK 值的字符串
+func (v *Value) AsString() (string, error) {
+	if v.Type != TypeString && v.Type != TypeExpandString && v.Type != TypeLink {
+		return "", fmt.Errorf("value %q is %s, not a string", v.Name, v.Type)
+	}
+	s := decodeUTF16(v.Data)
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return s, nil
+}
+
+// AsStrings 返回 REG_MULTI_SZ 值的字符串列表
+func (v *Value) AsStrings() ([]string, error) {
+	if v.Type != TypeMultiString {
+		return nil, fmt.Errorf("value %q is %s, not a multi-string", v.Name, v.Type)
+	}
+	s := strings.TrimRight(decodeUTF16(v.Data), "\x00")
+	if s == "" {
+		return []string{}, nil
+	}
+	return strings.Split(s, "\x00"), nil
+}
+
+// AsUint64 返回 REG_DWORD、REG_DWORD_BIG_ENDIAN、REG_QWORD 值的数值
+func (v *Value) AsUint64() (uint64, error) {
+	switch {
+	case v.Type == TypeDWord && len(v.Data) == 4:
+		return uint64(binary.LittleEndian.Uint32(v.Data)), nil
+	case v.Type == TypeDWordBigEndian && len(v.Data) == 4:
+		return uint64(binary.BigEndian.Uint32(v.Data)), nil
+	case v.Type == TypeQWord && len(v.Data) == 8:
+		return binary.LittleEndian.Uint64(v.Data), nil
+	}
+	return 0, fmt.Errorf("value %q is %s with %d bytes, not a number", v.Name, v.Type, len(v.Data))
+}
+
+// Key 注册表项及其下的值
+type Key struct {
+	Path   string   // 完整路径，如 HKEY_CURRENT_USER\Software\Vendor
+	Delete bool     // 删除整个项（文件中写作 [-path]）
+	Values []*Value // 按文件中的顺序排列
+}
+
+// Value 按名称查找值，名称不区分大小写
+func (k *Key) Value(name string) *Value {
+	for _, v := range k.Values {
+		if strings.EqualFold(v.Name, name) {
+			return v
+		}
+	}
+	return nil
+}
+
+// Set 设置值，已有同名值时替换
+func (k *Key) Set(v *Value) {
+	for i, existing := range k.Values {
+		if strings.EqualFold(existing.Name, v.Name) {
+			k.Values[i] = v
+			return
+		}
+	}
+	k.Values = append(k.Values, v)
+}
+
+// Remove 删除指定名称的值，名称不区分大小写，返回是否存在该值
+func (k *Key) Remove(name string) bool {
+	for i, existing := range k.Values {
+		if strings.EqualFold(existing.Name, name) {
+			k.Values = append(k.Values[:i], k.Values[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// File .reg 文件
+type File struct {
+	Header   string // HeaderV5 或 HeaderV4
+	Encoding string // 文件编码，EncodingUTF16LE 或 EncodingUTF8
+	Keys     []*Key // 按文件中的顺序排列
+}
+
+// New 创建 regedit 默认格式的空文件
+func New() *File {
+	return &File{Header: HeaderV5, Encoding: EncodingUTF16LE}
+}
+
+// Key 按路径查找项，路径不区分大小写
+func (f *File) Key(path string) *Key {
+	for _, k := range f.Keys {
+		if strings.EqualFold(k.Path, path) {
+			return k
+		}
+	}
+	return nil
+}
+
+// AddKey 返回指定路径的项，不存在时追加
+func (f *File) AddKey(path string) *Key {
+	if k := f.Key(path); k != nil {
+		return k
+	}
+	k := &Key{Path: path}
+	f.Keys = append(f.Keys, k)
+	return k
+}
+
+// encodeUTF16 将字符串编码为以 0 结尾的 UTF-16LE
+func encodeUTF16(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	data := make([]byte, 0, len(units)*2+2)
+	for _, u := range units {
+		data = append(data, byte(u), byte(u>>8))
+	}
+	return append(data, 0, 0)
+}
+
+// decodeUTF16 解码 UTF-16LE 数据，奇数长度时忽略最后一个字节
+func decodeUTF16(data []byte) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(data[i*2:])
+	}
+	return string(utf16.Decode(units))
+}
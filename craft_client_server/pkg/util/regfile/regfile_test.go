@@ -0,0 +1,176 @@
+package regfile
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const sample = `Windows Registry Editor Version 5.00
+
+; 示例导出
+[HKEY_CURRENT_USER\Software\EnvCraft]
+@="default"
+"Path"="C:\\Program Files\\EnvCraft"
+"Quote"="say \"hi\""
+"Count"=dword:0000001f
+"Big"=hex(b):00,01,00,00,00,00,00,00
+"Home"=hex(2):25,00,55,00,53,00,45,00,52,00,50,00,52,00,4f,00,46,00,49,00,4c,00,\
+  45,00,25,00,00,00
+"List"=hex(7):61,00,00,00,62,00,63,00,00,00,00,00
+"Blob"=hex:de,ad,be,ef
+"Empty"=hex:
+"Old"=-
+
+[-HKEY_CURRENT_USER\Software\EnvCraft\Legacy]
+`
+
+func TestParse(t *testing.T) {
+	f, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Header != HeaderV5 || f.Encoding != EncodingUTF8 || len(f.Keys) != 2 {
+		t.Fatalf("unexpected file: %+v", f)
+	}
+	key := f.Key(`hkey_current_user\software\envcraft`)
+	if key == nil || len(key.Values) != 10 {
+		t.Fatalf("unexpected key: %+v", key)
+	}
+
+	expectString := func(name, expected string) {
+		t.Helper()
+		if s, err := key.Value(name).AsString(); err != nil || s != expected {
+			t.Errorf("%q: got %q (%v), expected %q", name, s, err, expected)
+		}
+	}
+	expectString("", "default")
+	expectString("Path", `C:\Program Files\EnvCraft`)
+	expectString("Quote", `say "hi"`)
+	expectString("Home", "%USERPROFILE%")
+
+	if n, err := key.Value("Count").AsUint64(); err != nil || n != 31 {
+		t.Errorf("unexpected dword %d (%v)", n, err)
+	}
+	if n, err := key.Value("Big").AsUint64(); err != nil || n != 256 {
+		t.Errorf("unexpected qword %d (%v)", n, err)
+	}
+	if items, err := key.Value("List").AsStrings(); err != nil || strings.Join(items, "|") != "a|bc" {
+		t.Errorf("unexpected multi-string %q (%v)", items, err)
+	}
+	if v := key.Value("Blob"); v.Type != TypeBinary || !bytes.Equal(v.Data, []byte{0xde, 0xad, 0xbe, 0xef}) {
+		t.Errorf("unexpected binary %+v", v)
+	}
+	if v := key.Value("Empty"); v.Type != TypeBinary || len(v.Data) != 0 {
+		t.Errorf("unexpected empty binary %+v", v)
+	}
+	if !key.Value("Old").Delete || !f.Keys[1].Delete {
+		t.Error("deletions not parsed")
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	f, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Encoding = EncodingUTF16LE
+	data := f.Marshal()
+	if !bytes.HasPrefix(data, []byte{0xFF, 0xFE}) || !bytes.Contains(data, []byte{'\r', 0, '\n', 0}) {
+		t.Fatal("expected UTF-16LE with BOM and CRLF line endings")
+	}
+
+	again, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Encoding != EncodingUTF16LE {
+		t.Errorf("unexpected encoding %s", again.Encoding)
+	}
+	if again.Text() != f.Text() {
+		t.Errorf("round trip changed content:\n%s\n---\n%s", again.Text(), f.Text())
+	}
+	for _, line := range strings.Split(f.Text(), "\n") {
+		if len(line) > maxLineWidth {
+			t.Errorf("line exceeds %d characters: %s", maxLineWidth, line)
+		}
+	}
+}
+
+func TestWriteValues(t *testing.T) {
+	f := New()
+	key := f.AddKey(`HKEY_LOCAL_MACHINE\Software\EnvCraft`)
+	key.Set(StringValue("Name", `a\b "c"`))
+	key.Set(StringValue("Multiline", "line1\nline2"))
+	key.Set(ExpandStringValue("Home", "%USERPROFILE%"))
+	key.Set(MultiStringValue("List", []string{"x", "y"}))
+	key.Set(DWordValue("Flag", 1))
+	key.Set(QWordValue("Size", 1<<40))
+	key.Set(BinaryValue("Blob", bytes.Repeat([]byte{0xab}, 40)))
+	key.Set(DWordValue("flag", 2))
+	f.AddKey(`HKEY_LOCAL_MACHINE\Software\Old`).Delete = true
+
+	text := f.Text()
+	for _, expected := range []string{
+		`"Name"="a\\b \"c\""`,
+		`"Multiline"=hex(1):6c,00`,
+		`"Home"=hex(2):25,00,55,00`,
+		`"List"=hex(7):78,00,00,00,79,00,00,00,00,00`,
+		`"flag"=dword:00000002`,
+		`"Size"=hex(b):00,00,00,00,00,01,00,00`,
+		"\"Blob\"=hex:ab,ab",
+		`[-HKEY_LOCAL_MACHINE\Software\Old]`,
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("missing %s in:\n%s", expected, text)
+		}
+	}
+
+	parsed, err := Parse([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := parsed.Keys[0].Value("Multiline").AsString(); s != "line1\nline2" {
+		t.Errorf("multiline string not preserved: %q", s)
+	}
+	if v := parsed.Keys[0].Value("Blob"); len(v.Data) != 40 {
+		t.Errorf("wrapped binary not preserved: %d bytes", len(v.Data))
+	}
+}
+
+func TestParseREGEDIT4(t *testing.T) {
+	f, err := Parse([]byte("REGEDIT4\r\n\r\n[HKEY_CURRENT_USER\\Env]\r\n\"Home\"=hex(2):25,48,25,00\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err := f.Keys[0].Value("Home").AsString(); err != nil || s != "%H%" {
+		t.Errorf("ANSI expand string not converted: %q (%v)", s, err)
+	}
+	if !strings.Contains(f.Text(), `"Home"=hex(2):25,48,25,00`) {
+		t.Errorf("REGEDIT4 output should keep ANSI data:\n%s", f.Text())
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := map[string]string{
+		"missing header": "[HKEY_CURRENT_USER\\X]\n",
+		"value outside":  HeaderV5 + "\n\"a\"=\"b\"\n",
+		"bad dword":      HeaderV5 + "\n[HKEY_CURRENT_USER\\X]\n\"a\"=dword:zz\n",
+		"bad hex":        HeaderV5 + "\n[HKEY_CURRENT_USER\\X]\n\"a\"=hex:0g\n",
+		"unterminated":   HeaderV5 + "\n[HKEY_CURRENT_USER\\X]\n\"a=\"b\"\n",
+	}
+	for name, content := range cases {
+		if _, err := Parse([]byte(content)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestValueTypeNames(t *testing.T) {
+	for _, typ := range []ValueType{TypeString, TypeQWord, ValueType(0x20)} {
+		parsed, ok := ParseValueType(typ.String())
+		if !ok || parsed != typ {
+			t.Errorf("%s did not round trip", typ)
+		}
+	}
+}
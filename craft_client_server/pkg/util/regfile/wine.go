@@ -0,0 +1,428 @@
+package regfile
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// WineHeader Wine 注册表文件（system.reg、user.reg、userdef.reg）的文件头
+const WineHeader = "WINE REGISTRY Version 2"
+
+// wineRelativePrefix 说明文件中项路径相对位置的注释
+const wineRelativePrefix = ";; All keys relative to "
+
+// Hive Wine 注册表文件，项路径相对于文件对应的根（如 user.reg 对应 HKEY_CURRENT_USER）
+// Wine 退出时会重写这些文件，修改前需确保 wineserver 未在运行
+type Hive struct {
+	Preamble []string   // 第一个项之前的行（文件头、相对路径注释、#arch 等），原样保留
+	Keys     []*HiveKey // 按文件中的顺序排列
+}
+
+// HiveKey Wine 注册表文件中的项
+type HiveKey struct {
+	Key
+	Modified int64    // 项后记录的修改时间（Unix 秒）
+	Meta     []string // 项后以 # 开头的行（#time、#class、#link 等），原样保留
+}
+
+// NewHive 创建空的 Wine 注册表文件，relative 为项路径的相对位置，如 \Machine、\User\.Default
+func NewHive(relative string) *Hive {
+	return &Hive{Preamble: []string{
+		WineHeader,
+		wineRelativePrefix + formatWineString(relative, 0),
+		"",
+		"#arch=win64",
+	}}
+}
+
+// Relative 返回文件头注释中记录的项路径相对位置，如 \User\S-1-5-21-0-0-0-1000
+func (h *Hive) Relative() string {
+	for _, line := range h.Preamble {
+		if rest, ok := strings.CutPrefix(line, wineRelativePrefix); ok {
+			s, _, err := parseWineString(rest, 0)
+			if err == nil {
+				return s
+			}
+			return rest
+		}
+	}
+	return ""
+}
+
+// Key 按相对路径查找项，不区分大小写
+func (h *Hive) Key(path string) *HiveKey {
+	for _, k := range h.Keys {
+		if strings.EqualFold(k.Path, path) {
+			return k
+		}
+	}
+	return nil
+}
+
+// AddKey 返回指定路径的项，不存在时按 Wine 的顺序（不区分大小写）插入新项
+func (h *Hive) AddKey(path string) *HiveKey {
+	if k := h.Key(path); k != nil {
+		return k
+	}
+	k := &HiveKey{Key: Key{Path: path}}
+	k.Touch(time.Now())
+	index := sort.Search(len(h.Keys), func(i int) bool {
+		return compareWinePath(h.Keys[i].Path, path) > 0
+	})
+	h.Keys = append(h.Keys, nil)
+	copy(h.Keys[index+1:], h.Keys[index:])
+	h.Keys[index] = k
+	return k
+}
+
+// DeleteKey 删除项及其所有子项，返回删除的项数
+func (h *Hive) DeleteKey(path string) int {
+	kept := h.Keys[:0]
+	removed := 0
+	for _, k := range h.Keys {
+		if IsSubKey(k.Path, path) {
+			removed++
+			continue
+		}
+		kept = append(kept, k)
+	}
+	h.Keys = kept
+	return removed
+}
+
+// IsSubKey 判断 path 是否为 parent 本身或其子项，不区分大小写；parent 为空时匹配所有项
+func IsSubKey(path, parent string) bool {
+	if parent == "" {
+		return true
+	}
+	return strings.EqualFold(path, parent) ||
+		(len(path) > len(parent) && path[len(parent)] == '\\' && strings.EqualFold(path[:len(parent)], parent))
+}
+
+// compareWinePath 按路径分段、不区分大小写比较，使子项紧跟在父项之后
+func compareWinePath(a, b string) int {
+	as, bs := strings.Split(strings.ToLower(a), `\`), strings.Split(strings.ToLower(b), `\`)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := strings.Compare(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// Touch 更新项的修改时间，同时更新 #time 行（FILETIME 的十六进制）
+func (k *HiveKey) Touch(t time.Time) {
+	k.Modified = t.Unix()
+	filetime := uint64(t.UnixNano()/100) + 116444736000000000
+	line := fmt.Sprintf("#time=%x", filetime)
+	for i, meta := range k.Meta {
+		if strings.HasPrefix(meta, "#time=") {
+			k.Meta[i] = line
+			return
+		}
+	}
+	k.Meta = append([]string{line}, k.Meta...)
+}
+
+// ParseHive 解析 Wine 注册表文件
+func ParseHive(data []byte) (*Hive, error) {
+	text, _ := decodeText(data)
+	h := &Hive{}
+
+	var key *HiveKey
+	for _, line := range logicalLines(text) {
+		content := strings.TrimSpace(line.text)
+		if key == nil && !strings.HasPrefix(content, "[") {
+			if len(h.Preamble) == 0 && content != WineHeader {
+				return nil, fmt.Errorf("line %d: missing Wine registry header", line.number)
+			}
+			h.Preamble = append(h.Preamble, line.text)
+			continue
+		}
+		if content == "" {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(content, "["):
+			path, n, err := parseWineString(content[1:], ']')
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid key path: %w", line.number, err)
+			}
+			key = &HiveKey{Key: Key{Path: path}}
+			if stamp := strings.TrimSpace(content[1+n:]); stamp != "" {
+				if key.Modified, err = strconv.ParseInt(stamp, 10, 64); err != nil {
+					return nil, fmt.Errorf("line %d: invalid key timestamp %q", line.number, stamp)
+				}
+			}
+			h.Keys = append(h.Keys, key)
+		case strings.HasPrefix(content, "#"):
+			key.Meta = append(key.Meta, content)
+		case strings.HasPrefix(content, ";"):
+		default:
+			value, err := parseWineValue(content)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line.number, err)
+			}
+			key.Values = append(key.Values, value)
+		}
+	}
+
+	if len(h.Preamble) == 0 {
+		return nil, fmt.Errorf("missing Wine registry header")
+	}
+	return h, nil
+}
+
+// Marshal 按 Wine 的写法生成文件内容（LF 换行）
+func (h *Hive) Marshal() []byte {
+	var b strings.Builder
+	preamble := h.Preamble
+	for len(preamble) > 0 && strings.TrimSpace(preamble[len(preamble)-1]) == "" {
+		preamble = preamble[:len(preamble)-1]
+	}
+	for _, line := range preamble {
+		b.WriteString(line + "\n")
+	}
+	for _, k := range h.Keys {
+		b.WriteString("\n[" + formatWineString(k.Path, ']') + "]")
+		if k.Modified != 0 {
+			b.WriteString(" " + strconv.FormatInt(k.Modified, 10))
+		}
+		b.WriteString("\n")
+		for _, meta := range k.Meta {
+			b.WriteString(meta + "\n")
+		}
+		for _, v := range k.Values {
+			if !v.Delete {
+				b.WriteString(formatWineValue(v) + "\n")
+			}
+		}
+	}
+	return []byte(b.String())
+}
+
+// parseWineValue 解析值行，字符串使用 Wine 的 C 风格转义，REG_EXPAND_SZ 与 REG_MULTI_SZ 写作 str(N):"..."
+func parseWineValue(line string) (*Value, error) {
+	v := &Value{}
+	var rest string
+	if strings.HasPrefix(line, "@") {
+		rest = line[1:]
+	} else {
+		if !strings.HasPrefix(line, `"`) {
+			return nil, fmt.Errorf("invalid value line: %s", line)
+		}
+		name, n, err := parseWineString(line[1:], '"')
+		if err != nil {
+			return nil, fmt.Errorf("invalid value name: %w", err)
+		}
+		v.Name, rest = name, line[1+n:]
+	}
+	data, ok := strings.CutPrefix(strings.TrimSpace(rest), "=")
+	if !ok {
+		return nil, fmt.Errorf("missing '=' after value name %q", v.Name)
+	}
+	data = strings.TrimSpace(data)
+
+	switch {
+	case strings.HasPrefix(data, `"`):
+		s, _, err := parseWineString(data[1:], '"')
+		if err != nil {
+			return nil, fmt.Errorf("invalid string value %q: %w", v.Name, err)
+		}
+		*v = *StringValue(v.Name, s)
+	case strings.HasPrefix(data, "str("):
+		end := strings.Index(data, `):"`)
+		if end < 0 {
+			return nil, fmt.Errorf("invalid string value %q", v.Name)
+		}
+		n, err := strconv.ParseUint(data[4:end], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string type for value %q", v.Name)
+		}
+		s, _, err := parseWineString(data[end+3:], '"')
+		if err != nil {
+			return nil, fmt.Errorf("invalid string value %q: %w", v.Name, err)
+		}
+		*v = *StringValue(v.Name, s)
+		v.Type = ValueType(n)
+	case hasPrefixFold(data, "dword:"):
+		n, err := strconv.ParseUint(strings.TrimSpace(data[len("dword:"):]), 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dword value %q: %w", v.Name, err)
+		}
+		*v = *DWordValue(v.Name, uint32(n))
+	case hasPrefixFold(data, "hex"):
+		typ, bytesText, err := splitHexPrefix(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex value %q: %w", v.Name, err)
+		}
+		raw, err := ParseHex(bytesText)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex value %q: %w", v.Name, err)
+		}
+		v.Type, v.Data = typ, raw
+	default:
+		return nil, fmt.Errorf("unsupported data for value %q: %s", v.Name, data)
+	}
+	return v, nil
+}
+
+// formatWineValue 生成值行：可表示为字符串的 REG_SZ/REG_EXPAND_SZ/REG_MULTI_SZ 写作字符串，其余写作十六进制
+func formatWineValue(v *Value) string {
+	name := "@"
+	if v.Name != "" {
+		name = `"` + formatWineString(v.Name, '"') + `"`
+	}
+	prefix := name + "="
+
+	switch v.Type {
+	case TypeString, TypeExpandString, TypeMultiString:
+		if s, ok := wineStringData(v.Data); ok {
+			if v.Type == TypeString {
+				return prefix + `"` + formatWineString(s, '"') + `"`
+			}
+			return prefix + fmt.Sprintf(`str(%x):"`, uint32(v.Type)) + formatWineString(s, '"') + `"`
+		}
+	case TypeDWord:
+		if n, err := v.AsUint64(); err == nil {
+			return prefix + fmt.Sprintf("dword:%08x", n)
+		}
+	}
+	if v.Type == TypeBinary {
+		return formatHex(prefix+"hex:", v.Data)
+	}
+	return formatHex(prefix+fmt.Sprintf("hex(%x):", uint32(v.Type)), v.Data)
+}
+
+// wineStringData 判断数据能否写成字符串：长度为偶数且以 0 结尾，结尾的 0 不写入文件
+func wineStringData(data []byte) (string, bool) {
+	if len(data) < 2 || len(data)%2 != 0 || data[len(data)-1] != 0 || data[len(data)-2] != 0 {
+		return "", false
+	}
+	return decodeUTF16(data[:len(data)-2]), true
+}
+
+// wineEscapes Wine 使用的单字符转义
+var wineEscapes = map[rune]byte{'\a': 'a', '\b': 'b', '\t': 't', '\n': 'n', '\v': 'v', '\f': 'f', '\r': 'r', 0x1b: 'e'}
+
+// formatWineString 按 Wine 的规则转义字符串（以 UTF-16 码元为单位）：
+// 控制字符写作 C 转义或八进制，非 ASCII 字符写作 \x 加十六进制，\ 与 delim 前加 \
+func formatWineString(s string, delim rune) string {
+	units := utf16.Encode([]rune(s))
+	var b strings.Builder
+	for i, u := range units {
+		next := uint16(0)
+		if i+1 < len(units) {
+			next = units[i+1]
+		}
+		switch {
+		case u > 127:
+			// 下一个字符是十六进制数字时固定写 4 位，避免与后续字符混淆
+			if next < 128 && isHexDigit(byte(next)) {
+				fmt.Fprintf(&b, `\x%04x`, u)
+			} else {
+				fmt.Fprintf(&b, `\x%x`, u)
+			}
+		case u < 32:
+			if c, ok := wineEscapes[rune(u)]; ok {
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			} else if next >= '0' && next <= '7' {
+				fmt.Fprintf(&b, `\%03o`, u)
+			} else {
+				fmt.Fprintf(&b, `\%o`, u)
+			}
+		default:
+			if rune(u) == '\\' || (delim != 0 && rune(u) == delim) {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(byte(u))
+		}
+	}
+	return b.String()
+}
+
+// parseWineString 解析 Wine 转义的字符串直到未转义的 delim，返回字符串与消耗的字节数（含 delim）
+// delim 为 0 时解析到末尾
+func parseWineString(s string, delim byte) (string, int, error) {
+	var units []uint16
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		if delim != 0 && c == delim {
+			return string(utf16.Decode(units)), i + 1, nil
+		}
+		if c != '\\' || i+1 >= len(s) {
+			r, size := decodeRune(s[i:])
+			units = append(units, utf16.Encode([]rune{r})...)
+			i += size
+			continue
+		}
+
+		i++
+		switch e := s[i]; {
+		case e == 'x':
+			j := i + 1
+			for j < len(s) && j < i+5 && isHexDigit(s[j]) {
+				j++
+			}
+			if j == i+1 {
+				units = append(units, 'x')
+				i++
+				continue
+			}
+			n, _ := strconv.ParseUint(s[i+1:j], 16, 16)
+			units = append(units, uint16(n))
+			i = j
+		case e >= '0' && e <= '7':
+			j := i
+			for j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7' {
+				j++
+			}
+			n, _ := strconv.ParseUint(s[i:j], 8, 16)
+			units = append(units, uint16(n))
+			i = j
+		default:
+			if r, ok := unescapeWine(e); ok {
+				units = append(units, uint16(r))
+			} else {
+				units = append(units, uint16(e))
+			}
+			i++
+		}
+	}
+	if delim != 0 {
+		return "", 0, fmt.Errorf("unterminated string")
+	}
+	return string(utf16.Decode(units)), len(s), nil
+}
+
+// unescapeWine 还原单字符转义
+func unescapeWine(c byte) (rune, bool) {
+	for r, e := range wineEscapes {
+		if e == c {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+// decodeRune 解码 UTF-8 字符，非法字节按 Latin-1 处理；Wine 写入的文件为 ASCII，兼容手工编辑时写入的 UTF-8
+func decodeRune(s string) (rune, int) {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError && size == 1 {
+		return rune(s[0]), 1
+	}
+	return r, size
+}
+
+// isHexDigit 判断是否为十六进制数字
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
@@ -0,0 +1,142 @@
+package regfile
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleHive = `WINE REGISTRY Version 2
+;; All keys relative to \\User\\S-1-5-21-0-0-0-1000
+
+#arch=win64
+
+[Software\\Vendor\\App] 1700000000
+#time=1da1b2c3d4e5f60
+@="default"
+"Path"="C:\\Program Files\\App"
+"Quote"="say \"hi\"\n"
+"Name"="\x4e2d\x6587"
+"Home"=str(2):"%USERPROFILE%\\app"
+"Recent"=str(7):"a.txt\0b.txt\0"
+"Count"=dword:0000001f
+"Blob"=hex:de,ad,be,ef
+
+[Software\\Vendor\\App\\Sub] 1700000001
+#time=1da1b2c3d4e5f61
+"Flag"=dword:00000001
+`
+
+func TestParseHive(t *testing.T) {
+	h, err := ParseHive([]byte(sampleHive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Relative() != `\User\S-1-5-21-0-0-0-1000` {
+		t.Errorf("relative = %q", h.Relative())
+	}
+	if len(h.Keys) != 2 || h.Keys[0].Path != `Software\Vendor\App` || h.Keys[0].Modified != 1700000000 {
+		t.Fatalf("unexpected keys: %+v", h.Keys)
+	}
+	k := h.Key(`software\vendor\app`)
+
+	checks := map[string]string{"": "default", "Path": `C:\Program Files\App`, "Quote": "say \"hi\"\n", "Name": "中文"}
+	for name, want := range checks {
+		if got, err := k.Value(name).AsString(); err != nil || got != want {
+			t.Errorf("%q = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if v := k.Value("Home"); v.Type != TypeExpandString {
+		t.Errorf("Home type = %s", v.Type)
+	}
+	if items, err := k.Value("Recent").AsStrings(); err != nil || strings.Join(items, ",") != "a.txt,b.txt" {
+		t.Errorf("Recent = %v, %v", items, err)
+	}
+	if n, _ := k.Value("Count").AsUint64(); n != 31 {
+		t.Errorf("Count = %d", n)
+	}
+	if got := FormatHex(k.Value("Blob").Data); got != "de,ad,be,ef" {
+		t.Errorf("Blob = %s", got)
+	}
+
+	// 十六进制数据以 \ 续行
+	wrapped, err := ParseHive([]byte("WINE REGISTRY Version 2\n\n[Software] 1\n\"Bin\"=hex(3):01,02,\\\n  03\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := FormatHex(wrapped.Keys[0].Value("Bin").Data); got != "01,02,03" {
+		t.Errorf("Bin = %s", got)
+	}
+}
+
+func TestHiveRoundTrip(t *testing.T) {
+	h, err := ParseHive([]byte(sampleHive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(h.Marshal()); got != sampleHive {
+		t.Errorf("round trip changed content:\n%s", got)
+	}
+}
+
+func TestHiveEdit(t *testing.T) {
+	h, err := ParseHive([]byte(sampleHive))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 新项按路径顺序插入，父项之后紧跟子项
+	k := h.AddKey(`Software\Vendor\Aaa`)
+	k.Set(StringValue("Tab", "a\tb"))
+	if h.Keys[0] != k {
+		t.Errorf("new key should be inserted first, got %s", h.Keys[0].Path)
+	}
+	k.Touch(time.Unix(1700000100, 0))
+	if k.Modified != 1700000100 || k.Meta[0] != "#time=1da17480207ca00" {
+		t.Errorf("touch = %d %v", k.Modified, k.Meta)
+	}
+
+	if n := h.DeleteKey(`software\vendor\app`); n != 2 {
+		t.Errorf("deleted %d keys, want 2", n)
+	}
+	out := string(h.Marshal())
+	if !strings.Contains(out, "[Software\\\\Vendor\\\\Aaa] 1700000100\n#time=1da17480207ca00\n\"Tab\"=\"a\\tb\"\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if strings.Contains(out, "App") {
+		t.Errorf("deleted keys still present:\n%s", out)
+	}
+}
+
+func TestWineStringEscapes(t *testing.T) {
+	cases := map[string]string{
+		"plain":          "plain",
+		`a\b"c`:          `a\\b\"c`,
+		"中a":             `\x4e2da`,
+		"中1":             `\x4e2d1`,
+		"\x01\x027":      `\1\0027`,
+		"tab\tnewline\n": `tab\tnewline\n`,
+		"😀":              `\xd83d\xde00`,
+	}
+	for input, want := range cases {
+		if got := formatWineString(input, '"'); got != want {
+			t.Errorf("format %q = %s, want %s", input, got, want)
+		}
+		got, n, err := parseWineString(want+`"`, '"')
+		if err != nil || got != input || n != len(want)+1 {
+			t.Errorf("parse %s = %q, %d, %v", want, got, n, err)
+		}
+	}
+}
+
+func TestParseHiveErrors(t *testing.T) {
+	for _, input := range []string{
+		"Windows Registry Editor Version 5.00\n",
+		"WINE REGISTRY Version 2\n\n[Software] abc\n",
+		"WINE REGISTRY Version 2\n\n[Software] 1\n\"Name\"=unknown:1\n",
+	} {
+		if _, err := ParseHive([]byte(input)); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package regfile
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// maxLineWidth regedit 折行时每行的最大宽度
+const maxLineWidth = 80
+
+// Text 按 regedit 的写法生成文件文本（LF 换行），便于查看与比较差异
+func (f *File) Text() string {
+	header := f.Header
+	if header == "" {
+		header = HeaderV5
+	}
+	ansi := header == HeaderV4
+
+	var b strings.Builder
+	b.WriteString(header + "\n\n")
+	for _, k := range f.Keys {
+		if k.Delete {
+			b.WriteString("[-" + k.Path + "]\n\n")
+			continue
+		}
+		b.WriteString("[" + k.Path + "]\n")
+		for _, v := range k.Values {
+			b.WriteString(formatValue(v, ansi) + "\n")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Marshal 生成文件内容：CRLF 换行，Encoding 为 UTF-16LE 时带 BOM
+func (f *File) Marshal() []byte {
+	text := strings.ReplaceAll(f.Text(), "\n", "\r\n")
+	if f.Encoding == EncodingUTF8 {
+		return []byte(text)
+	}
+	units := utf16.Encode([]rune(text))
+	data := make([]byte, 0, len(units)*2+2)
+	data = append(data, 0xFF, 0xFE)
+	for _, u := range units {
+		data = append(data, byte(u), byte(u>>8))
+	}
+	return data
+}
+
+// formatValue 生成值行；字符串含换行或不是合法的以 0 结尾的数据时改用 hex 写法，保证可原样还原
+func formatValue(v *Value, ansi bool) string {
+	name := "@"
+	if v.Name != "" {
+		name = quote(v.Name)
+	}
+	prefix := name + "="
+
+	if v.Delete {
+		return prefix + "-"
+	}
+	switch v.Type {
+	case TypeString:
+		if s, ok := plainString(v.Data); ok {
+			return prefix + quote(s)
+		}
+	case TypeDWord:
+		if n, err := v.AsUint64(); err == nil {
+			return prefix + fmt.Sprintf("dword:%08x", n)
+		}
+	}
+
+	data := v.Data
+	if ansi && v.Type.isString() {
+		data = utf16ToANSI(data)
+	}
+	if v.Type == TypeBinary {
+		return formatHex(prefix+"hex:", data)
+	}
+	return formatHex(prefix+fmt.Sprintf("hex(%x):", uint32(v.Type)), data)
+}
+
+// plainString 判断 REG_SZ 数据能否写成 "..." 形式：以单个 0 结尾且不含换行与其他 0
+func plainString(data []byte) (string, bool) {
+	if len(data) < 2 || len(data)%2 != 0 || data[len(data)-1] != 0 || data[len(data)-2] != 0 {
+		return "", false
+	}
+	s := decodeUTF16(data[:len(data)-2])
+	if strings.ContainsAny(s, "\x00\r\n") || string(encodeUTF16(s)) != string(data) {
+		return "", false
+	}
+	return s, true
+}
+
+// quote 为名称或字符串加引号并转义 \ 与 "
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// formatHex 生成逗号分隔的十六进制字节，超出行宽时以 \ 续行，续行缩进两个空格
+func formatHex(prefix string, data []byte) string {
+	var b strings.Builder
+	line := prefix
+	for i, c := range data {
+		item := fmt.Sprintf("%02x", c)
+		if i < len(data)-1 {
+			item += ","
+		}
+		// 预留行尾 \ 的位置
+		if len(line)+len(item) > maxLineWidth-1 && line != "  " {
+			b.WriteString(line + "\\\n")
+			line = "  "
+		}
+		line += item
+	}
+	b.WriteString(line)
+	return b.String()
+}
+
+// FormatHex 将字节格式化为逗号分隔的十六进制（不折行），是 ParseHex 的逆过程
+func FormatHex(data []byte) string {
+	items := make([]string, len(data))
+	for i, c := range data {
+		items[i] = fmt.Sprintf("%02x", c)
+	}
+	return strings.Join(items, ",")
+}
+
+// utf16ToANSI 将 UTF-16LE 数据按 Latin-1 转换为单字节，无法表示的字符写为 ?
+func utf16ToANSI(data []byte) []byte {
+	out := make([]byte, 0, len(data)/2)
+	for _, r := range decodeUTF16(data) {
+		if r > 0xFF {
+			r = '?'
+		}
+		out = append(out, byte(r))
+	}
+	return out
+}
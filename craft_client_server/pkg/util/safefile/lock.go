@@ -0,0 +1,123 @@
+package safefile
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// pathLock 进程内同一路径的锁，refs 为持有或等待该锁的数量
+type pathLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+var (
+	locksMu sync.Mutex
+	locks   = make(map[string]*pathLock)
+)
+
+// userLockBase 返回当前用户的锁文件根目录，测试中可替换
+var userLockBase = os.UserCacheDir
+
+// lockDir 返回跨进程锁文件所在目录：当前用户缓存目录下的 envcraft/locks，以 0700 创建
+// 锁文件不放在目标目录下，避免在用户的配置目录中留下多余文件；也不使用共享的锁目录，
+// 避免其他本地用户预先创建锁文件或符号链接来阻塞或重定向加锁
+// 没有用户缓存目录（如未设置 HOME）时使用临时目录下按用户 id 区分的目录
+func lockDir() (string, error) {
+	dir := filepath.Join(os.TempDir(), fmt.Sprintf("envcraft-locks-%d", os.Getuid()))
+	if base, err := userLockBase(); err == nil {
+		dir = filepath.Join(base, "envcraft", "locks")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	if err := checkLockDir(dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// Lock 获取 path 的建议锁，返回释放函数
+// 同一进程内的协程之间以及多个进程之间互斥；仅约束同样使用 Lock 的写入方，不可重入
+func Lock(path string) (func(), error) {
+	key, err := lockKey(path)
+	if err != nil {
+		return nil, err
+	}
+
+	locksMu.Lock()
+	l := locks[key]
+	if l == nil {
+		l = &pathLock{}
+		locks[key] = l
+	}
+	l.refs++
+	locksMu.Unlock()
+
+	release := func() {
+		locksMu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(locks, key)
+		}
+		locksMu.Unlock()
+	}
+
+	l.mu.Lock()
+	file, err := lockFile(key)
+	if err != nil {
+		l.mu.Unlock()
+		release()
+		return nil, err
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			unlockFile(file)
+			l.mu.Unlock()
+			release()
+		})
+	}, nil
+}
+
+// lockKey 返回路径的规范形式，Windows 下不区分大小写
+func lockKey(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "windows" {
+		abs = strings.ToLower(abs)
+	}
+	return abs, nil
+}
+
+// lockFile 打开并锁定 key 对应的锁文件，阻塞直到获得锁
+func lockFile(key string) (*os.File, error) {
+	dir, err := lockDir()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum([]byte(key))
+	file, err := os.OpenFile(filepath.Join(dir, hex.EncodeToString(sum[:])+".lock"), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := lockHandle(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", key, err)
+	}
+	return file, nil
+}
+
+// unlockFile 释放并关闭锁文件
+func unlockFile(file *os.File) {
+	unlockHandle(file)
+	file.Close()
+}
@@ -0,0 +1,302 @@
+package safefile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// defaultPerm 目标文件不存在且未指定权限时使用的权限
+const defaultPerm os.FileMode = 0644
+
+// Options 写入选项
+type Options struct {
+	Perm    os.FileMode // 非零时使用该权限；否则沿用目标文件原有权限，目标不存在时为 0644
+	ModTime time.Time   // 非零时将目标文件修改时间设置为该值
+	NoLock  bool        // 调用方已通过 Lock 持有目标路径的锁
+	Replace bool        // 替换目标路径本身：不写入符号链接指向的文件，也不就地写入有多个硬链接的文件（用于按源目录重建目标目录）
+}
+
+// File 写入中的临时文件
+// 内容先写入目标所在目录下的临时文件，Commit 时落盘并重命名为目标文件；
+// 未 Commit 的临时文件由 Abort 删除，目标文件在此之前保持不变
+// 目标为符号链接时写入链接指向的文件，链接本身保持不变（Options.Replace 除外）
+type File struct {
+	*os.File
+	path   string
+	real   string // 解析符号链接后实际写入的路径
+	opts   Options
+	unlock func()
+	done   bool
+	keep   bool // Abort 时保留临时文件，供断点续传
+}
+
+// Create 为 path 创建临时文件，并在 Commit 或 Abort 前持有 path 的锁
+func Create(path string, opts Options) (*File, error) {
+	unlock := func() {}
+	if !opts.NoLock {
+		var err error
+		if unlock, err = Lock(path); err != nil {
+			return nil, err
+		}
+	}
+
+	real := path
+	if !opts.Replace {
+		real = resolvePath(path)
+	}
+	dir := filepath.Dir(real)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		unlock()
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(real)+".tmp-*")
+	if err != nil {
+		unlock()
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	return &File{File: tmp, path: path, real: real, opts: opts, unlock: unlock}, nil
+}
+
+// maxLinkHops 解析符号链接的最大层数，超过时视为循环
+const maxLinkHops = 40
+
+// resolvePath 返回 path 解析符号链接后的路径；链接指向的文件不存在时返回其应在的路径
+// path 不是符号链接或无法解析时原样返回
+func resolvePath(path string) string {
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		return real
+	}
+	// 悬空链接：逐层读取链接目标，写入时创建链接指向的文件
+	current := path
+	for i := 0; i < maxLinkHops; i++ {
+		info, err := os.Lstat(current)
+		if err != nil {
+			return current
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			return current
+		}
+		link, err := os.Readlink(current)
+		if err != nil {
+			return path
+		}
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(filepath.Dir(current), link)
+		}
+		current = link
+	}
+	return path
+}
+
+// partSuffix 可续传的临时文件后缀
+const partSuffix = ".part"
+
+// Resume 打开 path 对应的可续传临时文件（path.part，path 为符号链接时位于链接指向的文件旁），不存在时创建
+// 写入追加到已有内容之后，返回已有内容的长度；Abort 时保留该文件，供下次续传
+func Resume(path string, opts Options) (*File, int64, error) {
+	unlock := func() {}
+	if !opts.NoLock {
+		var err error
+		if unlock, err = Lock(path); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	real := path
+	if !opts.Replace {
+		real = resolvePath(path)
+	}
+	if err := os.MkdirAll(filepath.Dir(real), 0755); err != nil {
+		unlock()
+		return nil, 0, fmt.Errorf("failed to create directory: %w", err)
+	}
+	part, err := os.OpenFile(real+partSuffix, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		unlock()
+		return nil, 0, fmt.Errorf("failed to open partial file: %w", err)
+	}
+	info, err := part.Stat()
+	if err != nil {
+		part.Close()
+		unlock()
+		return nil, 0, err
+	}
+	return &File{File: part, path: path, real: real, opts: opts, unlock: unlock, keep: true}, info.Size(), nil
+}
+
+// Path 返回目标文件路径（Name 返回的是临时文件路径）
+func (f *File) Path() string {
+	return f.path
+}
+
+// Commit 将临时文件落盘后替换目标文件，并释放锁
+// 目标文件已存在时保留其权限与属主；目标文件有多个硬链接时就地写入，使其他链接看到新内容
+func (f *File) Commit() error {
+	if f.done {
+		return errors.New("safefile: file already committed or aborted")
+	}
+	f.done = true
+	defer f.unlock()
+
+	tmpPath := f.File.Name()
+	if err := f.commit(tmpPath); err != nil {
+		if !f.keep {
+			os.Remove(tmpPath)
+		}
+		return err
+	}
+	return nil
+}
+
+func (f *File) commit(tmpPath string) error {
+	if err := f.File.Sync(); err != nil {
+		f.File.Close()
+		return fmt.Errorf("failed to sync file: %w", err)
+	}
+	if err := f.File.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+
+	perm := f.opts.Perm
+	existing, err := os.Lstat(f.real)
+	if err == nil && !f.opts.Replace && existing.Mode().IsRegular() && linkCount(existing) > 1 {
+		return f.commitInPlace(tmpPath, existing)
+	}
+	if err == nil && existing.Mode().IsRegular() {
+		if perm == 0 {
+			perm = existing.Mode().Perm()
+		}
+		// 属主无法保留（如非特权用户覆盖他人文件）时沿用当前用户
+		_ = chownLike(tmpPath, existing)
+	}
+	if perm == 0 {
+		perm = defaultPerm
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+	if !f.opts.ModTime.IsZero() {
+		if err := os.Chtimes(tmpPath, f.opts.ModTime, f.opts.ModTime); err != nil {
+			return fmt.Errorf("failed to set modification time: %w", err)
+		}
+	}
+
+	if err := os.Rename(tmpPath, f.real); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+	// 重命名本身也需落盘，否则崩溃后目录项可能仍指向旧文件
+	syncDir(filepath.Dir(f.real))
+	return nil
+}
+
+// commitInPlace 将临时文件的内容复制到已存在的目标文件中，保留硬链接
+// 重命名会使目标成为新文件并与其他链接分离，因此这里放弃原子替换：复制中途失败时目标内容可能不完整
+func (f *File) commitInPlace(tmpPath string, existing os.FileInfo) error {
+	source, err := os.Open(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	target, err := os.OpenFile(f.real, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	if _, err := io.Copy(target, source); err != nil {
+		target.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := target.Sync(); err != nil {
+		target.Close()
+		return fmt.Errorf("failed to sync file: %w", err)
+	}
+	if err := target.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+
+	if f.opts.Perm != 0 && f.opts.Perm != existing.Mode().Perm() {
+		if err := os.Chmod(f.real, f.opts.Perm); err != nil {
+			return fmt.Errorf("failed to set file mode: %w", err)
+		}
+	}
+	if !f.opts.ModTime.IsZero() {
+		if err := os.Chtimes(f.real, f.opts.ModTime, f.opts.ModTime); err != nil {
+			return fmt.Errorf("failed to set modification time: %w", err)
+		}
+	}
+	source.Close()
+	return os.Remove(tmpPath)
+}
+
+// Abort 放弃写入，删除临时文件（Resume 打开的除外）并释放锁；Commit 后调用无效果，可直接 defer
+func (f *File) Abort() error {
+	if f.done {
+		return nil
+	}
+	f.done = true
+	defer f.unlock()
+
+	if f.keep {
+		return f.File.Close()
+	}
+	f.File.Close()
+	return os.Remove(f.File.Name())
+}
+
+// WriteFile 原子地写入 data 到 path
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	return WriteFileOptions(path, data, Options{Perm: perm})
+}
+
+// WriteFileOptions 按 opts 原子地写入 data 到 path
+func WriteFileOptions(path string, data []byte, opts Options) error {
+	_, err := Write(path, bytes.NewReader(data), opts)
+	return err
+}
+
+// Write 原子地将 r 的全部内容写入 path，返回写入的字节数
+func Write(path string, r io.Reader, opts Options) (int64, error) {
+	f, err := Create(path, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Abort()
+
+	n, err := io.Copy(f, r)
+	if err != nil {
+		return n, fmt.Errorf("failed to write file: %w", err)
+	}
+	return n, f.Commit()
+}
+
+// CopyFile 原子地将 src 复制到 dst，保留源文件的权限与修改时间
+func CopyFile(src, dst string) error {
+	return CopyFileOptions(src, dst, Options{})
+}
+
+// CopyFileOptions 按 opts 原子地将 src 复制到 dst；未指定的权限与修改时间取自源文件
+func CopyFileOptions(src, dst string, opts Options) error {
+	source, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+	if opts.Perm == 0 {
+		opts.Perm = info.Mode().Perm()
+	}
+	if opts.ModTime.IsZero() {
+		opts.ModTime = info.ModTime()
+	}
+	_, err = Write(dst, source, opts)
+	return err
+}
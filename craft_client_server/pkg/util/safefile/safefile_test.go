@@ -0,0 +1,265 @@
+package safefile
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriteFilePreservesMode(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(path, []byte("new"), 0); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	if string(content) != "new" {
+		t.Errorf("unexpected content %q", content)
+	}
+	if runtime.GOOS != "windows" {
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+			t.Errorf("mode not preserved: %v", info.Mode())
+		}
+	}
+
+	// 不应留下临时文件
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("unexpected leftover files: %v", entries)
+	}
+}
+
+func TestWriteFileThroughSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on windows")
+	}
+	dir := t.TempDir()
+	// 常见的 dotfiles 布局：~/.bashrc -> dotfiles/bashrc
+	real := filepath.Join(dir, "dotfiles", "bashrc")
+	link := filepath.Join(dir, ".bashrc")
+	os.MkdirAll(filepath.Dir(real), 0755)
+	if err := os.WriteFile(real, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("dotfiles/bashrc", link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(link, []byte("new"), 0); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if target, err := os.Readlink(link); err != nil || target != "dotfiles/bashrc" {
+		t.Fatalf("symlink replaced: %q %v", target, err)
+	}
+	if content, _ := os.ReadFile(real); string(content) != "new" {
+		t.Errorf("unexpected content %q", content)
+	}
+	if info, _ := os.Stat(real); info.Mode().Perm() != 0600 {
+		t.Errorf("mode not preserved: %v", info.Mode())
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(real)); len(entries) != 1 {
+		t.Errorf("unexpected leftover files: %v", entries)
+	}
+
+	// 悬空链接：创建链接指向的文件
+	dangling := filepath.Join(dir, ".profile")
+	if err := os.Symlink("dotfiles/profile", dangling); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(dangling, []byte("profile"), 0); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "dotfiles", "profile")); string(content) != "profile" {
+		t.Errorf("link target not created: %q", content)
+	}
+
+	// Replace：替换链接本身
+	if err := WriteFileOptions(link, []byte("replaced"), Options{Replace: true}); err != nil {
+		t.Fatalf("WriteFileOptions failed: %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || !info.Mode().IsRegular() {
+		t.Errorf("symlink not replaced: %v", err)
+	}
+	if content, _ := os.ReadFile(real); string(content) != "new" {
+		t.Errorf("link target modified: %q", content)
+	}
+}
+
+func TestWriteFileKeepsHardLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("link count is not available on windows")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	other := filepath.Join(dir, "config.link.json")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(path, other); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(path, []byte("new content"), 0); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if content, _ := os.ReadFile(other); string(content) != "new content" {
+		t.Errorf("hard link split: %q", content)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("unexpected leftover files: %v", entries)
+	}
+}
+
+func TestAbortLeavesTargetUntouched(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.xml")
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Create(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("half-written")
+	if err := f.Abort(); err != nil {
+		t.Fatalf("Abort failed: %v", err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "original" {
+		t.Errorf("target modified by aborted write: %q", content)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temp file not removed: %v", entries)
+	}
+}
+
+func TestCopyFilePreservesModTime(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	dst := filepath.Join(dir, "nested", "dst.txt")
+	if err := os.WriteFile(src, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(src, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CopyFile(src, dst); err != nil {
+		t.Fatalf("CopyFile failed: %v", err)
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(modTime) {
+		t.Errorf("mtime not preserved: %v", info.ModTime())
+	}
+}
+
+func TestResumeKeepsPartialFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.tar.gz")
+
+	f, offset, err := Resume(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 0 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+	f.WriteString("abc")
+	f.Abort()
+
+	f, offset, err = Resume(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 3 {
+		t.Errorf("expected to resume at 3, got %d", offset)
+	}
+	f.WriteString("def")
+	if err := f.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "abcdef" {
+		t.Errorf("unexpected content %q", content)
+	}
+	if _, err := os.Stat(path + partSuffix); !os.IsNotExist(err) {
+		t.Error("partial file should be renamed on commit")
+	}
+}
+
+func TestLockSerializesWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shared.txt")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := Lock(path)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer unlock()
+
+			// 持锁期间的读-改-写不会丢失其他协程的更新
+			content, _ := os.ReadFile(path)
+			if err := WriteFileOptions(path, append(content, 'x'), Options{NoLock: true}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	content, _ := os.ReadFile(path)
+	if strings.Count(string(content), "x") != 8 {
+		t.Errorf("lost updates: %q", content)
+	}
+	if len(locks) != 0 {
+		t.Errorf("lock table not cleaned up: %v", locks)
+	}
+}
+
+func TestLockDirPrivate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("lock directory permissions are checked on unix only")
+	}
+	base := t.TempDir()
+	original := userLockBase
+	userLockBase = func() (string, error) { return base, nil }
+	t.Cleanup(func() { userLockBase = original })
+
+	// 已存在但其他用户可写的目录收紧为 0700
+	dir := filepath.Join(base, "envcraft", "locks")
+	os.MkdirAll(dir, 0777)
+	os.Chmod(dir, 0777)
+	unlock, err := Lock(filepath.Join(base, "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	if info, _ := os.Stat(dir); info.Mode().Perm() != 0700 {
+		t.Errorf("lock directory mode = %v", info.Mode().Perm())
+	}
+
+	// 锁目录被替换为符号链接时拒绝加锁
+	os.RemoveAll(dir)
+	os.Symlink(t.TempDir(), dir)
+	if _, err := Lock(filepath.Join(base, "a.txt")); err == nil {
+		t.Error("expected error for a symlinked lock directory")
+	}
+}
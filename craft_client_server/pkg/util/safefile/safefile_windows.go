@@ -0,0 +1,37 @@
+//go:build windows
+
+package safefile
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockHandle 以 LockFileEx 独占锁定文件
+func lockHandle(file *os.File) error {
+	overlapped := new(windows.Overlapped)
+	return windows.LockFileEx(windows.Handle(file.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, overlapped)
+}
+
+// unlockHandle 释放 LockFileEx 锁
+func unlockHandle(file *os.File) {
+	overlapped := new(windows.Overlapped)
+	windows.UnlockFileEx(windows.Handle(file.Fd()), 0, 1, 0, overlapped)
+}
+
+// chownLike Windows 下属主由 ACL 继承，不做处理
+func chownLike(path string, info os.FileInfo) error {
+	return nil
+}
+
+// linkCount Windows 下 FileInfo 不含硬链接数，按单链接处理
+func linkCount(info os.FileInfo) uint64 {
+	return 1
+}
+
+// syncDir Windows 不支持对目录调用 FlushFileBuffers，重命名由文件系统日志保证
+func syncDir(dir string) {}
+
+// checkLockDir Windows 下锁目录位于用户的本地应用数据目录，由其 ACL 限制访问
+func checkLockDir(dir string) error { return nil }